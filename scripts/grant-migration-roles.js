@@ -0,0 +1,20 @@
+// 🔑 Grant the roles the API's migrations need to the application user
+//
+// mongo-init.js only runs when the data volume is created, so volumes from
+// before it granted dbAdmin keep a user that cannot change collection
+// validators. docker-compose runs this script on every start (mongodb-roles
+// service); it is safe to run again.
+
+db = db.getSiblingDB('whisper_db');
+
+const roles = [
+  { role: 'readWrite', db: 'whisper_db' },
+  { role: 'dbAdmin', db: 'whisper_db' }
+];
+
+if (db.getUser('whisper_app')) {
+  db.grantRolesToUser('whisper_app', roles);
+  print('🔑 Roles granted to whisper_app: readWrite, dbAdmin');
+} else {
+  print('⚠️ User whisper_app not found; mongo-init.js creates it on a fresh volume');
+}
@@ -8,9 +8,12 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embed IANA zones so user timezone validation works in slim containers
 
 	"whisper-server/internal/infrastructure/config"
 	"whisper-server/internal/infrastructure/database"
+	"whisper-server/internal/infrastructure/database/migrations"
+	"whisper-server/internal/infrastructure/services"
 	"whisper-server/internal/interfaces/http/routes"
 
 	"github.com/gin-gonic/gin"
@@ -33,6 +36,18 @@ func main() {
 	}
 	defer db.Disconnect()
 
+	// Migrations that move stored media need the storage the API uses
+	mediaStorage, err := services.NewMediaStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+	migrateCtx := migrations.WithMediaStorage(context.Background(), mediaStorage)
+
+	// Refuse to serve on an outdated schema unless configured otherwise
+	if err := migrations.EnsureApplied(migrateCtx, db.GetDatabase(), cfg.Database.MigrationsMode); err != nil {
+		log.Fatalf("Database schema is not up to date: %v", err)
+	}
+
 	// Set Gin mode
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -43,6 +58,11 @@ func main() {
 	// Avoid automatic 301/307 redirects that break CORS preflight (trailing slash, fixed path)
 	router.RedirectTrailingSlash = false
 	router.RedirectFixedPath = false
+	// Client addresses key the login lockouts, so forwarded headers only count
+	// when they come from a configured proxy
+	if err := router.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Setup middleware
 	router.Use(gin.Logger())
@@ -69,7 +89,11 @@ func main() {
 	})
 
 	// Setup routes
-	routes.SetupRoutes(router, db, cfg)
+	scheduler := routes.SetupRoutes(router, db, cfg)
+
+	// Start background jobs (account purge, data export sweep)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler.Start(jobsCtx)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -93,6 +117,7 @@ func main() {
 	<-quit
 
 	log.Println("🔄 Shutting down server...")
+	stopJobs()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -101,6 +126,7 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	scheduler.Wait()
 
 	log.Println("✅ Server shutdown complete")
 }
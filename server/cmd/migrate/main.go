@@ -0,0 +1,97 @@
+// Command migrate applies, reverts and lists database migrations for the
+// database configured by MONGODB_URI and MONGODB_NAME, moving stored media in
+// the storage configured by MEDIA_STORAGE where a migration needs to:
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down [steps]   (default 1)
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/database"
+	"whisper-server/internal/infrastructure/database/migrations"
+	"whisper-server/internal/infrastructure/services"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = "usage: migrate up | down [steps] | status"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	cfg := config.Load()
+	// Migrations do not use multi-document transactions
+	cfg.Database.RequireTransactions = false
+
+	db, err := database.NewMongoDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Disconnect()
+
+	// Some migrations move stored media objects
+	storage, err := services.NewMediaStorage(cfg)
+	if err != nil {
+		fail(db, "Failed to initialize media storage: %v", err)
+	}
+	ctx := migrations.WithMediaStorage(context.Background(), storage)
+	runner := migrations.NewRunner(db.GetDatabase())
+
+	switch os.Args[1] {
+	case "up":
+		n, err := runner.Up(ctx)
+		if err != nil {
+			fail(db, "applied %d migrations, then: %v", n, err)
+		}
+		log.Printf("Applied %d migrations", n)
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			if steps, err = strconv.Atoi(os.Args[2]); err != nil || steps < 1 {
+				fail(db, "steps must be a positive number")
+			}
+		}
+		n, err := runner.Down(ctx, steps)
+		if err != nil {
+			fail(db, "reverted %d migrations, then: %v", n, err)
+		}
+		log.Printf("Reverted %d migrations", n)
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			fail(db, "%v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tDESCRIPTION")
+		for _, st := range statuses {
+			appliedAt := "-"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, st.State, appliedAt, st.Description)
+		}
+		w.Flush()
+	default:
+		fail(db, usage)
+	}
+}
+
+// fail disconnects before exiting, which log.Fatal would skip
+func fail(db *database.MongoDB, format string, args ...interface{}) {
+	db.Disconnect()
+	log.Fatalf(format, args...)
+}
@@ -0,0 +1,39 @@
+// Command mock-oidc runs a local OpenID Connect provider that signs in a fixed user,
+// for trying the OIDC login flow without a real identity provider:
+//
+//	go run ./cmd/mock-oidc -addr :9400 -email jane@example.com
+//	OIDC_PROVIDERS=mock OIDC_MOCK_ISSUER=http://localhost:9400 OIDC_MOCK_CLIENT_ID=whisper
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"whisper-server/internal/infrastructure/oidcmock"
+)
+
+func main() {
+	addr := flag.String("addr", ":9400", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9400", "issuer URL as seen by the API server")
+	clientID := flag.String("client-id", "whisper", "accepted client ID")
+	subject := flag.String("sub", "mock-user-1", "subject of the signed-in user")
+	email := flag.String("email", "mock.user@example.com", "email of the signed-in user")
+	name := flag.String("name", "Mock User", "display name of the signed-in user")
+	username := flag.String("username", "mockuser", "preferred_username of the signed-in user")
+	flag.Parse()
+
+	srv, err := oidcmock.New(*issuer, *clientID, oidcmock.User{
+		Subject:           *subject,
+		Email:             *email,
+		EmailVerified:     true,
+		Name:              *name,
+		PreferredUsername: *username,
+	})
+	if err != nil {
+		log.Fatalf("Failed to start mock OIDC provider: %v", err)
+	}
+
+	log.Printf("Mock OIDC provider for client %q running at %s", *clientID, *issuer)
+	log.Fatal(http.ListenAndServe(*addr, srv))
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// DeleteAccountRequest confirms account deletion with the current password or a
+// two-factor code; neither is needed within a few minutes of signing in, which
+// is how accounts created through OIDC confirm
+type DeleteAccountRequest struct {
+	Password string `json:"password,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+type AccountDeletionResponse struct {
+	PurgeAt time.Time `json:"purgeAt"` // the account can be restored until then
+}
+
+// RestoreAccountRequest carries the token from the restore link mailed at deletion
+type RestoreAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"` // pending, processing, ready, failed
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`   // when the archive is deleted
+	Size        int64      `json:"size,omitempty"`        // bytes
+	DownloadURL string     `json:"downloadUrl,omitempty"` // short-lived signed link, set when ready
+}
@@ -4,34 +4,71 @@ import "time"
 
 // Authentication Request DTOs
 type RegisterRequest struct {
-	Username         string     `json:"username" binding:"required,min=3,max=30"`
+	Username         string     `json:"username" binding:"required,min=3,max=30,excludes=@"` // "@" is reserved for email logins
 	Email            string     `json:"email" binding:"required,email"`
 	Password         string     `json:"password" binding:"required,min=6,max=100"`
 	Name             string     `json:"name" binding:"required,min=2,max=100"`
 	FirstMeetingDate *time.Time `json:"firstMeetingDate,omitempty"`
+	DeviceName       string     `json:"deviceName,omitempty" binding:"omitempty,max=100"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"` // username or email address
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"deviceName,omitempty" binding:"omitempty,max=100"` // derived from the user agent when empty
+}
+
+// ClientInfo describes the device a request came from; it is filled by the handler
+// from request headers and recorded on the session
+type ClientInfo struct {
+	DeviceName string
+	UserAgent  string
+	IP         string
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6,max=100"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6,max=100"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 // Authentication Response DTOs
+// AuthResponse carries either a token pair or, for accounts with two-factor
+// authentication, an MFA challenge to complete at /auth/2fa/verify
 type AuthResponse struct {
-	User         UserResponse `json:"user"`
-	AccessToken  string       `json:"accessToken"`
-	RefreshToken string       `json:"refreshToken"`
-	ExpiresIn    int64        `json:"expiresIn"` // seconds
+	User         *UserResponse `json:"user,omitempty"`
+	AccessToken  string        `json:"accessToken,omitempty"`
+	RefreshToken string        `json:"refreshToken,omitempty"`
+	ExpiresIn    int64         `json:"expiresIn"` // seconds, of the access token or the MFA challenge
+	MFARequired  bool          `json:"mfaRequired,omitempty"`
+	MFAToken     string        `json:"mfaToken,omitempty"`
 }
 
 type UserResponse struct {
 	ID             string      `json:"id"`
 	Username       string      `json:"username"`
 	Email          string      `json:"email"`
+	EmailVerified  bool        `json:"emailVerified"`
 	Name           string      `json:"name"`
 	Avatar         *UserAvatar `json:"avatar,omitempty"`
 	RelationshipID *string     `json:"relationshipId,omitempty"`
@@ -51,8 +88,24 @@ type TokenResponse struct {
 	ExpiresIn    int64  `json:"expiresIn"`
 }
 
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"deviceName"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"` // the session the request was made with
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	Details    string `json:"details,omitempty"`
+	ErrorCode  string `json:"errorCode,omitempty"`  // stable machine-readable reason, e.g. ACCOUNT_LOCKED
+	RetryAfter int64  `json:"retryAfter,omitempty"` // seconds until the request may be retried
 }
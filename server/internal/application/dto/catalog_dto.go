@@ -0,0 +1,19 @@
+package dto
+
+// TypeDefinitionResponse is a catalog type localized to the requested language
+type TypeDefinitionResponse struct {
+	Key               string `json:"key"`
+	Label             string `json:"label"`
+	Icon              string `json:"icon"`
+	Color             string `json:"color,omitempty"`
+	Category          string `json:"category"`
+	DefaultText       string `json:"defaultText,omitempty"`
+	DefaultRecurrence string `json:"defaultRecurrence,omitempty"`
+	RequiresText      bool   `json:"requiresText"`
+}
+
+type CatalogResponse struct {
+	Language     string                    `json:"language"`
+	WhisperTypes []*TypeDefinitionResponse `json:"whisperTypes"`
+	EventTypes   []*TypeDefinitionResponse `json:"eventTypes"`
+}
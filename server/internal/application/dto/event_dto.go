@@ -28,7 +28,14 @@ type EventResponse struct {
 	RelationshipID string             `json:"relationshipId"`
 	CreatedBy      string             `json:"createdBy"`
 	IsPublic       bool               `json:"isPublic"`
+	IsAnonymous    bool               `json:"isAnonymous"`
+	ViewCount      int                `json:"viewCount"`
 	Image          *EventImagePayload `json:"image,omitempty"`
 	CreatedAt      time.Time          `json:"createdAt"`
 	UpdatedAt      time.Time          `json:"updatedAt"`
 }
+
+type SetEventVisibilityRequest struct {
+	IsPublic  *bool `json:"isPublic" binding:"required"`
+	Anonymous *bool `json:"anonymous" binding:"omitempty"` // defaults to true when publishing
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// PublicEventResponse is the explore projection of an event; it never carries relationship or user IDs
+type PublicEventResponse struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Date        time.Time          `json:"date"`
+	Type        string             `json:"type"`
+	Category    string             `json:"category"`
+	Image       *EventImagePayload `json:"image,omitempty"`
+	ViewCount   int                `json:"viewCount"`
+	PublishedAt *time.Time         `json:"publishedAt,omitempty"`
+	AuthorName  string             `json:"authorName,omitempty"` // empty when published anonymously
+}
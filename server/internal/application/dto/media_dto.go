@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+type MediaResponse struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	ContentType string            `json:"contentType"`
+	Size        int64             `json:"size"`
+	Width       int               `json:"width,omitempty"`
+	Height      int               `json:"height,omitempty"`
+	Filename    string            `json:"filename,omitempty"`
+	URL         string            `json:"url"`                  // signed, expires after MEDIA_URL_TTL
+	Thumbnails  map[string]string `json:"thumbnails,omitempty"` // variant name (small, medium) -> signed URL
+	CreatedAt   time.Time         `json:"createdAt"`
+}
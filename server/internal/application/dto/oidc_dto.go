@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// OIDCStartResponse tells the client where to send the browser; the provider
+// redirects back to {BASE_URL}/auth/oidc/callback with code and state
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+	ExpiresIn        int64  `json:"expiresIn"` // seconds the sign-in attempt stays valid
+	BrowserNonce     string `json:"-"`         // set as a cookie, the callback must bring it back
+}
+
+// OIDCCallbackRequest relays the provider's redirect parameters to the API
+type OIDCCallbackRequest struct {
+	State      string `json:"state" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"deviceName,omitempty" binding:"omitempty,max=100"`
+	// BrowserNonce is read from the cookie set when the sign-in started
+	BrowserNonce string `json:"-"`
+}
+
+// OIDCCallbackResponse is a login (token pair or MFA challenge) for sign-in
+// attempts, or just the linked provider for attempts started with /link
+type OIDCCallbackResponse struct {
+	*AuthResponse
+	Provider string `json:"provider"`
+	Linked   bool   `json:"linked,omitempty"`  // the identity was attached to an account in this flow
+	Created  bool   `json:"created,omitempty"` // a new account was provisioned
+}
+
+type ExternalIdentityResponse struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"`
+	LinkedAt    time.Time  `json:"linkedAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+type ExternalIdentityListResponse struct {
+	Identities []ExternalIdentityResponse `json:"identities"`
+}
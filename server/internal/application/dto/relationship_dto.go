@@ -9,6 +9,23 @@ type GenerateInviteCodeRequest struct {
 type GenerateInviteCodeResponse struct {
 	InviteCode string     `json:"inviteCode"`
 	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	Link       string     `json:"link"`      // shareable deep link that opens the join screen
+	QRCodeURL  string     `json:"qrCodeUrl"` // API path of the link rendered as a QR code PNG
+}
+
+type InviteCodeResponse struct {
+	Code             string     `json:"code"`
+	Status           string     `json:"status"` // active, used, revoked, expired
+	Link             string     `json:"link"`
+	FirstMeetingDate time.Time  `json:"firstMeetingDate"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+// InviteCodeListResponse lists the caller's invite codes, newest first; at most one is active
+type InviteCodeListResponse struct {
+	Invites []*InviteCodeResponse `json:"invites"`
 }
 
 type JoinWithInviteRequest struct {
@@ -20,8 +37,32 @@ type RelationshipResponse struct {
 	Status     string                `json:"status"`
 	Partners   []RelationshipPartner `json:"partners"`
 	InviteCode string                `json:"inviteCode,omitempty"`
-	CreatedAt  time.Time             `json:"createdAt"`
-	UpdatedAt  time.Time             `json:"updatedAt"`
+	// Counted from the first meeting date, or from when the partners joined if it is unknown
+	FirstMeetingDate *time.Time `json:"firstMeetingDate,omitempty"`
+	RelationshipDays int        `json:"relationshipDays"`
+	YearsTogether    int        `json:"yearsTogether"`
+	NextAnniversary  *time.Time `json:"nextAnniversary,omitempty"` // only for current relationships
+	// Only set for past relationships
+	DisconnectedAt       *time.Time `json:"disconnectedAt,omitempty"`
+	ReconnectRequestedBy string     `json:"reconnectRequestedBy,omitempty"` // user ID waiting for the other partner to reconnect
+	// Latest disconnect request, pending while status is disconnect_requested
+	DisconnectRequest *DisconnectRequestResponse `json:"disconnectRequest,omitempty"`
+	CreatedAt         time.Time                  `json:"createdAt"`
+	UpdatedAt         time.Time                  `json:"updatedAt"`
+}
+
+type DisconnectRequestResponse struct {
+	RequestedBy   string     `json:"requestedBy"`
+	RequestedAt   time.Time  `json:"requestedAt"`
+	UndoUntil     time.Time  `json:"undoUntil"`     // the requester can cancel until then
+	AutoConfirmAt time.Time  `json:"autoConfirmAt"` // confirmed automatically if the partner does not answer
+	State         string     `json:"state"`         // requested, confirmed, cancelled, auto_confirmed
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// RelationshipHistoryResponse lists the user's past relationships, most recent first
+type RelationshipHistoryResponse struct {
+	Relationships []*RelationshipResponse `json:"relationships"`
 }
 
 type RelationshipPartner struct {
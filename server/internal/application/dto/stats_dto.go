@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+type RelationshipStatsResponse struct {
+	RelationshipID   string     `json:"relationshipId"`
+	FirstMeetingDate *time.Time `json:"firstMeetingDate,omitempty"`
+	RelationshipDays int        `json:"relationshipDays"`
+
+	// Memories (events)
+	MemoriesCount     int            `json:"memoriesCount"`
+	PublicEventsCount int            `json:"publicEventsCount"`
+	EventsByType      map[string]int `json:"eventsByType"`
+	EventsByCategory  map[string]int `json:"eventsByCategory"`
+	FirstMemoryAt     *time.Time     `json:"firstMemoryAt,omitempty"`
+	LastMemoryAt      *time.Time     `json:"lastMemoryAt,omitempty"`
+	LongestGapDays    int            `json:"longestGapDays"`
+	LongestGapFrom    *time.Time     `json:"longestGapFrom,omitempty"`
+	LongestGapTo      *time.Time     `json:"longestGapTo,omitempty"`
+
+	// Whispers
+	WhispersCount int `json:"whispersCount"`
+	WhispersDone  int `json:"whispersDone"` // completed occurrences; a recurring whisper counts every day done
+	WhispersOpen  int `json:"whispersOpen"` // one-off whispers still to do
+}
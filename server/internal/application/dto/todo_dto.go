@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+type CreateTodoRequest struct {
+	Title       string     `json:"title" binding:"required,min=1,max=200"`
+	Description string     `json:"description" binding:"omitempty,max=500"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low medium high"`
+	DueDate     *time.Time `json:"dueDate" binding:"omitempty"`
+	AssignedTo  string     `json:"assignedTo" binding:"omitempty"`
+}
+
+type UpdateTodoRequest struct {
+	Title       string     `json:"title" binding:"omitempty,min=1,max=200"`
+	Description string     `json:"description" binding:"omitempty,max=500"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low medium high"`
+	DueDate     *time.Time `json:"dueDate" binding:"omitempty"`
+	AssignedTo  *string    `json:"assignedTo" binding:"omitempty"` // empty string unassigns
+}
+
+type TodoResponse struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description,omitempty"`
+	Priority       string     `json:"priority"`
+	DueDate        *time.Time `json:"dueDate,omitempty"`
+	AssignedTo     string     `json:"assignedTo,omitempty"`
+	RelationshipID string     `json:"relationshipId"`
+	CreatedBy      string     `json:"createdBy"`
+	IsCompleted    bool       `json:"isCompleted"`
+	IsOverdue      bool       `json:"isOverdue"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+	CompletedBy    string     `json:"completedBy,omitempty"`
+	EventID        string     `json:"eventId,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+type CompleteTodoResponse struct {
+	Todo  *TodoResponse  `json:"todo"`
+	Event *EventResponse `json:"event"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt,omitempty"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`     // base32 key for manual entry
+	OTPAuthURI string `json:"otpauthUri"` // otpauth:// URI to render as a QR code
+}
+
+// TwoFactorCodeRequest carries a TOTP code or, where accepted, a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest needs the password unless the session signed in moments ago
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse is the only time recovery codes are shown
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// VerifyMFARequest completes a login that answered with mfaRequired
+type VerifyMFARequest struct {
+	MFAToken   string `json:"mfaToken" binding:"required"`
+	Code       string `json:"code" binding:"required"` // TOTP code or recovery code
+	DeviceName string `json:"deviceName,omitempty" binding:"omitempty,max=100"`
+}
@@ -2,15 +2,45 @@ package dto
 
 // UpdateUserProfileRequest represents the request to update user profile
 type UpdateUserProfileRequest struct {
-	Name   *string `json:"name,omitempty"`
-	Avatar *string `json:"avatar,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	Avatar        *string `json:"avatar,omitempty"`        // legacy base64 image, moved into media storage
+	AvatarMediaID *string `json:"avatarMediaId,omitempty"` // uploaded avatar media; empty string removes the avatar
 }
 
 // UserProfileResponse represents the user profile response
 type UserProfileResponse struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Avatar   string `json:"avatar,omitempty"`
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	Username      string                `json:"username"`
+	Email         string                `json:"email"`
+	EmailVerified bool                  `json:"emailVerified"`
+	Avatar        string                `json:"avatar,omitempty"` // signed URL, or legacy base64 data
+	Settings      *UserSettingsResponse `json:"settings,omitempty"`
+}
+
+// UpdateUserSettingsRequest is a partial update; omitted fields keep their current value
+type UpdateUserSettingsRequest struct {
+	AutoPublic    *bool                              `json:"autoPublic,omitempty"`
+	Language      *string                            `json:"language,omitempty"`
+	Timezone      *string                            `json:"timezone,omitempty"`
+	Notifications *UpdateNotificationSettingsRequest `json:"notifications,omitempty"`
+}
+
+type UpdateNotificationSettingsRequest struct {
+	Whispers *bool `json:"whispers,omitempty"`
+	Events   *bool `json:"events,omitempty"`
+	Partner  *bool `json:"partner,omitempty"`
+}
+
+type UserSettingsResponse struct {
+	AutoPublic    bool                         `json:"autoPublic"`
+	Language      string                       `json:"language"`
+	Timezone      string                       `json:"timezone"`
+	Notifications NotificationSettingsResponse `json:"notifications"`
+}
+
+type NotificationSettingsResponse struct {
+	Whispers bool `json:"whispers"`
+	Events   bool `json:"events"`
+	Partner  bool `json:"partner"`
 }
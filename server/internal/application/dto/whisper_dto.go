@@ -3,17 +3,19 @@ package dto
 import "time"
 
 type CreateWhisperRequest struct {
-	Type       string    `json:"type" binding:"required"`
-	Text       string    `json:"text" binding:"omitempty,max=200"`
-	Recurrence string    `json:"recurrence" binding:"required,oneof=once everyday weekly"`
+	Type       string    `json:"type" binding:"required"`                                           // key from the whisper type catalog
+	Text       string    `json:"text" binding:"omitempty,max=200"`                                  // defaults to the type's text in the creator's language
+	Recurrence string    `json:"recurrence" binding:"omitempty,oneof=once everyday weekly monthly"` // defaults to the type's recurrence
+	RRule      string    `json:"rrule" binding:"omitempty,max=200"`                                 // e.g. FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10; wins over recurrence
 	Date       time.Time `json:"date" binding:"required"`
 }
 
 type UpdateWhisperRequest struct {
 	Text       string    `json:"text" binding:"omitempty,max=200"`
-	Recurrence string    `json:"recurrence" binding:"omitempty,oneof=once everyday weekly"`
+	Recurrence string    `json:"recurrence" binding:"omitempty,oneof=once everyday weekly monthly"`
+	RRule      *string   `json:"rrule" binding:"omitempty,max=200"` // empty string removes the rule
 	Date       time.Time `json:"date" binding:"omitempty"`
-	IsDone     *bool     `json:"isDone" binding:"omitempty"`
+	IsDone     *bool     `json:"isDone" binding:"omitempty"` // applies to today's occurrence for recurring whispers
 }
 
 type WhisperResponse struct {
@@ -21,6 +23,8 @@ type WhisperResponse struct {
 	Type           string    `json:"type"`
 	Text           string    `json:"text,omitempty"`
 	Recurrence     string    `json:"recurrence"`
+	RRule          string    `json:"rrule,omitempty"`
+	Timezone       string    `json:"timezone"`
 	Date           time.Time `json:"date"`
 	RelationshipID string    `json:"relationshipId"`
 	CreatedBy      string    `json:"createdBy"`
@@ -29,12 +33,42 @@ type WhisperResponse struct {
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
+// WhisperOccurrenceResponse is one dated instance of a (possibly recurring) whisper
+type WhisperOccurrenceResponse struct {
+	WhisperID   string     `json:"whisperId"`
+	Date        string     `json:"date"` // YYYY-MM-DD
+	Type        string     `json:"type"`
+	Text        string     `json:"text,omitempty"`
+	Recurrence  string     `json:"recurrence"`
+	RRule       string     `json:"rrule,omitempty"`
+	IsDone      bool       `json:"isDone"`
+	CompletedBy string     `json:"completedBy,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// WhisperDayResponse groups the whispers due on one calendar day by completion state
+type WhisperDayResponse struct {
+	Date     string                       `json:"date"` // YYYY-MM-DD in the caller's timezone
+	Timezone string                       `json:"timezone"`
+	Pending  []*WhisperOccurrenceResponse `json:"pending"`
+	Done     []*WhisperOccurrenceResponse `json:"done"`
+}
+
+type SetOccurrenceDoneRequest struct {
+	IsDone *bool `json:"isDone" binding:"required"`
+}
+
 type ConvertWhisperRequest struct {
 	Image *EventImagePayload `json:"image" binding:"omitempty"`
 }
 
+// EventImagePayload references an uploaded media object ("media"); "base64" payloads are
+// still accepted and moved into media storage, "url" keeps an external link
 type EventImagePayload struct {
-	Type     string `json:"type" binding:"omitempty,oneof=base64 url"`
-	Data     string `json:"data" binding:"omitempty"`
-	Filename string `json:"filename" binding:"omitempty"`
+	Type       string            `json:"type" binding:"omitempty,oneof=media base64 url"`
+	MediaID    string            `json:"mediaId,omitempty" binding:"omitempty"`
+	Data       string            `json:"data,omitempty" binding:"omitempty"`
+	Filename   string            `json:"filename,omitempty" binding:"omitempty"`
+	URL        string            `json:"url,omitempty"`        // response only: signed full image URL, detail views only
+	Thumbnails map[string]string `json:"thumbnails,omitempty"` // response only: small/medium signed URLs
 }
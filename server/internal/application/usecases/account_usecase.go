@@ -0,0 +1,334 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountUseCase handles account deletion: a soft delete that can be undone
+// during a grace period, followed by a purge of the user's data
+type AccountUseCase interface {
+	// RequestDeletion deletes the account after the user confirmed it was them;
+	// sessionID is the session making the request
+	RequestDeletion(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error)
+	Restore(ctx context.Context, req *dto.RestoreAccountRequest) error
+	// PurgeDue removes accounts whose grace period is over and returns how many
+	PurgeDue(ctx context.Context) (int, error)
+}
+
+var (
+	ErrInvalidRestoreToken = fmtError("invalid or expired restore token")
+	ErrReauthRequired      = fmtError("confirm with your password or a two-factor code, or sign in again")
+)
+
+// freshLoginWindow is how long after signing in a session may delete the
+// account or turn off 2FA without confirming its password again
+const freshLoginWindow = 10 * time.Minute
+
+// signedInRecently reports whether the session is the user's and signed in
+// within freshLoginWindow. It stands in for the password of accounts
+// provisioned through OIDC, which have none the user knows.
+func signedInRecently(ctx context.Context, sessions repositories.SessionRepository, userID, sessionID primitive.ObjectID) bool {
+	session, err := sessions.FindByID(ctx, sessionID)
+	return err == nil && session.UserID == userID && session.IsActive(time.Now()) && time.Since(session.CreatedAt) <= freshLoginWindow
+}
+
+// RelationshipLeaver ends the user's active relationship (implemented by RelationshipUseCase)
+type RelationshipLeaver interface {
+	DisconnectRelationship(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// ExportRemover deletes a user's data export archives (implemented by DataExportUseCase)
+type ExportRemover interface {
+	DeleteExports(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// purgeBatchSize bounds the accounts handled per purge run
+const purgeBatchSize = 50
+
+// AccountPurgeRepos are the stores a purge deletes from
+type AccountPurgeRepos struct {
+	Relationships repositories.RelationshipRepository
+	Events        repositories.EventRepository
+	Whispers      repositories.WhisperRepository
+	Todos         repositories.TodoRepository
+	Invites       repositories.InviteRepository
+	Identities    repositories.ExternalIdentityRepository
+	TwoFactor     repositories.TwoFactorRepository
+}
+
+type accountUseCase struct {
+	userRepo        repositories.UserRepository
+	tokenRepo       repositories.OneTimeTokenRepository
+	sessionRepo     repositories.SessionRepository
+	purge           AccountPurgeRepos
+	passwordService services.PasswordService
+	twoFactor       TwoFactorVerifier
+	mailer          services.Mailer
+	sessions        SessionRevoker
+	relationships   RelationshipLeaver
+	exports         ExportRemover
+	media           MediaUseCase
+	baseURL         string
+	gracePeriod     time.Duration
+}
+
+func NewAccountUseCase(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.OneTimeTokenRepository,
+	sessionRepo repositories.SessionRepository,
+	purge AccountPurgeRepos,
+	passwordService services.PasswordService,
+	twoFactor TwoFactorVerifier,
+	mailer services.Mailer,
+	sessions SessionRevoker,
+	relationships RelationshipLeaver,
+	exports ExportRemover,
+	media MediaUseCase,
+	baseURL string,
+	gracePeriod time.Duration,
+) AccountUseCase {
+	return &accountUseCase{
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		sessionRepo:     sessionRepo,
+		purge:           purge,
+		passwordService: passwordService,
+		twoFactor:       twoFactor,
+		mailer:          mailer,
+		sessions:        sessions,
+		relationships:   relationships,
+		exports:         exports,
+		media:           media,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		gracePeriod:     gracePeriod,
+	}
+}
+
+func (uc *accountUseCase) RequestDeletion(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error) {
+	log.Printf("[ACCOUNT][DELETE][START] user=%s", userID.Hex())
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.reauthenticate(ctx, user, sessionID, req); err != nil {
+		log.Printf("[ACCOUNT][DELETE][DENIED] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+
+	// The partner should not keep sharing a timeline with a vanished account
+	if user.HasRelationship() {
+		if err := uc.relationships.DisconnectRelationship(ctx, userID); err != nil {
+			log.Printf("[ACCOUNT][DELETE][WARN] user=%s disconnect: %v", userID.Hex(), err)
+		}
+	}
+
+	purgeAt := time.Now().Add(uc.gracePeriod)
+	if err := uc.userRepo.Delete(ctx, userID, purgeAt); err != nil {
+		log.Printf("[ACCOUNT][DELETE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	if err := uc.sessions.LogoutAll(ctx, userID); err != nil {
+		log.Printf("[ACCOUNT][DELETE][WARN] user=%s logout: %v", userID.Hex(), err)
+	}
+	if user.Email != "" {
+		uc.sendRestoreLink(ctx, user, purgeAt)
+	}
+
+	log.Printf("[ACCOUNT][DELETE][DONE] user=%s purgeAt=%s", userID.Hex(), purgeAt.Format(time.RFC3339))
+	return &dto.AccountDeletionResponse{PurgeAt: purgeAt}, nil
+}
+
+// reauthenticate checks that the deletion comes from the account owner: by
+// password, by two-factor code, or from a session that signed in moments ago
+func (uc *accountUseCase) reauthenticate(ctx context.Context, user *entities.User, sessionID primitive.ObjectID, req *dto.DeleteAccountRequest) error {
+	switch {
+	case req.Password != "":
+		if err := uc.passwordService.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+			return ErrIncorrectPassword
+		}
+		return nil
+	case req.Code != "":
+		return uc.twoFactor.VerifySecondFactor(ctx, user.ID, req.Code)
+	}
+	if !signedInRecently(ctx, uc.sessionRepo, user.ID, sessionID) {
+		return ErrReauthRequired
+	}
+	return nil
+}
+
+// sendRestoreLink mails a link that undoes the deletion until the purge; failures
+// are only logged since the deletion itself already happened
+func (uc *accountUseCase) sendRestoreLink(ctx context.Context, user *entities.User, purgeAt time.Time) {
+	token, hash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		log.Printf("[ACCOUNT][DELETE][WARN] user=%s restore token: %v", user.ID.Hex(), err)
+		return
+	}
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID, entities.OneTimeTokenAccountRestore); err != nil {
+		log.Printf("[ACCOUNT][DELETE][WARN] user=%s restore token: %v", user.ID.Hex(), err)
+		return
+	}
+	record := entities.NewOneTimeToken(user.ID, entities.OneTimeTokenAccountRestore, hash, purgeAt)
+	if err := uc.tokenRepo.Create(ctx, record); err != nil {
+		log.Printf("[ACCOUNT][DELETE][WARN] user=%s restore token: %v", user.ID.Hex(), err)
+		return
+	}
+
+	link := uc.baseURL + "/restore-account?token=" + url.QueryEscape(token)
+	msg := services.MailMessage{
+		To:      user.Email,
+		Subject: "Your Whisper account was deleted",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Your Whisper account has been deleted and will be erased for good on %s.\n"+
+			"Changed your mind? Open the link below before then to restore it:\n\n"+
+			"%s\n",
+			user.Name, purgeAt.UTC().Format("January 2, 2006"), link),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		log.Printf("[ACCOUNT][DELETE][WARN] user=%s send failed: %v", user.ID.Hex(), err)
+	}
+}
+
+func (uc *accountUseCase) Restore(ctx context.Context, req *dto.RestoreAccountRequest) error {
+	record, err := uc.tokenRepo.FindByHash(ctx, entities.OneTimeTokenAccountRestore, services.HashOpaqueToken(req.Token))
+	if err != nil || !record.IsUsable(time.Now()) {
+		return ErrInvalidRestoreToken
+	}
+	log.Printf("[ACCOUNT][RESTORE][START] user=%s", record.UserID.Hex())
+
+	consumed, err := uc.tokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidRestoreToken
+	}
+	if err := uc.userRepo.Restore(ctx, record.UserID); err != nil {
+		log.Printf("[ACCOUNT][RESTORE][ERROR] user=%s err=%v", record.UserID.Hex(), err)
+		return ErrInvalidRestoreToken
+	}
+
+	log.Printf("[ACCOUNT][RESTORE][DONE] user=%s", record.UserID.Hex())
+	return nil
+}
+
+func (uc *accountUseCase) PurgeDue(ctx context.Context) (int, error) {
+	users, err := uc.userRepo.FindDueForPurge(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, user := range users {
+		if err := uc.purgeUser(ctx, user); err != nil {
+			// Left in place; the next run tries again
+			log.Printf("[ACCOUNT][PURGE][ERROR] user=%s err=%v", user.ID.Hex(), err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeUser erases a deleted account. Relationship content stays while a partner
+// account still exists, since it is that partner's history too; it goes with the
+// last remaining partner.
+func (uc *accountUseCase) purgeUser(ctx context.Context, user *entities.User) error {
+	log.Printf("[ACCOUNT][PURGE][START] user=%s", user.ID.Hex())
+
+	rels, err := uc.purge.Relationships.FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		shared, err := uc.hasOtherPartner(ctx, rel, user.ID)
+		if err != nil {
+			return err
+		}
+		if shared {
+			continue
+		}
+		if err := uc.deleteRelationshipContent(ctx, rel.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := uc.purge.Invites.DeleteByCreator(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.purge.Identities.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := uc.purge.TwoFactor.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	for _, purpose := range []string{entities.OneTimeTokenPasswordReset, entities.OneTimeTokenEmailVerification, entities.OneTimeTokenAccountRestore} {
+		if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID, purpose); err != nil {
+			return err
+		}
+	}
+	if err := uc.exports.DeleteExports(ctx, user.ID); err != nil {
+		return err
+	}
+	if user.Avatar != nil {
+		uc.media.Discard(ctx, user.Avatar.MediaID, user.ID)
+	}
+	if err := uc.userRepo.Purge(ctx, user.ID); err != nil {
+		return err
+	}
+
+	log.Printf("[ACCOUNT][PURGE][DONE] user=%s", user.ID.Hex())
+	return nil
+}
+
+func (uc *accountUseCase) hasOtherPartner(ctx context.Context, rel *entities.Relationship, userID primitive.ObjectID) (bool, error) {
+	for _, p := range rel.Partners {
+		if p.UserID == userID {
+			continue
+		}
+		exists, err := uc.userRepo.Exists(ctx, p.UserID)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+func (uc *accountUseCase) deleteRelationshipContent(ctx context.Context, relationshipID primitive.ObjectID) error {
+	// Release event images before the events referencing them disappear
+	for {
+		events, err := uc.purge.Events.FindAllByRelationshipID(ctx, relationshipID, 100, 0)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if ev.Image != nil {
+				uc.media.Discard(ctx, ev.Image.MediaID, ev.ID)
+			}
+			if err := uc.purge.Events.Delete(ctx, ev.ID); err != nil {
+				return err
+			}
+		}
+		if len(events) < 100 {
+			break
+		}
+	}
+	if err := uc.purge.Whispers.DeleteByRelationshipID(ctx, relationshipID); err != nil {
+		return err
+	}
+	if err := uc.purge.Todos.DeleteByRelationshipID(ctx, relationshipID); err != nil {
+		return err
+	}
+	return uc.purge.Relationships.Delete(ctx, relationshipID)
+}
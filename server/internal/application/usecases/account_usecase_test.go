@@ -0,0 +1,297 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// purgeLog records deletions in the stores that have no in-memory fake
+type purgeLog struct {
+	todos      []primitive.ObjectID // relationship IDs
+	identities []primitive.ObjectID // user IDs
+	twoFactor  []primitive.ObjectID
+	exports    []primitive.ObjectID
+	media      []primitive.ObjectID
+}
+
+type loggedTodos struct {
+	repositories.TodoRepository
+	log *purgeLog
+}
+
+func (r loggedTodos) DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error {
+	r.log.todos = append(r.log.todos, relationshipID)
+	return nil
+}
+
+type loggedIdentities struct {
+	repositories.ExternalIdentityRepository
+	log *purgeLog
+}
+
+func (r loggedIdentities) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	r.log.identities = append(r.log.identities, userID)
+	return nil
+}
+
+type loggedTwoFactor struct {
+	repositories.TwoFactorRepository
+	log *purgeLog
+}
+
+func (r loggedTwoFactor) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	r.log.twoFactor = append(r.log.twoFactor, userID)
+	return nil
+}
+
+type loggedExports struct{ log *purgeLog }
+
+func (r loggedExports) DeleteExports(ctx context.Context, userID primitive.ObjectID) error {
+	r.log.exports = append(r.log.exports, userID)
+	return nil
+}
+
+type loggedMedia struct {
+	MediaUseCase
+	log *purgeLog
+}
+
+func (m loggedMedia) Discard(ctx context.Context, id *primitive.ObjectID, from primitive.ObjectID) {
+	if id != nil {
+		m.log.media = append(m.log.media, *id)
+	}
+}
+
+type accountFixture struct {
+	*relationshipFixture
+	testPeople
+	sessions  repositories.SessionRepository
+	events    repositories.EventRepository
+	twoFactor *fakeTwoFactor
+	mail      *outbox
+	purged    *purgeLog
+	uc        AccountUseCase
+}
+
+const accountPassword = "secret123"
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	t.Helper()
+	rf := newRelationshipFixture(nil)
+	f := &accountFixture{
+		relationshipFixture: rf,
+		testPeople:          rf.addPeople(t),
+		sessions:            memory.NewSessionRepository(rf.store),
+		events:              memory.NewEventRepository(rf.store),
+		twoFactor:           &fakeTwoFactor{enabled: map[primitive.ObjectID]bool{}},
+		mail:                &outbox{},
+		purged:              &purgeLog{},
+	}
+	passwords := services.NewPasswordService()
+	hash, err := passwords.HashPassword(accountPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rf.users.UpdatePassword(context.Background(), f.alice, hash); err != nil {
+		t.Fatal(err)
+	}
+	jwt := services.NewJWTService(&config.Config{JWT: config.JWTConfig{
+		Secret: "test-secret", AccessExpiresIn: "15m", RefreshExpiresIn: "24h",
+	}})
+	auth := NewAuthUseCase(rf.users, memory.NewRefreshTokenRepository(rf.store), f.sessions, jwt,
+		passwords, nopVerifier{}, nopThrottle{}, f.twoFactor, nil)
+	f.uc = NewAccountUseCase(rf.users, memory.NewOneTimeTokenRepository(rf.store), f.sessions, AccountPurgeRepos{
+		Relationships: rf.rels,
+		Events:        f.events,
+		Whispers:      memory.NewWhisperRepository(rf.store),
+		Todos:         loggedTodos{log: f.purged},
+		Invites:       rf.invs,
+		Identities:    loggedIdentities{log: f.purged},
+		TwoFactor:     loggedTwoFactor{log: f.purged},
+	}, passwords, f.twoFactor, f.mail, auth, rf.uc, loggedExports{log: f.purged}, loggedMedia{log: f.purged},
+		"https://whisper.test", 30*24*time.Hour)
+	return f
+}
+
+// signIn stores a session for the user as a login made at the given time would
+func (f *accountFixture) signIn(t *testing.T, userID primitive.ObjectID, at time.Time) primitive.ObjectID {
+	t.Helper()
+	session := entities.NewSession(userID, "phone", "whisper-tests/1.0", "127.0.0.1", time.Now().Add(24*time.Hour))
+	session.CreatedAt = at
+	if err := f.sessions.Create(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session.ID
+}
+
+func (f *accountFixture) deleted(t *testing.T, userID primitive.ObjectID) bool {
+	t.Helper()
+	_, err := f.users.FindByID(context.Background(), userID)
+	return err != nil
+}
+
+func TestRequestDeletionReauthentication(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     dto.DeleteAccountRequest
+		session func(f *accountFixture) primitive.ObjectID
+		wantErr error
+	}{
+		{"password", dto.DeleteAccountRequest{Password: accountPassword}, nil, nil},
+		{"wrong password", dto.DeleteAccountRequest{Password: "guess"}, nil, ErrIncorrectPassword},
+		{"two-factor code", dto.DeleteAccountRequest{Code: validTwoFactorCode}, nil, nil},
+		{"wrong two-factor code", dto.DeleteAccountRequest{Code: "000000"}, nil, ErrInvalidTwoFactorCode},
+		{"fresh login", dto.DeleteAccountRequest{}, func(f *accountFixture) primitive.ObjectID {
+			return f.signIn(t, f.alice, time.Now().Add(-time.Minute))
+		}, nil},
+		{"old login", dto.DeleteAccountRequest{}, func(f *accountFixture) primitive.ObjectID {
+			return f.signIn(t, f.alice, time.Now().Add(-freshLoginWindow-time.Minute))
+		}, ErrReauthRequired},
+		{"another user's session", dto.DeleteAccountRequest{}, func(f *accountFixture) primitive.ObjectID {
+			return f.signIn(t, f.bob, time.Now())
+		}, ErrReauthRequired},
+		{"unknown session", dto.DeleteAccountRequest{}, nil, ErrReauthRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			f.twoFactor.enabled[f.alice] = true
+			// Sessions from a while ago, so only the ones a case adds are fresh
+			sessionID := f.signIn(t, f.alice, time.Now().Add(-time.Hour))
+			if tt.session != nil {
+				sessionID = tt.session(f)
+			}
+
+			_, err := f.uc.RequestDeletion(context.Background(), f.alice, sessionID, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := f.deleted(t, f.alice); got != (tt.wantErr == nil) {
+				t.Errorf("deleted = %t", got)
+			}
+		})
+	}
+}
+
+var restoreLink = regexp.MustCompile(`/restore-account\?token=(\S+)`)
+
+func TestRequestDeletionAndRestore(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+	sessionID := f.signIn(t, f.alice, time.Now())
+
+	res, err := f.uc.RequestDeletion(ctx, f.alice, sessionID, &dto.DeleteAccountRequest{Password: accountPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.PurgeAt.After(time.Now().Add(29 * 24 * time.Hour)) {
+		t.Errorf("purgeAt = %s, want the end of the grace period", res.PurgeAt)
+	}
+	if !f.deleted(t, f.alice) {
+		t.Fatal("account still visible")
+	}
+	if active, _ := f.sessions.FindActiveByUserID(ctx, f.alice); len(active) != 0 {
+		t.Errorf("%d sessions still active", len(active))
+	}
+	if f.relationshipID(t, f.bob) != nil {
+		t.Error("partner still points at the relationship")
+	}
+	if ok, _ := f.users.ExistsByUsername(ctx, "alice"); !ok {
+		t.Error("username released before the purge")
+	}
+
+	token := f.mail.linkToken(t, restoreLink)
+	if err := f.uc.Restore(ctx, &dto.RestoreAccountRequest{Token: token}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if f.deleted(t, f.alice) {
+		t.Fatal("account not restored")
+	}
+	if err := f.uc.Restore(ctx, &dto.RestoreAccountRequest{Token: token}); !errors.Is(err, ErrInvalidRestoreToken) {
+		t.Errorf("second restore: %v, want %v", err, ErrInvalidRestoreToken)
+	}
+}
+
+func TestPurgeDue(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountFixture(t)
+
+	imageID := primitive.NewObjectID()
+	event := entities.NewEvent("First date", entities.EventTypeDate, time.Now(), f.relID, f.alice)
+	event.SetImage(&entities.EventImage{Type: entities.ImageTypeMedia, MediaID: &imageID, Key: "events/first.jpg"})
+	if err := f.events.Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	// Deletion ends the relationship; purging is what removes data
+	if err := f.relationshipFixture.uc.DisconnectRelationship(ctx, f.alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.users.Delete(ctx, f.alice, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.users.Delete(ctx, f.erin, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := f.uc.PurgeDue(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("purged %d, %v; want alice only", n, err)
+	}
+	if ok, _ := f.users.Exists(ctx, f.alice); ok {
+		t.Error("alice still stored")
+	}
+	if ok, _ := f.users.Exists(ctx, f.erin); !ok {
+		t.Error("erin purged before the grace period ended")
+	}
+	if ok, _ := f.users.ExistsByUsername(ctx, "alice"); ok {
+		t.Error("username still reserved after the purge")
+	}
+	for name, ids := range map[string][]primitive.ObjectID{"identities": f.purged.identities, "two-factor": f.purged.twoFactor, "exports": f.purged.exports} {
+		if len(ids) != 1 || ids[0] != f.alice {
+			t.Errorf("%s purged for %v, want alice", name, ids)
+		}
+	}
+
+	// Bob still has the shared history
+	if _, err := f.rels.FindByID(ctx, f.relID); err != nil {
+		t.Errorf("relationship purged while bob exists: %v", err)
+	}
+	if _, err := f.events.FindByID(ctx, event.ID); err != nil {
+		t.Errorf("event purged while bob exists: %v", err)
+	}
+	if len(f.purged.todos) != 0 || len(f.purged.media) != 0 {
+		t.Errorf("shared content purged: todos=%v media=%v", f.purged.todos, f.purged.media)
+	}
+
+	// It goes with the last partner
+	if err := f.users.Delete(ctx, f.bob, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := f.uc.PurgeDue(ctx); err != nil || n != 1 {
+		t.Fatalf("purged %d, %v; want bob", n, err)
+	}
+	if _, err := f.rels.FindByID(ctx, f.relID); err == nil {
+		t.Error("relationship outlived both partners")
+	}
+	if _, err := f.events.FindByID(ctx, event.ID); err == nil {
+		t.Error("event outlived both partners")
+	}
+	if len(f.purged.todos) != 1 || f.purged.todos[0] != f.relID {
+		t.Errorf("todos purged for %v, want the relationship", f.purged.todos)
+	}
+	if len(f.purged.media) != 1 || f.purged.media[0] != imageID {
+		t.Errorf("media discarded = %v, want the event image", f.purged.media)
+	}
+}
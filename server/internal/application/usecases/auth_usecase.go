@@ -3,38 +3,78 @@ package usecases
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"whisper-server/internal/application/dto"
 	"whisper-server/internal/domain/entities"
 	"whisper-server/internal/domain/repositories"
 	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AuthUseCase interface {
-	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.AuthResponse, error)
-	Login(ctx context.Context, req *dto.LoginRequest) (*dto.AuthResponse, error)
-	RefreshToken(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.TokenResponse, error)
+	Register(ctx context.Context, req *dto.RegisterRequest, client dto.ClientInfo) (*dto.AuthResponse, error)
+	Login(ctx context.Context, req *dto.LoginRequest, client dto.ClientInfo) (*dto.AuthResponse, error)
+	// VerifyMFA completes a login that returned an MFA challenge
+	VerifyMFA(ctx context.Context, req *dto.VerifyMFARequest, client dto.ClientInfo) (*dto.AuthResponse, error)
+	ExternalLoginCompleter
+	RefreshToken(ctx context.Context, req *dto.RefreshTokenRequest, client dto.ClientInfo) (*dto.TokenResponse, error)
+	Logout(ctx context.Context, userID primitive.ObjectID, req *dto.LogoutRequest) error
+	LogoutAll(ctx context.Context, userID primitive.ObjectID) error
+	// LogoutOthers ends every session of the user except keepSessionID
+	LogoutOthers(ctx context.Context, userID, keepSessionID primitive.ObjectID) error
+	ListSessions(ctx context.Context, userID, currentSessionID primitive.ObjectID) (*dto.SessionListResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) error
 }
 
+var (
+	ErrInvalidRefreshToken = fmtError("invalid refresh token")
+	ErrRefreshTokenReused  = fmtError("refresh token reuse detected")
+	ErrUserInactive        = fmtError("account is deactivated")
+	ErrSessionNotFound     = fmtError("session not found")
+	ErrInvalidMFAToken     = fmtError("invalid or expired MFA challenge")
+)
+
 type authUseCase struct {
 	userRepo        repositories.UserRepository
+	refreshRepo     repositories.RefreshTokenRepository
+	sessionRepo     repositories.SessionRepository
 	jwtService      services.JWTService
 	passwordService services.PasswordService
+	verifier        EmailVerifier
+	throttle        LoginThrottle
+	twoFactor       TwoFactorVerifier
+	media           MediaURLs
 }
 
 func NewAuthUseCase(
 	userRepo repositories.UserRepository,
+	refreshRepo repositories.RefreshTokenRepository,
+	sessionRepo repositories.SessionRepository,
 	jwtService services.JWTService,
 	passwordService services.PasswordService,
+	verifier EmailVerifier,
+	throttle LoginThrottle,
+	twoFactor TwoFactorVerifier,
+	media MediaURLs,
 ) AuthUseCase {
 	return &authUseCase{
 		userRepo:        userRepo,
+		refreshRepo:     refreshRepo,
+		sessionRepo:     sessionRepo,
 		jwtService:      jwtService,
 		passwordService: passwordService,
+		verifier:        verifier,
+		throttle:        throttle,
+		twoFactor:       twoFactor,
+		media:           media,
 	}
 }
 
-func (uc *authUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.AuthResponse, error) {
+func (uc *authUseCase) Register(ctx context.Context, req *dto.RegisterRequest, client dto.ClientInfo) (*dto.AuthResponse, error) {
 	// Check if username already exists
 	exists, err := uc.userRepo.ExistsByUsername(ctx, req.Username)
 	if err != nil {
@@ -68,78 +108,426 @@ func (uc *authUseCase) Register(ctx context.Context, req *dto.RegisterRequest) (
 		return nil, err
 	}
 
-	// Generate tokens
-	accessToken, refreshToken, err := uc.jwtService.GenerateTokens(user.ID, user.Username)
+	// Registration succeeds even if the mail cannot be sent; the user can request a new link
+	if err := uc.verifier.SendVerification(ctx, user); err != nil {
+		log.Printf("[AUTH][REGISTER][WARN] user=%s verification email: %v", user.ID.Hex(), err)
+	}
+
+	// Generate tokens; every login starts a new session
+	client.DeviceName = req.DeviceName
+	tokens, err := uc.startSession(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert to response DTO
-	return &dto.AuthResponse{
-		User:         uc.toUserResponse(user),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
-	}, nil
+	return uc.toAuthResponse(user, tokens), nil
 }
 
-func (uc *authUseCase) Login(ctx context.Context, req *dto.LoginRequest) (*dto.AuthResponse, error) {
-	// Find user by username
-	user, err := uc.userRepo.FindByUsername(ctx, req.Username)
-	if err != nil {
+func (uc *authUseCase) Login(ctx context.Context, req *dto.LoginRequest, client dto.ClientInfo) (*dto.AuthResponse, error) {
+	// Refuse early while the client address is locked out
+	if err := uc.throttle.Check(ctx, "", client.IP); err != nil {
+		return nil, err
+	}
+
+	// Find user by username or email. An identifier without an account is
+	// throttled under its own key and answers like a wrong password, so the
+	// responses do not tell whether an account exists.
+	user, lookupErr := uc.findByLogin(ctx, req.Username)
+	account := LoginKey(req.Username)
+	if lookupErr == nil {
+		account = AccountKey(user.ID)
+	}
+	if err := uc.throttle.Check(ctx, account, client.IP); err != nil {
+		log.Printf("[AUTH][LOGIN][LOCKED] ip=%s known=%t", client.IP, lookupErr == nil)
+		return nil, err
+	}
+	if lookupErr != nil {
+		uc.throttle.RecordFailure(ctx, account, client.IP)
 		return nil, errors.New("invalid username or password")
 	}
 
 	// Verify password
-	err = uc.passwordService.VerifyPassword(user.PasswordHash, req.Password)
+	err := uc.passwordService.VerifyPassword(user.PasswordHash, req.Password)
 	if err != nil {
+		uc.throttle.RecordFailure(ctx, account, client.IP)
 		return nil, errors.New("invalid username or password")
 	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	// With 2FA the password only earns a challenge; failures are not reset until
+	// the second factor is verified so codes cannot be guessed across logins
+	challenge, err := uc.mfaChallenge(ctx, user)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+	uc.throttle.RecordSuccess(ctx, user.ID)
 
-	// Generate tokens
-	accessToken, refreshToken, err := uc.jwtService.GenerateTokens(user.ID, user.Username)
+	// Generate tokens; every login starts a new session
+	client.DeviceName = req.DeviceName
+	tokens, err := uc.startSession(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert to response DTO
+	return uc.toAuthResponse(user, tokens), nil
+}
+
+func (uc *authUseCase) VerifyMFA(ctx context.Context, req *dto.VerifyMFARequest, client dto.ClientInfo) (*dto.AuthResponse, error) {
+	claims, err := uc.jwtService.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := uc.throttle.Check(ctx, AccountKey(userID), client.IP); err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+
+	if err := uc.twoFactor.VerifySecondFactor(ctx, user.ID, req.Code); err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			uc.throttle.RecordFailure(ctx, AccountKey(user.ID), client.IP)
+		}
+		log.Printf("[AUTH][MFA][ERROR] user=%s err=%v", user.ID.Hex(), err)
+		return nil, err
+	}
+	uc.throttle.RecordSuccess(ctx, user.ID)
+
+	client.DeviceName = req.DeviceName
+	tokens, err := uc.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[AUTH][MFA][DONE] user=%s", user.ID.Hex())
+	return uc.toAuthResponse(user, tokens), nil
+}
+
+// CompleteExternalLogin signs in a user whose identity an external provider
+// vouched for; 2FA still applies
+func (uc *authUseCase) CompleteExternalLogin(ctx context.Context, user *entities.User, client dto.ClientInfo) (*dto.AuthResponse, error) {
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	challenge, err := uc.mfaChallenge(ctx, user)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	tokens, err := uc.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return uc.toAuthResponse(user, tokens), nil
+}
+
+// mfaChallenge returns the challenge to answer at /auth/2fa/verify, or nil when
+// the user has no second factor
+func (uc *authUseCase) mfaChallenge(ctx context.Context, user *entities.User) (*dto.AuthResponse, error) {
+	mfaEnabled, err := uc.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil || !mfaEnabled {
+		return nil, err
+	}
+	mfaToken, err := uc.jwtService.GenerateMFAToken(user.ID, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[AUTH][LOGIN][MFA_CHALLENGE] user=%s", user.ID.Hex())
 	return &dto.AuthResponse{
-		User:         uc.toUserResponse(user),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    24 * 60 * 60, // 24 hours in seconds
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int64(services.MFAChallengeTTL.Seconds()),
 	}, nil
 }
 
-func (uc *authUseCase) RefreshToken(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.TokenResponse, error) {
-	// Validate refresh token and get new access token
-	newAccessToken, err := uc.jwtService.RefreshAccessToken(req.RefreshToken)
+// findByLogin resolves the login identifier: anything with an "@" is looked up
+// as an email address first, then as a username for accounts whose username
+// predates the rule against "@"
+func (uc *authUseCase) findByLogin(ctx context.Context, login string) (*entities.User, error) {
+	login = strings.TrimSpace(login)
+	if strings.Contains(login, "@") {
+		if user, err := uc.userRepo.FindByEmail(ctx, login); err == nil {
+			return user, nil
+		}
+	}
+	return uc.userRepo.FindByUsername(ctx, login)
+}
+
+func (uc *authUseCase) RefreshToken(ctx context.Context, req *dto.RefreshTokenRequest, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	stored, err := uc.lookupRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// A token that was already exchanged or revoked is being replayed: treat the
+	// whole session as compromised so neither the thief nor the owner can continue
+	now := time.Now()
+	if !stored.IsActive(now) {
+		if stored.IsRotated() || stored.RevokedAt != nil {
+			uc.revokeSession(ctx, stored.SessionID)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := uc.sessionRepo.FindByID(ctx, stored.SessionID)
+	if err != nil || !session.IsActive(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	user, err := uc.userRepo.FindByID(ctx, stored.UserID)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, ErrUserInactive
 	}
 
-	return &dto.TokenResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: req.RefreshToken, // Keep the same refresh token
-		ExpiresIn:    24 * 60 * 60,     // 24 hours in seconds
+	tokens, err := uc.issueTokens(ctx, user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := uc.refreshRepo.MarkRotated(ctx, stored.ID, tokens.tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Lost the race against another refresh of the same token
+		uc.revokeSession(ctx, session.ID)
+		return nil, ErrRefreshTokenReused
+	}
+	if err := uc.sessionRepo.Extend(ctx, session.ID, client.IP, now, tokens.expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &tokens.TokenResponse, nil
+}
+
+func (uc *authUseCase) Logout(ctx context.Context, userID primitive.ObjectID, req *dto.LogoutRequest) error {
+	stored, err := uc.lookupRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if stored.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return uc.RevokeSession(ctx, userID, stored.SessionID)
+}
+
+func (uc *authUseCase) LogoutAll(ctx context.Context, userID primitive.ObjectID) error {
+	if err := uc.sessionRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		return err
+	}
+	return uc.refreshRepo.RevokeAllByUserID(ctx, userID)
+}
+
+func (uc *authUseCase) LogoutOthers(ctx context.Context, userID, keepSessionID primitive.ObjectID) error {
+	sessions, err := uc.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if s.ID == keepSessionID {
+			continue
+		}
+		if err := uc.sessionRepo.Revoke(ctx, s.ID); err != nil {
+			return err
+		}
+		if err := uc.refreshRepo.RevokeBySessionID(ctx, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *authUseCase) ListSessions(ctx context.Context, userID, currentSessionID primitive.ObjectID) (*dto.SessionListResponse, error) {
+	sessions, err := uc.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.SessionListResponse{Sessions: make([]dto.SessionResponse, 0, len(sessions))}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, dto.SessionResponse{
+			ID:         s.ID.Hex(),
+			DeviceName: s.DeviceName,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			Current:    s.ID == currentSessionID,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+		})
+	}
+	return resp, nil
+}
+
+func (uc *authUseCase) RevokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) error {
+	session, err := uc.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if err := uc.sessionRepo.Revoke(ctx, session.ID); err != nil {
+		return err
+	}
+	return uc.refreshRepo.RevokeBySessionID(ctx, session.ID)
+}
+
+// revokeSession is the best-effort revocation used on token reuse, where the
+// request fails regardless of whether the revocation went through
+func (uc *authUseCase) revokeSession(ctx context.Context, sessionID primitive.ObjectID) {
+	_ = uc.sessionRepo.Revoke(ctx, sessionID)
+	_ = uc.refreshRepo.RevokeBySessionID(ctx, sessionID)
+}
+
+// lookupRefreshToken validates a refresh JWT and loads its server-side record
+func (uc *authUseCase) lookupRefreshToken(ctx context.Context, token string) (*entities.RefreshToken, error) {
+	claims, err := uc.jwtService.ValidateRefreshToken(token)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	tokenID, err := primitive.ObjectIDFromHex(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	stored, err := uc.refreshRepo.FindByID(ctx, tokenID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return stored, nil
+}
+
+type issuedTokens struct {
+	dto.TokenResponse
+	tokenID   primitive.ObjectID
+	expiresAt time.Time // refresh token expiry
+}
+
+// startSession records a new device session and issues its first token pair
+func (uc *authUseCase) startSession(ctx context.Context, user *entities.User, client dto.ClientInfo) (*issuedTokens, error) {
+	deviceName := strings.TrimSpace(client.DeviceName)
+	if deviceName == "" {
+		deviceName = deviceNameFromUserAgent(client.UserAgent)
+	}
+	session := entities.NewSession(user.ID, deviceName, client.UserAgent, client.IP, time.Now().Add(uc.jwtService.RefreshTokenTTL()))
+	if err := uc.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return uc.issueTokens(ctx, user, session.ID)
+}
+
+// issueTokens signs an access token and persists a new refresh token for the session
+func (uc *authUseCase) issueTokens(ctx context.Context, user *entities.User, sessionID primitive.ObjectID) (*issuedTokens, error) {
+	accessToken, err := uc.jwtService.GenerateAccessToken(user.ID, user.Username, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := entities.NewRefreshToken(user.ID, sessionID, time.Now().Add(uc.jwtService.RefreshTokenTTL()))
+	if err := uc.refreshRepo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	refreshToken, err := uc.jwtService.GenerateRefreshToken(user.ID, user.Username, record.ID, record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &issuedTokens{
+		TokenResponse: dto.TokenResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int64(uc.jwtService.AccessTokenTTL().Seconds()),
+		},
+		tokenID:   record.ID,
+		expiresAt: record.ExpiresAt,
 	}, nil
 }
 
+// deviceNameFromUserAgent builds a readable label such as "Chrome on Android" for
+// clients that did not name themselves
+func deviceNameFromUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+	platform := ""
+	for _, p := range []struct{ marker, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, p.marker) {
+			platform = p.name
+			break
+		}
+	}
+	browser := ""
+	// Order matters: Edge and Opera also announce Chrome, Chrome also announces Safari
+	for _, b := range []struct{ marker, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case platform != "":
+		return platform
+	case browser != "":
+		return browser
+	}
+	// Non-browser clients (mobile app HTTP stacks, curl) usually lead with product/version
+	if name, _, ok := strings.Cut(ua, "/"); ok && name != "" {
+		return name
+	}
+	return "Unknown device"
+}
+
+func (uc *authUseCase) toAuthResponse(user *entities.User, tokens *issuedTokens) *dto.AuthResponse {
+	userResp := uc.toUserResponse(user)
+	return &dto.AuthResponse{
+		User:         &userResp,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
+}
+
 func (uc *authUseCase) toUserResponse(user *entities.User) dto.UserResponse {
 	userResp := dto.UserResponse{
-		ID:        user.ID.Hex(),
-		Username:  user.Username,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID.Hex(),
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		Name:          user.Name,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 
 	// Convert avatar if exists
 	if user.Avatar != nil {
 		userResp.Avatar = &dto.UserAvatar{
-			URL:      user.Avatar.Data,
-			MimeType: user.Avatar.Type,
+			URL:      avatarURL(user.Avatar, uc.media),
+			MimeType: user.Avatar.ContentType,
 		}
 	}
 
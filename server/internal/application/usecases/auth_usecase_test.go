@@ -0,0 +1,255 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// nopVerifier sends no verification mails
+type nopVerifier struct{}
+
+func (nopVerifier) SendVerification(ctx context.Context, user *entities.User) error { return nil }
+
+// nopThrottle never locks anyone out; lockouts are covered by the throttle itself
+type nopThrottle struct{}
+
+func (nopThrottle) Check(ctx context.Context, account, ip string) error          { return nil }
+func (nopThrottle) RecordFailure(ctx context.Context, account, ip string)        {}
+func (nopThrottle) RecordSuccess(ctx context.Context, userID primitive.ObjectID) {}
+
+// fakeTwoFactor reports 2FA enabled for the listed users and accepts one code
+type fakeTwoFactor struct {
+	enabled map[primitive.ObjectID]bool
+}
+
+const validTwoFactorCode = "123456"
+
+func (f *fakeTwoFactor) IsEnabled(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	return f.enabled[userID], nil
+}
+
+func (f *fakeTwoFactor) VerifySecondFactor(ctx context.Context, userID primitive.ObjectID, code string) error {
+	if !f.enabled[userID] || code != validTwoFactorCode {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+type authFixture struct {
+	store     *memory.Store
+	users     repositories.UserRepository
+	sessions  repositories.SessionRepository
+	twoFactor *fakeTwoFactor
+	jwt       services.JWTService
+	uc        AuthUseCase
+}
+
+func newAuthFixture() *authFixture {
+	store := memory.NewStore()
+	f := &authFixture{
+		store:     store,
+		users:     memory.NewUserRepository(store),
+		sessions:  memory.NewSessionRepository(store),
+		twoFactor: &fakeTwoFactor{enabled: map[primitive.ObjectID]bool{}},
+		jwt: services.NewJWTService(&config.Config{JWT: config.JWTConfig{
+			Secret: "test-secret", AccessExpiresIn: "15m", RefreshExpiresIn: "24h",
+		}}),
+	}
+	f.uc = NewAuthUseCase(f.users, memory.NewRefreshTokenRepository(store), f.sessions, f.jwt,
+		services.NewPasswordService(), nopVerifier{}, nopThrottle{}, f.twoFactor, nil)
+	return f
+}
+
+var testClient = dto.ClientInfo{UserAgent: "whisper-tests/1.0", IP: "127.0.0.1"}
+
+func (f *authFixture) register(t *testing.T, username string) *dto.AuthResponse {
+	t.Helper()
+	req := &dto.RegisterRequest{Username: username, Email: username + "@example.com", Password: "secret123", Name: username}
+	res, err := f.uc.Register(context.Background(), req, testClient)
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	return res
+}
+
+func userIDOf(t *testing.T, res *dto.AuthResponse) primitive.ObjectID {
+	t.Helper()
+	id, err := primitive.ObjectIDFromHex(res.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		email    string
+		wantErr  string
+	}{
+		{"new user", "bob", "bob@example.com", ""},
+		{"taken username", "alice", "other@example.com", "username already exists"},
+		{"taken email", "bob", "alice@example.com", "email already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture()
+			f.register(t, "alice")
+			req := &dto.RegisterRequest{Username: tt.username, Email: tt.email, Password: "secret123", Name: "Bob"}
+			res, err := f.uc.Register(context.Background(), req, testClient)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.AccessToken == "" || res.RefreshToken == "" || res.User.Username != tt.username {
+				t.Errorf("response user=%q access=%t refresh=%t", res.User.Username, res.AccessToken != "", res.RefreshToken != "")
+			}
+			sessions, err := f.sessions.FindActiveByUserID(context.Background(), userIDOf(t, res))
+			if err != nil || len(sessions) != 1 {
+				t.Errorf("active sessions = %d (%v), want 1", len(sessions), err)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		setup    func(t *testing.T, f *authFixture, user *entities.User)
+		wantErr  string
+		wantMFA  bool
+	}{
+		{"username", "alice", "secret123", nil, "", false},
+		{"email", " alice@example.com ", "secret123", nil, "", false},
+		{"wrong password", "alice", "wrong", nil, "invalid username or password", false},
+		{"unknown user", "nobody", "secret123", nil, "invalid username or password", false},
+		{"deactivated", "alice", "secret123", func(t *testing.T, f *authFixture, user *entities.User) {
+			user.IsActive = false
+			if err := f.users.Update(context.Background(), user); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrUserInactive.Error(), false},
+		{"two-factor", "alice", "secret123", func(t *testing.T, f *authFixture, user *entities.User) {
+			f.twoFactor.enabled[user.ID] = true
+		}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newAuthFixture()
+			user, err := f.users.FindByID(ctx, userIDOf(t, f.register(t, "alice")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(t, f, user)
+			}
+			res, err := f.uc.Login(ctx, &dto.LoginRequest{Username: tt.login, Password: tt.password}, testClient)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.MFARequired != tt.wantMFA {
+				t.Fatalf("mfaRequired = %t, want %t", res.MFARequired, tt.wantMFA)
+			}
+			if tt.wantMFA {
+				// The password alone must not open a session
+				if res.AccessToken != "" || res.RefreshToken != "" || res.MFAToken == "" {
+					t.Errorf("challenge access=%t refresh=%t mfaToken=%t", res.AccessToken != "", res.RefreshToken != "", res.MFAToken != "")
+				}
+				if _, err := f.uc.VerifyMFA(ctx, &dto.VerifyMFARequest{MFAToken: res.MFAToken, Code: "000000"}, testClient); !errors.Is(err, ErrInvalidTwoFactorCode) {
+					t.Errorf("wrong code error = %v, want %v", err, ErrInvalidTwoFactorCode)
+				}
+				res, err = f.uc.VerifyMFA(ctx, &dto.VerifyMFARequest{MFAToken: res.MFAToken, Code: validTwoFactorCode}, testClient)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if res.AccessToken == "" || res.RefreshToken == "" || res.User.ID != user.ID.Hex() {
+				t.Errorf("response user=%s access=%t refresh=%t", res.User.ID, res.AccessToken != "", res.RefreshToken != "")
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	f := newAuthFixture()
+	reg := f.register(t, "alice")
+
+	rotated, err := f.uc.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: reg.RefreshToken}, testClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RefreshToken == reg.RefreshToken {
+		t.Fatal("refresh returned the same token")
+	}
+
+	// Replaying the first token revokes the session, so the rotated one dies too
+	for _, token := range []string{reg.RefreshToken, rotated.RefreshToken} {
+		if _, err := f.uc.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: token}, testClient); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Errorf("refresh error = %v, want %v", err, ErrRefreshTokenReused)
+		}
+	}
+	if sessions, _ := f.sessions.FindActiveByUserID(ctx, userIDOf(t, reg)); len(sessions) != 0 {
+		t.Errorf("active sessions after reuse = %d, want 0", len(sessions))
+	}
+}
+
+func TestSessionsOfOtherUsers(t *testing.T) {
+	ctx := context.Background()
+	f := newAuthFixture()
+	alice := f.register(t, "alice")
+	bob := f.register(t, "bob")
+	aliceID, bobID := userIDOf(t, alice), userIDOf(t, bob)
+	sessions, err := f.sessions.FindActiveByUserID(ctx, aliceID)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("alice sessions = %d (%v), want 1", len(sessions), err)
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"revoke session", func() error {
+			return f.uc.RevokeSession(ctx, bobID, sessions[0].ID)
+		}, ErrSessionNotFound},
+		{"logout with token", func() error {
+			return f.uc.Logout(ctx, bobID, &dto.LogoutRequest{RefreshToken: alice.RefreshToken})
+		}, ErrInvalidRefreshToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if active, _ := f.sessions.FindActiveByUserID(ctx, aliceID); len(active) != 1 {
+				t.Errorf("alice sessions after %s = %d, want 1", tt.name, len(active))
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package usecases
+
+import "whisper-server/internal/domain/entities"
+
+// Built-in catalog inserted at startup when missing; keys match the client's type ids
+
+func defaultWhisperTypes() []*entities.TypeDefinition {
+	return []*entities.TypeDefinition{
+		whisperType(1, "cook_together", "👨‍🍳", entities.WhisperRecurrenceOnce,
+			"Cook Something Together", "با هم آشپزی کنیم",
+			"Let's cook something together tonight", "امشب با هم یه چیزی بپزیم"),
+		whisperType(2, "watch_sunset", "🌅", entities.WhisperRecurrenceOnce,
+			"Watch the Sunset", "تماشای غروب",
+			"Let's watch the sunset together", "بیا با هم غروب رو تماشا کنیم"),
+		whisperType(3, "share_story", "📖", entities.WhisperRecurrenceEveryday,
+			"Share a Story", "یه داستان تعریف کن",
+			"Tell me a story from your day", "از روزت یه داستان برام تعریف کن"),
+		whisperType(4, "play_game", "🎲", entities.WhisperRecurrenceWeekly,
+			"Play a Game Together", "با هم بازی کنیم",
+			"Let's play a game together", "بیا با هم یه بازی کنیم"),
+		whisperType(5, "take_walk", "🚶", entities.WhisperRecurrenceEveryday,
+			"Take a Walk Together", "با هم قدم بزنیم",
+			"Let's go for a walk together", "بیا با هم بریم قدم بزنیم"),
+		whisperType(6, "watch_movie", "🎬", entities.WhisperRecurrenceWeekly,
+			"Watch a Movie", "فیلم ببینیم",
+			"Let's watch a movie together", "بیا با هم یه فیلم ببینیم"),
+		{
+			Key:               WhisperTypeCustom,
+			Category:          "custom",
+			Icon:              "✨",
+			Labels:            map[string]string{entities.LanguageEnglish: "Custom Whisper", entities.LanguagePersian: "نجوای دلخواه"},
+			DefaultRecurrence: entities.WhisperRecurrenceOnce,
+			RequiresText:      true,
+			IsActive:          true,
+			SortOrder:         100,
+		},
+	}
+}
+
+func defaultEventTypes() []*entities.TypeDefinition {
+	return []*entities.TypeDefinition{
+		eventType(1, entities.EventTypeMeeting, entities.EventCategoryMilestone, "💕", "#FFDF6E", "First Meeting", "اولین دیدار"),
+		eventType(2, entities.EventTypeTrip, entities.EventCategoryActivity, "✈️", "#4DA8FF", "Trip", "سفر"),
+		eventType(3, entities.EventTypeParty, entities.EventCategorySpecial, "🎉", "#FF66A8", "Party", "مهمانی"),
+		eventType(4, entities.EventTypeBirthday, entities.EventCategorySpecial, "🎂", "#B588FF", "Birthday", "تولد"),
+		eventType(5, entities.EventTypeAnniversary, entities.EventCategoryMilestone, "💐", "#FF9A3C", "Anniversary", "سالگرد"),
+		eventType(6, entities.EventTypeDate, entities.EventCategoryActivity, "🌹", "#7CE38B", "Date", "قرار"),
+		eventType(7, entities.EventTypeFightMakeup, entities.EventCategoryActivity, "🤝", "#9D9D9D", "Fight & Makeup", "دعوا و آشتی"),
+		{
+			Key:       entities.EventTypeTodoCompleted,
+			Category:  entities.EventCategoryActivity,
+			Icon:      "✅",
+			Color:     "#6FCF97",
+			Labels:    map[string]string{entities.LanguageEnglish: "Todo Completed", entities.LanguagePersian: "کار انجام‌شده"},
+			IsSystem:  true,
+			IsActive:  true,
+			SortOrder: 100,
+		},
+	}
+}
+
+func whisperType(order int, key, icon, recurrence, labelEn, labelFa, textEn, textFa string) *entities.TypeDefinition {
+	return &entities.TypeDefinition{
+		Key:               key,
+		Category:          "shared",
+		Icon:              icon,
+		Labels:            map[string]string{entities.LanguageEnglish: labelEn, entities.LanguagePersian: labelFa},
+		DefaultTexts:      map[string]string{entities.LanguageEnglish: textEn, entities.LanguagePersian: textFa},
+		DefaultRecurrence: recurrence,
+		IsActive:          true,
+		SortOrder:         order,
+	}
+}
+
+func eventType(order int, key, category, icon, color, labelEn, labelFa string) *entities.TypeDefinition {
+	return &entities.TypeDefinition{
+		Key:       key,
+		Category:  category,
+		Icon:      icon,
+		Color:     color,
+		Labels:    map[string]string{entities.LanguageEnglish: labelEn, entities.LanguagePersian: labelFa},
+		IsActive:  true,
+		SortOrder: order,
+	}
+}
@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+)
+
+// TypeCatalog resolves whisper and event types; create/update flows validate against it
+type TypeCatalog interface {
+	WhisperType(ctx context.Context, key string) (*entities.TypeDefinition, error)
+	EventType(ctx context.Context, key string) (*entities.TypeDefinition, error)
+}
+
+type CatalogUseCase interface {
+	TypeCatalog
+	GetCatalog(ctx context.Context, lang string) (*dto.CatalogResponse, error)
+	// SeedDefaults inserts the built-in types that are missing from the database
+	SeedDefaults(ctx context.Context) error
+}
+
+// WhisperTypeCustom is the free-form whisper type; its text always comes from the user
+const WhisperTypeCustom = "custom"
+
+// catalog errors
+var (
+	ErrUnknownWhisperType  = fmtError("unknown whisper type")
+	ErrUnknownEventType    = fmtError("unknown event type")
+	ErrWhisperTextRequired = fmtError("text is required for this whisper type")
+)
+
+// catalogCacheTTL bounds how long edits made directly in the database take to show up
+const catalogCacheTTL = 5 * time.Minute
+
+type catalogUseCase struct {
+	repo domainRepos.CatalogRepository
+
+	mu           sync.RWMutex
+	whisperTypes []*entities.TypeDefinition
+	eventTypes   []*entities.TypeDefinition
+	loadedAt     time.Time
+}
+
+func NewCatalogUseCase(repo domainRepos.CatalogRepository) CatalogUseCase {
+	return &catalogUseCase{repo: repo}
+}
+
+func (uc *catalogUseCase) SeedDefaults(ctx context.Context) error {
+	if err := uc.repo.SeedWhisperTypes(ctx, defaultWhisperTypes()); err != nil {
+		return err
+	}
+	if err := uc.repo.SeedEventTypes(ctx, defaultEventTypes()); err != nil {
+		return err
+	}
+	uc.invalidate()
+	return nil
+}
+
+func (uc *catalogUseCase) GetCatalog(ctx context.Context, lang string) (*dto.CatalogResponse, error) {
+	if !entities.IsSupportedLanguage(lang) {
+		lang = entities.LanguageEnglish
+	}
+	whisperTypes, eventTypes, err := uc.load(ctx)
+	if err != nil {
+		log.Printf("[CATALOG][GET][ERROR] lang=%s err=%v", lang, err)
+		return nil, err
+	}
+	res := &dto.CatalogResponse{
+		Language:     lang,
+		WhisperTypes: make([]*dto.TypeDefinitionResponse, 0, len(whisperTypes)),
+		EventTypes:   make([]*dto.TypeDefinitionResponse, 0, len(eventTypes)),
+	}
+	for _, t := range whisperTypes {
+		if t.IsSelectable() {
+			res.WhisperTypes = append(res.WhisperTypes, toTypeDefinitionResponse(t, lang))
+		}
+	}
+	for _, t := range eventTypes {
+		if t.IsSelectable() {
+			res.EventTypes = append(res.EventTypes, toTypeDefinitionResponse(t, lang))
+		}
+	}
+	return res, nil
+}
+
+func (uc *catalogUseCase) WhisperType(ctx context.Context, key string) (*entities.TypeDefinition, error) {
+	whisperTypes, _, err := uc.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t := findSelectable(whisperTypes, key); t != nil {
+		return t, nil
+	}
+	return nil, ErrUnknownWhisperType
+}
+
+func (uc *catalogUseCase) EventType(ctx context.Context, key string) (*entities.TypeDefinition, error) {
+	_, eventTypes, err := uc.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t := findSelectable(eventTypes, key); t != nil {
+		return t, nil
+	}
+	return nil, ErrUnknownEventType
+}
+
+// load returns the cached catalog, refreshing it from the repository once stale
+func (uc *catalogUseCase) load(ctx context.Context) ([]*entities.TypeDefinition, []*entities.TypeDefinition, error) {
+	uc.mu.RLock()
+	if !uc.loadedAt.IsZero() && time.Since(uc.loadedAt) < catalogCacheTTL {
+		w, e := uc.whisperTypes, uc.eventTypes
+		uc.mu.RUnlock()
+		return w, e, nil
+	}
+	uc.mu.RUnlock()
+
+	whisperTypes, err := uc.repo.FindAllWhisperTypes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	eventTypes, err := uc.repo.FindAllEventTypes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	uc.mu.Lock()
+	uc.whisperTypes, uc.eventTypes, uc.loadedAt = whisperTypes, eventTypes, time.Now()
+	uc.mu.Unlock()
+	return whisperTypes, eventTypes, nil
+}
+
+func (uc *catalogUseCase) invalidate() {
+	uc.mu.Lock()
+	uc.loadedAt = time.Time{}
+	uc.mu.Unlock()
+}
+
+func findSelectable(list []*entities.TypeDefinition, key string) *entities.TypeDefinition {
+	for _, t := range list {
+		if t.Key == key && t.IsSelectable() {
+			return t
+		}
+	}
+	return nil
+}
+
+// helpers
+func toTypeDefinitionResponse(t *entities.TypeDefinition, lang string) *dto.TypeDefinitionResponse {
+	return &dto.TypeDefinitionResponse{
+		Key:               t.Key,
+		Label:             t.Label(lang),
+		Icon:              t.Icon,
+		Color:             t.Color,
+		Category:          t.Category,
+		DefaultText:       t.DefaultText(lang),
+		DefaultRecurrence: t.DefaultRecurrence,
+		RequiresText:      t.RequiresText,
+	}
+}
@@ -0,0 +1,332 @@
+package usecases
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DataExportUseCase builds downloadable archives of everything stored about a user
+type DataExportUseCase interface {
+	ExportRemover
+	// GetOrRequest returns the user's latest export, starting a new one when there
+	// is none that is in progress or still downloadable
+	GetOrRequest(ctx context.Context, userID primitive.ObjectID) (*dto.DataExportResponse, error)
+	// Request always starts a new export unless one is already in progress
+	Request(ctx context.Context, userID primitive.ObjectID) (*dto.DataExportResponse, error)
+	// ProcessPending builds exports left unfinished, e.g. by a restart
+	ProcessPending(ctx context.Context) error
+	// CleanupExpired deletes archives past their expiry
+	CleanupExpired(ctx context.Context) error
+}
+
+const (
+	// exportStaleAfter is when a processing export is assumed abandoned
+	exportStaleAfter = 15 * time.Minute
+	// exportTimeout bounds one build; it ends before the export counts as stale
+	// so no second worker reclaims an archive that is still being written
+	exportTimeout = 10 * time.Minute
+	// exportLinkTTL is the lifetime of each signed download link
+	exportLinkTTL  = 15 * time.Minute
+	exportPageSize = 200
+)
+
+type dataExportUseCase struct {
+	repo        repositories.DataExportRepository
+	userRepo    repositories.UserRepository
+	relRepo     repositories.RelationshipRepository
+	eventRepo   repositories.EventRepository
+	whisperRepo repositories.WhisperRepository
+	inviteRepo  repositories.InviteRepository
+	storage     services.MediaStorage
+	ttl         time.Duration
+}
+
+func NewDataExportUseCase(
+	repo repositories.DataExportRepository,
+	userRepo repositories.UserRepository,
+	relRepo repositories.RelationshipRepository,
+	eventRepo repositories.EventRepository,
+	whisperRepo repositories.WhisperRepository,
+	inviteRepo repositories.InviteRepository,
+	storage services.MediaStorage,
+	ttl time.Duration,
+) DataExportUseCase {
+	return &dataExportUseCase{
+		repo:        repo,
+		userRepo:    userRepo,
+		relRepo:     relRepo,
+		eventRepo:   eventRepo,
+		whisperRepo: whisperRepo,
+		inviteRepo:  inviteRepo,
+		storage:     storage,
+		ttl:         ttl,
+	}
+}
+
+func (uc *dataExportUseCase) GetOrRequest(ctx context.Context, userID primitive.ObjectID) (*dto.DataExportResponse, error) {
+	latest, err := uc.repo.FindLatestByUserID(ctx, userID)
+	if err == nil && (latest.IsAvailable(time.Now()) || isExportInProgress(latest)) {
+		return uc.toResponse(latest), nil
+	}
+	return uc.Request(ctx, userID)
+}
+
+func (uc *dataExportUseCase) Request(ctx context.Context, userID primitive.ObjectID) (*dto.DataExportResponse, error) {
+	if latest, err := uc.repo.FindLatestByUserID(ctx, userID); err == nil && isExportInProgress(latest) {
+		return uc.toResponse(latest), nil
+	}
+
+	export := entities.NewDataExport(userID)
+	if err := uc.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	log.Printf("[EXPORT][REQUEST][DONE] user=%s export=%s", userID.Hex(), export.ID.Hex())
+
+	// Built in the background; ProcessPending picks it up if this process dies first
+	go uc.process(context.Background(), export)
+	return uc.toResponse(export), nil
+}
+
+func isExportInProgress(e *entities.DataExport) bool {
+	return e.Status == entities.DataExportStatusPending || e.Status == entities.DataExportStatusProcessing
+}
+
+func (uc *dataExportUseCase) ProcessPending(ctx context.Context) error {
+	exports, err := uc.repo.FindUnfinished(ctx, time.Now().Add(-exportStaleAfter))
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		uc.process(ctx, export)
+	}
+	return nil
+}
+
+func (uc *dataExportUseCase) process(ctx context.Context, export *entities.DataExport) {
+	claimed, err := uc.repo.Claim(ctx, export.ID, time.Now().Add(-exportStaleAfter))
+	if err != nil || !claimed {
+		return
+	}
+	log.Printf("[EXPORT][BUILD][START] user=%s export=%s", export.UserID.Hex(), export.ID.Hex())
+
+	buildCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+	size, err := uc.build(buildCtx, export)
+	cancel()
+	if err != nil {
+		log.Printf("[EXPORT][BUILD][ERROR] export=%s err=%v", export.ID.Hex(), err)
+		if markErr := uc.repo.MarkFailed(ctx, export.ID, "export could not be created"); markErr != nil {
+			log.Printf("[EXPORT][BUILD][ERROR] export=%s mark failed: %v", export.ID.Hex(), markErr)
+		}
+		return
+	}
+	if err := uc.repo.MarkReady(ctx, export.ID, size, time.Now().Add(uc.ttl)); err != nil {
+		log.Printf("[EXPORT][BUILD][ERROR] export=%s mark ready: %v", export.ID.Hex(), err)
+		return
+	}
+	log.Printf("[EXPORT][BUILD][DONE] export=%s size=%d", export.ID.Hex(), size)
+}
+
+// exportedEvent adds the archive path of the event's image
+type exportedEvent struct {
+	*entities.Event
+	ImageFile string `json:"imageFile,omitempty"`
+}
+
+// build writes the archive to a temporary file and uploads it to storage
+func (uc *dataExportUseCase) build(ctx context.Context, export *entities.DataExport) (int64, error) {
+	user, err := uc.userRepo.FindByID(ctx, export.UserID)
+	if err != nil {
+		return 0, err
+	}
+	rels, err := uc.relRepo.FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	invites, err := uc.inviteRepo.FindByCreator(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp("", "whisper-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	if err := writeZipJSON(zw, "profile.json", user); err != nil {
+		return 0, err
+	}
+	if err := writeZipJSON(zw, "relationships.json", rels); err != nil {
+		return 0, err
+	}
+	if err := writeZipJSON(zw, "invites.json", invites); err != nil {
+		return 0, err
+	}
+
+	events := []exportedEvent{}
+	whispers := []*entities.Whisper{}
+	for _, rel := range rels {
+		for offset := int64(0); ; offset += exportPageSize {
+			page, err := uc.eventRepo.FindAllByRelationshipID(ctx, rel.ID, exportPageSize, offset)
+			if err != nil {
+				return 0, err
+			}
+			for _, ev := range page {
+				events = append(events, exportedEvent{Event: ev, ImageFile: uc.addEventImage(ctx, zw, ev)})
+			}
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+		for offset := int64(0); ; offset += exportPageSize {
+			page, err := uc.whisperRepo.FindAllByRelationshipID(ctx, rel.ID, exportPageSize, offset)
+			if err != nil {
+				return 0, err
+			}
+			whispers = append(whispers, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+	if err := writeZipJSON(zw, "events.json", events); err != nil {
+		return 0, err
+	}
+	if err := writeZipJSON(zw, "whispers.json", whispers); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	// Local storage copies without watching ctx, so stop here once the build ran out of time
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if err := uc.storage.Put(ctx, export.Key, tmp, info.Size(), "application/zip"); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// addEventImage copies the event's image into images/ and returns its archive path;
+// external URLs stay in events.json and unreadable images are skipped
+func (uc *dataExportUseCase) addEventImage(ctx context.Context, zw *zip.Writer, ev *entities.Event) string {
+	img := ev.Image
+	if img == nil {
+		return ""
+	}
+	name := "images/" + ev.ID.Hex() + entities.ImageExtensions[img.ContentType]
+
+	switch img.Type {
+	case entities.ImageTypeMedia:
+		r, err := uc.storage.Get(ctx, img.Key)
+		if err != nil {
+			log.Printf("[EXPORT][BUILD][WARN] event=%s image: %v", ev.ID.Hex(), err)
+			return ""
+		}
+		defer r.Close()
+		w, err := zw.Create(name)
+		if err != nil {
+			return ""
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			log.Printf("[EXPORT][BUILD][WARN] event=%s image: %v", ev.ID.Hex(), err)
+		}
+		return name
+	case entities.ImageTypeBase64:
+		data, err := decodeBase64Image(img.Data)
+		if err != nil {
+			return ""
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return ""
+		}
+		if _, err := w.Write(data); err != nil {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (uc *dataExportUseCase) CleanupExpired(ctx context.Context) error {
+	exports, err := uc.repo.FindExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		uc.remove(ctx, export)
+	}
+	return nil
+}
+
+func (uc *dataExportUseCase) DeleteExports(ctx context.Context, userID primitive.ObjectID) error {
+	exports, err := uc.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		uc.remove(ctx, export)
+	}
+	return nil
+}
+
+func (uc *dataExportUseCase) remove(ctx context.Context, export *entities.DataExport) {
+	if err := uc.storage.Delete(ctx, export.Key); err != nil {
+		log.Printf("[EXPORT][DELETE][WARN] export=%s err=%v", export.ID.Hex(), err)
+		return
+	}
+	if err := uc.repo.Delete(ctx, export.ID); err != nil {
+		log.Printf("[EXPORT][DELETE][WARN] export=%s err=%v", export.ID.Hex(), err)
+	}
+}
+
+func (uc *dataExportUseCase) toResponse(export *entities.DataExport) *dto.DataExportResponse {
+	resp := &dto.DataExportResponse{
+		ID:          export.ID.Hex(),
+		Status:      export.Status,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		Size:        export.Size,
+	}
+	if export.IsAvailable(time.Now()) {
+		if link, err := uc.storage.SignedURL(export.Key, exportLinkTTL); err == nil {
+			resp.DownloadURL = link
+		}
+	}
+	return resp
+}
@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+type exportFixture struct {
+	*relationshipFixture
+	testPeople
+	exports repositories.DataExportRepository
+	events  repositories.EventRepository
+	storage services.MediaStorage
+	uc      DataExportUseCase
+}
+
+func newExportFixture(t *testing.T) *exportFixture {
+	t.Helper()
+	rf := newRelationshipFixture(nil)
+	storage, err := services.NewLocalMediaStorage(t.TempDir(), "https://whisper.test/api/v1/media/files", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &exportFixture{
+		relationshipFixture: rf,
+		testPeople:          rf.addPeople(t),
+		exports:             memory.NewDataExportRepository(rf.store),
+		events:              memory.NewEventRepository(rf.store),
+		storage:             storage,
+	}
+	f.uc = NewDataExportUseCase(f.exports, rf.users, rf.rels, f.events, memory.NewWhisperRepository(rf.store),
+		rf.invs, storage, 24*time.Hour)
+	return f
+}
+
+// archive reads the files of a built export
+func (f *exportFixture) archive(t *testing.T, export *entities.DataExport) map[string][]byte {
+	t.Helper()
+	r, err := f.storage.Get(context.Background(), export.Key)
+	if err != nil {
+		t.Fatalf("archive not stored: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[zf.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestProcessPendingBuildsArchive(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t)
+	event := entities.NewEvent("First date", entities.EventTypeDate, time.Now(), f.relID, f.alice)
+	event.SetImage(&entities.EventImage{Type: entities.ImageTypeBase64, ContentType: "image/png", Data: onePixelPNG})
+	if err := f.events.Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	export := entities.NewDataExport(f.alice)
+	if err := f.exports.Create(ctx, export); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.uc.ProcessPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.uc.GetOrRequest(ctx, f.alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ID != export.ID.Hex() || res.Status != entities.DataExportStatusReady || res.DownloadURL == "" || res.Size == 0 {
+		t.Fatalf("export = %+v, want it ready with a download link", res)
+	}
+
+	files := f.archive(t, export)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"events.json", "images/" + event.ID.Hex() + ".png", "invites.json", "profile.json", "relationships.json", "whispers.json"}
+	if len(names) != len(want) {
+		t.Fatalf("archive files = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("archive files = %v, want %v", names, want)
+		}
+	}
+	var profile entities.User
+	if err := json.Unmarshal(files["profile.json"], &profile); err != nil || profile.ID != f.alice {
+		t.Errorf("profile.json = %s, %v", files["profile.json"], err)
+	}
+	if bytes.Contains(files["profile.json"], []byte(`"hash"`)) {
+		t.Error("profile.json contains the password hash")
+	}
+	var events []exportedEvent
+	if err := json.Unmarshal(files["events.json"], &events); err != nil || len(events) != 1 || events[0].ImageFile != "images/"+event.ID.Hex()+".png" {
+		t.Errorf("events.json = %s, %v", files["events.json"], err)
+	}
+
+	// Nothing is left for a second worker
+	if claimed, _ := f.exports.Claim(ctx, export.ID, time.Now()); claimed {
+		t.Error("finished export claimed again")
+	}
+}
+
+func TestRequestReusesExportInProgress(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t)
+	pending := entities.NewDataExport(f.alice)
+	if err := f.exports.Create(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.uc.Request(ctx, f.alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ID != pending.ID.Hex() {
+		t.Errorf("request started export %s while %s is pending", res.ID, pending.ID.Hex())
+	}
+	if all, _ := f.exports.FindByUserID(ctx, f.alice); len(all) != 1 {
+		t.Errorf("%d exports stored, want 1", len(all))
+	}
+}
+
+func TestDeleteExports(t *testing.T) {
+	ctx := context.Background()
+	f := newExportFixture(t)
+	export := entities.NewDataExport(f.alice)
+	if err := f.exports.Create(ctx, export); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.uc.ProcessPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.archive(t, export)
+
+	if err := f.uc.(*dataExportUseCase).DeleteExports(ctx, f.alice); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := f.exports.FindByUserID(ctx, f.alice); len(all) != 0 {
+		t.Errorf("%d exports left", len(all))
+	}
+	if _, err := f.storage.Get(ctx, export.Key); err == nil {
+		t.Error("archive left in storage")
+	}
+}
@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Features that can be gated behind a verified email (EMAIL_VERIFICATION_REQUIRED_FOR)
+const (
+	FeatureInviteCodes      = "invite_codes"      // generating relationship invite codes
+	FeatureJoinRelationship = "join_relationship" // redeeming a partner's invite code
+)
+
+var knownVerificationFeatures = map[string]bool{
+	FeatureInviteCodes:      true,
+	FeatureJoinRelationship: true,
+}
+
+var (
+	ErrEmailNotVerified         = fmtError("email address is not verified")
+	ErrEmailAlreadyVerified     = fmtError("email address is already verified")
+	ErrVerificationRateLimited  = fmtError("a verification email was sent recently; try again later")
+	ErrInvalidVerificationToken = fmtError("invalid or expired verification token")
+)
+
+type EmailVerificationUseCase interface {
+	EmailVerifier
+	Resend(ctx context.Context, userID primitive.ObjectID) error
+	Verify(ctx context.Context, req *dto.VerifyEmailRequest) error
+}
+
+// EmailVerifier mails a verification link to a newly registered user
+type EmailVerifier interface {
+	SendVerification(ctx context.Context, user *entities.User) error
+}
+
+// VerificationPolicy decides which features need a verified email
+type VerificationPolicy interface {
+	RequireVerified(user *entities.User, feature string) error
+}
+
+type emailVerificationPolicy struct {
+	required map[string]bool
+}
+
+func NewEmailVerificationPolicy(features []string) VerificationPolicy {
+	required := make(map[string]bool, len(features))
+	for _, f := range features {
+		if !knownVerificationFeatures[f] {
+			log.Printf("[VERIFY][POLICY][WARN] unknown feature %q ignored", f)
+			continue
+		}
+		required[f] = true
+	}
+	return &emailVerificationPolicy{required: required}
+}
+
+func (p *emailVerificationPolicy) RequireVerified(user *entities.User, feature string) error {
+	if p.required[feature] && !user.IsEmailVerified() {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+type emailVerificationUseCase struct {
+	userRepo    repositories.UserRepository
+	tokenRepo   repositories.OneTimeTokenRepository
+	mailer      services.Mailer
+	baseURL     string
+	ttl         time.Duration
+	resendAfter time.Duration
+}
+
+func NewEmailVerificationUseCase(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.OneTimeTokenRepository,
+	mailer services.Mailer,
+	baseURL string,
+	ttl time.Duration,
+	resendAfter time.Duration,
+) EmailVerificationUseCase {
+	return &emailVerificationUseCase{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		mailer:      mailer,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		ttl:         ttl,
+		resendAfter: resendAfter,
+	}
+}
+
+func (uc *emailVerificationUseCase) SendVerification(ctx context.Context, user *entities.User) error {
+	log.Printf("[VERIFY][SEND][START] user=%s", user.ID.Hex())
+
+	token, hash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	// Only the newest link stays valid
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID, entities.OneTimeTokenEmailVerification); err != nil {
+		return err
+	}
+	record := entities.NewOneTimeToken(user.ID, entities.OneTimeTokenEmailVerification, hash, time.Now().Add(uc.ttl))
+	if err := uc.tokenRepo.Create(ctx, record); err != nil {
+		log.Printf("[VERIFY][SEND][ERROR] user=%s err=%v", user.ID.Hex(), err)
+		return err
+	}
+
+	link := uc.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	msg := services.MailMessage{
+		To:      user.Email,
+		Subject: "Confirm your email for Whisper",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening the link below within %s:\n\n"+
+			"%s\n\n"+
+			"If you did not create a Whisper account, you can ignore this email.\n",
+			user.Name, uc.ttl, link),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		log.Printf("[VERIFY][SEND][ERROR] user=%s send failed: %v", user.ID.Hex(), err)
+		return err
+	}
+
+	log.Printf("[VERIFY][SEND][DONE] user=%s", user.ID.Hex())
+	return nil
+}
+
+func (uc *emailVerificationUseCase) Resend(ctx context.Context, userID primitive.ObjectID) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+	if last, err := uc.tokenRepo.FindLatestByUserID(ctx, userID, entities.OneTimeTokenEmailVerification); err == nil {
+		if time.Since(last.CreatedAt) < uc.resendAfter {
+			log.Printf("[VERIFY][RESEND][LIMITED] user=%s", userID.Hex())
+			return ErrVerificationRateLimited
+		}
+	}
+	return uc.SendVerification(ctx, user)
+}
+
+func (uc *emailVerificationUseCase) Verify(ctx context.Context, req *dto.VerifyEmailRequest) error {
+	record, err := uc.tokenRepo.FindByHash(ctx, entities.OneTimeTokenEmailVerification, services.HashOpaqueToken(req.Token))
+	if err != nil || !record.IsUsable(time.Now()) {
+		return ErrInvalidVerificationToken
+	}
+	consumed, err := uc.tokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+
+	if err := uc.userRepo.MarkEmailVerified(ctx, record.UserID, time.Now()); err != nil {
+		log.Printf("[VERIFY][CONFIRM][ERROR] user=%s err=%v", record.UserID.Hex(), err)
+		return err
+	}
+	log.Printf("[VERIFY][CONFIRM][DONE] user=%s", record.UserID.Hex())
+	return nil
+}
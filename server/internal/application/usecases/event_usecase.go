@@ -19,16 +19,23 @@ type EventUseCase interface {
 	DeleteEventByID(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
 	GetAllEventsByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]*dto.EventResponse, error)
 	GetAllEventsByCurrentRelationship(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]*dto.EventResponse, error)
+	// GetAllEventsByRelationship lists the memories of any relationship the user was part of, e.g. an archived one
+	GetAllEventsByRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID, limit, offset int64) ([]*dto.EventResponse, error)
+	SetVisibility(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.SetEventVisibilityRequest) (*dto.EventResponse, error)
 }
 
 type eventUseCase struct {
-	repo    domainRepos.EventRepository
-	relRepo domainRepos.RelationshipRepository
+	repo     domainRepos.EventRepository
+	relRepo  domainRepos.RelationshipRepository
+	userRepo domainRepos.UserRepository
+	catalog  TypeCatalog
+	media    MediaUseCase
+	stats    StatsSyncer
 	// could inject logger later; using std log for now
 }
 
-func NewEventUseCase(repo domainRepos.EventRepository, relRepo domainRepos.RelationshipRepository) EventUseCase {
-	return &eventUseCase{repo: repo, relRepo: relRepo}
+func NewEventUseCase(repo domainRepos.EventRepository, relRepo domainRepos.RelationshipRepository, userRepo domainRepos.UserRepository, catalog TypeCatalog, media MediaUseCase, stats StatsSyncer) EventUseCase {
+	return &eventUseCase{repo: repo, relRepo: relRepo, userRepo: userRepo, catalog: catalog, media: media, stats: stats}
 }
 
 func (uc *eventUseCase) RegisterEvent(ctx context.Context, userID primitive.ObjectID, req *dto.CreateEventRequest) (*dto.EventResponse, error) {
@@ -39,23 +46,38 @@ func (uc *eventUseCase) RegisterEvent(ctx context.Context, userID primitive.Obje
 		log.Printf("[EVENT][CREATE][ERROR] user=%s no current relationship: %v", userID.Hex(), err)
 		return nil, err
 	}
+	def, err := uc.catalog.EventType(ctx, req.Type)
+	if err != nil {
+		log.Printf("[EVENT][CREATE][INVALID] user=%s type=%s err=%v", userID.Hex(), req.Type, err)
+		return nil, err
+	}
 	ev := entities.NewEvent(req.Title, req.Type, req.Date, rel.ID, userID)
+	ev.Category = def.Category
 	ev.Description = req.Description
 	if req.Image != nil && req.Image.Type != "" {
-		ev.Image = &entities.EventImage{
-			Type:       req.Image.Type,
-			Data:       req.Image.Data,
-			Filename:   req.Image.Filename,
-			UploadedAt: time.Now(),
+		img, err := resolveEventImage(ctx, uc.media, userID, ev.ID, req.Image)
+		if err != nil {
+			log.Printf("[EVENT][CREATE][INVALID] user=%s image err=%v", userID.Hex(), err)
+			return nil, err
 		}
+		ev.Image = img
+	}
+	// Honor the creator's auto-publish preference; auto-published memories stay anonymous
+	if u, err := uc.userRepo.FindByID(ctx, userID); err == nil && u.Settings.AutoPublic {
+		ev.MakePublic()
+		ev.SetAnonymous(true)
 	}
 
 	if err := uc.repo.Create(ctx, ev); err != nil {
 		log.Printf("[EVENT][CREATE][ERROR] user=%s err=%v", userID.Hex(), err)
+		if ev.Image != nil {
+			uc.media.Release(ctx, ev.Image.MediaID, ev.ID)
+		}
 		return nil, err
 	}
+	uc.stats.SyncRelationshipStats(ctx, rel.ID)
 	log.Printf("[EVENT][CREATE][DONE] user=%s event=%s", userID.Hex(), ev.ID.Hex())
-	return toEventResponse(ev), nil
+	return toEventResponse(ev, uc.media), nil
 }
 
 func (uc *eventUseCase) GetEventByID(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*dto.EventResponse, error) {
@@ -65,14 +87,14 @@ func (uc *eventUseCase) GetEventByID(ctx context.Context, userID primitive.Objec
 		log.Printf("[EVENT][GET][ERROR] user=%s id=%s err=%v", userID.Hex(), id.Hex(), err)
 		return nil, err
 	}
-	// Authorization: allow any partner in the same active relationship
-	rel, relErr := uc.relRepo.FindCurrentByUserID(ctx, userID)
-	if relErr != nil || rel.ID != ev.RelationshipID {
+	// Authorization: allow any partner of the relationship; memories of a
+	// disconnected relationship stay readable
+	if _, err := findMemberRelationship(ctx, uc.relRepo, userID, ev.RelationshipID); err != nil {
 		log.Printf("[EVENT][GET][DENY] user=%s id=%s", userID.Hex(), id.Hex())
 		return nil, ErrForbidden
 	}
 	log.Printf("[EVENT][GET][DONE] user=%s id=%s", userID.Hex(), id.Hex())
-	return toEventResponse(ev), nil
+	return toEventResponse(ev, uc.media), nil
 }
 
 func (uc *eventUseCase) UpdateEventByID(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.UpdateEventRequest) (*dto.EventResponse, error) {
@@ -89,27 +111,46 @@ func (uc *eventUseCase) UpdateEventByID(ctx context.Context, userID primitive.Ob
 		return nil, ErrForbidden
 	}
 
+	var def *entities.TypeDefinition
+	if req.Type != "" && req.Type != ev.Type {
+		if def, err = uc.catalog.EventType(ctx, req.Type); err != nil {
+			log.Printf("[EVENT][UPDATE][INVALID] user=%s id=%s type=%s err=%v", userID.Hex(), id.Hex(), req.Type, err)
+			return nil, err
+		}
+	}
+
 	// apply updates
 	var newDate time.Time
 	if !req.Date.IsZero() {
 		newDate = req.Date
 	}
 	ev.Update(req.Title, req.Description, newDate, req.Type)
+	if def != nil {
+		ev.Category = def.Category
+	}
+	var replaced *entities.EventImage
 	if req.Image != nil && req.Image.Type != "" {
-		ev.Image = &entities.EventImage{
-			Type:       req.Image.Type,
-			Data:       req.Image.Data,
-			Filename:   req.Image.Filename,
-			UploadedAt: time.Now(),
+		img, err := resolveEventImage(ctx, uc.media, userID, ev.ID, req.Image)
+		if err != nil {
+			log.Printf("[EVENT][UPDATE][INVALID] user=%s id=%s image err=%v", userID.Hex(), id.Hex(), err)
+			return nil, err
 		}
+		replaced, ev.Image = ev.Image, img
 	}
 
+	imageChanged := replaced != nil && (ev.Image.MediaID == nil || replaced.MediaID == nil || *replaced.MediaID != *ev.Image.MediaID)
 	if err := uc.repo.Update(ctx, ev); err != nil {
 		log.Printf("[EVENT][UPDATE][ERROR] save id=%s err=%v", id.Hex(), err)
+		if imageChanged || (replaced == nil && req.Image != nil && req.Image.Type != "") {
+			uc.media.Release(ctx, ev.Image.MediaID, ev.ID)
+		}
 		return nil, err
 	}
+	if imageChanged {
+		uc.media.Discard(ctx, replaced.MediaID, ev.ID)
+	}
 	log.Printf("[EVENT][UPDATE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
-	return toEventResponse(ev), nil
+	return toEventResponse(ev, uc.media), nil
 }
 
 func (uc *eventUseCase) DeleteEventByID(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
@@ -129,6 +170,10 @@ func (uc *eventUseCase) DeleteEventByID(ctx context.Context, userID primitive.Ob
 		log.Printf("[EVENT][DELETE][ERROR] delete id=%s err=%v", id.Hex(), err)
 		return err
 	}
+	if ev.Image != nil {
+		uc.media.Discard(ctx, ev.Image.MediaID, ev.ID)
+	}
+	uc.stats.SyncRelationshipStats(ctx, ev.RelationshipID)
 	log.Printf("[EVENT][DELETE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
 	return nil
 }
@@ -142,7 +187,7 @@ func (uc *eventUseCase) GetAllEventsByUserID(ctx context.Context, userID primiti
 	}
 	res := make([]*dto.EventResponse, 0, len(events))
 	for _, e := range events {
-		res = append(res, toEventResponse(e))
+		res = append(res, toEventListResponse(e, uc.media))
 	}
 	log.Printf("[EVENT][LIST][DONE] user=%s count=%d", userID.Hex(), len(res))
 	return res, nil
@@ -162,14 +207,77 @@ func (uc *eventUseCase) GetAllEventsByCurrentRelationship(ctx context.Context, u
 	}
 	res := make([]*dto.EventResponse, 0, len(events))
 	for _, e := range events {
-		res = append(res, toEventResponse(e))
+		res = append(res, toEventListResponse(e, uc.media))
 	}
 	log.Printf("[EVENT][LIST_REL][DONE] user=%s count=%d", userID.Hex(), len(res))
 	return res, nil
 }
 
+func (uc *eventUseCase) GetAllEventsByRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID, limit, offset int64) ([]*dto.EventResponse, error) {
+	log.Printf("[EVENT][LIST_ARCHIVE][START] user=%s rel=%s limit=%d offset=%d", userID.Hex(), relationshipID.Hex(), limit, offset)
+	rel, err := findMemberRelationship(ctx, uc.relRepo, userID, relationshipID)
+	if err != nil {
+		log.Printf("[EVENT][LIST_ARCHIVE][DENY] user=%s rel=%s", userID.Hex(), relationshipID.Hex())
+		return nil, err
+	}
+	events, err := uc.repo.FindAllByRelationshipID(ctx, rel.ID, limit, offset)
+	if err != nil {
+		log.Printf("[EVENT][LIST_ARCHIVE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	res := make([]*dto.EventResponse, 0, len(events))
+	for _, e := range events {
+		res = append(res, toEventListResponse(e, uc.media))
+	}
+	log.Printf("[EVENT][LIST_ARCHIVE][DONE] user=%s count=%d", userID.Hex(), len(res))
+	return res, nil
+}
+
+// SetVisibility publishes an event to the explore feed or takes it back private
+func (uc *eventUseCase) SetVisibility(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.SetEventVisibilityRequest) (*dto.EventResponse, error) {
+	log.Printf("[EVENT][VISIBILITY][START] user=%s id=%s public=%t", userID.Hex(), id.Hex(), *req.IsPublic)
+	ev, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		log.Printf("[EVENT][VISIBILITY][ERROR] find id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	// Authorization: allow any partner in the same active relationship
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil || rel.ID != ev.RelationshipID {
+		log.Printf("[EVENT][VISIBILITY][DENY] user=%s id=%s", userID.Hex(), id.Hex())
+		return nil, ErrForbidden
+	}
+	if *req.IsPublic {
+		anonymous := true
+		if req.Anonymous != nil {
+			anonymous = *req.Anonymous
+		}
+		ev.MakePublic()
+		ev.SetAnonymous(anonymous)
+	} else {
+		ev.MakePrivate()
+	}
+	if err := uc.repo.Update(ctx, ev); err != nil {
+		log.Printf("[EVENT][VISIBILITY][ERROR] save id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	uc.stats.SyncRelationshipStats(ctx, ev.RelationshipID)
+	log.Printf("[EVENT][VISIBILITY][DONE] user=%s id=%s", userID.Hex(), id.Hex())
+	return toEventResponse(ev, uc.media), nil
+}
+
 // helpers
-func toEventResponse(ev *entities.Event) *dto.EventResponse {
+// toEventResponse renders an event with its full image; used for single-event results
+func toEventResponse(ev *entities.Event, urls MediaURLs) *dto.EventResponse {
+	return buildEventResponse(ev, urls, true)
+}
+
+// toEventListResponse renders an event for lists, with image thumbnails only
+func toEventListResponse(ev *entities.Event, urls MediaURLs) *dto.EventResponse {
+	return buildEventResponse(ev, urls, false)
+}
+
+func buildEventResponse(ev *entities.Event, urls MediaURLs, fullImage bool) *dto.EventResponse {
 	response := &dto.EventResponse{
 		ID:             ev.ID.Hex(),
 		Title:          ev.Title,
@@ -180,16 +288,12 @@ func toEventResponse(ev *entities.Event) *dto.EventResponse {
 		RelationshipID: ev.RelationshipID.Hex(),
 		CreatedBy:      ev.CreatedBy.Hex(),
 		IsPublic:       ev.Visibility.IsPublic,
+		IsAnonymous:    ev.Visibility.IsAnonymous,
+		ViewCount:      ev.ViewCount,
 		CreatedAt:      ev.CreatedAt,
 		UpdatedAt:      ev.UpdatedAt,
 	}
-	if ev.Image != nil {
-		response.Image = &dto.EventImagePayload{
-			Type:     ev.Image.Type,
-			Data:     ev.Image.Data,
-			Filename: ev.Image.Filename,
-		}
-	}
+	response.Image = toEventImagePayload(ev.Image, urls, fullImage)
 	return response
 }
 
@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+)
+
+type eventFixture struct {
+	*relationshipFixture
+	testPeople
+	events repositories.EventRepository
+	uc     EventUseCase
+}
+
+func newEventFixture(t *testing.T) *eventFixture {
+	t.Helper()
+	rf := newRelationshipFixture(nil)
+	f := &eventFixture{relationshipFixture: rf, testPeople: rf.addPeople(t), events: memory.NewEventRepository(rf.store)}
+	f.uc = NewEventUseCase(f.events, rf.rels, rf.users, testCatalog{}, nil, nopStats{})
+	return f
+}
+
+func (f *eventFixture) createEvent(t *testing.T, userID primitive.ObjectID, title string, date time.Time) primitive.ObjectID {
+	t.Helper()
+	res, err := f.uc.RegisterEvent(context.Background(), userID, &dto.CreateEventRequest{Title: title, Type: entities.EventTypeDate, Date: date})
+	if err != nil {
+		t.Fatalf("register event %q: %v", title, err)
+	}
+	id, err := primitive.ObjectIDFromHex(res.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRegisterEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      func(f *eventFixture) primitive.ObjectID
+		eventType string
+		wantErr   string
+	}{
+		{"partner", func(f *eventFixture) primitive.ObjectID { return f.bob }, entities.EventTypeTrip, ""},
+		{"unknown type", func(f *eventFixture) primitive.ObjectID { return f.alice }, "PICNIC", ErrUnknownEventType.Error()},
+		{"no relationship", func(f *eventFixture) primitive.ObjectID { return f.erin }, entities.EventTypeDate, "no active relationship"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newEventFixture(t)
+			req := &dto.CreateEventRequest{Title: "Weekend away", Type: tt.eventType, Date: time.Now()}
+			res, err := f.uc.RegisterEvent(context.Background(), tt.user(f), req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.RelationshipID != f.relID.Hex() || res.Category != entities.EventCategoryActivity {
+				t.Errorf("event relationship=%s category=%s, want %s and %s", res.RelationshipID, res.Category, f.relID.Hex(), entities.EventCategoryActivity)
+			}
+		})
+	}
+}
+
+func TestEventUseCaseAuthorization(t *testing.T) {
+	public := true
+	ops := []struct {
+		name string
+		call func(uc EventUseCase, userID, id primitive.ObjectID) error
+	}{
+		{"get", func(uc EventUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.GetEventByID(context.Background(), userID, id)
+			return err
+		}},
+		{"update", func(uc EventUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.UpdateEventByID(context.Background(), userID, id, &dto.UpdateEventRequest{Title: "Renamed"})
+			return err
+		}},
+		{"set visibility", func(uc EventUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.SetVisibility(context.Background(), userID, id, &dto.SetEventVisibilityRequest{IsPublic: &public})
+			return err
+		}},
+		{"delete", func(uc EventUseCase, userID, id primitive.ObjectID) error {
+			return uc.DeleteEventByID(context.Background(), userID, id)
+		}},
+	}
+	actors := []struct {
+		name    string
+		user    func(f *eventFixture) primitive.ObjectID
+		wantErr error
+	}{
+		{"author", func(f *eventFixture) primitive.ObjectID { return f.alice }, nil},
+		{"partner", func(f *eventFixture) primitive.ObjectID { return f.bob }, nil},
+		{"other couple", func(f *eventFixture) primitive.ObjectID { return f.carol }, ErrForbidden},
+		{"single user", func(f *eventFixture) primitive.ObjectID { return f.erin }, ErrForbidden},
+	}
+	for _, op := range ops {
+		for _, actor := range actors {
+			t.Run(op.name+"/"+actor.name, func(t *testing.T) {
+				f := newEventFixture(t)
+				id := f.createEvent(t, f.alice, "First date", time.Now())
+				err := op.call(f.uc, actor.user(f), id)
+				if !errors.Is(err, actor.wantErr) {
+					t.Fatalf("error = %v, want %v", err, actor.wantErr)
+				}
+				if actor.wantErr == nil {
+					return
+				}
+				// A denied call must leave the event as it was
+				ev, err := f.events.FindByID(context.Background(), id)
+				if err != nil {
+					t.Fatalf("event gone after denied %s: %v", op.name, err)
+				}
+				if ev.Title != "First date" || ev.Visibility.IsPublic {
+					t.Errorf("denied %s changed the event: title=%q public=%t", op.name, ev.Title, ev.Visibility.IsPublic)
+				}
+			})
+		}
+	}
+}
+
+func TestEventsOfDisconnectedRelationship(t *testing.T) {
+	ctx := context.Background()
+	f := newEventFixture(t)
+	id := f.createEvent(t, f.alice, "First date", time.Now())
+	if err := f.relationshipFixture.uc.DisconnectRelationship(ctx, f.alice); err != nil {
+		t.Fatal(err)
+	}
+
+	// Memories stay readable by the former partners but can no longer be changed
+	if _, err := f.uc.GetEventByID(ctx, f.bob, id); err != nil {
+		t.Errorf("former partner get: %v", err)
+	}
+	if _, err := f.uc.GetAllEventsByRelationship(ctx, f.bob, f.relID, 0, 0); err != nil {
+		t.Errorf("former partner list: %v", err)
+	}
+	if _, err := f.uc.UpdateEventByID(ctx, f.bob, id, &dto.UpdateEventRequest{Title: "Renamed"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("former partner update error = %v, want %v", err, ErrForbidden)
+	}
+	if err := f.uc.DeleteEventByID(ctx, f.alice, id); !errors.Is(err, ErrForbidden) {
+		t.Errorf("former partner delete error = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := f.uc.GetAllEventsByRelationship(ctx, f.carol, f.relID, 0, 0); !errors.Is(err, ErrRelationshipNotFound) {
+		t.Errorf("stranger list error = %v, want %v", err, ErrRelationshipNotFound)
+	}
+}
+
+func TestEventListsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	f := newEventFixture(t)
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, author := range []primitive.ObjectID{f.alice, f.bob, f.alice, f.bob, f.alice} {
+		f.createEvent(t, author, day.AddDate(0, 0, i).Format("Jan 2"), day.AddDate(0, 0, i))
+	}
+	f.createEvent(t, f.carol, "Elsewhere", day)
+
+	tests := []struct {
+		name string
+		list func() ([]*dto.EventResponse, error)
+		want []string
+	}{
+		{"relationship", func() ([]*dto.EventResponse, error) {
+			return f.uc.GetAllEventsByCurrentRelationship(ctx, f.bob, 0, 0)
+		}, []string{"Mar 5", "Mar 4", "Mar 3", "Mar 2", "Mar 1"}},
+		{"relationship page", func() ([]*dto.EventResponse, error) {
+			return f.uc.GetAllEventsByCurrentRelationship(ctx, f.bob, 2, 1)
+		}, []string{"Mar 4", "Mar 3"}},
+		{"past the end", func() ([]*dto.EventResponse, error) {
+			return f.uc.GetAllEventsByCurrentRelationship(ctx, f.bob, 2, 5)
+		}, []string{}},
+		{"author", func() ([]*dto.EventResponse, error) {
+			return f.uc.GetAllEventsByUserID(ctx, f.alice, 0, 0)
+		}, []string{"Mar 5", "Mar 3", "Mar 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := tt.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(list))
+			for _, ev := range list {
+				got = append(got, ev.Title)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("titles = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("titles = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
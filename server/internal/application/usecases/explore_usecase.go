@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"context"
+	"log"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExploreUseCase serves the public feed of moments couples chose to share
+type ExploreUseCase interface {
+	ListPublicEvents(ctx context.Context, eventType, sortBy string, limit, offset int64) ([]*dto.PublicEventResponse, error)
+	ViewPublicEvent(ctx context.Context, id primitive.ObjectID) (*dto.PublicEventResponse, error)
+}
+
+type exploreUseCase struct {
+	eventRepo domainRepos.EventRepository
+	userRepo  domainRepos.UserRepository
+	media     MediaURLs
+}
+
+func NewExploreUseCase(eventRepo domainRepos.EventRepository, userRepo domainRepos.UserRepository, media MediaURLs) ExploreUseCase {
+	return &exploreUseCase{eventRepo: eventRepo, userRepo: userRepo, media: media}
+}
+
+const maxExplorePageSize = 50
+
+func (uc *exploreUseCase) ListPublicEvents(ctx context.Context, eventType, sortBy string, limit, offset int64) ([]*dto.PublicEventResponse, error) {
+	log.Printf("[EXPLORE][LIST][START] type=%s sort=%s limit=%d offset=%d", eventType, sortBy, limit, offset)
+	if sortBy != domainRepos.PublicEventSortPopular {
+		sortBy = domainRepos.PublicEventSortRecent
+	}
+	if limit <= 0 || limit > maxExplorePageSize {
+		limit = maxExplorePageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	events, err := uc.eventRepo.FindPublic(ctx, eventType, sortBy, limit, offset)
+	if err != nil {
+		log.Printf("[EXPLORE][LIST][ERROR] err=%v", err)
+		return nil, err
+	}
+	res := make([]*dto.PublicEventResponse, 0, len(events))
+	for _, ev := range events {
+		res = append(res, uc.toPublicEventResponse(ctx, ev, false))
+	}
+	log.Printf("[EXPLORE][LIST][DONE] count=%d", len(res))
+	return res, nil
+}
+
+// ViewPublicEvent returns a public event and counts the view
+func (uc *exploreUseCase) ViewPublicEvent(ctx context.Context, id primitive.ObjectID) (*dto.PublicEventResponse, error) {
+	log.Printf("[EXPLORE][VIEW][START] id=%s", id.Hex())
+	ev, err := uc.eventRepo.IncrementViewCount(ctx, id)
+	if err != nil {
+		log.Printf("[EXPLORE][VIEW][ERROR] id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[EXPLORE][VIEW][DONE] id=%s views=%d", id.Hex(), ev.ViewCount)
+	return uc.toPublicEventResponse(ctx, ev, true), nil
+}
+
+// toPublicEventResponse projects an event for anonymous readers; ownership IDs are never exposed.
+// The full image is only included for the detail view.
+func (uc *exploreUseCase) toPublicEventResponse(ctx context.Context, ev *entities.Event, fullImage bool) *dto.PublicEventResponse {
+	res := &dto.PublicEventResponse{
+		ID:          ev.ID.Hex(),
+		Title:       ev.Title,
+		Description: ev.Description,
+		Date:        ev.Date,
+		Type:        ev.Type,
+		Category:    ev.Category,
+		ViewCount:   ev.ViewCount,
+		PublishedAt: ev.Visibility.PublishedAt,
+	}
+	res.Image = toEventImagePayload(ev.Image, uc.media, fullImage)
+	if !ev.Visibility.IsAnonymous {
+		if u, err := uc.userRepo.FindByID(ctx, ev.CreatedBy); err == nil {
+			res.AuthorName = u.Name
+		}
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+// testCatalog knows the types used by the tests; any other key is unknown
+type testCatalog struct{}
+
+func (testCatalog) WhisperType(ctx context.Context, key string) (*entities.TypeDefinition, error) {
+	switch key {
+	case "watch_sunset":
+		return &entities.TypeDefinition{Key: key, DefaultTexts: map[string]string{entities.LanguageEnglish: "Watch the sunset"}, DefaultRecurrence: entities.WhisperRecurrenceOnce, IsActive: true}, nil
+	case WhisperTypeCustom:
+		return &entities.TypeDefinition{Key: key, RequiresText: true, IsActive: true}, nil
+	}
+	return nil, ErrUnknownWhisperType
+}
+
+func (testCatalog) EventType(ctx context.Context, key string) (*entities.TypeDefinition, error) {
+	switch key {
+	case entities.EventTypeDate, entities.EventTypeTrip:
+		return &entities.TypeDefinition{Key: key, Category: entities.EventCategoryActivity, IsActive: true}, nil
+	}
+	return nil, ErrUnknownEventType
+}
+
+// nopStats skips statistics, which have their own repository
+type nopStats struct{}
+
+func (nopStats) SyncRelationshipStats(ctx context.Context, relationshipID primitive.ObjectID) {}
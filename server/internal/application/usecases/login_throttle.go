@@ -0,0 +1,130 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"whisper-server/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lockout scopes reported to clients
+const (
+	LockScopeAccount = "account"
+	LockScopeIP      = "ip"
+)
+
+// ErrLoginLocked matches every LoginLockedError via errors.Is
+var ErrLoginLocked = fmtError("too many failed login attempts; try again later")
+
+// LoginLockedError is returned while an account or client address is locked out
+type LoginLockedError struct {
+	Scope      string // LockScopeAccount or LockScopeIP
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string { return ErrLoginLocked.Error() }
+
+func (e *LoginLockedError) Is(target error) bool { return target == ErrLoginLocked }
+
+// LoginThrottleConfig tunes brute-force protection. Once a key reaches its failure
+// threshold it is locked for BaseLockout, doubling with every further failure up to
+// MaxLockout; failures older than Window are forgotten.
+type LoginThrottleConfig struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+	Window             time.Duration
+}
+
+// LoginThrottle tracks failed logins per account and per client address. The
+// account is a key from AccountKey, or from LoginKey when the identifier matches
+// no user, so unknown and existing accounts lock out alike.
+type LoginThrottle interface {
+	// Check returns a *LoginLockedError when either the account (if not empty) or
+	// the address is locked out
+	Check(ctx context.Context, account, ip string) error
+	RecordFailure(ctx context.Context, account, ip string)
+	RecordSuccess(ctx context.Context, userID primitive.ObjectID)
+}
+
+type loginThrottle struct {
+	repo repositories.LoginAttemptRepository
+	cfg  LoginThrottleConfig
+}
+
+func NewLoginThrottle(repo repositories.LoginAttemptRepository, cfg LoginThrottleConfig) LoginThrottle {
+	return &loginThrottle{repo: repo, cfg: cfg}
+}
+
+// AccountKey is the throttle key of an existing account
+func AccountKey(userID primitive.ObjectID) string { return "user:" + userID.Hex() }
+
+// LoginKey is the throttle key of a login identifier that matches no account
+func LoginKey(login string) string { return "login:" + strings.ToLower(strings.TrimSpace(login)) }
+
+func ipKey(ip string) string { return "ip:" + ip }
+
+func (t *loginThrottle) Check(ctx context.Context, account, ip string) error {
+	now := time.Now()
+	if ip != "" {
+		if a, err := t.repo.FindByKey(ctx, ipKey(ip)); err == nil && a.IsLocked(now) {
+			return &LoginLockedError{Scope: LockScopeIP, RetryAfter: a.LockedUntil.Sub(now)}
+		}
+	}
+	if account != "" {
+		if a, err := t.repo.FindByKey(ctx, account); err == nil && a.IsLocked(now) {
+			return &LoginLockedError{Scope: LockScopeAccount, RetryAfter: a.LockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+func (t *loginThrottle) RecordFailure(ctx context.Context, account, ip string) {
+	if ip != "" {
+		t.recordFailure(ctx, ipKey(ip), t.cfg.MaxIPFailures)
+	}
+	if account != "" {
+		t.recordFailure(ctx, account, t.cfg.MaxAccountFailures)
+	}
+}
+
+func (t *loginThrottle) recordFailure(ctx context.Context, key string, threshold int) {
+	now := time.Now()
+	a, err := t.repo.RecordFailure(ctx, key, now, t.cfg.Window)
+	if err != nil {
+		log.Printf("[AUTH][THROTTLE][ERROR] key=%s err=%v", key, err)
+		return
+	}
+	if threshold <= 0 || a.Failures < threshold {
+		return
+	}
+	lockout := t.lockoutFor(a.Failures - threshold)
+	if err := t.repo.Lock(ctx, key, now.Add(lockout)); err != nil {
+		log.Printf("[AUTH][THROTTLE][ERROR] key=%s lock err=%v", key, err)
+		return
+	}
+	log.Printf("[AUTH][THROTTLE][LOCKED] key=%s failures=%d for=%s", key, a.Failures, lockout)
+}
+
+// lockoutFor returns BaseLockout doubled once per failure beyond the threshold
+func (t *loginThrottle) lockoutFor(extraFailures int) time.Duration {
+	lockout := float64(t.cfg.BaseLockout) * math.Pow(2, float64(extraFailures))
+	if lockout > float64(t.cfg.MaxLockout) {
+		return t.cfg.MaxLockout
+	}
+	return time.Duration(lockout)
+}
+
+// RecordSuccess clears the account's failures; the address keeps its count so one
+// valid login cannot be used to reset guessing against other accounts
+func (t *loginThrottle) RecordSuccess(ctx context.Context, userID primitive.ObjectID) {
+	if err := t.repo.Reset(ctx, AccountKey(userID)); err != nil {
+		log.Printf("[AUTH][THROTTLE][ERROR] user=%s reset err=%v", userID.Hex(), err)
+	}
+}
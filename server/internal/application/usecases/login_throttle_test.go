@@ -0,0 +1,156 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+var testThrottleConfig = LoginThrottleConfig{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      10,
+	BaseLockout:        time.Minute,
+	MaxLockout:         4 * time.Minute,
+	Window:             15 * time.Minute,
+}
+
+func wantLocked(t *testing.T, err error, scope string) *LoginLockedError {
+	t.Helper()
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("error = %v, want a lockout", err)
+	}
+	if locked.Scope != scope {
+		t.Errorf("lockout scope = %s, want %s", locked.Scope, scope)
+	}
+	return locked
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	attempts := memory.NewLoginAttemptRepository(store)
+	throttle := NewLoginThrottle(attempts, testThrottleConfig)
+	alice := primitive.NewObjectID()
+	account := AccountKey(alice)
+
+	// Failures from different addresses add up on the account
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		throttle.RecordFailure(ctx, account, ip)
+		if err := throttle.Check(ctx, account, "10.0.0.9"); err != nil {
+			t.Fatalf("locked after %d failures: %v", i+1, err)
+		}
+	}
+	throttle.RecordFailure(ctx, account, "10.0.0.3")
+	locked := wantLocked(t, throttle.Check(ctx, account, "10.0.0.9"), LockScopeAccount)
+	if locked.RetryAfter <= 0 || locked.RetryAfter > time.Minute {
+		t.Errorf("first lockout retry after %s, want up to %s", locked.RetryAfter, time.Minute)
+	}
+	if err := throttle.Check(ctx, AccountKey(primitive.NewObjectID()), "10.0.0.9"); err != nil {
+		t.Errorf("other account locked: %v", err)
+	}
+
+	// Every further failure doubles the lockout up to the maximum
+	tests := []time.Duration{2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, want := range tests {
+		throttle.RecordFailure(ctx, account, "10.0.0.3")
+		a, err := attempts.FindByKey(ctx, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := time.Until(*a.LockedUntil); got <= want-time.Second || got > want {
+			t.Errorf("lockout after %d extra failures = %s, want %s", i+1, got, want)
+		}
+	}
+
+	// A successful login clears the account
+	throttle.RecordSuccess(ctx, alice)
+	if err := throttle.Check(ctx, account, "10.0.0.9"); err != nil {
+		t.Errorf("locked after success: %v", err)
+	}
+}
+
+func TestLoginThrottleLocksAddress(t *testing.T) {
+	ctx := context.Background()
+	throttle := NewLoginThrottle(memory.NewLoginAttemptRepository(memory.NewStore()), testThrottleConfig)
+	alice := primitive.NewObjectID()
+
+	// Guessing across many accounts locks the address, and a valid login elsewhere
+	// does not reset it
+	for i := 0; i < testThrottleConfig.MaxIPFailures; i++ {
+		throttle.RecordFailure(ctx, LoginKey(primitive.NewObjectID().Hex()), "10.0.0.1")
+	}
+	throttle.RecordSuccess(ctx, alice)
+	wantLocked(t, throttle.Check(ctx, "", "10.0.0.1"), LockScopeIP)
+	wantLocked(t, throttle.Check(ctx, AccountKey(alice), "10.0.0.1"), LockScopeIP)
+	if err := throttle.Check(ctx, AccountKey(alice), "10.0.0.2"); err != nil {
+		t.Errorf("other address locked: %v", err)
+	}
+}
+
+func TestLoginKeyNormalizes(t *testing.T) {
+	if LoginKey(" Alice@Example.com ") != LoginKey("alice@example.com") {
+		t.Error("login keys differ by case or spacing")
+	}
+}
+
+// newThrottledAuthFixture wires the real throttle instead of nopThrottle
+func newThrottledAuthFixture() *authFixture {
+	f := newAuthFixture()
+	throttle := NewLoginThrottle(memory.NewLoginAttemptRepository(f.store), testThrottleConfig)
+	f.uc = NewAuthUseCase(f.users, memory.NewRefreshTokenRepository(f.store), f.sessions, f.jwt,
+		services.NewPasswordService(), nopVerifier{}, throttle, f.twoFactor, nil)
+	return f
+}
+
+func TestLoginLockoutDoesNotRevealAccounts(t *testing.T) {
+	ctx := context.Background()
+	f := newThrottledAuthFixture()
+	f.register(t, "alice")
+
+	// The same sequence of answers for an existing and an unknown account
+	for _, login := range []string{"alice", "nobody"} {
+		t.Run(login, func(t *testing.T) {
+			for i := 0; i < testThrottleConfig.MaxAccountFailures; i++ {
+				_, err := f.uc.Login(ctx, &dto.LoginRequest{Username: login, Password: "wrong"}, testClient)
+				if err == nil || err.Error() != "invalid username or password" {
+					t.Fatalf("attempt %d error = %v", i+1, err)
+				}
+			}
+			_, err := f.uc.Login(ctx, &dto.LoginRequest{Username: login, Password: "secret123"}, testClient)
+			wantLocked(t, err, LockScopeAccount)
+		})
+	}
+}
+
+func TestLoginFallsBackToUsername(t *testing.T) {
+	ctx := context.Background()
+	f := newAuthFixture()
+	// Usernames with "@" are no longer accepted at registration but may exist
+	legacy := entities.NewUser("old@name", "Old", "old@example.com", "")
+	hash, err := services.NewPasswordService().HashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy.PasswordHash = hash
+	if err := f.users.Create(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+	for _, login := range []string{"old@name", "old@example.com"} {
+		res, err := f.uc.Login(ctx, &dto.LoginRequest{Username: login, Password: "secret123"}, testClient)
+		if err != nil {
+			t.Fatalf("login as %s: %v", login, err)
+		}
+		if res.User.ID != legacy.ID.Hex() {
+			t.Errorf("login as %s signed in %s", login, res.User.ID)
+		}
+	}
+}
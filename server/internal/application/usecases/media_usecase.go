@@ -0,0 +1,348 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MediaURLs signs storage keys so responses can link to private media
+type MediaURLs interface {
+	URL(key string) string
+}
+
+type MediaUseCase interface {
+	MediaURLs
+	Upload(ctx context.Context, userID primitive.ObjectID, kind, filename string, r io.Reader) (*dto.MediaResponse, error)
+	Get(ctx context.Context, userID, id primitive.ObjectID) (*dto.MediaResponse, error)
+	// Claim returns the caller's media of the given kind and attaches it to target,
+	// the event or user about to reference it. Media attached elsewhere is refused
+	// so a later Discard by one holder cannot break another.
+	Claim(ctx context.Context, userID, id primitive.ObjectID, kind string, target primitive.ObjectID) (*entities.Media, error)
+	// Release undoes a Claim whose reference was not saved after all
+	Release(ctx context.Context, id *primitive.ObjectID, target primitive.ObjectID)
+	// Import stores raw bytes, e.g. legacy base64 payloads, through the same checks as Upload
+	Import(ctx context.Context, userID primitive.ObjectID, kind, filename string, data []byte) (*entities.Media, error)
+	// Discard removes a media object that from no longer references; media attached
+	// to something else is left alone. Failures are only logged.
+	Discard(ctx context.Context, id *primitive.ObjectID, from primitive.ObjectID)
+	MaxBytes(kind string) int64
+}
+
+// media errors
+var (
+	ErrMediaTooLarge        = fmtError("file exceeds the size limit")
+	ErrUnsupportedMediaType = fmtError("unsupported media type; use JPEG, PNG or WebP")
+	ErrInvalidMediaKind     = fmtError("invalid media kind")
+	ErrInvalidMediaPayload  = fmtError("invalid image payload")
+	ErrUnreadableImage      = fmtError("image could not be decoded")
+	ErrMediaInUse           = fmtError("media is already used elsewhere")
+)
+
+type mediaUseCase struct {
+	repo           domainRepos.MediaRepository
+	storage        services.MediaStorage
+	images         services.ImageProcessor
+	urlTTL         time.Duration
+	maxImageBytes  int64
+	maxAvatarBytes int64
+}
+
+func NewMediaUseCase(repo domainRepos.MediaRepository, storage services.MediaStorage, images services.ImageProcessor, urlTTL time.Duration, maxImageBytes, maxAvatarBytes int64) MediaUseCase {
+	if urlTTL <= 0 {
+		urlTTL = time.Hour
+	}
+	return &mediaUseCase{repo: repo, storage: storage, images: images, urlTTL: urlTTL, maxImageBytes: maxImageBytes, maxAvatarBytes: maxAvatarBytes}
+}
+
+func (uc *mediaUseCase) MaxBytes(kind string) int64 {
+	if kind == entities.MediaKindAvatar {
+		return uc.maxAvatarBytes
+	}
+	return uc.maxImageBytes
+}
+
+func (uc *mediaUseCase) Upload(ctx context.Context, userID primitive.ObjectID, kind, filename string, r io.Reader) (*dto.MediaResponse, error) {
+	log.Printf("[MEDIA][UPLOAD][START] user=%s kind=%s", userID.Hex(), kind)
+	if kind != entities.MediaKindEventImage && kind != entities.MediaKindAvatar {
+		return nil, ErrInvalidMediaKind
+	}
+	// Read one byte past the limit to tell "exactly at the limit" from "too large"
+	data, err := io.ReadAll(io.LimitReader(r, uc.MaxBytes(kind)+1))
+	if err != nil {
+		log.Printf("[MEDIA][UPLOAD][ERROR] user=%s read err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	m, err := uc.Import(ctx, userID, kind, filename, data)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[MEDIA][UPLOAD][DONE] user=%s id=%s type=%s size=%d", userID.Hex(), m.ID.Hex(), m.ContentType, m.Size)
+	return uc.toMediaResponse(m), nil
+}
+
+func (uc *mediaUseCase) Import(ctx context.Context, userID primitive.ObjectID, kind, filename string, data []byte) (*entities.Media, error) {
+	if int64(len(data)) > uc.MaxBytes(kind) {
+		log.Printf("[MEDIA][IMPORT][REJECT] user=%s size=%d too large", userID.Hex(), len(data))
+		return nil, ErrMediaTooLarge
+	}
+	// Trust the bytes, not the client supplied Content-Type or file name
+	contentType := http.DetectContentType(data)
+	if _, ok := entities.ImageExtensions[contentType]; !ok {
+		log.Printf("[MEDIA][IMPORT][REJECT] user=%s sniffed=%s", userID.Hex(), contentType)
+		return nil, ErrUnsupportedMediaType
+	}
+	// Only the re-encoded image is kept: EXIF/GPS and other metadata never reach storage
+	processed, err := uc.images.Process(data)
+	if err != nil {
+		log.Printf("[MEDIA][IMPORT][REJECT] user=%s process err=%v", userID.Hex(), err)
+		if err == services.ErrImageDimensions {
+			return nil, ErrMediaTooLarge
+		}
+		return nil, ErrUnreadableImage
+	}
+	orig := processed.Original
+	m := entities.NewMedia(userID, kind, orig.ContentType, filename, int64(len(orig.Data)))
+	m.Width, m.Height = orig.Width, orig.Height
+	for _, v := range processed.Variants {
+		m.AddVariant(v.Name, v.ContentType, v.Width, v.Height, int64(len(v.Data)))
+	}
+
+	objects := append([]services.ImageVariant{orig}, processed.Variants...)
+	for i, key := range m.Keys() {
+		obj := objects[i]
+		if err := uc.storage.Put(ctx, key, bytes.NewReader(obj.Data), int64(len(obj.Data)), obj.ContentType); err != nil {
+			log.Printf("[MEDIA][IMPORT][ERROR] user=%s put key=%s err=%v", userID.Hex(), key, err)
+			uc.deleteObjects(ctx, m.Keys()[:i])
+			return nil, err
+		}
+	}
+	if err := uc.repo.Create(ctx, m); err != nil {
+		log.Printf("[MEDIA][IMPORT][ERROR] user=%s save err=%v", userID.Hex(), err)
+		uc.deleteObjects(ctx, m.Keys())
+		return nil, err
+	}
+	return m, nil
+}
+
+func (uc *mediaUseCase) Get(ctx context.Context, userID, id primitive.ObjectID) (*dto.MediaResponse, error) {
+	m, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+	return uc.toMediaResponse(m), nil
+}
+
+func (uc *mediaUseCase) Claim(ctx context.Context, userID, id primitive.ObjectID, kind string, target primitive.ObjectID) (*entities.Media, error) {
+	m, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m.OwnerID != userID {
+		return nil, ErrForbidden
+	}
+	if m.Kind != kind {
+		return nil, ErrInvalidMediaKind
+	}
+	attached, err := uc.repo.Attach(ctx, id, target)
+	if err != nil {
+		return nil, err
+	}
+	if !attached {
+		log.Printf("[MEDIA][CLAIM][REJECT] user=%s id=%s already attached", userID.Hex(), id.Hex())
+		return nil, ErrMediaInUse
+	}
+	m.AttachedTo = &target
+	return m, nil
+}
+
+func (uc *mediaUseCase) Release(ctx context.Context, id *primitive.ObjectID, target primitive.ObjectID) {
+	if id == nil {
+		return
+	}
+	if err := uc.repo.Detach(ctx, *id, target); err != nil {
+		log.Printf("[MEDIA][RELEASE][WARN] id=%s err=%v", id.Hex(), err)
+	}
+}
+
+func (uc *mediaUseCase) Discard(ctx context.Context, id *primitive.ObjectID, from primitive.ObjectID) {
+	if id == nil {
+		return
+	}
+	// Taking the attachment first keeps anyone else from claiming the media
+	// while its objects are deleted
+	attached, err := uc.repo.Attach(ctx, *id, from)
+	if err != nil {
+		log.Printf("[MEDIA][DISCARD][WARN] id=%s err=%v", id.Hex(), err)
+		return
+	}
+	if !attached {
+		log.Printf("[MEDIA][DISCARD][SKIP] id=%s attached elsewhere", id.Hex())
+		return
+	}
+	m, err := uc.repo.FindByID(ctx, *id)
+	if err != nil {
+		log.Printf("[MEDIA][DISCARD][WARN] id=%s err=%v", id.Hex(), err)
+		return
+	}
+	if !uc.deleteObjects(ctx, m.Keys()) {
+		log.Printf("[MEDIA][DISCARD][WARN] id=%s objects left behind", id.Hex())
+		return
+	}
+	if err := uc.repo.Delete(ctx, m.ID); err != nil {
+		log.Printf("[MEDIA][DISCARD][WARN] id=%s delete record err=%v", id.Hex(), err)
+	}
+}
+
+// deleteObjects removes storage objects and reports whether all deletions succeeded
+func (uc *mediaUseCase) deleteObjects(ctx context.Context, keys []string) bool {
+	ok := true
+	for _, key := range keys {
+		if err := uc.storage.Delete(ctx, key); err != nil {
+			log.Printf("[MEDIA][DELETE][WARN] key=%s err=%v", key, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (uc *mediaUseCase) URL(key string) string {
+	u, err := uc.storage.SignedURL(key, uc.urlTTL)
+	if err != nil {
+		log.Printf("[MEDIA][SIGN][ERROR] key=%s err=%v", key, err)
+		return ""
+	}
+	return u
+}
+
+func (uc *mediaUseCase) toMediaResponse(m *entities.Media) *dto.MediaResponse {
+	return &dto.MediaResponse{
+		ID:          m.ID.Hex(),
+		Kind:        m.Kind,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Filename:    m.Filename,
+		URL:         uc.URL(m.Key),
+		Thumbnails:  thumbnailURLs(m.Variants, uc),
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+// resolveEventImage turns a request image into what is stored on event eventID.
+// Legacy base64 payloads are moved into media storage instead of the document.
+func resolveEventImage(ctx context.Context, media MediaUseCase, userID, eventID primitive.ObjectID, p *dto.EventImagePayload) (*entities.EventImage, error) {
+	now := time.Now()
+	var m *entities.Media
+	var err error
+	switch p.Type {
+	case entities.ImageTypeURL:
+		return &entities.EventImage{Type: entities.ImageTypeURL, Data: p.Data, Filename: p.Filename, UploadedAt: now}, nil
+	case entities.ImageTypeMedia:
+		oid, perr := primitive.ObjectIDFromHex(p.MediaID)
+		if perr != nil {
+			return nil, ErrInvalidMediaPayload
+		}
+		m, err = media.Claim(ctx, userID, oid, entities.MediaKindEventImage, eventID)
+	case entities.ImageTypeBase64:
+		data, derr := decodeBase64Image(p.Data)
+		if derr != nil {
+			return nil, derr
+		}
+		if m, err = media.Import(ctx, userID, entities.MediaKindEventImage, p.Filename, data); err == nil {
+			m, err = media.Claim(ctx, userID, m.ID, entities.MediaKindEventImage, eventID)
+		}
+	default:
+		return nil, ErrInvalidMediaPayload
+	}
+	if err != nil {
+		return nil, err
+	}
+	filename := p.Filename
+	if filename == "" {
+		filename = m.Filename
+	}
+	return &entities.EventImage{
+		Type:        entities.ImageTypeMedia,
+		MediaID:     &m.ID,
+		Key:         m.Key,
+		ContentType: m.ContentType,
+		Variants:    m.Variants,
+		Filename:    filename,
+		Size:        m.Size,
+		UploadedAt:  now,
+	}, nil
+}
+
+// toEventImagePayload presents a stored image; media objects are exposed via signed URLs only.
+// List views get thumbnails only, the full image is reserved for detail views.
+func toEventImagePayload(img *entities.EventImage, urls MediaURLs, full bool) *dto.EventImagePayload {
+	if img == nil {
+		return nil
+	}
+	if img.Type != entities.ImageTypeMedia {
+		res := &dto.EventImagePayload{Type: img.Type, Filename: img.Filename}
+		// Legacy inline data is as heavy as a full image; external URLs are cheap
+		if full || img.Type == entities.ImageTypeURL {
+			res.Data = img.Data
+		}
+		return res
+	}
+	res := &dto.EventImagePayload{Type: img.Type, Filename: img.Filename}
+	if img.MediaID != nil {
+		res.MediaID = img.MediaID.Hex()
+	}
+	if urls != nil {
+		if full {
+			res.URL = urls.URL(img.Key)
+		}
+		res.Thumbnails = thumbnailURLs(img.Variants, urls)
+	}
+	return res
+}
+
+// thumbnailURLs signs every rendition, keyed by variant name
+func thumbnailURLs(variants []entities.MediaVariant, urls MediaURLs) map[string]string {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(variants))
+	for _, v := range variants {
+		out[v.Name] = urls.URL(v.Key)
+	}
+	return out
+}
+
+// decodeBase64Image accepts raw base64 or a data URL ("data:image/png;base64,...")
+func decodeBase64Image(s string) ([]byte, error) {
+	if i := strings.Index(s, ","); strings.HasPrefix(s, "data:") && i >= 0 {
+		s = s[i+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, ErrInvalidMediaPayload
+	}
+	return data, nil
+}
+
+// IsMediaValidationError reports media errors caused by client input
+func IsMediaValidationError(err error) bool {
+	return err == ErrMediaTooLarge || err == ErrUnsupportedMediaType || err == ErrInvalidMediaKind ||
+		err == ErrInvalidMediaPayload || err == ErrUnreadableImage
+}
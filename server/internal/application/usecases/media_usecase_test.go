@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// A 1x1 transparent PNG
+const onePixelPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+type mediaFixture struct {
+	repo    repositories.MediaRepository
+	storage services.MediaStorage
+	uc      MediaUseCase
+	owner   primitive.ObjectID
+}
+
+func newMediaFixture(t *testing.T) *mediaFixture {
+	t.Helper()
+	storage, err := services.NewLocalMediaStorage(t.TempDir(), "https://whisper.test/api/v1/media/files", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &mediaFixture{
+		repo:    memory.NewMediaRepository(memory.NewStore()),
+		storage: storage,
+		owner:   primitive.NewObjectID(),
+	}
+	f.uc = NewMediaUseCase(f.repo, storage, services.NewImageProcessor(services.DefaultThumbnailSizes), time.Hour, 1<<20, 1<<20)
+	return f
+}
+
+func (f *mediaFixture) upload(t *testing.T, kind string) *entities.Media {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(onePixelPNG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := f.uc.Import(context.Background(), f.owner, kind, "pixel.png", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func (f *mediaFixture) stored(t *testing.T, m *entities.Media) bool {
+	t.Helper()
+	_, err := f.repo.FindByID(context.Background(), m.ID)
+	r, getErr := f.storage.Get(context.Background(), m.Key)
+	if getErr == nil {
+		r.Close()
+	}
+	return err == nil && getErr == nil
+}
+
+func TestClaimAttachesToOneTarget(t *testing.T) {
+	ctx := context.Background()
+	f := newMediaFixture(t)
+	m := f.upload(t, entities.MediaKindEventImage)
+	event, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	if _, err := f.uc.Claim(ctx, primitive.NewObjectID(), m.ID, entities.MediaKindEventImage, event); !errors.Is(err, ErrForbidden) {
+		t.Errorf("claim by another user: %v, want %v", err, ErrForbidden)
+	}
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindAvatar, event); !errors.Is(err, ErrInvalidMediaKind) {
+		t.Errorf("claim as avatar: %v, want %v", err, ErrInvalidMediaKind)
+	}
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, event); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same event again keeps its image
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, event); err != nil {
+		t.Errorf("claim again by the holder: %v", err)
+	}
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, other); !errors.Is(err, ErrMediaInUse) {
+		t.Fatalf("claim for a second event: %v, want %v", err, ErrMediaInUse)
+	}
+
+	// A failed save releases the claim for someone else
+	f.uc.Release(ctx, &m.ID, event)
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, other); err != nil {
+		t.Errorf("claim after release: %v", err)
+	}
+}
+
+func TestDiscardOnlyByHolder(t *testing.T) {
+	ctx := context.Background()
+	f := newMediaFixture(t)
+	m := f.upload(t, entities.MediaKindEventImage)
+	event, other := primitive.NewObjectID(), primitive.NewObjectID()
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// An event that lost the image to another one must not delete it
+	f.uc.Discard(ctx, &m.ID, other)
+	if !f.stored(t, m) {
+		t.Fatal("media discarded by an event not holding it")
+	}
+
+	f.uc.Discard(ctx, &m.ID, event)
+	if f.stored(t, m) {
+		t.Error("media kept after its holder discarded it")
+	}
+	if _, err := f.uc.Claim(ctx, f.owner, m.ID, entities.MediaKindEventImage, other); err == nil {
+		t.Error("discarded media claimed")
+	}
+
+	// Unclaimed uploads can be discarded by whoever drops them
+	loose := f.upload(t, entities.MediaKindAvatar)
+	f.uc.Discard(ctx, &loose.ID, f.owner)
+	if f.stored(t, loose) {
+		t.Error("unclaimed media kept")
+	}
+}
@@ -0,0 +1,399 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OIDCUseCase signs users in through external OpenID Connect providers
+type OIDCUseCase interface {
+	Providers() *dto.OIDCProvidersResponse
+	// StartLogin begins a sign-in; the callback logs in, links by verified email
+	// or provisions a new account
+	StartLogin(ctx context.Context, provider string) (*dto.OIDCStartResponse, error)
+	// StartLink begins attaching a provider account to the signed-in user
+	StartLink(ctx context.Context, userID primitive.ObjectID, provider string) (*dto.OIDCStartResponse, error)
+	Callback(ctx context.Context, req *dto.OIDCCallbackRequest, client dto.ClientInfo) (*dto.OIDCCallbackResponse, error)
+	ListIdentities(ctx context.Context, userID primitive.ObjectID) (*dto.ExternalIdentityListResponse, error)
+}
+
+// ExternalLoginCompleter turns a user authenticated elsewhere into a session, or
+// into an MFA challenge when the account has 2FA enabled
+type ExternalLoginCompleter interface {
+	CompleteExternalLogin(ctx context.Context, user *entities.User, client dto.ClientInfo) (*dto.AuthResponse, error)
+}
+
+var (
+	ErrUnknownIdentityProvider = fmtError("unknown identity provider")
+	ErrInvalidOIDCState        = fmtError("invalid or expired sign-in attempt")
+	ErrOIDCLoginFailed         = fmtError("sign-in with the identity provider failed")
+	ErrIdentityAlreadyLinked   = fmtError("this provider account is linked to another user")
+	ErrProviderAlreadyLinked   = fmtError("an account from this provider is already linked")
+	ErrOIDCAccountExists       = fmtError("an account with this email already exists; sign in and link the provider instead")
+)
+
+// oidcStateTTL bounds how long the user may spend at the provider
+const oidcStateTTL = 10 * time.Minute
+
+type oidcUseCase struct {
+	providers       map[string]services.IdentityProvider
+	identityRepo    repositories.ExternalIdentityRepository
+	stateRepo       repositories.OIDCStateRepository
+	userRepo        repositories.UserRepository
+	passwordService services.PasswordService
+	verifier        EmailVerifier
+	logins          ExternalLoginCompleter
+	redirectURI     string
+}
+
+func NewOIDCUseCase(
+	providers map[string]services.IdentityProvider,
+	identityRepo repositories.ExternalIdentityRepository,
+	stateRepo repositories.OIDCStateRepository,
+	userRepo repositories.UserRepository,
+	passwordService services.PasswordService,
+	verifier EmailVerifier,
+	logins ExternalLoginCompleter,
+	redirectURI string,
+) OIDCUseCase {
+	return &oidcUseCase{
+		providers:       providers,
+		identityRepo:    identityRepo,
+		stateRepo:       stateRepo,
+		userRepo:        userRepo,
+		passwordService: passwordService,
+		verifier:        verifier,
+		logins:          logins,
+		redirectURI:     redirectURI,
+	}
+}
+
+func (uc *oidcUseCase) Providers() *dto.OIDCProvidersResponse {
+	names := make([]string, 0, len(uc.providers))
+	for name := range uc.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &dto.OIDCProvidersResponse{Providers: names}
+}
+
+func (uc *oidcUseCase) StartLogin(ctx context.Context, provider string) (*dto.OIDCStartResponse, error) {
+	return uc.start(ctx, provider, nil)
+}
+
+func (uc *oidcUseCase) StartLink(ctx context.Context, userID primitive.ObjectID, provider string) (*dto.OIDCStartResponse, error) {
+	return uc.start(ctx, provider, &userID)
+}
+
+func (uc *oidcUseCase) start(ctx context.Context, providerName string, linkUserID *primitive.ObjectID) (*dto.OIDCStartResponse, error) {
+	provider, ok := uc.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	state, stateHash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	browserNonce, browserHash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := services.GeneratePKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge, uc.redirectURI)
+	if err != nil {
+		log.Printf("[OIDC][START][ERROR] provider=%s err=%v", providerName, err)
+		return nil, ErrOIDCLoginFailed
+	}
+	now := time.Now()
+	if err := uc.stateRepo.Create(ctx, &entities.OIDCState{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BrowserHash:  browserHash,
+		LinkUserID:   linkUserID,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(oidcStateTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &dto.OIDCStartResponse{
+		AuthorizationURL: authURL,
+		ExpiresIn:        int64(oidcStateTTL.Seconds()),
+		BrowserNonce:     browserNonce,
+	}, nil
+}
+
+func (uc *oidcUseCase) Callback(ctx context.Context, req *dto.OIDCCallbackRequest, client dto.ClientInfo) (*dto.OIDCCallbackResponse, error) {
+	state, err := uc.stateRepo.Consume(ctx, services.HashOpaqueToken(req.State))
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	// A state lured into another browser, e.g. through a forged callback link,
+	// must not sign that browser into the attacker's provider account
+	if req.BrowserNonce == "" || services.HashOpaqueToken(req.BrowserNonce) != state.BrowserHash {
+		log.Printf("[OIDC][CALLBACK][ERROR] provider=%s state not started by this browser", state.Provider)
+		return nil, ErrInvalidOIDCState
+	}
+	provider, ok := uc.providers[state.Provider]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+	log.Printf("[OIDC][CALLBACK][START] provider=%s", state.Provider)
+
+	ext, err := provider.Exchange(ctx, req.Code, state.CodeVerifier, uc.redirectURI, state.Nonce)
+	if err != nil {
+		log.Printf("[OIDC][CALLBACK][ERROR] provider=%s err=%v", state.Provider, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	if state.LinkUserID != nil {
+		return uc.link(ctx, *state.LinkUserID, ext)
+	}
+
+	user, identity, linked, created, err := uc.resolveUser(ctx, ext)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.identityRepo.TouchLogin(ctx, identity.ID, time.Now()); err != nil {
+		log.Printf("[OIDC][CALLBACK][WARN] identity=%s touch: %v", identity.ID.Hex(), err)
+	}
+
+	client.DeviceName = req.DeviceName
+	auth, err := uc.logins.CompleteExternalLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[OIDC][CALLBACK][DONE] provider=%s user=%s linked=%t created=%t", ext.Provider, user.ID.Hex(), linked, created)
+	return &dto.OIDCCallbackResponse{
+		AuthResponse: auth,
+		Provider:     ext.Provider,
+		Linked:       linked,
+		Created:      created,
+	}, nil
+}
+
+// resolveUser finds the account for an external identity: an existing link, a
+// local account with the same verified email, or a newly provisioned one
+func (uc *oidcUseCase) resolveUser(ctx context.Context, ext *services.ExternalIdentity) (user *entities.User, identity *entities.ExternalIdentity, linked, created bool, err error) {
+	identity, err = uc.identityRepo.FindByProviderSubject(ctx, ext.Provider, ext.Subject)
+	if err == nil {
+		user, err = uc.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, nil, false, false, err
+		}
+		return user, identity, false, false, nil
+	}
+	if !errors.Is(err, repositories.ErrExternalIdentityNotFound) {
+		return nil, nil, false, false, err
+	}
+
+	if ext.Email != "" {
+		existing, findErr := uc.userRepo.FindByEmail(ctx, ext.Email)
+		if findErr == nil {
+			// Only link automatically when both sides proved ownership of the address;
+			// otherwise whoever controls either account could take over the other
+			if !ext.EmailVerified || !existing.IsEmailVerified() {
+				return nil, nil, false, false, ErrOIDCAccountExists
+			}
+			identity, err = uc.createIdentity(ctx, existing.ID, ext)
+			if err != nil {
+				return nil, nil, false, false, err
+			}
+			log.Printf("[OIDC][LINK][EMAIL] provider=%s user=%s", ext.Provider, existing.ID.Hex())
+			return existing, identity, true, false, nil
+		}
+		// A deleted account keeps its address until the purge so it can be restored
+		taken, err := uc.userRepo.ExistsByEmail(ctx, ext.Email)
+		if err != nil {
+			return nil, nil, false, false, err
+		}
+		if taken {
+			return nil, nil, false, false, ErrOIDCAccountExists
+		}
+	}
+
+	user, err = uc.provisionUser(ctx, ext)
+	if err != nil {
+		return nil, nil, false, false, err
+	}
+	identity, err = uc.createIdentity(ctx, user.ID, ext)
+	if err != nil {
+		return nil, nil, false, false, err
+	}
+	return user, identity, true, true, nil
+}
+
+func (uc *oidcUseCase) link(ctx context.Context, userID primitive.ObjectID, ext *services.ExternalIdentity) (*dto.OIDCCallbackResponse, error) {
+	existing, err := uc.identityRepo.FindByProviderSubject(ctx, ext.Provider, ext.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+		return &dto.OIDCCallbackResponse{Provider: ext.Provider, Linked: true}, nil
+	}
+	if !errors.Is(err, repositories.ErrExternalIdentityNotFound) {
+		return nil, err
+	}
+
+	if _, err := uc.createIdentity(ctx, userID, ext); err != nil {
+		return nil, err
+	}
+	log.Printf("[OIDC][LINK][DONE] provider=%s user=%s", ext.Provider, userID.Hex())
+	return &dto.OIDCCallbackResponse{Provider: ext.Provider, Linked: true}, nil
+}
+
+func (uc *oidcUseCase) createIdentity(ctx context.Context, userID primitive.ObjectID, ext *services.ExternalIdentity) (*entities.ExternalIdentity, error) {
+	identity := entities.NewExternalIdentity(userID, ext.Provider, ext.Subject, ext.Email)
+	if err := uc.identityRepo.Create(ctx, identity); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// Either the provider account was linked concurrently or the user
+			// already has a different account from this provider
+			if other, findErr := uc.identityRepo.FindByProviderSubject(ctx, ext.Provider, ext.Subject); findErr == nil && other.UserID != userID {
+				return nil, ErrIdentityAlreadyLinked
+			}
+			return nil, ErrProviderAlreadyLinked
+		}
+		return nil, err
+	}
+	return identity, nil
+}
+
+// provisionUser creates an account for a first-time external sign-in. It gets an
+// unusable random password; the user can set one through the reset flow.
+func (uc *oidcUseCase) provisionUser(ctx context.Context, ext *services.ExternalIdentity) (*entities.User, error) {
+	username, err := uc.uniqueUsername(ctx, ext)
+	if err != nil {
+		return nil, err
+	}
+	secret, _, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := uc.passwordService.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(ext.Name)
+	if len([]rune(name)) < 2 {
+		name = username
+	}
+	if len([]rune(name)) > 50 {
+		name = string([]rune(name)[:50])
+	}
+
+	user := entities.NewUser(username, name, ext.Email, passwordHash)
+	if ext.Email != "" && ext.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	log.Printf("[OIDC][PROVISION][DONE] provider=%s user=%s username=%s", ext.Provider, user.ID.Hex(), username)
+
+	if ext.Email != "" && !ext.EmailVerified {
+		if err := uc.verifier.SendVerification(ctx, user); err != nil {
+			log.Printf("[OIDC][PROVISION][WARN] user=%s verification email: %v", user.ID.Hex(), err)
+		}
+	}
+	return user, nil
+}
+
+// uniqueUsername derives a username from the provider's claims and appends digits
+// until it is free
+func (uc *oidcUseCase) uniqueUsername(ctx context.Context, ext *services.ExternalIdentity) (string, error) {
+	localPart, _, _ := strings.Cut(ext.Email, "@")
+	base := ""
+	for _, candidate := range []string{ext.PreferredUsername, localPart, ext.Name} {
+		if base = sanitizeUsername(candidate); base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	for i := 0; i < 100; i++ {
+		candidate := base
+		if i > 0 {
+			suffix := fmt.Sprintf("%d", i+1)
+			if len(candidate)+len(suffix) > 30 {
+				candidate = candidate[:30-len(suffix)]
+			}
+			candidate += suffix
+		}
+		exists, err := uc.userRepo.ExistsByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a free username for %q", base)
+}
+
+// sanitizeUsername keeps lowercase letters, digits and underscores and enforces
+// the 3-30 character limit of registration; "" means nothing usable was left
+func sanitizeUsername(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case r == '_' || r == '.' || r == '-' || r == ' ':
+			b.WriteRune('_')
+		}
+	}
+	out := strings.Trim(b.String(), "_")
+	if len(out) > 30 {
+		out = strings.TrimRight(out[:30], "_")
+	}
+	if len(out) < 3 {
+		return ""
+	}
+	return out
+}
+
+func (uc *oidcUseCase) ListIdentities(ctx context.Context, userID primitive.ObjectID) (*dto.ExternalIdentityListResponse, error) {
+	identities, err := uc.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.ExternalIdentityListResponse{Identities: make([]dto.ExternalIdentityResponse, 0, len(identities))}
+	for _, identity := range identities {
+		resp.Identities = append(resp.Identities, dto.ExternalIdentityResponse{
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			LinkedAt:    identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,344 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// fakeProvider signs in as identity; it accepts the code "code" with the nonce
+// of the last authorization URL it handed out
+type fakeProvider struct {
+	identity services.ExternalIdentity
+	nonce    string
+}
+
+func (p *fakeProvider) Name() string { return "idp" }
+
+func (p *fakeProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge, redirectURI string) (string, error) {
+	p.nonce = nonce
+	return "https://idp.test/authorize?state=" + url.QueryEscape(state), nil
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code, codeVerifier, redirectURI, nonce string) (*services.ExternalIdentity, error) {
+	if code != "code" || nonce != p.nonce {
+		return nil, services.ErrIdentityVerification
+	}
+	identity := p.identity
+	identity.Provider = "idp"
+	return &identity, nil
+}
+
+// loginRecorder completes external logins without issuing tokens
+type loginRecorder struct {
+	users []primitive.ObjectID
+}
+
+func (l *loginRecorder) CompleteExternalLogin(ctx context.Context, user *entities.User, client dto.ClientInfo) (*dto.AuthResponse, error) {
+	l.users = append(l.users, user.ID)
+	return &dto.AuthResponse{AccessToken: "token"}, nil
+}
+
+type verificationRecorder struct {
+	sent []string // emails
+}
+
+func (v *verificationRecorder) SendVerification(ctx context.Context, user *entities.User) error {
+	v.sent = append(v.sent, user.Email)
+	return nil
+}
+
+type oidcFixture struct {
+	users         repositories.UserRepository
+	identities    repositories.ExternalIdentityRepository
+	provider      *fakeProvider
+	logins        *loginRecorder
+	verifications *verificationRecorder
+	uc            OIDCUseCase
+}
+
+func newOIDCFixture() *oidcFixture {
+	store := memory.NewStore()
+	f := &oidcFixture{
+		users:         memory.NewUserRepository(store),
+		identities:    memory.NewExternalIdentityRepository(store),
+		provider:      &fakeProvider{},
+		logins:        &loginRecorder{},
+		verifications: &verificationRecorder{},
+	}
+	f.uc = NewOIDCUseCase(map[string]services.IdentityProvider{"idp": f.provider}, f.identities,
+		memory.NewOIDCStateRepository(store), f.users, services.NewPasswordService(), f.verifications, f.logins,
+		"https://whisper.test/auth/oidc/callback")
+	return f
+}
+
+// createUser stores a local account, with its email verified when asked
+func (f *oidcFixture) createUser(t *testing.T, username string, verified bool) *entities.User {
+	t.Helper()
+	user := entities.NewUser(username, username, username+"@example.com", "hash")
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := f.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// callbackFor returns the callback request a browser coming back from the
+// provider would send for the attempt start began
+func callbackFor(t *testing.T, start *dto.OIDCStartResponse) *dto.OIDCCallbackRequest {
+	t.Helper()
+	u, err := url.Parse(start.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dto.OIDCCallbackRequest{State: u.Query().Get("state"), Code: "code", BrowserNonce: start.BrowserNonce}
+}
+
+// signIn runs a whole sign-in as the given provider account
+func (f *oidcFixture) signIn(t *testing.T, ext services.ExternalIdentity) (*dto.OIDCCallbackResponse, error) {
+	t.Helper()
+	f.provider.identity = ext
+	start, err := f.uc.StartLogin(context.Background(), "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.uc.Callback(context.Background(), callbackFor(t, start), dto.ClientInfo{})
+}
+
+// link runs a whole link of the provider account to userID
+func (f *oidcFixture) link(t *testing.T, userID primitive.ObjectID, ext services.ExternalIdentity) (*dto.OIDCCallbackResponse, error) {
+	t.Helper()
+	f.provider.identity = ext
+	start, err := f.uc.StartLink(context.Background(), userID, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.uc.Callback(context.Background(), callbackFor(t, start), dto.ClientInfo{})
+}
+
+func TestOIDCResolveUser(t *testing.T) {
+	aliceAccount := services.ExternalIdentity{Subject: "sub-alice", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"}
+	tests := []struct {
+		name string
+		// setup prepares the store and returns the account the sign-in should reach
+		setup       func(t *testing.T, f *oidcFixture) primitive.ObjectID
+		ext         services.ExternalIdentity
+		wantErr     error
+		wantLinked  bool
+		wantCreated bool
+	}{
+		{"linked identity", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			// The link wins over the email, which belongs to someone else
+			alice := f.createUser(t, "alice", true)
+			f.createUser(t, "other", true)
+			if err := f.identities.Create(context.Background(), entities.NewExternalIdentity(alice.ID, "idp", "sub-alice", "")); err != nil {
+				t.Fatal(err)
+			}
+			return alice.ID
+		}, services.ExternalIdentity{Subject: "sub-alice", Email: "other@example.com", EmailVerified: true}, nil, false, false},
+		{"verified email on both sides", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			return f.createUser(t, "alice", true).ID
+		}, aliceAccount, nil, true, false},
+		{"email not verified by the provider", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			f.createUser(t, "alice", true)
+			return primitive.NilObjectID
+		}, services.ExternalIdentity{Subject: "sub-alice", Email: "alice@example.com"}, ErrOIDCAccountExists, false, false},
+		{"local email not verified", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			f.createUser(t, "alice", false)
+			return primitive.NilObjectID
+		}, aliceAccount, ErrOIDCAccountExists, false, false},
+		{"email of a deleted account", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			alice := f.createUser(t, "alice", true)
+			if err := f.users.Delete(context.Background(), alice.ID, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			return primitive.NilObjectID
+		}, aliceAccount, ErrOIDCAccountExists, false, false},
+		{"unknown email", func(t *testing.T, f *oidcFixture) primitive.ObjectID {
+			f.createUser(t, "bob", true)
+			return primitive.NilObjectID
+		}, aliceAccount, nil, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newOIDCFixture()
+			want := tt.setup(t, f)
+
+			res, err := f.signIn(t, tt.ext)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			identity, findErr := f.identities.FindByProviderSubject(ctx, "idp", tt.ext.Subject)
+			if tt.wantErr != nil {
+				if findErr == nil || len(f.logins.users) != 0 {
+					t.Errorf("refused sign-in stored identity=%v logins=%v", findErr == nil, f.logins.users)
+				}
+				return
+			}
+			if res.Linked != tt.wantLinked || res.Created != tt.wantCreated || res.Provider != "idp" || res.AuthResponse == nil {
+				t.Errorf("response = %+v, want linked=%t created=%t", res, tt.wantLinked, tt.wantCreated)
+			}
+			if findErr != nil {
+				t.Fatalf("identity: %v", findErr)
+			}
+			if !tt.wantCreated && identity.UserID != want {
+				t.Errorf("identity points at %s, want %s", identity.UserID.Hex(), want.Hex())
+			}
+			if len(f.logins.users) != 1 || f.logins.users[0] != identity.UserID {
+				t.Errorf("logged in %v, want %s", f.logins.users, identity.UserID.Hex())
+			}
+			if identity.LastLoginAt == nil {
+				t.Error("lastLoginAt not recorded")
+			}
+		})
+	}
+}
+
+func TestOIDCProvisionUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		taken        []string // usernames already in use
+		ext          services.ExternalIdentity
+		wantUsername string
+		wantName     string
+		wantVerified bool
+	}{
+		{"preferred username", nil,
+			services.ExternalIdentity{Email: "a.smith@example.com", EmailVerified: true, Name: "Alice Smith", PreferredUsername: "Alice.Smith"},
+			"alice_smith", "Alice Smith", true},
+		{"email local part", nil,
+			services.ExternalIdentity{Email: "bob-jones@example.com", EmailVerified: true, Name: "Bob"},
+			"bob_jones", "Bob", true},
+		{"taken username gets a number", []string{"carol", "carol2"},
+			services.ExternalIdentity{Email: "carol@elsewhere.test", EmailVerified: true, Name: "Carol"},
+			"carol3", "Carol", true},
+		{"nothing usable", nil,
+			services.ExternalIdentity{Name: "李"},
+			"user", "user", false},
+		{"long name", nil,
+			services.ExternalIdentity{PreferredUsername: "dave", Name: strings.Repeat("d", 60)},
+			"dave", strings.Repeat("d", 50), false},
+		{"unverified email", nil,
+			services.ExternalIdentity{Email: "erin@example.com", Name: "Erin"},
+			"erin", "Erin", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newOIDCFixture()
+			for _, username := range tt.taken {
+				f.createUser(t, username, false)
+			}
+			ext := tt.ext
+			ext.Subject = "sub-new"
+
+			res, err := f.signIn(t, ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Created {
+				t.Fatal("no account created")
+			}
+			user, err := f.users.FindByUsername(ctx, tt.wantUsername)
+			if err != nil {
+				t.Fatalf("username: %v, want %s", err, tt.wantUsername)
+			}
+			if user.Name != tt.wantName || user.Email != ext.Email || (user.EmailVerifiedAt != nil) != tt.wantVerified {
+				t.Errorf("user name=%q email=%q verified=%t", user.Name, user.Email, user.EmailVerifiedAt != nil)
+			}
+			if user.PasswordHash == "" || user.PasswordHash == "hash" {
+				t.Errorf("password hash = %q, want a random password's", user.PasswordHash)
+			}
+			wantMail := ext.Email != "" && !ext.EmailVerified
+			if got := len(f.verifications.sent) == 1 && f.verifications.sent[0] == ext.Email; got != wantMail {
+				t.Errorf("verification mails = %v", f.verifications.sent)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackRequiresStartingBrowser(t *testing.T) {
+	for _, nonce := range []string{"", "another-browser"} {
+		t.Run("nonce "+nonce, func(t *testing.T) {
+			ctx := context.Background()
+			f := newOIDCFixture()
+			f.provider.identity = services.ExternalIdentity{Subject: "sub-alice"}
+			start, err := f.uc.StartLogin(ctx, "idp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := callbackFor(t, start)
+			req.BrowserNonce = nonce
+			if _, err := f.uc.Callback(ctx, req, dto.ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+				t.Fatalf("error = %v, want %v", err, ErrInvalidOIDCState)
+			}
+			if len(f.logins.users) != 0 {
+				t.Error("foreign browser signed in")
+			}
+			// The attempt is spent, even for the browser that started it
+			if _, err := f.uc.Callback(ctx, callbackFor(t, start), dto.ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+				t.Errorf("state after a refused callback: %v, want %v", err, ErrInvalidOIDCState)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	f := newOIDCFixture()
+	f.provider.identity = services.ExternalIdentity{Subject: "sub-alice"}
+	start, err := f.uc.StartLogin(ctx, "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start.BrowserNonce == "" || strings.Contains(start.AuthorizationURL, start.BrowserNonce) {
+		t.Fatalf("browser nonce %q must be set and stay out of the provider URL", start.BrowserNonce)
+	}
+	if _, err := f.uc.Callback(ctx, callbackFor(t, start), dto.ClientInfo{}); err != nil {
+		t.Fatalf("starting browser: %v", err)
+	}
+	if _, err := f.uc.Callback(ctx, callbackFor(t, start), dto.ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed callback: %v, want %v", err, ErrInvalidOIDCState)
+	}
+}
+
+func TestOIDCLink(t *testing.T) {
+	ctx := context.Background()
+	f := newOIDCFixture()
+	alice := f.createUser(t, "alice", true)
+	bob := f.createUser(t, "bob", true)
+	account := services.ExternalIdentity{Subject: "sub-alice", Email: "someone@example.com"}
+
+	res, err := f.link(t, alice.ID, account)
+	if err != nil || !res.Linked || res.AuthResponse != nil {
+		t.Fatalf("link = %+v, %v; want linked without a login", res, err)
+	}
+	if identity, err := f.identities.FindByProviderSubject(ctx, "idp", "sub-alice"); err != nil || identity.UserID != alice.ID {
+		t.Fatalf("identity after link: %v", err)
+	}
+	if _, err := f.link(t, alice.ID, account); err != nil {
+		t.Errorf("linking again: %v", err)
+	}
+	if _, err := f.link(t, bob.ID, account); !errors.Is(err, ErrIdentityAlreadyLinked) {
+		t.Errorf("bob linking alice's account: %v, want %v", err, ErrIdentityAlreadyLinked)
+	}
+	if _, err := f.link(t, alice.ID, services.ExternalIdentity{Subject: "sub-second"}); !errors.Is(err, ErrProviderAlreadyLinked) {
+		t.Errorf("second account of the provider: %v, want %v", err, ErrProviderAlreadyLinked)
+	}
+	if len(f.logins.users) != 0 {
+		t.Errorf("linking logged in %v", f.logins.users)
+	}
+}
@@ -0,0 +1,183 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PasswordUseCase interface {
+	// ChangePassword sets a new password and ends every session but sessionID,
+	// the one making the request
+	ChangePassword(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.ChangePasswordRequest) error
+	// ForgotPassword mails a reset link when the address belongs to an active account;
+	// it reports success either way so the endpoint cannot be used to probe emails
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
+}
+
+var (
+	ErrIncorrectPassword = fmtError("current password is incorrect")
+	ErrInvalidResetToken = fmtError("invalid or expired reset token")
+)
+
+// SessionRevoker ends the sessions of a user (implemented by AuthUseCase)
+type SessionRevoker interface {
+	LogoutAll(ctx context.Context, userID primitive.ObjectID) error
+	LogoutOthers(ctx context.Context, userID, keepSessionID primitive.ObjectID) error
+}
+
+type passwordUseCase struct {
+	userRepo        repositories.UserRepository
+	tokenRepo       repositories.OneTimeTokenRepository
+	passwordService services.PasswordService
+	mailer          services.Mailer
+	sessions        SessionRevoker
+	baseURL         string
+	resetTTL        time.Duration
+}
+
+func NewPasswordUseCase(
+	userRepo repositories.UserRepository,
+	tokenRepo repositories.OneTimeTokenRepository,
+	passwordService services.PasswordService,
+	mailer services.Mailer,
+	sessions SessionRevoker,
+	baseURL string,
+	resetTTL time.Duration,
+) PasswordUseCase {
+	return &passwordUseCase{
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		passwordService: passwordService,
+		mailer:          mailer,
+		sessions:        sessions,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		resetTTL:        resetTTL,
+	}
+}
+
+func (uc *passwordUseCase) ChangePassword(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.ChangePasswordRequest) error {
+	log.Printf("[PASSWORD][CHANGE][START] user=%s", userID.Hex())
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		log.Printf("[PASSWORD][CHANGE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return err
+	}
+	if err := uc.passwordService.VerifyPassword(user.PasswordHash, req.CurrentPassword); err != nil {
+		log.Printf("[PASSWORD][CHANGE][ERROR] user=%s incorrect current password", userID.Hex())
+		return ErrIncorrectPassword
+	}
+
+	hashed, err := uc.passwordService.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.userRepo.UpdatePassword(ctx, userID, hashed); err != nil {
+		log.Printf("[PASSWORD][CHANGE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return err
+	}
+	// Whoever knew the old password may still hold a session on another device
+	if err := uc.sessions.LogoutOthers(ctx, userID, sessionID); err != nil {
+		log.Printf("[PASSWORD][CHANGE][ERROR] user=%s sessions not revoked err=%v", userID.Hex(), err)
+		return err
+	}
+
+	log.Printf("[PASSWORD][CHANGE][DONE] user=%s", userID.Hex())
+	return nil
+}
+
+func (uc *passwordUseCase) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) error {
+	user, err := uc.userRepo.FindByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil || !user.IsActive {
+		log.Printf("[PASSWORD][FORGOT][SKIP] no active account for the address")
+		return nil
+	}
+	log.Printf("[PASSWORD][FORGOT][START] user=%s", user.ID.Hex())
+
+	token, hash, err := services.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	// Only the newest link stays valid
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID, entities.OneTimeTokenPasswordReset); err != nil {
+		return err
+	}
+	record := entities.NewOneTimeToken(user.ID, entities.OneTimeTokenPasswordReset, hash, time.Now().Add(uc.resetTTL))
+	if err := uc.tokenRepo.Create(ctx, record); err != nil {
+		log.Printf("[PASSWORD][FORGOT][ERROR] user=%s err=%v", user.ID.Hex(), err)
+		return err
+	}
+
+	link := uc.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	msg := services.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your Whisper password",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset your Whisper password. Open the link below within %s to choose a new one:\n\n"+
+			"%s\n\n"+
+			"If you did not ask for this, you can ignore this email; your password stays the same.\n",
+			user.Name, uc.resetTTL, link),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		// Not surfaced: a failure only for existing accounts would reveal them
+		log.Printf("[PASSWORD][FORGOT][ERROR] user=%s send failed: %v", user.ID.Hex(), err)
+		return nil
+	}
+
+	log.Printf("[PASSWORD][FORGOT][DONE] user=%s", user.ID.Hex())
+	return nil
+}
+
+func (uc *passwordUseCase) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	record, err := uc.tokenRepo.FindByHash(ctx, entities.OneTimeTokenPasswordReset, services.HashOpaqueToken(req.Token))
+	if err != nil || !record.IsUsable(time.Now()) {
+		return ErrInvalidResetToken
+	}
+	log.Printf("[PASSWORD][RESET][START] user=%s", record.UserID.Hex())
+
+	// Redeem before changing anything so a link cannot be used twice concurrently
+	consumed, err := uc.tokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, record.UserID)
+	if err != nil || !user.IsActive {
+		return ErrInvalidResetToken
+	}
+	hashed, err := uc.passwordService.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.userRepo.UpdatePassword(ctx, user.ID, hashed); err != nil {
+		log.Printf("[PASSWORD][RESET][ERROR] user=%s err=%v", user.ID.Hex(), err)
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in
+	if err := uc.sessions.LogoutAll(ctx, user.ID); err != nil {
+		log.Printf("[PASSWORD][RESET][ERROR] user=%s revoke sessions: %v", user.ID.Hex(), err)
+		return err
+	}
+	if err := uc.tokenRepo.DeleteByUserID(ctx, user.ID, entities.OneTimeTokenPasswordReset); err != nil {
+		log.Printf("[PASSWORD][RESET][WARN] user=%s cleanup tokens: %v", user.ID.Hex(), err)
+	}
+
+	log.Printf("[PASSWORD][RESET][DONE] user=%s", user.ID.Hex())
+	return nil
+}
@@ -0,0 +1,216 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// outbox keeps sent messages so tests can follow the links in them
+type outbox struct {
+	sent []services.MailMessage
+	err  error
+}
+
+func (o *outbox) Send(ctx context.Context, msg services.MailMessage) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+var resetLink = regexp.MustCompile(`/reset-password\?token=(\S+)`)
+
+// resetToken returns the token from the last reset mail
+func (o *outbox) resetToken(t *testing.T) string {
+	t.Helper()
+	return o.linkToken(t, resetLink)
+}
+
+// linkToken returns the token of the link matching link in the last mail
+func (o *outbox) linkToken(t *testing.T, link *regexp.Regexp) string {
+	t.Helper()
+	if len(o.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	m := link.FindStringSubmatch(o.sent[len(o.sent)-1].Text)
+	if m == nil {
+		t.Fatalf("no link in %q", o.sent[len(o.sent)-1].Text)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+type passwordFixture struct {
+	*authFixture
+	mail *outbox
+	uc   PasswordUseCase
+}
+
+func newPasswordFixture(resetTTL time.Duration) *passwordFixture {
+	af := newAuthFixture()
+	f := &passwordFixture{authFixture: af, mail: &outbox{}}
+	f.uc = NewPasswordUseCase(af.users, memory.NewOneTimeTokenRepository(af.store), services.NewPasswordService(),
+		f.mail, af.uc, "https://whisper.example/", resetTTL)
+	return f
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	f := newPasswordFixture(time.Hour)
+	reg := f.register(t, "alice")
+	userID := userIDOf(t, reg)
+	sessions, err := f.sessions.FindActiveByUserID(ctx, userID)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("sessions = %d (%v), want 1", len(sessions), err)
+	}
+	current := sessions[0].ID
+	other, err := f.authFixture.uc.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"}, testClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = f.uc.ChangePassword(ctx, userID, current, &dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new-secret"})
+	if !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("wrong current password error = %v, want %v", err, ErrIncorrectPassword)
+	}
+	if sessions, _ := f.sessions.FindActiveByUserID(ctx, userID); len(sessions) != 2 {
+		t.Fatalf("active sessions after a failed change = %d, want 2", len(sessions))
+	}
+
+	if err := f.uc.ChangePassword(ctx, userID, current, &dto.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.authFixture.uc.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"}, testClient); err == nil {
+		t.Error("old password still accepted")
+	}
+
+	// The other device is logged out, including its refresh token, while the
+	// session that changed the password carries on
+	if _, err := f.authFixture.uc.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: other.RefreshToken}, testClient); err == nil {
+		t.Error("refresh token of the other session still works")
+	}
+	sessions, _ = f.sessions.FindActiveByUserID(ctx, userID)
+	if len(sessions) != 1 || sessions[0].ID != current {
+		t.Errorf("active sessions = %d, want only the current one", len(sessions))
+	}
+	if _, err := f.authFixture.uc.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: reg.RefreshToken}, testClient); err != nil {
+		t.Errorf("refresh of the current session: %v", err)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		mailErr  error
+		wantMail bool
+	}{
+		{"known address", " alice@example.com ", nil, true},
+		{"unknown address", "nobody@example.com", nil, false},
+		{"send fails", "alice@example.com", errors.New("relay down"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPasswordFixture(time.Hour)
+			f.register(t, "alice")
+			f.mail.err = tt.mailErr
+			// Every outcome looks the same to the caller so addresses cannot be probed
+			if err := f.uc.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: tt.email}); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if got := len(f.mail.sent) == 1; got != tt.wantMail {
+				t.Fatalf("mails sent = %d, want mail %t", len(f.mail.sent), tt.wantMail)
+			}
+			if tt.wantMail && f.mail.sent[0].To != "alice@example.com" {
+				t.Errorf("mail to %q", f.mail.sent[0].To)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	f := newPasswordFixture(time.Hour)
+	reg := f.register(t, "alice")
+	if err := f.uc.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	token := f.mail.resetToken(t)
+
+	if err := f.uc.ResetPassword(ctx, &dto.ResetPasswordRequest{Token: token, NewPassword: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.authFixture.uc.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "new-secret"}, testClient); err != nil {
+		t.Errorf("login with new password: %v", err)
+	}
+	if _, err := f.authFixture.uc.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"}, testClient); err == nil {
+		t.Error("old password still accepted")
+	}
+
+	// Sessions opened before the reset are gone, including their refresh tokens
+	if _, err := f.authFixture.uc.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: reg.RefreshToken}, testClient); err == nil {
+		t.Error("refresh token from before the reset still works")
+	}
+	if sessions, _ := f.sessions.FindActiveByUserID(ctx, userIDOf(t, reg)); len(sessions) != 1 {
+		t.Errorf("active sessions = %d, want only the one from the new login", len(sessions))
+	}
+
+	// The link works once
+	err := f.uc.ResetPassword(ctx, &dto.ResetPasswordRequest{Token: token, NewPassword: "third-secret"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("second reset error = %v, want %v", err, ErrInvalidResetToken)
+	}
+}
+
+func TestResetPasswordRejectsToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		token func(t *testing.T, f *passwordFixture) string
+	}{
+		{"expired", time.Millisecond, func(t *testing.T, f *passwordFixture) string {
+			token := f.mail.resetToken(t)
+			time.Sleep(5 * time.Millisecond)
+			return token
+		}},
+		{"superseded", time.Hour, func(t *testing.T, f *passwordFixture) string {
+			token := f.mail.resetToken(t)
+			if err := f.uc.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}},
+		{"unknown", time.Hour, func(t *testing.T, f *passwordFixture) string {
+			return "not-a-token"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newPasswordFixture(tt.ttl)
+			f.register(t, "alice")
+			if err := f.uc.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "alice@example.com"}); err != nil {
+				t.Fatal(err)
+			}
+			err := f.uc.ResetPassword(ctx, &dto.ResetPasswordRequest{Token: tt.token(t, f), NewPassword: "new-secret"})
+			if !errors.Is(err, ErrInvalidResetToken) {
+				t.Fatalf("error = %v, want %v", err, ErrInvalidResetToken)
+			}
+			if _, err := f.authFixture.uc.Login(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"}, testClient); err != nil {
+				t.Errorf("old password rejected after a failed reset: %v", err)
+			}
+		})
+	}
+}
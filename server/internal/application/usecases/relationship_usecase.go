@@ -3,49 +3,148 @@ package usecases
 import (
 	"context"
 	"crypto/rand"
+	"errors"
+	"fmt"
 	"log"
 	"math/big"
+	"net/url"
+	"strings"
 	"time"
 
 	"whisper-server/internal/application/dto"
 	"whisper-server/internal/domain/entities"
 	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type RelationshipUseCase interface {
+	// GenerateInvitationCode creates a new invite code and revokes the user's previous active one
 	GenerateInvitationCode(ctx context.Context, userID primitive.ObjectID, firstMeetingDate time.Time) (*dto.GenerateInviteCodeResponse, error)
+	ListInvites(ctx context.Context, userID primitive.ObjectID) (*dto.InviteCodeListResponse, error)
+	RevokeInvite(ctx context.Context, userID primitive.ObjectID, code string) error
+	// InviteQRCode renders the deep link of one of the user's active codes as a PNG
+	InviteQRCode(ctx context.Context, userID primitive.ObjectID, code string) ([]byte, error)
 	JoinWithInviteCode(ctx context.Context, userID primitive.ObjectID, code string) (*dto.RelationshipResponse, error)
 	GetCurrentRelationship(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error)
+	// DisconnectRelationship ends the relationship immediately without the partner's
+	// consent; only used when the account goes away
 	DisconnectRelationship(ctx context.Context, userID primitive.ObjectID) error
+	// RequestDisconnect asks to end the relationship; the partner confirms, or it is
+	// confirmed automatically after the configured time
+	RequestDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error)
+	ConfirmDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error)
+	// CancelDisconnect lets the requester undo during the cooling-off window
+	CancelDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error)
+	// AutoConfirmDisconnects ends relationships whose disconnect request went unanswered and returns how many
+	AutoConfirmDisconnects(ctx context.Context) (int, error)
+	// ListHistory returns the user's disconnected relationships
+	ListHistory(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipHistoryResponse, error)
+	// Reconnect asks to revive a past relationship; it becomes active again under the
+	// same ID once both partners have asked
+	Reconnect(ctx context.Context, userID, relationshipID primitive.ObjectID) (*dto.RelationshipResponse, error)
+	CancelReconnect(ctx context.Context, userID, relationshipID primitive.ObjectID) error
+}
+
+// relationship history errors
+var (
+	ErrRelationshipNotFound    = fmtError("relationship not found")
+	ErrRelationshipNotArchived = fmtError("relationship is not disconnected")
+	ErrAlreadyInRelationship   = fmtError("user already in an active relationship")
+	ErrPartnerInRelationship   = fmtError("partner already in an active relationship")
+	ErrNoReconnectRequest      = fmtError("no reconnect request to cancel")
+	ErrReconnectConflict       = fmtError("relationship changed while reconnecting, try again")
+)
+
+// reconnectAttempts bounds how often Reconnect rereads a relationship whose
+// reconnect request changed under it
+const reconnectAttempts = 3
+
+// invite code errors
+var (
+	ErrInviteNotFound = fmtError("invite code not found")
+	ErrInviteInactive = fmtError("invite code is no longer valid")
+)
+
+// IsDisconnectFlowError reports disconnect request transitions that are not allowed in the current state
+func IsDisconnectFlowError(err error) bool {
+	switch err {
+	case entities.ErrDisconnectAlreadyRequested, entities.ErrNoDisconnectRequest, entities.ErrNotDisconnectRequester,
+		entities.ErrCannotConfirmOwnRequest, entities.ErrUndoWindowClosed:
+		return true
+	}
+	return false
+}
+
+// InvitePolicy configures invite codes
+type InvitePolicy struct {
+	TTL         time.Duration
+	LinkBaseURL string // deep links are LinkBaseURL + "/invite/" + code
+}
+
+// DisconnectPolicy configures the two-party disconnect flow
+type DisconnectPolicy struct {
+	UndoWindow       time.Duration
+	AutoConfirmAfter time.Duration
 }
 
 type relationshipUseCase struct {
-	relRepo  domainRepos.RelationshipRepository
-	userRepo domainRepos.UserRepository
-	invRepo  domainRepos.InviteRepository
+	relRepo    domainRepos.RelationshipRepository
+	userRepo   domainRepos.UserRepository
+	invRepo    domainRepos.InviteRepository
+	uow        domainRepos.UnitOfWork
+	policy     VerificationPolicy
+	mailer     services.Mailer
+	invites    InvitePolicy
+	disconnect DisconnectPolicy
 }
 
-func NewRelationshipUseCase(relRepo domainRepos.RelationshipRepository, userRepo domainRepos.UserRepository, invRepo domainRepos.InviteRepository) RelationshipUseCase {
-	return &relationshipUseCase{relRepo: relRepo, userRepo: userRepo, invRepo: invRepo}
+func NewRelationshipUseCase(relRepo domainRepos.RelationshipRepository, userRepo domainRepos.UserRepository, invRepo domainRepos.InviteRepository, uow domainRepos.UnitOfWork, policy VerificationPolicy, mailer services.Mailer, invites InvitePolicy, disconnect DisconnectPolicy) RelationshipUseCase {
+	invites.LinkBaseURL = strings.TrimRight(invites.LinkBaseURL, "/")
+	return &relationshipUseCase{relRepo: relRepo, userRepo: userRepo, invRepo: invRepo, uow: uow, policy: policy, mailer: mailer, invites: invites, disconnect: disconnect}
+}
+
+// requireVerified applies the email verification policy for a feature to the user
+func (uc *relationshipUseCase) requireVerified(ctx context.Context, userID primitive.ObjectID, feature string) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return uc.policy.RequireVerified(user, feature)
 }
 
 func (uc *relationshipUseCase) GenerateInvitationCode(ctx context.Context, userID primitive.ObjectID, firstMeetingDate time.Time) (*dto.GenerateInviteCodeResponse, error) {
 	log.Printf("[REL][INVITE][START] user=%s", userID.Hex())
-	exp := time.Now().Add(7 * 24 * time.Hour)
+	if err := uc.requireVerified(ctx, userID, FeatureInviteCodes); err != nil {
+		log.Printf("[REL][INVITE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	// The client sends local midnight of the day they met; only that day is kept
+	firstMeetingDate = entities.DateOf(firstMeetingDate, firstMeetingDate.Location())
+	now := time.Now()
+	exp := now.Add(uc.invites.TTL)
 
-	// Try up to 5 times to generate a unique short code (8 chars)
+	// One active code per user: a new code replaces the previous one, in one
+	// unit of work so a failed create keeps the previous code
 	const maxAttempts = 5
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// Try up to 5 times to generate a unique short code (8 chars)
 		code, err := randomAlnum(8)
 		if err != nil {
 			log.Printf("[REL][INVITE][ERROR] user=%s gen_code err=%v", userID.Hex(), err)
 			return nil, err
 		}
 		inv := entities.NewInviteCode(code, userID, firstMeetingDate, &exp)
-		if err := uc.invRepo.Create(ctx, inv); err != nil {
+		err = uc.uow.Do(ctx, func(ctx context.Context) error {
+			if err := uc.invRepo.RevokeActiveByCreator(ctx, userID, now); err != nil {
+				return err
+			}
+			return uc.invRepo.Create(ctx, inv)
+		})
+		if err != nil {
+			// The code is taken, or a concurrent request stored the user's current code first
 			if mongo.IsDuplicateKeyError(err) {
 				log.Printf("[REL][INVITE][WARN] user=%s duplicate code=%s attempt=%d", userID.Hex(), code, attempt)
 				continue
@@ -54,11 +153,86 @@ func (uc *relationshipUseCase) GenerateInvitationCode(ctx context.Context, userI
 			return nil, err
 		}
 		log.Printf("[REL][INVITE][DONE] user=%s code=%s", userID.Hex(), code)
-		return &dto.GenerateInviteCodeResponse{InviteCode: code, ExpiresAt: &exp}, nil
+		return &dto.GenerateInviteCodeResponse{
+			InviteCode: code,
+			ExpiresAt:  &exp,
+			Link:       uc.inviteLink(code),
+			QRCodeURL:  "/api/v1/relationships/invites/" + code + "/qr.png",
+		}, nil
 	}
 	return nil, fmtError("failed to generate unique invite code")
 }
 
+func (uc *relationshipUseCase) inviteLink(code string) string {
+	return uc.invites.LinkBaseURL + "/invite/" + url.PathEscape(code)
+}
+
+func (uc *relationshipUseCase) ListInvites(ctx context.Context, userID primitive.ObjectID) (*dto.InviteCodeListResponse, error) {
+	invites, err := uc.invRepo.FindByCreator(ctx, userID)
+	if err != nil {
+		log.Printf("[REL][INVITE_LIST][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	now := time.Now()
+	res := &dto.InviteCodeListResponse{Invites: make([]*dto.InviteCodeResponse, 0, len(invites))}
+	for _, inv := range invites {
+		res.Invites = append(res.Invites, &dto.InviteCodeResponse{
+			Code:             inv.Code,
+			Status:           inv.Status(now),
+			Link:             uc.inviteLink(inv.Code),
+			FirstMeetingDate: inv.FirstMeetingDate,
+			ExpiresAt:        inv.ExpiresAt,
+			RevokedAt:        inv.RevokedAt,
+			CreatedAt:        inv.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (uc *relationshipUseCase) RevokeInvite(ctx context.Context, userID primitive.ObjectID, code string) error {
+	log.Printf("[REL][INVITE_REVOKE][START] user=%s code=%s", userID.Hex(), code)
+	inv, err := uc.findOwnInvite(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+	if !inv.IsActive(time.Now()) {
+		return ErrInviteInactive
+	}
+	if err := uc.invRepo.Revoke(ctx, inv.Code, userID, time.Now()); err != nil {
+		log.Printf("[REL][INVITE_REVOKE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return ErrInviteInactive
+	}
+	log.Printf("[REL][INVITE_REVOKE][DONE] user=%s code=%s", userID.Hex(), code)
+	return nil
+}
+
+func (uc *relationshipUseCase) InviteQRCode(ctx context.Context, userID primitive.ObjectID, code string) ([]byte, error) {
+	inv, err := uc.findOwnInvite(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !inv.IsActive(time.Now()) {
+		return nil, ErrInviteInactive
+	}
+	qr, err := services.EncodeQR(uc.inviteLink(inv.Code))
+	if err != nil {
+		return nil, err
+	}
+	return qr.PNG(inviteQRModuleSize)
+}
+
+// inviteQRModuleSize is the pixel size of one QR module, giving roughly 300-500px images
+const inviteQRModuleSize = 8
+
+// findOwnInvite loads one of the user's codes; codes of other users are reported as not found
+func (uc *relationshipUseCase) findOwnInvite(ctx context.Context, userID primitive.ObjectID, code string) (*entities.InviteCode, error) {
+	inv, err := uc.invRepo.FindByCode(ctx, code)
+	if err != nil || inv.CreatedBy != userID {
+		return nil, ErrInviteNotFound
+	}
+	return inv, nil
+}
+
 // randomAlnum generates a random uppercase alphanumeric string of given length
 func randomAlnum(n int) (string, error) {
 	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no confusing chars like 0,O,1,I
@@ -75,46 +249,79 @@ func randomAlnum(n int) (string, error) {
 
 func (uc *relationshipUseCase) JoinWithInviteCode(ctx context.Context, userID primitive.ObjectID, code string) (*dto.RelationshipResponse, error) {
 	log.Printf("[REL][JOIN][START] user=%s code=%s", userID.Hex(), code)
+	if err := uc.requireVerified(ctx, userID, FeatureJoinRelationship); err != nil {
+		log.Printf("[REL][JOIN][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
 	inv, err := uc.invRepo.FindByCode(ctx, code)
 	if err != nil {
 		log.Printf("[REL][JOIN][ERROR] find code=%s err=%v", code, err)
 		return nil, err
 	}
+	if !inv.IsActive(time.Now()) {
+		return nil, ErrInviteInactive
+	}
 	// Prevent joining with own invite code
 	if inv.CreatedBy == userID {
 		return nil, fmtError("cannot join your own invite code")
 	}
 	// Prevent users already in an active relationship from joining another
 	if _, err := uc.relRepo.FindCurrentByUserID(ctx, userID); err == nil {
-		return nil, fmtError("user already in an active relationship")
+		return nil, ErrAlreadyInRelationship
 	}
 	if _, err := uc.relRepo.FindCurrentByUserID(ctx, inv.CreatedBy); err == nil {
-		return nil, fmtError("inviter already in an active relationship")
-	}
-	rel := &entities.Relationship{
-		Partners: []entities.RelationshipPartner{
-			{UserID: inv.CreatedBy, JoinedAt: time.Now()},
-			{UserID: userID, JoinedAt: time.Now()},
-		},
-		Status:     entities.RelationshipStatusActive,
-		InviteCode: inv.Code,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		return nil, ErrPartnerInRelationship
 	}
-	if err := uc.relRepo.CreateWithDetails(ctx, rel, []primitive.ObjectID{inv.CreatedBy, userID}, inv.FirstMeetingDate); err != nil {
-		log.Printf("[REL][JOIN][ERROR] create rel err=%v", err)
+	var rel *entities.Relationship
+	// Redeeming the code, creating the relationship and pointing both users at it
+	// succeed or fail together; the function may run again on transient errors
+	err = uc.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := uc.invRepo.Redeem(ctx, inv.Code, userID, time.Now()); err != nil {
+			if errors.Is(err, domainRepos.ErrInviteUnavailable) {
+				return ErrInviteInactive
+			}
+			return err
+		}
+		rel = entities.NewRelationship([]primitive.ObjectID{inv.CreatedBy, userID}, inv.Code, inv.FirstMeetingDate)
+		if err := uc.relRepo.Create(ctx, rel); err != nil {
+			return err
+		}
+		return uc.attachPartners(ctx, rel, userID)
+	})
+	if err != nil {
+		log.Printf("[REL][JOIN][ERROR] user=%s code=%s err=%v", userID.Hex(), code, err)
 		return nil, err
 	}
-	_ = uc.invRepo.MarkUsed(ctx, inv)
-	// Update users to reference relationship
+	log.Printf("[REL][JOIN][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
+	return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+}
+
+// attachPartners points both partners at rel. Each pointer moves with a
+// compare-and-set from the value just read, so a user who concurrently entered
+// another relationship makes the surrounding unit of work fail. actorID is the
+// user driving the change; the error tells them which side is taken.
+func (uc *relationshipUseCase) attachPartners(ctx context.Context, rel *entities.Relationship, actorID primitive.ObjectID) error {
+	days := rel.DaysTogether(time.Now())
 	for _, p := range rel.Partners {
-		if u, err := uc.userRepo.FindByID(ctx, p.UserID); err == nil {
-			u.SetRelationship(rel.ID, calculateRelationshipDays(rel))
-			_ = uc.userRepo.Update(ctx, u)
+		taken := ErrPartnerInRelationship
+		if p.UserID == actorID {
+			taken = ErrAlreadyInRelationship
+		}
+		if cur, err := uc.relRepo.FindCurrentByUserID(ctx, p.UserID); err == nil && cur.ID != rel.ID {
+			return taken
+		}
+		u, err := uc.userRepo.FindByID(ctx, p.UserID)
+		if err != nil {
+			return err
+		}
+		if err := uc.userRepo.SetRelationship(ctx, p.UserID, u.RelationshipID, rel.ID, days); err != nil {
+			if errors.Is(err, domainRepos.ErrRelationshipChanged) {
+				return taken
+			}
+			return err
 		}
 	}
-	log.Printf("[REL][JOIN][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
-	return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+	return nil
 }
 
 func (uc *relationshipUseCase) GetCurrentRelationship(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error) {
@@ -136,52 +343,344 @@ func (uc *relationshipUseCase) DisconnectRelationship(ctx context.Context, userI
 		return err
 	}
 	rel.Disconnect()
-	if err := uc.relRepo.Update(ctx, rel); err != nil {
+	if rel.DisconnectRequest != nil && rel.DisconnectRequest.State == entities.DisconnectRequested {
+		rel.DisconnectRequest.State = entities.DisconnectConfirmed
+		rel.DisconnectRequest.ResolvedAt = rel.DisconnectedAt
+	}
+	// Ending it right away wins over whatever the disconnect request went through meanwhile
+	if err := uc.finishDisconnect(ctx, rel, func(ctx context.Context) error { return uc.relRepo.Update(ctx, rel) }); err != nil {
 		log.Printf("[REL][DISCONNECT][ERROR] update err=%v", err)
 		return err
 	}
-	// Optionally clear user pointers
+	log.Printf("[REL][DISCONNECT][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
+	return nil
+}
+
+// finishDisconnect stores a relationship that was just disconnected with store
+// and clears the partners' pointers to it
+func (uc *relationshipUseCase) finishDisconnect(ctx context.Context, rel *entities.Relationship, store func(ctx context.Context) error) error {
+	return uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := store(ctx); err != nil {
+			return err
+		}
+		for _, p := range rel.Partners {
+			if err := uc.userRepo.ClearRelationship(ctx, p.UserID, rel.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateDisconnectRequest stores rel provided the disconnect request it was
+// loaded with is unchanged: pending from from, or none when from is nil. A
+// concurrent request, answer or cancellation makes it a disconnect flow error.
+func (uc *relationshipUseCase) updateDisconnectRequest(ctx context.Context, rel *entities.Relationship, from *primitive.ObjectID) error {
+	err := uc.relRepo.UpdateDisconnectRequest(ctx, rel, from)
+	if !errors.Is(err, domainRepos.ErrRelationshipStateChanged) {
+		return err
+	}
+	if from == nil {
+		return entities.ErrDisconnectAlreadyRequested
+	}
+	return entities.ErrNoDisconnectRequest
+}
+
+func (uc *relationshipUseCase) RequestDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error) {
+	log.Printf("[REL][DISCONNECT_REQUEST][START] user=%s", userID.Hex())
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[REL][DISCONNECT_REQUEST][ERROR] find current err=%v", err)
+		return nil, err
+	}
+	if err := rel.RequestDisconnect(userID, uc.disconnect.UndoWindow, uc.disconnect.AutoConfirmAfter); err != nil {
+		return nil, err
+	}
+	if err := uc.updateDisconnectRequest(ctx, rel, nil); err != nil {
+		log.Printf("[REL][DISCONNECT_REQUEST][ERROR] update err=%v", err)
+		return nil, err
+	}
+	req := rel.DisconnectRequest
+	uc.notifyPartner(ctx, rel, userID, "wants to end your relationship on Whisper",
+		fmt.Sprintf("%%s asked to end your relationship on Whisper.\n\n"+
+			"Open the app to confirm. If you do nothing, the relationship ends on %s.\n"+
+			"Your shared memories stay readable in your relationship history.\n",
+			req.AutoConfirmAt.UTC().Format("January 2, 2006")))
+	log.Printf("[REL][DISCONNECT_REQUEST][DONE] user=%s rel=%s autoConfirmAt=%s", userID.Hex(), rel.ID.Hex(), req.AutoConfirmAt.Format(time.RFC3339))
+	return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+}
+
+func (uc *relationshipUseCase) ConfirmDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error) {
+	log.Printf("[REL][DISCONNECT_CONFIRM][START] user=%s", userID.Hex())
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[REL][DISCONNECT_CONFIRM][ERROR] find current err=%v", err)
+		return nil, err
+	}
+	if err := rel.ConfirmDisconnect(userID, time.Now()); err != nil {
+		return nil, err
+	}
+	requester := rel.DisconnectRequest.RequestedBy
+	err = uc.finishDisconnect(ctx, rel, func(ctx context.Context) error {
+		return uc.updateDisconnectRequest(ctx, rel, &requester)
+	})
+	if err != nil {
+		log.Printf("[REL][DISCONNECT_CONFIRM][ERROR] update err=%v", err)
+		return nil, err
+	}
+	uc.notifyPartner(ctx, rel, userID, "confirmed the end of your relationship",
+		"%s confirmed your request to end your relationship on Whisper.\n"+
+			"Your shared memories stay readable in your relationship history.\n")
+	log.Printf("[REL][DISCONNECT_CONFIRM][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
+	return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+}
+
+func (uc *relationshipUseCase) CancelDisconnect(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipResponse, error) {
+	log.Printf("[REL][DISCONNECT_CANCEL][START] user=%s", userID.Hex())
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[REL][DISCONNECT_CANCEL][ERROR] find current err=%v", err)
+		return nil, err
+	}
+	if err := rel.CancelDisconnect(userID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := uc.updateDisconnectRequest(ctx, rel, &userID); err != nil {
+		log.Printf("[REL][DISCONNECT_CANCEL][ERROR] update err=%v", err)
+		return nil, err
+	}
+	uc.notifyPartner(ctx, rel, userID, "took back the request to end your relationship",
+		"%s cancelled the request to end your relationship on Whisper. Nothing changes.\n")
+	log.Printf("[REL][DISCONNECT_CANCEL][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
+	return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+}
+
+func (uc *relationshipUseCase) AutoConfirmDisconnects(ctx context.Context) (int, error) {
+	rels, err := uc.relRepo.FindDisconnectsDue(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	confirmed := 0
+	for _, rel := range rels {
+		if !rel.AutoConfirmDisconnect(time.Now()) {
+			continue
+		}
+		// The partner may have answered, or the requester cancelled, since the list was read
+		requester := rel.DisconnectRequest.RequestedBy
+		err := uc.finishDisconnect(ctx, rel, func(ctx context.Context) error {
+			return uc.updateDisconnectRequest(ctx, rel, &requester)
+		})
+		if IsDisconnectFlowError(err) {
+			log.Printf("[REL][DISCONNECT_AUTO][SKIP] rel=%s request resolved meanwhile", rel.ID.Hex())
+			continue
+		}
+		if err != nil {
+			log.Printf("[REL][DISCONNECT_AUTO][ERROR] rel=%s err=%v", rel.ID.Hex(), err)
+			continue
+		}
+		// Tell the requester their request went through, and the partner who did
+		// not answer that the relationship has ended
+		for _, p := range rel.Partners {
+			if p.UserID != requester {
+				uc.notifyPartner(ctx, rel, p.UserID, "no longer shares a relationship with you",
+					"Your request to end your relationship with %s on Whisper was not answered and has taken effect.\n"+
+						"Your shared memories stay readable in your relationship history.\n")
+			}
+		}
+		uc.notifyPartner(ctx, rel, requester, "ended your relationship on Whisper",
+			"%s asked to end your relationship on Whisper and the request was not answered in time, so it has now ended.\n"+
+				"Your shared memories stay readable in your relationship history.\n")
+		log.Printf("[REL][DISCONNECT_AUTO][DONE] rel=%s", rel.ID.Hex())
+		confirmed++
+	}
+	return confirmed, nil
+}
+
+// notifyPartner mails the other partner(s) of actorID about a disconnect step. The
+// subject is prefixed and %s in text replaced with the actor's name; failures are
+// only logged.
+func (uc *relationshipUseCase) notifyPartner(ctx context.Context, rel *entities.Relationship, actorID primitive.ObjectID, subject, text string) {
+	actorName := "Your partner"
+	if actor, err := uc.userRepo.FindByID(ctx, actorID); err == nil {
+		actorName = actor.Name
+	}
 	for _, p := range rel.Partners {
-		if u, err := uc.userRepo.FindByID(ctx, p.UserID); err == nil {
-			u.RelationshipID = nil
-			u.Stats.RelationshipDays = 0
-			_ = uc.userRepo.Update(ctx, u)
+		if p.UserID == actorID {
+			continue
+		}
+		partner, err := uc.userRepo.FindByID(ctx, p.UserID)
+		if err != nil || partner.Email == "" {
+			continue
+		}
+		msg := services.MailMessage{
+			To:      partner.Email,
+			Subject: actorName + " " + subject,
+			Text:    fmt.Sprintf("Hi %s,\n\n", partner.Name) + fmt.Sprintf(text, actorName),
+		}
+		if err := uc.mailer.Send(ctx, msg); err != nil {
+			log.Printf("[REL][NOTIFY][WARN] rel=%s user=%s send failed: %v", rel.ID.Hex(), p.UserID.Hex(), err)
 		}
 	}
-	log.Printf("[REL][DISCONNECT][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
+}
+
+func (uc *relationshipUseCase) ListHistory(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipHistoryResponse, error) {
+	log.Printf("[REL][HISTORY][START] user=%s", userID.Hex())
+	rels, err := uc.relRepo.FindPastByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[REL][HISTORY][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	res := &dto.RelationshipHistoryResponse{Relationships: make([]*dto.RelationshipResponse, 0, len(rels))}
+	for _, rel := range rels {
+		res.Relationships = append(res.Relationships, toRelationshipResponseWithUsers(rel, uc.userRepo, ctx))
+	}
+	log.Printf("[REL][HISTORY][DONE] user=%s count=%d", userID.Hex(), len(rels))
+	return res, nil
+}
+
+func (uc *relationshipUseCase) Reconnect(ctx context.Context, userID, relationshipID primitive.ObjectID) (*dto.RelationshipResponse, error) {
+	log.Printf("[REL][RECONNECT][START] user=%s rel=%s", userID.Hex(), relationshipID.Hex())
+	// Partners asking at the same moment both see no request; the one whose
+	// write loses rereads, finds the other's request and reactivates
+	for attempt := 1; ; attempt++ {
+		rel, reactivated, err := uc.reconnect(ctx, userID, relationshipID)
+		if errors.Is(err, domainRepos.ErrRelationshipStateChanged) {
+			if attempt < reconnectAttempts {
+				continue
+			}
+			err = ErrReconnectConflict
+		}
+		if err != nil {
+			log.Printf("[REL][RECONNECT][ERROR] user=%s rel=%s err=%v", userID.Hex(), relationshipID.Hex(), err)
+			return nil, err
+		}
+		log.Printf("[REL][RECONNECT][DONE] user=%s rel=%s reactivated=%t", userID.Hex(), rel.ID.Hex(), reactivated)
+		return toRelationshipResponseWithUsers(rel, uc.userRepo, ctx), nil
+	}
+}
+
+// reconnect records the user's reconnect request, or reactivates the
+// relationship when the other partner's request is the one stored
+func (uc *relationshipUseCase) reconnect(ctx context.Context, userID, relationshipID primitive.ObjectID) (*entities.Relationship, bool, error) {
+	rel, err := findMemberRelationship(ctx, uc.relRepo, userID, relationshipID)
+	if err != nil {
+		return nil, false, err
+	}
+	if rel.Status != entities.RelationshipStatusDisconnected {
+		return nil, false, ErrRelationshipNotArchived
+	}
+	// Neither side may have moved on to someone else in the meantime
+	for _, p := range rel.Partners {
+		if _, err := uc.relRepo.FindCurrentByUserID(ctx, p.UserID); err == nil {
+			if p.UserID == userID {
+				return nil, false, ErrAlreadyInRelationship
+			}
+			return nil, false, ErrPartnerInRelationship
+		}
+	}
+
+	var from *primitive.ObjectID
+	if rel.ReconnectRequestedBy != nil {
+		by := *rel.ReconnectRequestedBy
+		from = &by
+	}
+	reactivated := rel.RequestReconnect(userID)
+	err = uc.uow.Do(ctx, func(ctx context.Context) error {
+		if err := uc.relRepo.UpdateReconnectRequest(ctx, rel, from); err != nil {
+			return err
+		}
+		if !reactivated {
+			return nil
+		}
+		return uc.attachPartners(ctx, rel, userID)
+	})
+	return rel, reactivated, err
+}
+
+func (uc *relationshipUseCase) CancelReconnect(ctx context.Context, userID, relationshipID primitive.ObjectID) error {
+	log.Printf("[REL][RECONNECT_CANCEL][START] user=%s rel=%s", userID.Hex(), relationshipID.Hex())
+	rel, err := findMemberRelationship(ctx, uc.relRepo, userID, relationshipID)
+	if err != nil {
+		return err
+	}
+	if rel.Status != entities.RelationshipStatusDisconnected || !rel.CancelReconnect(userID) {
+		return ErrNoReconnectRequest
+	}
+	// The partner may have taken up the request meanwhile
+	err = uc.relRepo.UpdateReconnectRequest(ctx, rel, &userID)
+	if errors.Is(err, domainRepos.ErrRelationshipStateChanged) {
+		return ErrNoReconnectRequest
+	}
+	if err != nil {
+		log.Printf("[REL][RECONNECT_CANCEL][ERROR] update err=%v", err)
+		return err
+	}
+	log.Printf("[REL][RECONNECT_CANCEL][DONE] user=%s rel=%s", userID.Hex(), rel.ID.Hex())
 	return nil
 }
 
+// findMemberRelationship loads a relationship of any status the user belongs to;
+// other users' relationships are reported as not found
+func findMemberRelationship(ctx context.Context, relRepo domainRepos.RelationshipRepository, userID, relationshipID primitive.ObjectID) (*entities.Relationship, error) {
+	rel, err := relRepo.FindByID(ctx, relationshipID)
+	if err != nil || !rel.HasPartner(userID) {
+		return nil, ErrRelationshipNotFound
+	}
+	return rel, nil
+}
+
 func toRelationshipResponseWithUsers(rel *entities.Relationship, userRepo domainRepos.UserRepository, ctx context.Context) *dto.RelationshipResponse {
 	partners := make([]dto.RelationshipPartner, 0, len(rel.Partners))
+	// Anniversaries turn at midnight where the couple lives, taken from the first
+	// partner with a timezone set
+	tz := ""
 	for _, p := range rel.Partners {
 		rp := dto.RelationshipPartner{UserID: p.UserID.Hex(), JoinedAt: p.JoinedAt}
 		if user, err := userRepo.FindByID(ctx, p.UserID); err == nil && user != nil {
 			rp.Username = user.Username
 			rp.Name = user.Name
+			if tz == "" {
+				tz = user.Settings.Timezone
+			}
 		}
 		partners = append(partners, rp)
 	}
-	return &dto.RelationshipResponse{
-		ID:         rel.ID.Hex(),
-		Status:     string(rel.Status),
-		Partners:   partners,
-		InviteCode: rel.InviteCode,
-		CreatedAt:  rel.CreatedAt,
-		UpdatedAt:  rel.UpdatedAt,
+	res := &dto.RelationshipResponse{
+		ID:             rel.ID.Hex(),
+		Status:         string(rel.Status),
+		Partners:       partners,
+		InviteCode:     rel.InviteCode,
+		DisconnectedAt: rel.DisconnectedAt,
+		CreatedAt:      rel.CreatedAt,
+		UpdatedAt:      rel.UpdatedAt,
 	}
-}
-
-func calculateRelationshipDays(rel *entities.Relationship) int {
-	if len(rel.Partners) == 0 {
-		return 0
+	if !rel.FirstMeetingDate.IsZero() {
+		res.FirstMeetingDate = &rel.FirstMeetingDate
 	}
-	// min joinedAt among partners
-	earliest := rel.Partners[0].JoinedAt
-	for _, p := range rel.Partners {
-		if p.JoinedAt.Before(earliest) {
-			earliest = p.JoinedAt
+	// A past relationship counts up to the day it ended
+	until := time.Now()
+	if rel.DisconnectedAt != nil && !rel.Status.IsCurrent() {
+		until = *rel.DisconnectedAt
+	}
+	res.RelationshipDays = rel.DaysTogether(until)
+	loc := entities.LoadLocation(tz)
+	res.YearsTogether = rel.YearsTogether(until, loc)
+	if rel.Status.IsCurrent() {
+		next := rel.NextAnniversary(until, loc)
+		res.NextAnniversary = &next
+	}
+	if rel.ReconnectRequestedBy != nil {
+		res.ReconnectRequestedBy = rel.ReconnectRequestedBy.Hex()
+	}
+	if req := rel.DisconnectRequest; req != nil {
+		res.DisconnectRequest = &dto.DisconnectRequestResponse{
+			RequestedBy:   req.RequestedBy.Hex(),
+			RequestedAt:   req.RequestedAt,
+			UndoUntil:     req.UndoUntil,
+			AutoConfirmAt: req.AutoConfirmAt,
+			State:         req.State,
+			ResolvedAt:    req.ResolvedAt,
 		}
 	}
-	return int(time.Since(earliest).Hours() / 24)
+	return res
 }
@@ -0,0 +1,829 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+type relationshipFixture struct {
+	store *memory.Store
+	users repositories.UserRepository
+	rels  repositories.RelationshipRepository
+	invs  repositories.InviteRepository
+	uc    RelationshipUseCase
+}
+
+func newRelationshipFixture(wrapUsers func(repositories.UserRepository) repositories.UserRepository) *relationshipFixture {
+	store := memory.NewStore()
+	f := &relationshipFixture{
+		store: store,
+		users: memory.NewUserRepository(store),
+		rels:  memory.NewRelationshipRepository(store),
+		invs:  memory.NewInviteRepository(store),
+	}
+	users := f.users
+	if wrapUsers != nil {
+		users = wrapUsers(users)
+	}
+	f.uc = NewRelationshipUseCase(f.rels, users, f.invs, memory.NewUnitOfWork(store), NewEmailVerificationPolicy(nil),
+		services.NewLogMailer(), InvitePolicy{TTL: time.Hour}, DisconnectPolicy{UndoWindow: time.Hour, AutoConfirmAfter: 24 * time.Hour})
+	return f
+}
+
+func (f *relationshipFixture) createUser(t *testing.T, username string) primitive.ObjectID {
+	t.Helper()
+	u := entities.NewUser(username, username, username+"@example.com", "hash")
+	if err := f.users.Create(context.Background(), u); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return u.ID
+}
+
+func (f *relationshipFixture) invite(t *testing.T, inviter primitive.ObjectID) string {
+	t.Helper()
+	res, err := f.uc.GenerateInvitationCode(context.Background(), inviter, time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatalf("generate invite: %v", err)
+	}
+	return res.InviteCode
+}
+
+// couple creates two users joined in a relationship and returns them with its ID
+func (f *relationshipFixture) couple(t *testing.T, inviterName, joinerName string) (inviter, joiner, relID primitive.ObjectID) {
+	t.Helper()
+	inviter = f.createUser(t, inviterName)
+	joiner = f.createUser(t, joinerName)
+	res, err := f.uc.JoinWithInviteCode(context.Background(), joiner, f.invite(t, inviter))
+	if err != nil {
+		t.Fatalf("join %s and %s: %v", inviterName, joinerName, err)
+	}
+	relID, err = primitive.ObjectIDFromHex(res.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inviter, joiner, relID
+}
+
+// testPeople has alice and bob in one relationship, carol and dave in another
+// and erin is single
+type testPeople struct {
+	alice, bob, carol, erin primitive.ObjectID
+	relID                   primitive.ObjectID // alice and bob's relationship
+}
+
+func (f *relationshipFixture) addPeople(t *testing.T) testPeople {
+	t.Helper()
+	var p testPeople
+	p.alice, p.bob, p.relID = f.couple(t, "alice", "bob")
+	p.carol, _, _ = f.couple(t, "carol", "dave")
+	p.erin = f.createUser(t, "erin")
+	return p
+}
+
+func (f *relationshipFixture) relationshipID(t *testing.T, userID primitive.ObjectID) *primitive.ObjectID {
+	t.Helper()
+	u, err := f.users.FindByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	return u.RelationshipID
+}
+
+func TestJoinWithInviteCodeConcurrentJoinersOneWins(t *testing.T) {
+	const joiners = 32
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	inviter := f.createUser(t, "inviter")
+	code := f.invite(t, inviter)
+
+	ids := make([]primitive.ObjectID, joiners)
+	for i := range ids {
+		ids[i] = f.createUser(t, fmt.Sprintf("joiner%d", i))
+	}
+
+	errs := make([]error, joiners)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = f.uc.JoinWithInviteCode(ctx, ids[i], code)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner >= 0 {
+				t.Fatalf("joiners %d and %d both joined", winner, i)
+			}
+			winner = i
+		case errors.Is(err, ErrInviteInactive), errors.Is(err, ErrPartnerInRelationship):
+		default:
+			t.Errorf("joiner %d: unexpected error %v", i, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("no joiner succeeded")
+	}
+
+	rels, err := f.rels.FindAllByUserID(ctx, inviter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 1 {
+		t.Fatalf("inviter has %d relationships, want 1", len(rels))
+	}
+	rel := rels[0]
+	if !rel.HasPartner(ids[winner]) {
+		t.Errorf("relationship partners %v do not include the winner", rel.Partners)
+	}
+
+	inv, err := f.invs.FindByCode(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inv.IsUsed || inv.UsedBy == nil || *inv.UsedBy != ids[winner] {
+		t.Errorf("invite used=%t usedBy=%v, want used by the winner", inv.IsUsed, inv.UsedBy)
+	}
+
+	for _, id := range []primitive.ObjectID{inviter, ids[winner]} {
+		if got := f.relationshipID(t, id); got == nil || *got != rel.ID {
+			t.Errorf("user %s relationshipId = %v, want %s", id.Hex(), got, rel.ID.Hex())
+		}
+	}
+	for i, id := range ids {
+		if i == winner {
+			continue
+		}
+		if got := f.relationshipID(t, id); got != nil {
+			t.Errorf("losing joiner %d has relationshipId %s", i, got.Hex())
+		}
+	}
+}
+
+// failingPointerRepo refuses to move one user's relationship pointer, as if
+// they had joined someone else in the meantime
+type failingPointerRepo struct {
+	repositories.UserRepository
+	userID primitive.ObjectID
+}
+
+func (r *failingPointerRepo) SetRelationship(ctx context.Context, userID primitive.ObjectID, from *primitive.ObjectID, to primitive.ObjectID, relationshipDays int) error {
+	if userID == r.userID {
+		return repositories.ErrRelationshipChanged
+	}
+	return r.UserRepository.SetRelationship(ctx, userID, from, to, relationshipDays)
+}
+
+func TestJoinWithInviteCodeRollsBackOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		failUser func(inviter, joiner primitive.ObjectID) primitive.ObjectID
+		wantErr  error
+	}{
+		{"joiner taken", func(_, joiner primitive.ObjectID) primitive.ObjectID { return joiner }, ErrAlreadyInRelationship},
+		{"inviter taken", func(inviter, _ primitive.ObjectID) primitive.ObjectID { return inviter }, ErrPartnerInRelationship},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			failing := &failingPointerRepo{}
+			f := newRelationshipFixture(func(users repositories.UserRepository) repositories.UserRepository {
+				failing.UserRepository = users
+				return failing
+			})
+			inviter := f.createUser(t, "inviter")
+			joiner := f.createUser(t, "joiner")
+			code := f.invite(t, inviter)
+			failing.userID = tt.failUser(inviter, joiner)
+
+			if _, err := f.uc.JoinWithInviteCode(ctx, joiner, code); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("join error = %v, want %v", err, tt.wantErr)
+			}
+
+			inv, err := f.invs.FindByCode(ctx, code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !inv.IsActive(time.Now()) {
+				t.Errorf("invite status = %s after failed join, want active", inv.Status(time.Now()))
+			}
+			if rels, _ := f.rels.FindAllByUserID(ctx, inviter); len(rels) != 0 {
+				t.Errorf("inviter has %d relationships after failed join, want 0", len(rels))
+			}
+			for _, id := range []primitive.ObjectID{inviter, joiner} {
+				if got := f.relationshipID(t, id); got != nil {
+					t.Errorf("user %s kept relationshipId %s", id.Hex(), got.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestJoinWithInviteCode(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup returns the joiner and the code they use
+		setup   func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string)
+		wantErr string
+	}{
+		{"valid code", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			return f.createUser(t, "joiner"), f.invite(t, f.createUser(t, "inviter"))
+		}, ""},
+		{"unknown code", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			return f.createUser(t, "joiner"), "NOSUCHCODE"
+		}, "invite code not found"},
+		{"own code", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			inviter := f.createUser(t, "inviter")
+			return inviter, f.invite(t, inviter)
+		}, "cannot join your own invite code"},
+		{"revoked code", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			inviter := f.createUser(t, "inviter")
+			code := f.invite(t, inviter)
+			if err := f.uc.RevokeInvite(context.Background(), inviter, code); err != nil {
+				t.Fatal(err)
+			}
+			return f.createUser(t, "joiner"), code
+		}, ErrInviteInactive.Error()},
+		{"joiner in a relationship", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			joiner, _, _ := f.couple(t, "joiner", "partner")
+			return joiner, f.invite(t, f.createUser(t, "inviter"))
+		}, ErrAlreadyInRelationship.Error()},
+		{"inviter in a relationship", func(t *testing.T, f *relationshipFixture) (primitive.ObjectID, string) {
+			inviter := f.createUser(t, "inviter")
+			code := f.invite(t, inviter)
+			if _, err := f.uc.JoinWithInviteCode(context.Background(), f.createUser(t, "first"), code); err != nil {
+				t.Fatal(err)
+			}
+			return f.createUser(t, "second"), f.invite(t, inviter)
+		}, ErrPartnerInRelationship.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRelationshipFixture(nil)
+			joiner, code := tt.setup(t, f)
+			before := f.relationshipID(t, joiner)
+			res, err := f.uc.JoinWithInviteCode(context.Background(), joiner, code)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if after := f.relationshipID(t, joiner); (before == nil) != (after == nil) || (before != nil && *before != *after) {
+					t.Errorf("failed join moved the joiner from %v to %v", before, after)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.relationshipID(t, joiner); got == nil || got.Hex() != res.ID {
+				t.Errorf("joiner relationshipId = %v, want %s", got, res.ID)
+			}
+		})
+	}
+}
+
+func TestRelationshipAccessByOutsiders(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(f *relationshipFixture, outsider, relID primitive.ObjectID, code string) error
+		wantErr error
+	}{
+		{"revoke invite", func(f *relationshipFixture, outsider, _ primitive.ObjectID, code string) error {
+			return f.uc.RevokeInvite(context.Background(), outsider, code)
+		}, ErrInviteNotFound},
+		{"invite QR code", func(f *relationshipFixture, outsider, _ primitive.ObjectID, code string) error {
+			_, err := f.uc.InviteQRCode(context.Background(), outsider, code)
+			return err
+		}, ErrInviteNotFound},
+		{"reconnect", func(f *relationshipFixture, outsider, relID primitive.ObjectID, _ string) error {
+			_, err := f.uc.Reconnect(context.Background(), outsider, relID)
+			return err
+		}, ErrRelationshipNotFound},
+		{"cancel reconnect", func(f *relationshipFixture, outsider, relID primitive.ObjectID, _ string) error {
+			return f.uc.CancelReconnect(context.Background(), outsider, relID)
+		}, ErrRelationshipNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newRelationshipFixture(nil)
+			alice, bob, relID := f.couple(t, "alice", "bob")
+			if err := f.uc.DisconnectRelationship(ctx, alice); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.uc.Reconnect(ctx, bob, relID); err != nil {
+				t.Fatal(err)
+			}
+			code := f.invite(t, alice)
+			outsider := f.createUser(t, "outsider")
+
+			if err := tt.call(f, outsider, relID, code); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if inv, err := f.invs.FindByCode(ctx, code); err != nil || !inv.IsActive(time.Now()) {
+				t.Errorf("invite after outsider %s: %v, want it active", tt.name, err)
+			}
+			rel, err := f.rels.FindByID(ctx, relID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel.Status != entities.RelationshipStatusDisconnected || rel.ReconnectRequestedBy == nil || *rel.ReconnectRequestedBy != bob {
+				t.Errorf("relationship after outsider %s: status=%s reconnectRequestedBy=%v", tt.name, rel.Status, rel.ReconnectRequestedBy)
+			}
+		})
+	}
+}
+
+func TestDisconnectFlow(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps are run in order by "requester" or "partner"; only the last may fail
+		steps      []string
+		wantErr    error
+		wantStatus entities.RelationshipStatus
+	}{
+		{"request", []string{"request by requester"}, nil, entities.RelationshipStatusDisconnectRequested},
+		{"request twice", []string{"request by requester", "request by partner"}, entities.ErrDisconnectAlreadyRequested, entities.RelationshipStatusDisconnectRequested},
+		{"partner confirms", []string{"request by requester", "confirm by partner"}, nil, entities.RelationshipStatusDisconnected},
+		{"requester confirms", []string{"request by requester", "confirm by requester"}, entities.ErrCannotConfirmOwnRequest, entities.RelationshipStatusDisconnectRequested},
+		{"requester cancels", []string{"request by requester", "cancel by requester"}, nil, entities.RelationshipStatusActive},
+		{"partner cancels", []string{"request by requester", "cancel by partner"}, entities.ErrNotDisconnectRequester, entities.RelationshipStatusDisconnectRequested},
+		{"confirm without request", []string{"confirm by partner"}, entities.ErrNoDisconnectRequest, entities.RelationshipStatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newRelationshipFixture(nil)
+			requester, partner, relID := f.couple(t, "requester", "partner")
+			var err error
+			for i, step := range tt.steps {
+				action, by, _ := strings.Cut(step, " by ")
+				user := requester
+				if by == "partner" {
+					user = partner
+				}
+				switch action {
+				case "request":
+					_, err = f.uc.RequestDisconnect(ctx, user)
+				case "confirm":
+					_, err = f.uc.ConfirmDisconnect(ctx, user)
+				case "cancel":
+					_, err = f.uc.CancelDisconnect(ctx, user)
+				}
+				if err != nil && i < len(tt.steps)-1 {
+					t.Fatalf("step %q: %v", step, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			rel, err := f.rels.FindByID(ctx, relID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", rel.Status, tt.wantStatus)
+			}
+			// Partners point at the relationship exactly while it is current
+			for _, id := range []primitive.ObjectID{requester, partner} {
+				got := f.relationshipID(t, id)
+				if tt.wantStatus.IsCurrent() != (got != nil && *got == relID) {
+					t.Errorf("user %s relationshipId = %v with status %s", id.Hex(), got, rel.Status)
+				}
+			}
+		})
+	}
+}
+
+// staleRelationships answers the lookups of the disconnect flow with a snapshot
+// taken earlier, as a request handled concurrently with another would see it
+type staleRelationships struct {
+	repositories.RelationshipRepository
+	snapshot *entities.Relationship
+}
+
+func (r *staleRelationships) copy() *entities.Relationship {
+	c := *r.snapshot
+	if r.snapshot.DisconnectRequest != nil {
+		req := *r.snapshot.DisconnectRequest
+		c.DisconnectRequest = &req
+	}
+	return &c
+}
+
+func (r *staleRelationships) FindCurrentByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.Relationship, error) {
+	return r.copy(), nil
+}
+
+func (r *staleRelationships) FindDisconnectsDue(ctx context.Context, now time.Time) ([]*entities.Relationship, error) {
+	return []*entities.Relationship{r.copy()}, nil
+}
+
+// disconnectUseCase builds a use case over the fixture's store with its own
+// relationship repository and mailer
+func (f *relationshipFixture) disconnectUseCase(rels repositories.RelationshipRepository, mailer services.Mailer) RelationshipUseCase {
+	return NewRelationshipUseCase(rels, f.users, f.invs, memory.NewUnitOfWork(f.store), NewEmailVerificationPolicy(nil),
+		mailer, InvitePolicy{TTL: time.Hour}, DisconnectPolicy{UndoWindow: time.Hour, AutoConfirmAfter: 24 * time.Hour})
+}
+
+func TestDisconnectAnswerToChangedRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		// requested says whether the stale view has a pending request by the requester
+		requested bool
+		// meanwhile runs between the stale read and the answer
+		meanwhile  func(f *relationshipFixture, requester, partner primitive.ObjectID) error
+		answer     func(uc RelationshipUseCase, requester, partner primitive.ObjectID) error
+		wantErr    error
+		wantStatus entities.RelationshipStatus
+	}{
+		{"confirm after cancel", true,
+			func(f *relationshipFixture, requester, _ primitive.ObjectID) error {
+				_, err := f.uc.CancelDisconnect(context.Background(), requester)
+				return err
+			},
+			func(uc RelationshipUseCase, _, partner primitive.ObjectID) error {
+				_, err := uc.ConfirmDisconnect(context.Background(), partner)
+				return err
+			}, entities.ErrNoDisconnectRequest, entities.RelationshipStatusActive},
+		{"cancel after confirm", true,
+			func(f *relationshipFixture, _, partner primitive.ObjectID) error {
+				_, err := f.uc.ConfirmDisconnect(context.Background(), partner)
+				return err
+			},
+			func(uc RelationshipUseCase, requester, _ primitive.ObjectID) error {
+				_, err := uc.CancelDisconnect(context.Background(), requester)
+				return err
+			}, entities.ErrNoDisconnectRequest, entities.RelationshipStatusDisconnected},
+		{"confirm after cancel and new request", true,
+			func(f *relationshipFixture, requester, partner primitive.ObjectID) error {
+				if _, err := f.uc.CancelDisconnect(context.Background(), requester); err != nil {
+					return err
+				}
+				_, err := f.uc.RequestDisconnect(context.Background(), partner)
+				return err
+			},
+			func(uc RelationshipUseCase, _, partner primitive.ObjectID) error {
+				_, err := uc.ConfirmDisconnect(context.Background(), partner)
+				return err
+			}, entities.ErrNoDisconnectRequest, entities.RelationshipStatusDisconnectRequested},
+		{"request after request", false,
+			func(f *relationshipFixture, _, partner primitive.ObjectID) error {
+				_, err := f.uc.RequestDisconnect(context.Background(), partner)
+				return err
+			},
+			func(uc RelationshipUseCase, requester, _ primitive.ObjectID) error {
+				_, err := uc.RequestDisconnect(context.Background(), requester)
+				return err
+			}, entities.ErrDisconnectAlreadyRequested, entities.RelationshipStatusDisconnectRequested},
+		{"auto-confirm after cancel", true,
+			func(f *relationshipFixture, requester, _ primitive.ObjectID) error {
+				_, err := f.uc.CancelDisconnect(context.Background(), requester)
+				return err
+			},
+			func(uc RelationshipUseCase, _, _ primitive.ObjectID) error {
+				if n, err := uc.AutoConfirmDisconnects(context.Background()); err != nil || n != 0 {
+					return fmt.Errorf("auto-confirmed %d, %v", n, err)
+				}
+				return nil
+			}, nil, entities.RelationshipStatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newRelationshipFixture(nil)
+			requester, partner, relID := f.couple(t, "requester", "partner")
+			if tt.requested {
+				if _, err := f.uc.RequestDisconnect(ctx, requester); err != nil {
+					t.Fatal(err)
+				}
+			}
+			snapshot, err := f.rels.FindByID(ctx, relID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.DisconnectRequest != nil {
+				// Due for auto-confirmation as far as the stale view knows
+				snapshot.DisconnectRequest.AutoConfirmAt = time.Now().Add(-time.Minute)
+			}
+			stale := f.disconnectUseCase(&staleRelationships{RelationshipRepository: f.rels, snapshot: snapshot}, services.NewLogMailer())
+			if err := tt.meanwhile(f, requester, partner); err != nil {
+				t.Fatal(err)
+			}
+			before, err := f.rels.FindByID(ctx, relID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.answer(stale, requester, partner); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			rel, err := f.rels.FindByID(ctx, relID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel.Status != tt.wantStatus || rel.Status != before.Status || rel.DisconnectRequest.State != before.DisconnectRequest.State {
+				t.Errorf("status = %s request = %s, want it left at %s %s", rel.Status, rel.DisconnectRequest.State, before.Status, before.DisconnectRequest.State)
+			}
+			for _, id := range []primitive.ObjectID{requester, partner} {
+				got := f.relationshipID(t, id)
+				if tt.wantStatus.IsCurrent() != (got != nil && *got == relID) {
+					t.Errorf("user %s relationshipId = %v with status %s", id.Hex(), got, rel.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoConfirmDisconnectsNotifiesBothPartners(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	requester, partner, relID := f.couple(t, "requester", "partner")
+	if _, err := f.uc.RequestDisconnect(ctx, requester); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := f.rels.FindByID(ctx, relID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel.DisconnectRequest.AutoConfirmAt = time.Now().Add(-time.Minute)
+	if err := f.rels.Update(ctx, rel); err != nil {
+		t.Fatal(err)
+	}
+
+	mail := &outbox{}
+	n, err := f.disconnectUseCase(f.rels, mail).AutoConfirmDisconnects(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("auto-confirmed %d, %v; want 1", n, err)
+	}
+	if rel, _ := f.rels.FindByID(ctx, relID); rel.Status != entities.RelationshipStatusDisconnected || rel.DisconnectRequest.State != entities.DisconnectAutoConfirmed {
+		t.Errorf("status = %s request = %s", rel.Status, rel.DisconnectRequest.State)
+	}
+	for _, id := range []primitive.ObjectID{requester, partner} {
+		if got := f.relationshipID(t, id); got != nil {
+			t.Errorf("user %s still points at the relationship", id.Hex())
+		}
+	}
+
+	to := map[string]string{}
+	for _, msg := range mail.sent {
+		to[msg.To] = msg.Subject
+	}
+	if len(mail.sent) != 2 || to["requester@example.com"] == "" || to["partner@example.com"] == "" {
+		t.Fatalf("mails sent = %v, want one to each partner", to)
+	}
+	if want := "requester ended your relationship on Whisper"; to["partner@example.com"] != want {
+		t.Errorf("partner subject = %q, want %q", to["partner@example.com"], want)
+	}
+}
+
+// staleReads answers the first reads of a relationship with a snapshot taken
+// before another request changed it
+type staleReads struct {
+	repositories.RelationshipRepository
+	snapshot *entities.Relationship
+	reads    int
+}
+
+func (r *staleReads) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Relationship, error) {
+	if r.reads == 0 {
+		return r.RelationshipRepository.FindByID(ctx, id)
+	}
+	r.reads--
+	c := *r.snapshot
+	return &c, nil
+}
+
+// disconnected returns a couple whose relationship has just ended
+func (f *relationshipFixture) disconnected(t *testing.T) (alice, bob, relID primitive.ObjectID) {
+	t.Helper()
+	alice, bob, relID = f.couple(t, "alice", "bob")
+	if err := f.uc.DisconnectRelationship(context.Background(), alice); err != nil {
+		t.Fatal(err)
+	}
+	return alice, bob, relID
+}
+
+func (f *relationshipFixture) snapshot(t *testing.T, relID primitive.ObjectID) *entities.Relationship {
+	t.Helper()
+	rel, err := f.rels.FindByID(context.Background(), relID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rel
+}
+
+func TestReconnectAfterPartnerAskedMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	alice, bob, relID := f.disconnected(t)
+	// Alice's request reads the relationship before bob's request is stored
+	stale := f.disconnectUseCase(&staleReads{RelationshipRepository: f.rels, snapshot: f.snapshot(t, relID), reads: 1}, services.NewLogMailer())
+	if _, err := f.uc.Reconnect(ctx, bob, relID); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := stale.Reconnect(ctx, alice, relID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != string(entities.RelationshipStatusActive) {
+		t.Errorf("response status = %s, want active", res.Status)
+	}
+	if rel := f.snapshot(t, relID); rel.Status != entities.RelationshipStatusActive || rel.ReconnectRequestedBy != nil {
+		t.Errorf("status = %s reconnectRequestedBy = %v, want reactivated", rel.Status, rel.ReconnectRequestedBy)
+	}
+	for _, id := range []primitive.ObjectID{alice, bob} {
+		if got := f.relationshipID(t, id); got == nil || *got != relID {
+			t.Errorf("user %s relationshipId = %v, want %s", id.Hex(), got, relID.Hex())
+		}
+	}
+}
+
+func TestReconnectOwnRequestNeverReactivates(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	alice, _, relID := f.disconnected(t)
+	for i := 0; i < 2; i++ {
+		if _, err := f.uc.Reconnect(ctx, alice, relID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rel := f.snapshot(t, relID); rel.Status != entities.RelationshipStatusDisconnected || rel.ReconnectRequestedBy == nil || *rel.ReconnectRequestedBy != alice {
+		t.Errorf("status = %s reconnectRequestedBy = %v, want alice's request pending", rel.Status, rel.ReconnectRequestedBy)
+	}
+}
+
+func TestReconnectGivesUpOnPersistentConflict(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	alice, bob, relID := f.disconnected(t)
+	stale := f.disconnectUseCase(&staleReads{RelationshipRepository: f.rels, snapshot: f.snapshot(t, relID), reads: reconnectAttempts}, services.NewLogMailer())
+	if _, err := f.uc.Reconnect(ctx, bob, relID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stale.Reconnect(ctx, alice, relID); !errors.Is(err, ErrReconnectConflict) {
+		t.Fatalf("error = %v, want %v", err, ErrReconnectConflict)
+	}
+	if rel := f.snapshot(t, relID); rel.Status != entities.RelationshipStatusDisconnected || *rel.ReconnectRequestedBy != bob {
+		t.Errorf("status = %s reconnectRequestedBy = %v, want bob's request left alone", rel.Status, rel.ReconnectRequestedBy)
+	}
+}
+
+func TestConcurrentReconnectsReactivateOnce(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		f := newRelationshipFixture(nil)
+		alice, bob, relID := f.disconnected(t)
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, id := range []primitive.ObjectID{alice, bob} {
+			wg.Add(1)
+			go func(j int, id primitive.ObjectID) {
+				defer wg.Done()
+				_, errs[j] = f.uc.Reconnect(ctx, id, relID)
+			}(j, id)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if rel := f.snapshot(t, relID); rel.Status != entities.RelationshipStatusActive {
+			t.Fatalf("both partners asked but status = %s reconnectRequestedBy = %v", rel.Status, rel.ReconnectRequestedBy)
+		}
+	}
+}
+
+func TestCancelReconnectAfterPartnerReactivated(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	alice, bob, relID := f.disconnected(t)
+	if _, err := f.uc.Reconnect(ctx, alice, relID); err != nil {
+		t.Fatal(err)
+	}
+	stale := f.disconnectUseCase(&staleReads{RelationshipRepository: f.rels, snapshot: f.snapshot(t, relID), reads: 1}, services.NewLogMailer())
+	if _, err := f.uc.Reconnect(ctx, bob, relID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stale.CancelReconnect(ctx, alice, relID); !errors.Is(err, ErrNoReconnectRequest) {
+		t.Fatalf("error = %v, want %v", err, ErrNoReconnectRequest)
+	}
+	if rel := f.snapshot(t, relID); rel.Status != entities.RelationshipStatusActive {
+		t.Errorf("status = %s, want the reactivated relationship kept", rel.Status)
+	}
+}
+
+func TestFirstMeetingDateKeepsTheCalendarDay(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	inviter, joiner := f.createUser(t, "inviter"), f.createUser(t, "joiner")
+
+	// Local midnight east of UTC is the previous evening in UTC
+	metAt := time.Date(2020, time.June, 15, 0, 0, 0, 0, time.FixedZone("+0330", 3*3600+1800))
+	inv, err := f.uc.GenerateInvitationCode(ctx, inviter, metAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.uc.JoinWithInviteCode(ctx, joiner, inv.InviteCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC)
+	if res.FirstMeetingDate == nil || !res.FirstMeetingDate.Equal(want) {
+		t.Fatalf("firstMeetingDate = %v, want %s", res.FirstMeetingDate, want)
+	}
+	if res.NextAnniversary == nil || res.NextAnniversary.Month() != time.June || res.NextAnniversary.Day() != 15 {
+		t.Errorf("nextAnniversary = %v, want June 15", res.NextAnniversary)
+	}
+}
+
+type failingInviteCreate struct {
+	repositories.InviteRepository
+}
+
+func (r *failingInviteCreate) Create(ctx context.Context, inv *entities.InviteCode) error {
+	return errors.New("write failed")
+}
+
+func TestGenerateInvitationCodeKeepsPreviousCodeOnFailure(t *testing.T) {
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	inviter := f.createUser(t, "inviter")
+	code := f.invite(t, inviter)
+
+	uc := NewRelationshipUseCase(f.rels, f.users, &failingInviteCreate{f.invs}, memory.NewUnitOfWork(f.store), NewEmailVerificationPolicy(nil),
+		services.NewLogMailer(), InvitePolicy{TTL: time.Hour}, DisconnectPolicy{UndoWindow: time.Hour, AutoConfirmAfter: 24 * time.Hour})
+	if _, err := uc.GenerateInvitationCode(ctx, inviter, time.Now().AddDate(-1, 0, 0)); err == nil {
+		t.Fatal("generated a code although storing it failed")
+	}
+	inv, err := f.invs.FindByCode(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inv.IsActive(time.Now()) || !inv.Current {
+		t.Errorf("previous code status = %s current=%t, want active and current", inv.Status(time.Now()), inv.Current)
+	}
+}
+
+func TestConcurrentInvitationCodesLeaveOneActive(t *testing.T) {
+	const requests = 16
+	ctx := context.Background()
+	f := newRelationshipFixture(nil)
+	inviter := f.createUser(t, "inviter")
+
+	errs := make([]error, requests)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = f.uc.GenerateInvitationCode(ctx, inviter, time.Now().AddDate(-1, 0, 0))
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil && !strings.Contains(err.Error(), "failed to generate unique invite code") {
+			t.Errorf("request %d: unexpected error %v", i, err)
+		}
+	}
+	invites, err := f.invs.FindByCreator(ctx, inviter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := 0
+	for _, inv := range invites {
+		if inv.IsActive(time.Now()) {
+			active++
+		}
+	}
+	if active != 1 {
+		t.Errorf("%d active codes after %d concurrent requests, want 1", active, requests)
+	}
+}
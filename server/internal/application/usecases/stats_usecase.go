@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StatsSyncer keeps the denormalized User.Stats of both partners in line with the
+// relationship content. Use cases call it after every write that changes a counter;
+// it recomputes from the source collections instead of incrementing, so a missed
+// call heals on the next one.
+type StatsSyncer interface {
+	SyncRelationshipStats(ctx context.Context, relationshipID primitive.ObjectID)
+}
+
+type StatsUseCase interface {
+	StatsSyncer
+	GetRelationshipStats(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipStatsResponse, error)
+}
+
+type statsUseCase struct {
+	statsRepo domainRepos.StatsRepository
+	relRepo   domainRepos.RelationshipRepository
+	userRepo  domainRepos.UserRepository
+}
+
+func NewStatsUseCase(statsRepo domainRepos.StatsRepository, relRepo domainRepos.RelationshipRepository, userRepo domainRepos.UserRepository) StatsUseCase {
+	return &statsUseCase{statsRepo: statsRepo, relRepo: relRepo, userRepo: userRepo}
+}
+
+func (uc *statsUseCase) GetRelationshipStats(ctx context.Context, userID primitive.ObjectID) (*dto.RelationshipStatsResponse, error) {
+	log.Printf("[STATS][REL][START] user=%s", userID.Hex())
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[STATS][REL][ERROR] user=%s no current relationship: %v", userID.Hex(), err)
+		return nil, err
+	}
+	res, err := uc.compute(ctx, rel)
+	if err != nil {
+		log.Printf("[STATS][REL][ERROR] user=%s rel=%s err=%v", userID.Hex(), rel.ID.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[STATS][REL][DONE] user=%s rel=%s memories=%d whispers=%d", userID.Hex(), rel.ID.Hex(), res.MemoriesCount, res.WhispersCount)
+	return res, nil
+}
+
+func (uc *statsUseCase) SyncRelationshipStats(ctx context.Context, relationshipID primitive.ObjectID) {
+	rel, err := uc.relRepo.FindByID(ctx, relationshipID)
+	if err != nil {
+		log.Printf("[STATS][SYNC][ERROR] rel=%s err=%v", relationshipID.Hex(), err)
+		return
+	}
+	res, err := uc.compute(ctx, rel)
+	if err != nil {
+		log.Printf("[STATS][SYNC][ERROR] rel=%s err=%v", relationshipID.Hex(), err)
+		return
+	}
+	stats := entities.UserStats{
+		MemoriesCount:     res.MemoriesCount,
+		WhispersCount:     res.WhispersCount,
+		PublicEventsCount: res.PublicEventsCount,
+		RelationshipDays:  res.RelationshipDays,
+	}
+	for _, p := range rel.Partners {
+		if err := uc.userRepo.UpdateStats(ctx, p.UserID, stats); err != nil {
+			log.Printf("[STATS][SYNC][ERROR] rel=%s user=%s err=%v", relationshipID.Hex(), p.UserID.Hex(), err)
+		}
+	}
+}
+
+func (uc *statsUseCase) compute(ctx context.Context, rel *entities.Relationship) (*dto.RelationshipStatsResponse, error) {
+	eventStats, err := uc.statsRepo.AggregateEventStats(ctx, rel.ID)
+	if err != nil {
+		return nil, err
+	}
+	whisperStats, err := uc.statsRepo.AggregateWhisperStats(ctx, rel.ID)
+	if err != nil {
+		return nil, err
+	}
+	var firstMeeting *time.Time
+	if !rel.FirstMeetingDate.IsZero() {
+		firstMeeting = &rel.FirstMeetingDate
+	}
+	return &dto.RelationshipStatsResponse{
+		RelationshipID:    rel.ID.Hex(),
+		FirstMeetingDate:  firstMeeting,
+		RelationshipDays:  rel.DaysTogether(time.Now()),
+		MemoriesCount:     eventStats.Total,
+		PublicEventsCount: eventStats.PublicCount,
+		EventsByType:      eventStats.ByType,
+		EventsByCategory:  eventStats.ByCategory,
+		FirstMemoryAt:     eventStats.FirstMemoryAt,
+		LastMemoryAt:      eventStats.LastMemoryAt,
+		LongestGapDays:    eventStats.LongestGapDays,
+		LongestGapFrom:    eventStats.LongestGapFrom,
+		LongestGapTo:      eventStats.LongestGapTo,
+		WhispersCount:     whisperStats.Total,
+		WhispersDone:      whisperStats.Done,
+		WhispersOpen:      whisperStats.Open,
+	}, nil
+}
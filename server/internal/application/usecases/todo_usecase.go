@@ -0,0 +1,215 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TodoUseCase interface {
+	Create(ctx context.Context, userID primitive.ObjectID, req *dto.CreateTodoRequest) (*dto.TodoResponse, error)
+	ListByCurrentRelationship(ctx context.Context, userID primitive.ObjectID, completed *bool, limit, offset int64) ([]*dto.TodoResponse, error)
+	Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.UpdateTodoRequest) (*dto.TodoResponse, error)
+	Complete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*dto.CompleteTodoResponse, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+type todoUseCase struct {
+	repo      domainRepos.TodoRepository
+	relRepo   domainRepos.RelationshipRepository
+	eventRepo domainRepos.EventRepository
+	media     MediaURLs
+	stats     StatsSyncer
+}
+
+func NewTodoUseCase(repo domainRepos.TodoRepository, relRepo domainRepos.RelationshipRepository, eventRepo domainRepos.EventRepository, media MediaURLs, stats StatsSyncer) TodoUseCase {
+	return &todoUseCase{repo: repo, relRepo: relRepo, eventRepo: eventRepo, media: media, stats: stats}
+}
+
+// todo errors
+var (
+	ErrTodoAlreadyCompleted = fmtError("todo already completed")
+	ErrInvalidAssignee      = fmtError("assignee must be a partner in the relationship")
+)
+
+func (uc *todoUseCase) Create(ctx context.Context, userID primitive.ObjectID, req *dto.CreateTodoRequest) (*dto.TodoResponse, error) {
+	log.Printf("[TODO][CREATE][START] user=%s priority=%s", userID.Hex(), req.Priority)
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[TODO][CREATE][ERROR] user=%s no current relationship: %v", userID.Hex(), err)
+		return nil, err
+	}
+	t := entities.NewTodo(req.Title, req.Priority, rel.ID, userID)
+	t.Description = req.Description
+	t.DueDate = req.DueDate
+	if req.AssignedTo != "" {
+		assignee, err := resolveAssignee(rel, req.AssignedTo)
+		if err != nil {
+			return nil, err
+		}
+		t.AssignedTo = assignee
+	}
+	if err := uc.repo.Create(ctx, t); err != nil {
+		log.Printf("[TODO][CREATE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[TODO][CREATE][DONE] user=%s id=%s", userID.Hex(), t.ID.Hex())
+	return toTodoResponse(t), nil
+}
+
+func (uc *todoUseCase) ListByCurrentRelationship(ctx context.Context, userID primitive.ObjectID, completed *bool, limit, offset int64) ([]*dto.TodoResponse, error) {
+	log.Printf("[TODO][LIST_REL][START] user=%s limit=%d offset=%d", userID.Hex(), limit, offset)
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("[TODO][LIST_REL][ERROR] user=%s no current relationship: %v", userID.Hex(), err)
+		return nil, err
+	}
+	list, err := uc.repo.FindAllByRelationshipID(ctx, rel.ID, completed, limit, offset)
+	if err != nil {
+		log.Printf("[TODO][LIST_REL][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	res := make([]*dto.TodoResponse, 0, len(list))
+	for _, t := range list {
+		res = append(res, toTodoResponse(t))
+	}
+	log.Printf("[TODO][LIST_REL][DONE] user=%s count=%d", userID.Hex(), len(res))
+	return res, nil
+}
+
+func (uc *todoUseCase) Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.UpdateTodoRequest) (*dto.TodoResponse, error) {
+	log.Printf("[TODO][UPDATE][START] user=%s id=%s", userID.Hex(), id.Hex())
+	t, rel, err := uc.findAuthorized(ctx, userID, id)
+	if err != nil {
+		log.Printf("[TODO][UPDATE][ERROR] user=%s id=%s err=%v", userID.Hex(), id.Hex(), err)
+		return nil, err
+	}
+	if t.IsCompleted {
+		return nil, ErrTodoAlreadyCompleted
+	}
+	t.Update(req.Title, req.Description, req.Priority)
+	if req.DueDate != nil {
+		t.SetDueDate(req.DueDate)
+	}
+	if req.AssignedTo != nil {
+		if *req.AssignedTo == "" {
+			t.AssignTo(nil)
+		} else {
+			assignee, err := resolveAssignee(rel, *req.AssignedTo)
+			if err != nil {
+				return nil, err
+			}
+			t.AssignTo(assignee)
+		}
+	}
+	if err := uc.repo.Update(ctx, t); err != nil {
+		log.Printf("[TODO][UPDATE][ERROR] save id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[TODO][UPDATE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
+	return toTodoResponse(t), nil
+}
+
+// Complete marks a todo as done and records it on the timeline as a TODO_COMPLETED event
+func (uc *todoUseCase) Complete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*dto.CompleteTodoResponse, error) {
+	log.Printf("[TODO][COMPLETE][START] user=%s id=%s", userID.Hex(), id.Hex())
+	t, _, err := uc.findAuthorized(ctx, userID, id)
+	if err != nil {
+		log.Printf("[TODO][COMPLETE][ERROR] user=%s id=%s err=%v", userID.Hex(), id.Hex(), err)
+		return nil, err
+	}
+	if t.IsCompleted {
+		return nil, ErrTodoAlreadyCompleted
+	}
+	ev := entities.NewEventFromTodo(t.Title, time.Now(), t.RelationshipID, userID, t.ID)
+	ev.Description = t.Description
+	if err := uc.eventRepo.Create(ctx, ev); err != nil {
+		log.Printf("[TODO][COMPLETE][ERROR] create event id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	t.Complete(userID, ev.ID)
+	if err := uc.repo.Update(ctx, t); err != nil {
+		log.Printf("[TODO][COMPLETE][ERROR] save id=%s err=%v", id.Hex(), err)
+		// Roll back the event so a retry does not produce duplicates
+		_ = uc.eventRepo.Delete(ctx, ev.ID)
+		return nil, err
+	}
+	uc.stats.SyncRelationshipStats(ctx, t.RelationshipID)
+	log.Printf("[TODO][COMPLETE][DONE] user=%s id=%s event=%s", userID.Hex(), id.Hex(), ev.ID.Hex())
+	return &dto.CompleteTodoResponse{Todo: toTodoResponse(t), Event: toEventResponse(ev, uc.media)}, nil
+}
+
+func (uc *todoUseCase) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	log.Printf("[TODO][DELETE][START] user=%s id=%s", userID.Hex(), id.Hex())
+	if _, _, err := uc.findAuthorized(ctx, userID, id); err != nil {
+		log.Printf("[TODO][DELETE][ERROR] user=%s id=%s err=%v", userID.Hex(), id.Hex(), err)
+		return err
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		log.Printf("[TODO][DELETE][ERROR] delete id=%s err=%v", id.Hex(), err)
+		return err
+	}
+	log.Printf("[TODO][DELETE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
+	return nil
+}
+
+// findAuthorized loads a todo and ensures it belongs to the caller's active relationship
+func (uc *todoUseCase) findAuthorized(ctx context.Context, userID, id primitive.ObjectID) (*entities.Todo, *entities.Relationship, error) {
+	t, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil || rel.ID != t.RelationshipID {
+		return nil, nil, ErrForbidden
+	}
+	return t, rel, nil
+}
+
+// resolveAssignee parses a user ID and checks it is one of the relationship partners
+func resolveAssignee(rel *entities.Relationship, userIDHex string) (*primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		return nil, ErrInvalidAssignee
+	}
+	for _, p := range rel.Partners {
+		if p.UserID == oid {
+			return &oid, nil
+		}
+	}
+	return nil, ErrInvalidAssignee
+}
+
+// helpers
+func toTodoResponse(t *entities.Todo) *dto.TodoResponse {
+	res := &dto.TodoResponse{
+		ID:             t.ID.Hex(),
+		Title:          t.Title,
+		Description:    t.Description,
+		Priority:       t.Priority,
+		DueDate:        t.DueDate,
+		RelationshipID: t.RelationshipID.Hex(),
+		CreatedBy:      t.CreatedBy.Hex(),
+		IsCompleted:    t.IsCompleted,
+		IsOverdue:      t.IsOverdue(time.Now()),
+		CompletedAt:    t.CompletedAt,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+	}
+	if t.AssignedTo != nil {
+		res.AssignedTo = t.AssignedTo.Hex()
+	}
+	if t.CompletedBy != nil {
+		res.CompletedBy = t.CompletedBy.Hex()
+	}
+	if t.EventID != nil {
+		res.EventID = t.EventID.Hex()
+	}
+	return res
+}
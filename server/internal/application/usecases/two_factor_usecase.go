@@ -0,0 +1,300 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TwoFactorUseCase interface {
+	TwoFactorVerifier
+	Status(ctx context.Context, userID primitive.ObjectID) (*dto.TwoFactorStatusResponse, error)
+	// Enroll starts (or restarts) an unconfirmed enrollment and returns the secret
+	Enroll(ctx context.Context, userID primitive.ObjectID) (*dto.TwoFactorEnrollResponse, error)
+	// Confirm enables 2FA once the user enters a valid code, returning recovery codes
+	Confirm(ctx context.Context, userID primitive.ObjectID, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error)
+	// Disable turns 2FA off given a code and either the password or a session
+	// that signed in moments ago
+	Disable(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.DisableTwoFactorRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID primitive.ObjectID, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error)
+}
+
+// TwoFactorVerifier is what the login flow needs from 2FA
+type TwoFactorVerifier interface {
+	IsEnabled(ctx context.Context, userID primitive.ObjectID) (bool, error)
+	// VerifySecondFactor accepts a current TOTP code or an unused recovery code
+	VerifySecondFactor(ctx context.Context, userID primitive.ObjectID, code string) error
+}
+
+var (
+	ErrTwoFactorAlreadyEnabled = fmtError("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = fmtError("two-factor authentication is not set up")
+	ErrTwoFactorNotEnabled     = fmtError("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = fmtError("invalid authentication code")
+	ErrPasswordOrFreshLogin    = fmtError("confirm with your password or sign in again")
+)
+
+const recoveryCodeCount = 10
+
+type twoFactorUseCase struct {
+	repo            repositories.TwoFactorRepository
+	userRepo        repositories.UserRepository
+	sessionRepo     repositories.SessionRepository
+	passwordService services.PasswordService
+	box             services.SecretBox
+	issuer          string
+}
+
+func NewTwoFactorUseCase(
+	repo repositories.TwoFactorRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	passwordService services.PasswordService,
+	box services.SecretBox,
+	issuer string,
+) TwoFactorUseCase {
+	return &twoFactorUseCase{
+		repo:            repo,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		passwordService: passwordService,
+		box:             box,
+		issuer:          issuer,
+	}
+}
+
+func (uc *twoFactorUseCase) IsEnabled(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	tf, err := uc.repo.FindByUserID(ctx, userID)
+	if err == repositories.ErrTwoFactorNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+func (uc *twoFactorUseCase) Status(ctx context.Context, userID primitive.ObjectID) (*dto.TwoFactorStatusResponse, error) {
+	tf, err := uc.repo.FindByUserID(ctx, userID)
+	if err == repositories.ErrTwoFactorNotFound {
+		return &dto.TwoFactorStatusResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorStatusResponse{
+		Enabled:                tf.Enabled,
+		EnabledAt:              tf.EnabledAt,
+		RecoveryCodesRemaining: len(tf.RecoveryCodes),
+	}, nil
+}
+
+func (uc *twoFactorUseCase) Enroll(ctx context.Context, userID primitive.ObjectID) (*dto.TwoFactorEnrollResponse, error) {
+	log.Printf("[2FA][ENROLL][START] user=%s", userID.Hex())
+	enabled, err := uc.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := services.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := uc.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Save(ctx, entities.NewTwoFactor(userID, sealed)); err != nil {
+		log.Printf("[2FA][ENROLL][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	log.Printf("[2FA][ENROLL][DONE] user=%s", userID.Hex())
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: services.TOTPURI(uc.issuer, account, secret),
+	}, nil
+}
+
+func (uc *twoFactorUseCase) Confirm(ctx context.Context, userID primitive.ObjectID, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	log.Printf("[2FA][CONFIRM][START] user=%s", userID.Hex())
+	tf, err := uc.repo.FindByUserID(ctx, userID)
+	if err == repositories.ErrTwoFactorNotFound {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, ok := uc.checkTOTP(tf, req.Code)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tf.Enabled = true
+	tf.EnabledAt = &now
+	tf.LastUsedStep = step
+	tf.RecoveryCodes = hashes
+	if err := uc.repo.Save(ctx, tf); err != nil {
+		log.Printf("[2FA][CONFIRM][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+
+	log.Printf("[2FA][CONFIRM][DONE] user=%s", userID.Hex())
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (uc *twoFactorUseCase) Disable(ctx context.Context, userID, sessionID primitive.ObjectID, req *dto.DisableTwoFactorRequest) error {
+	log.Printf("[2FA][DISABLE][START] user=%s", userID.Hex())
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// Accounts provisioned through OIDC have no password the user knows, so a
+	// fresh sign-in counts instead, as for deleting the account
+	switch {
+	case req.Password != "":
+		if err := uc.passwordService.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+			return ErrIncorrectPassword
+		}
+	case !signedInRecently(ctx, uc.sessionRepo, userID, sessionID):
+		log.Printf("[2FA][DISABLE][DENIED] user=%s no password and no fresh sign-in", userID.Hex())
+		return ErrPasswordOrFreshLogin
+	}
+	if err := uc.VerifySecondFactor(ctx, userID, req.Code); err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, userID); err != nil {
+		log.Printf("[2FA][DISABLE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return err
+	}
+	log.Printf("[2FA][DISABLE][DONE] user=%s", userID.Hex())
+	return nil
+}
+
+func (uc *twoFactorUseCase) RegenerateRecoveryCodes(ctx context.Context, userID primitive.ObjectID, req *dto.TwoFactorCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	log.Printf("[2FA][RECOVERY_CODES][START] user=%s", userID.Hex())
+	if err := uc.VerifySecondFactor(ctx, userID, req.Code); err != nil {
+		return nil, err
+	}
+	// Reload so the step recorded by the verification above is kept
+	tf, err := uc.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	tf.RecoveryCodes = hashes
+	if err := uc.repo.Save(ctx, tf); err != nil {
+		return nil, err
+	}
+	log.Printf("[2FA][RECOVERY_CODES][DONE] user=%s", userID.Hex())
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (uc *twoFactorUseCase) VerifySecondFactor(ctx context.Context, userID primitive.ObjectID, code string) error {
+	tf, err := uc.repo.FindByUserID(ctx, userID)
+	if err == repositories.ErrTwoFactorNotFound {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		step, ok := uc.checkTOTP(tf, code)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		// Each code works once, even within its validity window
+		fresh, err := uc.repo.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := uc.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	log.Printf("[2FA][RECOVERY_CODE][USED] user=%s remaining=%d", userID.Hex(), len(tf.RecoveryCodes)-1)
+	return nil
+}
+
+func (uc *twoFactorUseCase) checkTOTP(tf *entities.TwoFactor, code string) (int64, bool) {
+	secret, err := uc.box.Open(tf.Secret)
+	if err != nil {
+		log.Printf("[2FA][VERIFY][ERROR] user=%s cannot decrypt secret: %v", tf.UserID.Hex(), err)
+		return 0, false
+	}
+	return services.ValidateTOTP(string(secret), code, time.Now())
+}
+
+// generateRecoveryCodes returns codes formatted for display ("abcde-fghij") and the
+// hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		for j := range b {
+			idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return nil, nil, err
+			}
+			b[j] = alphabet[idx.Int64()]
+		}
+		code := string(b[:5]) + "-" + string(b[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed loosely
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return services.HashOpaqueToken(normalized)
+}
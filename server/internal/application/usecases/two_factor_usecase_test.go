@@ -0,0 +1,233 @@
+package usecases
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// currentCode computes the TOTP code an authenticator app would show now, step
+// steps away from the current one
+func currentCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30+step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:])&0x7fffffff)%1000000)
+}
+
+const twoFactorPassword = "correct horse battery"
+
+type twoFactorFixture struct {
+	repo     repositories.TwoFactorRepository
+	sessions repositories.SessionRepository
+	userID   primitive.ObjectID
+	secret   string
+	used     string // the TOTP code that confirmed the enrollment
+	codes    []string
+	uc       TwoFactorUseCase
+}
+
+// newTwoFactorFixture enrolls a user and confirms the enrollment with the code
+// of the previous step, leaving the current one unused
+func newTwoFactorFixture(t *testing.T) *twoFactorFixture {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	box, err := services.NewSecretBox("test-mfa-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &twoFactorFixture{repo: memory.NewTwoFactorRepository(store), sessions: memory.NewSessionRepository(store)}
+	passwords := services.NewPasswordService()
+	f.uc = NewTwoFactorUseCase(f.repo, users, f.sessions, passwords, box, "Whisper")
+	hash, err := passwords.HashPassword(twoFactorPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := entities.NewUser("alice", "Alice", "alice@example.com", hash)
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	f.userID = user.ID
+
+	enroll, err := f.uc.Enroll(ctx, f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.secret = enroll.Secret
+	f.used = currentCode(t, f.secret, -1)
+	confirmed, err := f.uc.Confirm(ctx, f.userID, &dto.TwoFactorCodeRequest{Code: f.used})
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	f.codes = confirmed.RecoveryCodes
+	return f
+}
+
+func (f *twoFactorFixture) remaining(t *testing.T) int {
+	t.Helper()
+	status, err := f.uc.Status(context.Background(), f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status.RecoveryCodesRemaining
+}
+
+func TestTwoFactorEnrollmentStoresSealedSecret(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	tf, err := f.repo.FindByUserID(context.Background(), f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tf.Enabled || tf.Secret == f.secret || strings.Contains(tf.Secret, f.secret) {
+		t.Errorf("enabled=%t secret stored as %q", tf.Enabled, tf.Secret)
+	}
+	if len(f.codes) != recoveryCodeCount || f.remaining(t) != recoveryCodeCount {
+		t.Errorf("got %d codes, %d remaining; want %d", len(f.codes), f.remaining(t), recoveryCodeCount)
+	}
+	for _, h := range tf.RecoveryCodes {
+		for _, c := range f.codes {
+			if h == c {
+				t.Fatal("recovery code stored in plain text")
+			}
+		}
+	}
+}
+
+func TestVerifySecondFactorTOTPIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+
+	// The code that confirmed the enrollment cannot log in
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, f.used); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("confirmation code reused: %v", err)
+	}
+	code := currentCode(t, f.secret, 0)
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, code); err != nil {
+		t.Fatalf("current code: %v", err)
+	}
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("replayed code: %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+}
+
+func TestVerifySecondFactorRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, f.codes[0]); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, f.codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("recovery code used twice: %v", err)
+	}
+	// Typed loosely: upper case, without the dash
+	loose := strings.ToUpper(strings.ReplaceAll(f.codes[1], "-", ""))
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, loose); err != nil {
+		t.Errorf("loosely typed recovery code: %v", err)
+	}
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, "aaaaa-aaaaa"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("made-up recovery code: %v", err)
+	}
+	if got := f.remaining(t); got != recoveryCodeCount-2 {
+		t.Errorf("remaining = %d, want %d", got, recoveryCodeCount-2)
+	}
+
+	// New codes replace every old one
+	fresh, err := f.uc.RegenerateRecoveryCodes(ctx, f.userID, &dto.TwoFactorCodeRequest{Code: f.codes[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, f.codes[3]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("old code after regenerating: %v", err)
+	}
+	if err := f.uc.VerifySecondFactor(ctx, f.userID, fresh.RecoveryCodes[0]); err != nil {
+		t.Errorf("new code: %v", err)
+	}
+}
+
+// signIn stores a session for the user as a login made at the given time would
+func (f *twoFactorFixture) signIn(t *testing.T, userID primitive.ObjectID, at time.Time) primitive.ObjectID {
+	t.Helper()
+	session := entities.NewSession(userID, "phone", "whisper-tests/1.0", "127.0.0.1", time.Now().Add(24*time.Hour))
+	session.CreatedAt = at
+	if err := f.sessions.Create(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session.ID
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	stale := -freshLoginWindow - time.Minute
+	tests := []struct {
+		name     string
+		password string
+		loginAgo time.Duration
+		code     func(f *twoFactorFixture) string
+		wantErr  error
+	}{
+		{"password and code", twoFactorPassword, stale, nil, nil},
+		{"fresh sign-in and code", "", -time.Minute, nil, nil},
+		{"fresh sign-in and recovery code", "", -time.Minute, func(f *twoFactorFixture) string { return f.codes[0] }, nil},
+		{"wrong password", "wrong", -time.Minute, nil, ErrIncorrectPassword},
+		{"no password, old sign-in", "", stale, nil, ErrPasswordOrFreshLogin},
+		{"fresh sign-in, used code", "", -time.Minute, func(f *twoFactorFixture) string { return f.used }, ErrInvalidTwoFactorCode},
+		{"fresh sign-in, no code", "", -time.Minute, func(f *twoFactorFixture) string { return "" }, ErrInvalidTwoFactorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newTwoFactorFixture(t)
+			sessionID := f.signIn(t, f.userID, time.Now().Add(tt.loginAgo))
+			code := currentCode(t, f.secret, 0)
+			if tt.code != nil {
+				code = tt.code(f)
+			}
+
+			err := f.uc.Disable(ctx, f.userID, sessionID, &dto.DisableTwoFactorRequest{Password: tt.password, Code: code})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("disable = %v, want %v", err, tt.wantErr)
+			}
+			enabled, err := f.uc.IsEnabled(ctx, f.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enabled != (tt.wantErr != nil) {
+				t.Errorf("enabled = %t after disable returned %v", enabled, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDisableTwoFactorRejectsAnotherUsersSession(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	sessionID := f.signIn(t, primitive.NewObjectID(), time.Now())
+	err := f.uc.Disable(context.Background(), f.userID, sessionID, &dto.DisableTwoFactorRequest{Code: currentCode(t, f.secret, 0)})
+	if !errors.Is(err, ErrPasswordOrFreshLogin) {
+		t.Errorf("disable = %v, want %v", err, ErrPasswordOrFreshLogin)
+	}
+}
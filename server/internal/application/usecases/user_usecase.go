@@ -15,15 +15,25 @@ import (
 type UserUseCase interface {
 	GetProfile(ctx context.Context, userID primitive.ObjectID) (*dto.UserProfileResponse, error)
 	UpdateProfile(ctx context.Context, userID primitive.ObjectID, req *dto.UpdateUserProfileRequest) (*dto.UserProfileResponse, error)
+	GetSettings(ctx context.Context, userID primitive.ObjectID) (*dto.UserSettingsResponse, error)
+	UpdateSettings(ctx context.Context, userID primitive.ObjectID, req *dto.UpdateUserSettingsRequest) (*dto.UserSettingsResponse, error)
 }
 
+// settings validation errors
+var (
+	ErrUnsupportedLanguage = fmtError("unsupported language")
+	ErrInvalidTimezone     = fmtError("invalid timezone")
+)
+
 type userUseCase struct {
 	userRepo repositories.UserRepository
+	media    MediaUseCase
 }
 
-func NewUserUseCase(userRepo repositories.UserRepository) UserUseCase {
+func NewUserUseCase(userRepo repositories.UserRepository, media MediaUseCase) UserUseCase {
 	return &userUseCase{
 		userRepo: userRepo,
+		media:    media,
 	}
 }
 
@@ -36,18 +46,7 @@ func (uc *userUseCase) GetProfile(ctx context.Context, userID primitive.ObjectID
 		return nil, err
 	}
 
-	avatarData := ""
-	if user.Avatar != nil {
-		avatarData = user.Avatar.Data
-	}
-
-	response := &dto.UserProfileResponse{
-		ID:       user.ID.Hex(),
-		Name:     user.Name,
-		Username: user.Username,
-		Email:    user.Email,
-		Avatar:   avatarData,
-	}
+	response := toUserProfileResponse(user, uc.media)
 
 	log.Printf("[USER][GET_PROFILE][DONE] user=%s", userID.Hex())
 	return response, nil
@@ -69,38 +68,180 @@ func (uc *userUseCase) UpdateProfile(ctx context.Context, userID primitive.Objec
 		log.Printf("[USER][UPDATE_PROFILE][NAME] user=%s name=%s", userID.Hex(), *req.Name)
 	}
 
-	if req.Avatar != nil {
-		now := time.Now()
-		user.Avatar = &entities.Avatar{
-			Type:       "base64",
-			Data:       *req.Avatar,
-			Filename:   "avatar.jpg",
-			Size:       int64(len(*req.Avatar)),
-			UploadedAt: now,
+	previous := user.Avatar
+	if req.AvatarMediaID != nil {
+		if *req.AvatarMediaID == "" {
+			user.Avatar = nil
+		} else {
+			oid, err := primitive.ObjectIDFromHex(*req.AvatarMediaID)
+			if err != nil {
+				return nil, ErrInvalidMediaPayload
+			}
+			m, err := uc.media.Claim(ctx, userID, oid, entities.MediaKindAvatar, userID)
+			if err != nil {
+				log.Printf("[USER][UPDATE_PROFILE][ERROR] claim avatar user=%s err=%v", userID.Hex(), err)
+				return nil, err
+			}
+			user.Avatar = toAvatar(m)
+		}
+		log.Printf("[USER][UPDATE_PROFILE][AVATAR] user=%s media=%s", userID.Hex(), *req.AvatarMediaID)
+	} else if req.Avatar != nil {
+		// Legacy clients still send base64; store it as media instead of inline
+		data, err := decodeBase64Image(*req.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		m, err := uc.media.Import(ctx, userID, entities.MediaKindAvatar, "avatar", data)
+		if err == nil {
+			m, err = uc.media.Claim(ctx, userID, m.ID, entities.MediaKindAvatar, userID)
 		}
-		log.Printf("[USER][UPDATE_PROFILE][AVATAR] user=%s avatar_size=%d", userID.Hex(), len(*req.Avatar))
+		if err != nil {
+			log.Printf("[USER][UPDATE_PROFILE][ERROR] import avatar user=%s err=%v", userID.Hex(), err)
+			return nil, err
+		}
+		user.Avatar = toAvatar(m)
+		log.Printf("[USER][UPDATE_PROFILE][AVATAR] user=%s avatar_size=%d", userID.Hex(), m.Size)
 	}
 
+	avatarChanged := previous != user.Avatar && (previous == nil || user.Avatar == nil || user.Avatar.MediaID == nil || previous.MediaID == nil || *previous.MediaID != *user.Avatar.MediaID)
+
 	// Save updated user
 	err = uc.userRepo.Update(ctx, user)
 	if err != nil {
 		log.Printf("[USER][UPDATE_PROFILE][ERROR] update user=%s err=%v", userID.Hex(), err)
+		if avatarChanged && user.Avatar != nil {
+			uc.media.Release(ctx, user.Avatar.MediaID, userID)
+		}
 		return nil, err
 	}
-
-	avatarData := ""
-	if user.Avatar != nil {
-		avatarData = user.Avatar.Data
+	if avatarChanged && previous != nil {
+		uc.media.Discard(ctx, previous.MediaID, userID)
 	}
 
-	response := &dto.UserProfileResponse{
-		ID:       user.ID.Hex(),
-		Name:     user.Name,
-		Username: user.Username,
-		Email:    user.Email,
-		Avatar:   avatarData,
-	}
+	response := toUserProfileResponse(user, uc.media)
 
 	log.Printf("[USER][UPDATE_PROFILE][DONE] user=%s", userID.Hex())
 	return response, nil
 }
+
+func (uc *userUseCase) GetSettings(ctx context.Context, userID primitive.ObjectID) (*dto.UserSettingsResponse, error) {
+	log.Printf("[USER][GET_SETTINGS][START] user=%s", userID.Hex())
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		log.Printf("[USER][GET_SETTINGS][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[USER][GET_SETTINGS][DONE] user=%s", userID.Hex())
+	return toUserSettingsResponse(user.Settings), nil
+}
+
+func (uc *userUseCase) UpdateSettings(ctx context.Context, userID primitive.ObjectID, req *dto.UpdateUserSettingsRequest) (*dto.UserSettingsResponse, error) {
+	log.Printf("[USER][UPDATE_SETTINGS][START] user=%s", userID.Hex())
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		log.Printf("[USER][UPDATE_SETTINGS][ERROR] find user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+
+	// Start from current settings so omitted fields are preserved
+	settings := user.Settings
+	if req.AutoPublic != nil {
+		settings.AutoPublic = *req.AutoPublic
+	}
+	if req.Language != nil {
+		if !entities.IsSupportedLanguage(*req.Language) {
+			log.Printf("[USER][UPDATE_SETTINGS][INVALID] user=%s language=%s", userID.Hex(), *req.Language)
+			return nil, ErrUnsupportedLanguage
+		}
+		settings.Language = *req.Language
+	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			log.Printf("[USER][UPDATE_SETTINGS][INVALID] user=%s timezone=%s", userID.Hex(), *req.Timezone)
+			return nil, err
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if n := req.Notifications; n != nil {
+		if n.Whispers != nil {
+			settings.Notifications.Whispers = *n.Whispers
+		}
+		if n.Events != nil {
+			settings.Notifications.Events = *n.Events
+		}
+		if n.Partner != nil {
+			settings.Notifications.Partner = *n.Partner
+		}
+	}
+
+	// Only the settings are written: a join, verification or deletion may have
+	// changed the rest of the user since it was read
+	user.UpdateSettings(settings)
+	if err := uc.userRepo.UpdateSettings(ctx, userID, user.Settings); err != nil {
+		log.Printf("[USER][UPDATE_SETTINGS][ERROR] update user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[USER][UPDATE_SETTINGS][DONE] user=%s", userID.Hex())
+	return toUserSettingsResponse(user.Settings), nil
+}
+
+// validateTimezone accepts IANA zone names such as "Asia/Tehran" or "UTC"
+func validateTimezone(tz string) error {
+	// LoadLocation maps "" to UTC and "Local" to the server zone; neither is meaningful for a user
+	if tz == "" || tz == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// helpers
+func toUserProfileResponse(user *entities.User, urls MediaURLs) *dto.UserProfileResponse {
+	return &dto.UserProfileResponse{
+		ID:            user.ID.Hex(),
+		Name:          user.Name,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		Avatar:        avatarURL(user.Avatar, urls),
+		Settings:      toUserSettingsResponse(user.Settings),
+	}
+}
+
+func toAvatar(m *entities.Media) *entities.Avatar {
+	return &entities.Avatar{
+		Type:        entities.ImageTypeMedia,
+		MediaID:     &m.ID,
+		Key:         m.Key,
+		ContentType: m.ContentType,
+		Filename:    m.Filename,
+		Size:        m.Size,
+		UploadedAt:  time.Now(),
+	}
+}
+
+// avatarURL returns a signed URL for stored avatars and the raw data for legacy ones
+func avatarURL(a *entities.Avatar, urls MediaURLs) string {
+	if a == nil {
+		return ""
+	}
+	if a.Type == entities.ImageTypeMedia {
+		return urls.URL(a.Key)
+	}
+	return a.Data
+}
+
+func toUserSettingsResponse(s entities.UserSettings) *dto.UserSettingsResponse {
+	return &dto.UserSettingsResponse{
+		AutoPublic: s.AutoPublic,
+		Language:   s.Language,
+		Timezone:   s.Timezone,
+		Notifications: dto.NotificationSettingsResponse{
+			Whispers: s.Notifications.Whispers,
+			Events:   s.Notifications.Events,
+			Partner:  s.Notifications.Partner,
+		},
+	}
+}
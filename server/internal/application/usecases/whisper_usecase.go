@@ -2,7 +2,9 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sort"
 	"time"
 
 	"whisper-server/internal/application/dto"
@@ -15,19 +17,47 @@ import (
 type WhisperUseCase interface {
 	Create(ctx context.Context, userID primitive.ObjectID, req *dto.CreateWhisperRequest) (*dto.WhisperResponse, error)
 	ListByCurrentRelationship(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]*dto.WhisperResponse, error)
+	// ListByRelationship lists the whispers of any relationship the user was part of, e.g. an archived one
+	ListByRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID, limit, offset int64) ([]*dto.WhisperResponse, error)
 	Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.UpdateWhisperRequest) (*dto.WhisperResponse, error)
 	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
 	ConvertToEvent(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, img *dto.EventImagePayload) (*dto.EventResponse, error)
+	ListOccurrences(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*dto.WhisperOccurrenceResponse, error)
+	SetOccurrenceDone(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, date time.Time, done bool) (*dto.WhisperOccurrenceResponse, error)
+	Today(ctx context.Context, userID primitive.ObjectID) (*dto.WhisperDayResponse, error)
+	Upcoming(ctx context.Context, userID primitive.ObjectID, days int) ([]*dto.WhisperDayResponse, error)
 }
 
 type whisperUseCase struct {
 	repo      domainRepos.WhisperRepository
 	relRepo   domainRepos.RelationshipRepository
 	eventRepo domainRepos.EventRepository
+	userRepo  domainRepos.UserRepository
+	catalog   TypeCatalog
+	media     MediaUseCase
+	stats     StatsSyncer
 }
 
-func NewWhisperUseCase(repo domainRepos.WhisperRepository, relRepo domainRepos.RelationshipRepository, eventRepo domainRepos.EventRepository) WhisperUseCase {
-	return &whisperUseCase{repo: repo, relRepo: relRepo, eventRepo: eventRepo}
+func NewWhisperUseCase(repo domainRepos.WhisperRepository, relRepo domainRepos.RelationshipRepository, eventRepo domainRepos.EventRepository, userRepo domainRepos.UserRepository, catalog TypeCatalog, media MediaUseCase, stats StatsSyncer) WhisperUseCase {
+	return &whisperUseCase{repo: repo, relRepo: relRepo, eventRepo: eventRepo, userRepo: userRepo, catalog: catalog, media: media, stats: stats}
+}
+
+// whisper occurrence errors
+var (
+	ErrInvalidDateRange = fmtError("invalid date range")
+	ErrNotAnOccurrence  = fmtError("whisper does not occur on this date")
+)
+
+// maxOccurrenceRangeDays caps range queries so a single request stays cheap
+const maxOccurrenceRangeDays = 366
+
+// maxUpcomingDays caps the look-ahead of the upcoming view
+const maxUpcomingDays = 31
+
+// IsWhisperValidationError reports errors caused by client input rather than the server
+func IsWhisperValidationError(err error) bool {
+	return err == ErrInvalidDateRange || err == ErrNotAnOccurrence || err == ErrUnknownWhisperType ||
+		err == ErrWhisperTextRequired || errors.Is(err, entities.ErrInvalidRRule) || IsMediaValidationError(err)
 }
 
 func (uc *whisperUseCase) Create(ctx context.Context, userID primitive.ObjectID, req *dto.CreateWhisperRequest) (*dto.WhisperResponse, error) {
@@ -37,11 +67,45 @@ func (uc *whisperUseCase) Create(ctx context.Context, userID primitive.ObjectID,
 		log.Printf("[WHISPER][CREATE][ERROR] user=%s no current relationship: %v", userID.Hex(), err)
 		return nil, err
 	}
-	w := entities.NewWhisper(req.Type, req.Text, req.Recurrence, req.Date, rel.ID, userID)
+	def, err := uc.catalog.WhisperType(ctx, req.Type)
+	if err != nil {
+		log.Printf("[WHISPER][CREATE][INVALID] user=%s type=%s err=%v", userID.Hex(), req.Type, err)
+		return nil, err
+	}
+	creator, _ := uc.userRepo.FindByID(ctx, userID)
+	// Text and recurrence fall back to the catalog defaults, in the creator's language
+	text := req.Text
+	if text == "" {
+		if def.RequiresText {
+			return nil, ErrWhisperTextRequired
+		}
+		text = def.DefaultText(userLanguage(creator))
+	}
+	recurrence := req.Recurrence
+	if recurrence == "" {
+		recurrence = def.DefaultRecurrence
+	}
+	if recurrence == "" {
+		recurrence = entities.WhisperRecurrenceOnce
+	}
+	w := entities.NewWhisper(req.Type, text, recurrence, req.Date, rel.ID, userID)
+	if req.RRule != "" {
+		rule, err := entities.ParseRRule(req.RRule)
+		if err != nil {
+			log.Printf("[WHISPER][CREATE][INVALID] user=%s rrule=%q err=%v", userID.Hex(), req.RRule, err)
+			return nil, err
+		}
+		w.SetRule(rule)
+	}
+	// Day boundaries follow the creator's timezone so both partners see the same dates
+	if creator != nil && creator.Settings.Timezone != "" {
+		w.Timezone = creator.Settings.Timezone
+	}
 	if err := uc.repo.Create(ctx, w); err != nil {
 		log.Printf("[WHISPER][CREATE][ERROR] user=%s err=%v", userID.Hex(), err)
 		return nil, err
 	}
+	uc.stats.SyncRelationshipStats(ctx, rel.ID)
 	log.Printf("[WHISPER][CREATE][DONE] user=%s id=%s", userID.Hex(), w.ID.Hex())
 	return toWhisperResponse(w), nil
 }
@@ -66,6 +130,26 @@ func (uc *whisperUseCase) ListByCurrentRelationship(ctx context.Context, userID
 	return res, nil
 }
 
+func (uc *whisperUseCase) ListByRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID, limit, offset int64) ([]*dto.WhisperResponse, error) {
+	log.Printf("[WHISPER][LIST_ARCHIVE][START] user=%s rel=%s", userID.Hex(), relationshipID.Hex())
+	rel, err := findMemberRelationship(ctx, uc.relRepo, userID, relationshipID)
+	if err != nil {
+		log.Printf("[WHISPER][LIST_ARCHIVE][DENY] user=%s rel=%s", userID.Hex(), relationshipID.Hex())
+		return nil, err
+	}
+	list, err := uc.repo.FindAllByRelationshipID(ctx, rel.ID, limit, offset)
+	if err != nil {
+		log.Printf("[WHISPER][LIST_ARCHIVE][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	res := make([]*dto.WhisperResponse, 0, len(list))
+	for _, w := range list {
+		res = append(res, toWhisperResponse(w))
+	}
+	log.Printf("[WHISPER][LIST_ARCHIVE][DONE] user=%s count=%d", userID.Hex(), len(res))
+	return res, nil
+}
+
 func (uc *whisperUseCase) Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, req *dto.UpdateWhisperRequest) (*dto.WhisperResponse, error) {
 	log.Printf("[WHISPER][UPDATE][START] user=%s id=%s", userID.Hex(), id.Hex())
 	w, err := uc.repo.FindByID(ctx, id)
@@ -82,9 +166,27 @@ func (uc *whisperUseCase) Update(ctx context.Context, userID primitive.ObjectID,
 		dt = req.Date
 	}
 	w.Update(req.Text, req.Recurrence, dt)
+	if req.RRule != nil {
+		if *req.RRule == "" {
+			w.SetRule(nil)
+		} else {
+			rule, err := entities.ParseRRule(*req.RRule)
+			if err != nil {
+				return nil, err
+			}
+			w.SetRule(rule)
+		}
+	}
 	if req.IsDone != nil {
-		w.IsDone = *req.IsDone
-		w.UpdatedAt = time.Now()
+		// One-off whispers complete their single date; recurring ones complete today's occurrence
+		day := w.StartDate()
+		if w.IsRecurring() {
+			day = entities.DateOf(time.Now(), w.Location())
+			if !w.OccursOn(day) {
+				return nil, ErrNotAnOccurrence
+			}
+		}
+		w.SetOccurrenceDone(day, userID, *req.IsDone)
 	}
 	if err := uc.repo.Update(ctx, w); err != nil {
 		return nil, err
@@ -107,6 +209,7 @@ func (uc *whisperUseCase) Delete(ctx context.Context, userID primitive.ObjectID,
 	if err := uc.repo.Delete(ctx, id); err != nil {
 		return err
 	}
+	uc.stats.SyncRelationshipStats(ctx, w.RelationshipID)
 	log.Printf("[WHISPER][DELETE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
 	return nil
 }
@@ -128,28 +231,145 @@ func (uc *whisperUseCase) ConvertToEvent(ctx context.Context, userID primitive.O
 	ev := entities.NewEvent(w.Text, entities.EventTypeDate, now, w.RelationshipID, userID)
 	ev.Source = entities.EventSource{Type: entities.SourceTypeWhisperConverted}
 	if img != nil && img.Type != "" {
-		ev.Image = &entities.EventImage{
-			Type:       img.Type,
-			Data:       img.Data,
-			Filename:   img.Filename,
-			UploadedAt: time.Now(),
+		image, err := resolveEventImage(ctx, uc.media, userID, ev.ID, img)
+		if err != nil {
+			log.Printf("[WHISPER][CONVERT][INVALID] user=%s id=%s image err=%v", userID.Hex(), id.Hex(), err)
+			return nil, err
 		}
+		ev.Image = image
 	}
 	if err := uc.eventRepo.Create(ctx, ev); err != nil {
 		log.Printf("[WHISPER][CONVERT][ERROR] user=%s id=%s err=%v", userID.Hex(), id.Hex(), err)
+		if ev.Image != nil {
+			uc.media.Release(ctx, ev.Image.MediaID, ev.ID)
+		}
 		return nil, err
 	}
+	uc.stats.SyncRelationshipStats(ctx, w.RelationshipID)
 	log.Printf("[WHISPER][CONVERT][DONE] user=%s id=%s event=%s", userID.Hex(), id.Hex(), ev.ID.Hex())
-	return toEventResponse(ev), nil
+	return toEventResponse(ev, uc.media), nil
+}
+
+// ListOccurrences expands every whisper of the caller's relationship into the dated
+// occurrences that fall within the calendar range [from, to]
+func (uc *whisperUseCase) ListOccurrences(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*dto.WhisperOccurrenceResponse, error) {
+	log.Printf("[WHISPER][OCCURRENCES][START] user=%s from=%s to=%s", userID.Hex(), from.Format(entities.DateLayout), to.Format(entities.DateLayout))
+	if to.Before(from) || to.Sub(from) > maxOccurrenceRangeDays*24*time.Hour {
+		return nil, ErrInvalidDateRange
+	}
+	res, err := uc.occurrences(ctx, userID, from, to)
+	if err != nil {
+		log.Printf("[WHISPER][OCCURRENCES][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[WHISPER][OCCURRENCES][DONE] user=%s count=%d", userID.Hex(), len(res))
+	return res, nil
+}
+
+// SetOccurrenceDone records or clears completion of a single dated occurrence
+func (uc *whisperUseCase) SetOccurrenceDone(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, date time.Time, done bool) (*dto.WhisperOccurrenceResponse, error) {
+	log.Printf("[WHISPER][OCCURRENCE_DONE][START] user=%s id=%s date=%s done=%t", userID.Hex(), id.Hex(), date.Format(entities.DateLayout), done)
+	w, err := uc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Authorization: allow any partner in the same active relationship
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil || rel.ID != w.RelationshipID {
+		return nil, ErrForbidden
+	}
+	if !w.OccursOn(date) {
+		return nil, ErrNotAnOccurrence
+	}
+	w.SetOccurrenceDone(date, userID, done)
+	if err := uc.repo.Update(ctx, w); err != nil {
+		log.Printf("[WHISPER][OCCURRENCE_DONE][ERROR] id=%s err=%v", id.Hex(), err)
+		return nil, err
+	}
+	log.Printf("[WHISPER][OCCURRENCE_DONE][DONE] user=%s id=%s", userID.Hex(), id.Hex())
+	return toWhisperOccurrenceResponse(w, date), nil
+}
+
+// Today returns the whispers due today in the caller's timezone, split by completion
+func (uc *whisperUseCase) Today(ctx context.Context, userID primitive.ObjectID) (*dto.WhisperDayResponse, error) {
+	log.Printf("[WHISPER][TODAY][START] user=%s", userID.Hex())
+	tz := uc.userTimezone(ctx, userID)
+	today := entities.DateOf(time.Now(), entities.LoadLocation(tz))
+	list, err := uc.occurrences(ctx, userID, today, today)
+	if err != nil {
+		log.Printf("[WHISPER][TODAY][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	days := groupOccurrencesByDay(list, today, today, tz)
+	log.Printf("[WHISPER][TODAY][DONE] user=%s pending=%d done=%d", userID.Hex(), len(days[0].Pending), len(days[0].Done))
+	return days[0], nil
+}
+
+// Upcoming returns one entry per day for the next N days (starting tomorrow), including empty days
+func (uc *whisperUseCase) Upcoming(ctx context.Context, userID primitive.ObjectID, days int) ([]*dto.WhisperDayResponse, error) {
+	log.Printf("[WHISPER][UPCOMING][START] user=%s days=%d", userID.Hex(), days)
+	if days < 1 || days > maxUpcomingDays {
+		return nil, ErrInvalidDateRange
+	}
+	tz := uc.userTimezone(ctx, userID)
+	today := entities.DateOf(time.Now(), entities.LoadLocation(tz))
+	from, to := today.AddDate(0, 0, 1), today.AddDate(0, 0, days)
+	list, err := uc.occurrences(ctx, userID, from, to)
+	if err != nil {
+		log.Printf("[WHISPER][UPCOMING][ERROR] user=%s err=%v", userID.Hex(), err)
+		return nil, err
+	}
+	res := groupOccurrencesByDay(list, from, to, tz)
+	log.Printf("[WHISPER][UPCOMING][DONE] user=%s occurrences=%d", userID.Hex(), len(list))
+	return res, nil
+}
+
+// occurrences expands the whispers of the caller's relationship over [from, to], ordered by date
+func (uc *whisperUseCase) occurrences(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*dto.WhisperOccurrenceResponse, error) {
+	rel, err := uc.relRepo.FindCurrentByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := uc.repo.FindAllByRelationshipID(ctx, rel.ID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*dto.WhisperOccurrenceResponse, 0, len(list))
+	for _, w := range list {
+		for _, d := range w.Occurrences(from, to) {
+			res = append(res, toWhisperOccurrenceResponse(w, d))
+		}
+	}
+	// Stable so whispers keep repository order within a day
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Date < res[j].Date })
+	return res, nil
+}
+
+// userTimezone returns the user's configured IANA timezone, UTC when unknown
+func (uc *whisperUseCase) userTimezone(ctx context.Context, userID primitive.ObjectID) string {
+	u, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil || u.Settings.Timezone == "" {
+		return "UTC"
+	}
+	return u.Settings.Timezone
+}
+
+// userLanguage returns the user's preferred language, English when unknown
+func userLanguage(u *entities.User) string {
+	if u == nil || !entities.IsSupportedLanguage(u.Settings.Language) {
+		return entities.LanguageEnglish
+	}
+	return u.Settings.Language
 }
 
 // helpers
 func toWhisperResponse(w *entities.Whisper) *dto.WhisperResponse {
-	return &dto.WhisperResponse{
+	res := &dto.WhisperResponse{
 		ID:             w.ID.Hex(),
 		Type:           w.Type,
 		Text:           w.Text,
 		Recurrence:     w.Recurrence,
+		Timezone:       w.Timezone,
 		Date:           w.Date,
 		RelationshipID: w.RelationshipID.Hex(),
 		CreatedBy:      w.CreatedBy.Hex(),
@@ -157,4 +377,59 @@ func toWhisperResponse(w *entities.Whisper) *dto.WhisperResponse {
 		CreatedAt:      w.CreatedAt,
 		UpdatedAt:      w.UpdatedAt,
 	}
+	if w.Rule != nil {
+		res.RRule = w.Rule.String()
+	}
+	// For recurring whispers "done" means today's occurrence, not the whole series
+	if w.IsRecurring() {
+		res.IsDone = w.IsDoneOn(entities.DateOf(time.Now(), w.Location()))
+	}
+	return res
+}
+
+func groupOccurrencesByDay(list []*dto.WhisperOccurrenceResponse, from, to time.Time, tz string) []*dto.WhisperDayResponse {
+	var days []*dto.WhisperDayResponse
+	index := map[string]*dto.WhisperDayResponse{}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := &dto.WhisperDayResponse{
+			Date:     d.Format(entities.DateLayout),
+			Timezone: tz,
+			Pending:  []*dto.WhisperOccurrenceResponse{},
+			Done:     []*dto.WhisperOccurrenceResponse{},
+		}
+		days = append(days, day)
+		index[day.Date] = day
+	}
+	for _, o := range list {
+		day, ok := index[o.Date]
+		if !ok {
+			continue
+		}
+		if o.IsDone {
+			day.Done = append(day.Done, o)
+		} else {
+			day.Pending = append(day.Pending, o)
+		}
+	}
+	return days
+}
+
+func toWhisperOccurrenceResponse(w *entities.Whisper, date time.Time) *dto.WhisperOccurrenceResponse {
+	res := &dto.WhisperOccurrenceResponse{
+		WhisperID:  w.ID.Hex(),
+		Date:       date.Format(entities.DateLayout),
+		Type:       w.Type,
+		Text:       w.Text,
+		Recurrence: w.Recurrence,
+		IsDone:     w.IsDoneOn(date),
+	}
+	if w.Rule != nil {
+		res.RRule = w.Rule.String()
+	}
+	if c := w.CompletionOn(date); c != nil {
+		res.CompletedBy = c.CompletedBy.Hex()
+		completedAt := c.CompletedAt
+		res.CompletedAt = &completedAt
+	}
+	return res
 }
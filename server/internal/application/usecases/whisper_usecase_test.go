@@ -0,0 +1,230 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/memory"
+)
+
+type whisperFixture struct {
+	*relationshipFixture
+	testPeople
+	whispers repositories.WhisperRepository
+	events   repositories.EventRepository
+	uc       WhisperUseCase
+}
+
+func newWhisperFixture(t *testing.T) *whisperFixture {
+	t.Helper()
+	rf := newRelationshipFixture(nil)
+	f := &whisperFixture{
+		relationshipFixture: rf,
+		testPeople:          rf.addPeople(t),
+		whispers:            memory.NewWhisperRepository(rf.store),
+		events:              memory.NewEventRepository(rf.store),
+	}
+	f.uc = NewWhisperUseCase(f.whispers, rf.rels, f.events, rf.users, testCatalog{}, nil, nopStats{})
+	return f
+}
+
+// whisperDay is the date of the one-off whispers created by the tests
+var whisperDay = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func (f *whisperFixture) createWhisper(t *testing.T, userID primitive.ObjectID, text string, date time.Time) primitive.ObjectID {
+	t.Helper()
+	req := &dto.CreateWhisperRequest{Type: WhisperTypeCustom, Text: text, Recurrence: entities.WhisperRecurrenceOnce, Date: date}
+	res, err := f.uc.Create(context.Background(), userID, req)
+	if err != nil {
+		t.Fatalf("create whisper %q: %v", text, err)
+	}
+	id, err := primitive.ObjectIDFromHex(res.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCreateWhisper(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     func(f *whisperFixture) primitive.ObjectID
+		req      dto.CreateWhisperRequest
+		wantText string
+		wantErr  string
+	}{
+		{"catalog default text", func(f *whisperFixture) primitive.ObjectID { return f.alice },
+			dto.CreateWhisperRequest{Type: "watch_sunset"}, "Watch the sunset", ""},
+		{"custom text", func(f *whisperFixture) primitive.ObjectID { return f.bob },
+			dto.CreateWhisperRequest{Type: WhisperTypeCustom, Text: "Call grandma"}, "Call grandma", ""},
+		{"custom without text", func(f *whisperFixture) primitive.ObjectID { return f.alice },
+			dto.CreateWhisperRequest{Type: WhisperTypeCustom}, "", ErrWhisperTextRequired.Error()},
+		{"unknown type", func(f *whisperFixture) primitive.ObjectID { return f.alice },
+			dto.CreateWhisperRequest{Type: "skydive"}, "", ErrUnknownWhisperType.Error()},
+		{"no relationship", func(f *whisperFixture) primitive.ObjectID { return f.erin },
+			dto.CreateWhisperRequest{Type: "watch_sunset"}, "", "no active relationship"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newWhisperFixture(t)
+			req := tt.req
+			req.Date = whisperDay
+			res, err := f.uc.Create(context.Background(), tt.user(f), &req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Text != tt.wantText || res.Recurrence != entities.WhisperRecurrenceOnce || res.RelationshipID != f.relID.Hex() {
+				t.Errorf("whisper text=%q recurrence=%s relationship=%s, want %q, %s and %s",
+					res.Text, res.Recurrence, res.RelationshipID, tt.wantText, entities.WhisperRecurrenceOnce, f.relID.Hex())
+			}
+		})
+	}
+}
+
+func TestWhisperUseCaseAuthorization(t *testing.T) {
+	done := true
+	ops := []struct {
+		name string
+		call func(uc WhisperUseCase, userID, id primitive.ObjectID) error
+	}{
+		{"update", func(uc WhisperUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.Update(context.Background(), userID, id, &dto.UpdateWhisperRequest{Text: "Changed", IsDone: &done})
+			return err
+		}},
+		{"set occurrence done", func(uc WhisperUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.SetOccurrenceDone(context.Background(), userID, id, whisperDay, true)
+			return err
+		}},
+		{"convert to event", func(uc WhisperUseCase, userID, id primitive.ObjectID) error {
+			_, err := uc.ConvertToEvent(context.Background(), userID, id, nil)
+			return err
+		}},
+		{"delete", func(uc WhisperUseCase, userID, id primitive.ObjectID) error {
+			return uc.Delete(context.Background(), userID, id)
+		}},
+	}
+	actors := []struct {
+		name    string
+		user    func(f *whisperFixture) primitive.ObjectID
+		wantErr error
+	}{
+		{"author", func(f *whisperFixture) primitive.ObjectID { return f.alice }, nil},
+		{"partner", func(f *whisperFixture) primitive.ObjectID { return f.bob }, nil},
+		{"other couple", func(f *whisperFixture) primitive.ObjectID { return f.carol }, ErrForbidden},
+		{"single user", func(f *whisperFixture) primitive.ObjectID { return f.erin }, ErrForbidden},
+	}
+	for _, op := range ops {
+		for _, actor := range actors {
+			t.Run(op.name+"/"+actor.name, func(t *testing.T) {
+				ctx := context.Background()
+				f := newWhisperFixture(t)
+				id := f.createWhisper(t, f.alice, "Picnic", whisperDay)
+				err := op.call(f.uc, actor.user(f), id)
+				if !errors.Is(err, actor.wantErr) {
+					t.Fatalf("error = %v, want %v", err, actor.wantErr)
+				}
+				if actor.wantErr == nil {
+					return
+				}
+				// A denied call must leave the whisper as it was and create no event
+				w, err := f.whispers.FindByID(ctx, id)
+				if err != nil {
+					t.Fatalf("whisper gone after denied %s: %v", op.name, err)
+				}
+				if w.Text != "Picnic" || w.IsDone || len(w.Completions) != 0 {
+					t.Errorf("denied %s changed the whisper: text=%q done=%t completions=%d", op.name, w.Text, w.IsDone, len(w.Completions))
+				}
+				for _, relUser := range []primitive.ObjectID{f.alice, f.carol} {
+					if evs, _ := f.events.FindAllByUserID(ctx, relUser, 0, 0); len(evs) != 0 {
+						t.Errorf("denied %s created %d events", op.name, len(evs))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestConvertWhisperToEvent(t *testing.T) {
+	ctx := context.Background()
+	f := newWhisperFixture(t)
+	id := f.createWhisper(t, f.alice, "Stargazing", whisperDay)
+
+	res, err := f.uc.ConvertToEvent(ctx, f.bob, id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := f.events.FindAllByRelationshipID(ctx, f.relID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID.Hex() != res.ID {
+		t.Fatalf("relationship has %d events, want the converted one", len(events))
+	}
+	ev := events[0]
+	if ev.Title != "Stargazing" || ev.CreatedBy != f.bob || ev.Source.Type != entities.SourceTypeWhisperConverted {
+		t.Errorf("event title=%q createdBy=%s source=%s", ev.Title, ev.CreatedBy.Hex(), ev.Source.Type)
+	}
+}
+
+func TestWhisperListsOldestFirst(t *testing.T) {
+	ctx := context.Background()
+	f := newWhisperFixture(t)
+	for i, author := range []primitive.ObjectID{f.bob, f.alice, f.bob, f.alice} {
+		day := whisperDay.AddDate(0, 0, 3-i)
+		f.createWhisper(t, author, day.Format("Jan 2"), day)
+	}
+	f.createWhisper(t, f.carol, "Elsewhere", whisperDay)
+
+	tests := []struct {
+		name string
+		list func() ([]*dto.WhisperResponse, error)
+		want []string
+	}{
+		{"all", func() ([]*dto.WhisperResponse, error) {
+			return f.uc.ListByCurrentRelationship(ctx, f.alice, 0, 0)
+		}, []string{"Jun 1", "Jun 2", "Jun 3", "Jun 4"}},
+		{"page", func() ([]*dto.WhisperResponse, error) {
+			return f.uc.ListByCurrentRelationship(ctx, f.alice, 2, 1)
+		}, []string{"Jun 2", "Jun 3"}},
+		{"by relationship", func() ([]*dto.WhisperResponse, error) {
+			return f.uc.ListByRelationship(ctx, f.bob, f.relID, 1, 3)
+		}, []string{"Jun 4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := tt.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(list))
+			for _, w := range list {
+				got = append(got, w.Text)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("texts = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("texts = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := f.uc.ListByRelationship(ctx, f.carol, f.relID, 0, 0); !errors.Is(err, ErrRelationshipNotFound) {
+		t.Errorf("stranger list error = %v, want %v", err, ErrRelationshipNotFound)
+	}
+}
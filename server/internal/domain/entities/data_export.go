@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DataExport is a requested archive of everything stored about a user. It is built
+// in the background and kept in media storage until ExpiresAt.
+type DataExport struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	Status      string             `bson:"status" json:"status"`
+	Key         string             `bson:"key,omitempty" json:"-"` // storage key of the ZIP
+	Size        int64              `bson:"size,omitempty" json:"size,omitempty"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	StartedAt   *time.Time         `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // set once ready
+}
+
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusReady      = "ready"
+	DataExportStatusFailed     = "failed"
+)
+
+func NewDataExport(userID primitive.ObjectID) *DataExport {
+	id := primitive.NewObjectID()
+	return &DataExport{
+		ID:        id,
+		UserID:    userID,
+		Status:    DataExportStatusPending,
+		Key:       "exports/" + userID.Hex() + "/" + id.Hex() + ".zip",
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsAvailable reports whether the archive can still be downloaded
+func (e *DataExport) IsAvailable(now time.Time) bool {
+	return e.Status == DataExportStatusReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
@@ -36,17 +36,23 @@ type Event struct {
 }
 
 type EventImage struct {
-	Type       string    `bson:"type" json:"type"` // "base64" or "url"
-	Data       string    `bson:"data" json:"data"`
-	Filename   string    `bson:"filename" json:"filename"`
-	Size       int64     `bson:"size" json:"size"`
-	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
+	Type        string              `bson:"type" json:"type"`                           // "media", or legacy "base64"/"url"
+	Data        string              `bson:"data,omitempty" json:"data"`                 // base64 payload or external URL
+	MediaID     *primitive.ObjectID `bson:"mediaId,omitempty" json:"mediaId,omitempty"` // set for type "media"
+	Key         string              `bson:"key,omitempty" json:"key,omitempty"`         // storage key of the media object
+	ContentType string              `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	Variants    []MediaVariant      `bson:"variants,omitempty" json:"variants,omitempty"` // thumbnails of a media image
+	Filename    string              `bson:"filename" json:"filename"`
+	Size        int64               `bson:"size" json:"size"`
+	UploadedAt  time.Time           `bson:"uploadedAt" json:"uploadedAt"`
 }
 
 type EventVisibility struct {
-	IsPublic   bool                 `bson:"isPublic" json:"isPublic"`
-	IsShared   bool                 `bson:"isShared" json:"isShared"`
-	SharedWith []primitive.ObjectID `bson:"sharedWith" json:"sharedWith"`
+	IsPublic    bool                 `bson:"isPublic" json:"isPublic"`
+	IsAnonymous bool                 `bson:"isAnonymous" json:"isAnonymous"` // hide partner names in explore
+	PublishedAt *time.Time           `bson:"publishedAt,omitempty" json:"publishedAt,omitempty"`
+	IsShared    bool                 `bson:"isShared" json:"isShared"`
+	SharedWith  []primitive.ObjectID `bson:"sharedWith" json:"sharedWith"`
 }
 
 type EventSource struct {
@@ -155,12 +161,22 @@ func (e *Event) SetImage(image *EventImage) {
 }
 
 func (e *Event) MakePublic() {
+	now := time.Now()
+	if !e.Visibility.IsPublic || e.Visibility.PublishedAt == nil {
+		e.Visibility.PublishedAt = &now
+	}
 	e.Visibility.IsPublic = true
-	e.UpdatedAt = time.Now()
+	e.UpdatedAt = now
 }
 
 func (e *Event) MakePrivate() {
 	e.Visibility.IsPublic = false
+	e.Visibility.PublishedAt = nil
+	e.UpdatedAt = time.Now()
+}
+
+func (e *Event) SetAnonymous(anonymous bool) {
+	e.Visibility.IsAnonymous = anonymous
 	e.UpdatedAt = time.Now()
 }
 
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider. A
+// user has at most one identity per provider.
+type ExternalIdentity struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
+	Provider    string             `bson:"provider" json:"provider"`
+	Subject     string             `bson:"subject" json:"subject"` // stable user ID at the provider
+	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	LastLoginAt *time.Time         `bson:"lastLoginAt,omitempty" json:"lastLoginAt,omitempty"`
+}
+
+func NewExternalIdentity(userID primitive.ObjectID, provider, subject, email string) *ExternalIdentity {
+	return &ExternalIdentity{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+}
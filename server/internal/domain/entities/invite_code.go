@@ -7,14 +7,20 @@ import (
 )
 
 type InviteCode struct {
-    ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Code             string             `bson:"code" json:"code"`
-    CreatedBy        primitive.ObjectID `bson:"createdBy" json:"createdBy"`
-    FirstMeetingDate time.Time          `bson:"firstMeetingDate" json:"firstMeetingDate"`
-    IsUsed           bool               `bson:"isUsed" json:"isUsed"`
-    ExpiresAt        *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
-    CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
-    UpdatedAt        time.Time          `bson:"updatedAt" json:"updatedAt"`
+    ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+    Code             string              `bson:"code" json:"code"`
+    CreatedBy        primitive.ObjectID  `bson:"createdBy" json:"createdBy"`
+    FirstMeetingDate time.Time           `bson:"firstMeetingDate" json:"firstMeetingDate"`
+    IsUsed           bool                `bson:"isUsed" json:"isUsed"`
+    UsedBy           *primitive.ObjectID `bson:"usedBy,omitempty" json:"usedBy,omitempty"`
+    UsedAt           *time.Time          `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+    ExpiresAt        *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+    RevokedAt        *time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+    // Current marks the creator's latest code until it is used, revoked or
+    // replaced; a partial unique index allows one current code per creator
+    Current          bool                `bson:"current" json:"-"`
+    CreatedAt        time.Time           `bson:"createdAt" json:"createdAt"`
+    UpdatedAt        time.Time           `bson:"updatedAt" json:"updatedAt"`
 }
 
 func NewInviteCode(code string, createdBy primitive.ObjectID, firstMeetingDate time.Time, expiresAt *time.Time) *InviteCode {
@@ -26,8 +32,34 @@ func NewInviteCode(code string, createdBy primitive.ObjectID, firstMeetingDate t
         FirstMeetingDate: firstMeetingDate,
         IsUsed:           false,
         ExpiresAt:        expiresAt,
+        Current:          true,
         CreatedAt:        now,
         UpdatedAt:        now,
     }
 }
 
+
+// Invite code states as shown to their creator
+const (
+    InviteStatusActive  = "active"
+    InviteStatusUsed    = "used"
+    InviteStatusRevoked = "revoked"
+    InviteStatusExpired = "expired"
+)
+
+// Status reports whether the code can still be redeemed and why not
+func (i *InviteCode) Status(now time.Time) string {
+    switch {
+    case i.IsUsed:
+        return InviteStatusUsed
+    case i.RevokedAt != nil:
+        return InviteStatusRevoked
+    case i.ExpiresAt != nil && !now.Before(*i.ExpiresAt):
+        return InviteStatusExpired
+    }
+    return InviteStatusActive
+}
+
+func (i *InviteCode) IsActive(now time.Time) bool {
+    return i.Status(now) == InviteStatusActive
+}
@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// LoginAttempt counts recent failed logins for one throttling key: an account
+// ("user:<id>"), a login identifier without an account ("login:<name>") or a
+// client address ("ip:<addr>")
+type LoginAttempt struct {
+	Key           string     `bson:"_id" json:"key"`
+	Failures      int        `bson:"failures" json:"failures"`
+	LastFailureAt time.Time  `bson:"lastFailureAt" json:"lastFailureAt"`
+	LockedUntil   *time.Time `bson:"lockedUntil,omitempty" json:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time  `bson:"expiresAt" json:"expiresAt"` // record is dropped after this
+}
+
+// IsLocked reports whether logins for the key are currently refused
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Media is an uploaded file kept in media storage; events and avatars reference it by ID
+type Media struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	OwnerID     primitive.ObjectID  `bson:"ownerId" json:"ownerId"`
+	Kind        string              `bson:"kind" json:"kind"` // event_image, avatar
+	Key         string              `bson:"key" json:"key"`   // storage object key
+	ContentType string              `bson:"contentType" json:"contentType"`
+	Size        int64               `bson:"size" json:"size"`
+	Width       int                 `bson:"width,omitempty" json:"width,omitempty"`
+	Height      int                 `bson:"height,omitempty" json:"height,omitempty"`
+	Filename    string              `bson:"filename,omitempty" json:"filename,omitempty"`
+	Variants    []MediaVariant      `bson:"variants,omitempty" json:"variants,omitempty"` // downscaled renditions
+	AttachedTo  *primitive.ObjectID `bson:"attachedTo,omitempty" json:"-"`                // event or user referencing it
+	CreatedAt   time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// MediaVariant is a downscaled rendition of an image stored next to the original
+type MediaVariant struct {
+	Name        string `bson:"name" json:"name"` // small, medium
+	Key         string `bson:"key" json:"key"`
+	ContentType string `bson:"contentType" json:"contentType"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+	Size        int64  `bson:"size" json:"size"`
+}
+
+const (
+	MediaKindEventImage = "event_image"
+	MediaKindAvatar     = "avatar"
+)
+
+const (
+	MediaVariantSmall  = "small"
+	MediaVariantMedium = "medium"
+)
+
+// Image reference types stored on events and avatars
+const (
+	ImageTypeBase64 = "base64" // legacy inline data
+	ImageTypeURL    = "url"    // external URL
+	ImageTypeMedia  = "media"  // object in media storage
+)
+
+// ImageExtensions maps the accepted image content types to file extensions
+var ImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// MediaKey is the storage key of an original. Keys appear in signed URLs, also
+// on the public feed, so they name the media only and never its owner.
+func MediaKey(kind string, id primitive.ObjectID, contentType string) string {
+	return kind + "/" + id.Hex() + ImageExtensions[contentType]
+}
+
+func NewMedia(ownerID primitive.ObjectID, kind, contentType, filename string, size int64) *Media {
+	id := primitive.NewObjectID()
+	return &Media{
+		ID:          id,
+		OwnerID:     ownerID,
+		Kind:        kind,
+		Key:         MediaKey(kind, id, contentType),
+		ContentType: contentType,
+		Size:        size,
+		Filename:    filename,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// VariantKey derives the storage key of a rendition from the original's key
+func (m *Media) VariantKey(name, contentType string) string {
+	return m.Kind + "/" + m.ID.Hex() + "_" + name + ImageExtensions[contentType]
+}
+
+// AddVariant records a rendition stored under VariantKey
+func (m *Media) AddVariant(name, contentType string, width, height int, size int64) {
+	m.Variants = append(m.Variants, MediaVariant{
+		Name:        name,
+		Key:         m.VariantKey(name, contentType),
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+		Size:        size,
+	})
+}
+
+// Keys lists the storage keys of the original and all renditions
+func (m *Media) Keys() []string {
+	keys := []string{m.Key}
+	for _, v := range m.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OIDCState is a pending OpenID Connect sign-in, keyed by the hash of the state
+// parameter sent to the provider. It holds the PKCE verifier and nonce needed to
+// redeem the authorization code and is consumed by the callback. BrowserHash
+// ties it to the browser that started the sign-in: only a callback carrying
+// that browser's nonce cookie may redeem it.
+type OIDCState struct {
+	StateHash    string              `bson:"_id"`
+	Provider     string              `bson:"provider"`
+	CodeVerifier string              `bson:"codeVerifier"`
+	Nonce        string              `bson:"nonce"`
+	BrowserHash  string              `bson:"browserHash"`
+	LinkUserID   *primitive.ObjectID `bson:"linkUserId,omitempty"` // set when linking to a signed-in account
+	CreatedAt    time.Time           `bson:"createdAt"`
+	ExpiresAt    time.Time           `bson:"expiresAt"`
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// One-time token purposes
+const (
+	OneTimeTokenPasswordReset     = "password_reset"
+	OneTimeTokenEmailVerification = "email_verification"
+	OneTimeTokenAccountRestore    = "account_restore"
+)
+
+// OneTimeToken is a single-use secret mailed to a user; only its hash is stored
+type OneTimeToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+func NewOneTimeToken(userID primitive.ObjectID, purpose, tokenHash string, expiresAt time.Time) *OneTimeToken {
+	return &OneTimeToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsUsable reports whether the token can still be redeemed
+func (t *OneTimeToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,306 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecurrenceRule is a subset of the RFC 5545 RRULE: FREQ (DAILY, WEEKLY, MONTHLY),
+// INTERVAL, BYDAY (weekly only), BYMONTHDAY (monthly only), UNTIL and COUNT.
+// Weeks start on Monday (WKST=MO). Occurrences are calendar dates, not instants.
+type RecurrenceRule struct {
+	Freq       string     `bson:"freq" json:"freq"`
+	Interval   int        `bson:"interval,omitempty" json:"interval,omitempty"`
+	ByDay      []string   `bson:"byDay,omitempty" json:"byDay,omitempty"`           // MO, TU, WE, TH, FR, SA, SU
+	ByMonthDay []int      `bson:"byMonthDay,omitempty" json:"byMonthDay,omitempty"` // 1..31, or -1..-31 counted from month end
+	Until      *time.Time `bson:"until,omitempty" json:"until,omitempty"`           // inclusive calendar date
+	Count      int        `bson:"count,omitempty" json:"count,omitempty"`
+}
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// DateLayout is the wire and storage format of an occurrence date
+const DateLayout = "2006-01-02"
+
+// maxOccurrenceScan bounds how far ahead of the start date a rule is evaluated (~30 years)
+const maxOccurrenceScan = 366 * 30
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var ErrInvalidRRule = errors.New("invalid recurrence rule")
+
+// WeekdayCode returns the RRULE two letter code of a weekday
+func WeekdayCode(d time.Weekday) string {
+	for code, wd := range weekdayCodes {
+		if wd == d {
+			return code
+		}
+	}
+	return ""
+}
+
+// DateOf returns the calendar date of t in loc, as midnight UTC
+func DateOf(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// LoadLocation resolves an IANA timezone name, falling back to UTC when it is
+// empty or unknown
+func LoadLocation(tz string) *time.Location {
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ParseDate parses a YYYY-MM-DD calendar date
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, s, time.UTC)
+}
+
+// ParseRRule parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10".
+// A leading "RRULE:" is accepted. Unsupported parts are rejected rather than ignored.
+func ParseRRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRRule)
+	}
+	r := &RecurrenceRule{}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRRule)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				if _, ok := weekdayCodes[code]; !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRRule, code)
+				}
+				r.ByDay = append(r.ByDay, code)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY out of range", ErrInvalidRRule)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "UNTIL":
+			// Only the date part matters since occurrences are whole days
+			if len(value) < 8 {
+				return nil, fmt.Errorf("%w: UNTIL must be YYYYMMDD", ErrInvalidRRule)
+			}
+			until, err := time.ParseInLocation("20060102", value[:8], time.UTC)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be YYYYMMDD", ErrInvalidRRule)
+			}
+			r.Until = &until
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRRule)
+			}
+			r.Count = n
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRRule, key)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RecurrenceRule) Validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	case "":
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	default:
+		return fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRRule, r.Freq)
+	}
+	if len(r.ByDay) > 0 && r.Freq != FreqWeekly {
+		return fmt.Errorf("%w: BYDAY is only supported with FREQ=WEEKLY", ErrInvalidRRule)
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != FreqMonthly {
+		return fmt.Errorf("%w: BYMONTHDAY is only supported with FREQ=MONTHLY", ErrInvalidRRule)
+	}
+	if r.Until != nil && r.Count > 0 {
+		return fmt.Errorf("%w: UNTIL and COUNT are mutually exclusive", ErrInvalidRRule)
+	}
+	return nil
+}
+
+// String formats the rule back into RRULE syntax (without the "RRULE:" prefix)
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Summary maps the rule to the coarse recurrence label stored on whispers
+func (r *RecurrenceRule) Summary() string {
+	if r.Interval > 1 {
+		return WhisperRecurrenceCustom
+	}
+	switch r.Freq {
+	case FreqDaily:
+		return WhisperRecurrenceEveryday
+	case FreqWeekly:
+		if len(r.ByDay) <= 1 {
+			return WhisperRecurrenceWeekly
+		}
+	case FreqMonthly:
+		if len(r.ByMonthDay) <= 1 {
+			return WhisperRecurrenceMonthly
+		}
+	}
+	return WhisperRecurrenceCustom
+}
+
+// Occurrences expands the rule anchored at the calendar date start and returns the
+// occurrence dates falling within [from, to]. All dates are midnight UTC as built by DateOf.
+func (r *RecurrenceRule) Occurrences(start, from, to time.Time) []time.Time {
+	var out []time.Time
+	if to.Before(start) || to.Before(from) {
+		return out
+	}
+	end := to
+	if r.Until != nil && r.Until.Before(end) {
+		end = *r.Until
+	}
+	// Without COUNT, matching depends only on start and the date itself, so
+	// earlier days need not be visited
+	d := start
+	if r.Count == 0 && from.After(start) {
+		d = from
+	}
+	n := 0
+	for i := 0; !d.After(end) && i < maxOccurrenceScan; d, i = d.AddDate(0, 0, 1), i+1 {
+		if !r.matches(start, d) {
+			continue
+		}
+		n++
+		if r.Count > 0 && n > r.Count {
+			break
+		}
+		if !d.Before(from) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (r *RecurrenceRule) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// matches reports whether calendar date d (on or after start) is produced by the rule
+func (r *RecurrenceRule) matches(start, d time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		days := int(d.Sub(start).Hours() / 24)
+		return days%r.interval() == 0
+	case FreqWeekly:
+		weeks := int(weekStart(d).Sub(weekStart(start)).Hours() / (24 * 7))
+		if weeks%r.interval() != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return d.Weekday() == start.Weekday()
+		}
+		for _, code := range r.ByDay {
+			if weekdayCodes[code] == d.Weekday() {
+				return true
+			}
+		}
+		return false
+	case FreqMonthly:
+		months := (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+		if months%r.interval() != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) == 0 {
+			return d.Day() == start.Day()
+		}
+		last := daysIn(d.Year(), d.Month())
+		for _, md := range r.ByMonthDay {
+			if md > 0 && d.Day() == md {
+				return true
+			}
+			if md < 0 && d.Day() == last+md+1 {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// weekStart returns the Monday on or before d
+func weekStart(d time.Time) time.Time {
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,185 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func dates(ds []time.Time) string {
+	s := make([]string, len(ds))
+	for i, d := range ds {
+		s[i] = d.Format(DateLayout)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		want     string
+	}{
+		{"daily", "FREQ=DAILY", date(2025, time.March, 1), date(2025, time.March, 1), date(2025, time.March, 4),
+			"2025-03-01 2025-03-02 2025-03-03 2025-03-04"},
+		{"every other day from a later window", "FREQ=DAILY;INTERVAL=2", date(2025, time.March, 1), date(2025, time.March, 4), date(2025, time.March, 9),
+			"2025-03-05 2025-03-07 2025-03-09"},
+		{"weekly on the start weekday", "FREQ=WEEKLY", date(2025, time.March, 5), date(2025, time.March, 1), date(2025, time.March, 31),
+			"2025-03-05 2025-03-12 2025-03-19 2025-03-26"},
+		{"weekly on listed days", "FREQ=WEEKLY;BYDAY=MO,FR", date(2025, time.March, 5), date(2025, time.March, 1), date(2025, time.March, 16),
+			"2025-03-07 2025-03-10 2025-03-14"},
+		// Weeks start on Monday: the Sunday after the start is still in the first week
+		{"fortnightly", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SU", date(2025, time.March, 4), date(2025, time.March, 1), date(2025, time.March, 31),
+			"2025-03-04 2025-03-09 2025-03-18 2025-03-23"},
+		{"monthly on the start day", "FREQ=MONTHLY", date(2025, time.January, 15), date(2025, time.January, 1), date(2025, time.April, 30),
+			"2025-01-15 2025-02-15 2025-03-15 2025-04-15"},
+		{"monthly on the 31st skips short months", "FREQ=MONTHLY", date(2025, time.January, 31), date(2025, time.January, 1), date(2025, time.May, 31),
+			"2025-01-31 2025-03-31 2025-05-31"},
+		{"month end", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2024, time.January, 10), date(2024, time.January, 1), date(2024, time.April, 30),
+			"2024-01-31 2024-02-29 2024-03-31 2024-04-30"},
+		{"second to last day of a common february", "FREQ=MONTHLY;BYMONTHDAY=-2", date(2025, time.February, 1), date(2025, time.February, 1), date(2025, time.March, 31),
+			"2025-02-27 2025-03-30"},
+		{"quarterly on two days", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1,15", date(2025, time.January, 1), date(2025, time.January, 1), date(2025, time.July, 1),
+			"2025-01-01 2025-01-15 2025-04-01 2025-04-15 2025-07-01"},
+		{"until is inclusive", "FREQ=DAILY;UNTIL=20250303", date(2025, time.March, 1), date(2025, time.March, 1), date(2025, time.March, 10),
+			"2025-03-01 2025-03-02 2025-03-03"},
+		{"until with a time part", "FREQ=WEEKLY;UNTIL=20250312T235959Z", date(2025, time.March, 5), date(2025, time.March, 1), date(2025, time.March, 31),
+			"2025-03-05 2025-03-12"},
+		{"count", "FREQ=WEEKLY;COUNT=3", date(2025, time.March, 5), date(2025, time.March, 1), date(2025, time.April, 30),
+			"2025-03-05 2025-03-12 2025-03-19"},
+		// COUNT is counted from the start, not from the window
+		{"count in a later window", "FREQ=DAILY;COUNT=5", date(2025, time.March, 1), date(2025, time.March, 4), date(2025, time.March, 10),
+			"2025-03-04 2025-03-05"},
+		{"window before start", "FREQ=DAILY", date(2025, time.March, 10), date(2025, time.March, 1), date(2025, time.March, 5), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dates(rule.Occurrences(tt.start, tt.from, tt.to)); got != tt.want {
+				t.Errorf("occurrences = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // String() of the parsed rule; empty when invalid
+		summary string
+	}{
+		{"RRULE:FREQ=DAILY", "FREQ=DAILY", WhisperRecurrenceEveryday},
+		{"freq=weekly;byday=mo", "FREQ=WEEKLY;BYDAY=MO", WhisperRecurrenceWeekly},
+		{"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", WhisperRecurrenceCustom},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20251231", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20251231", WhisperRecurrenceMonthly},
+		{"FREQ=DAILY;INTERVAL=2", "FREQ=DAILY;INTERVAL=2", WhisperRecurrenceCustom},
+		{"", "", ""},
+		{"FREQ=YEARLY", "", ""},
+		{"FREQ=DAILY;BYDAY=MO", "", ""},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", "", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", "", ""},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20250101", "", ""},
+		{"FREQ=DAILY;INTERVAL=0", "", ""},
+		{"FREQ=DAILY;BYHOUR=9", "", ""},
+		{"INTERVAL=2", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			rule, err := ParseRRule(tt.in)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidRRule) {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidRRule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := rule.Summary(); got != tt.summary {
+				t.Errorf("Summary() = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+}
+
+func TestWhisperOccurrencesAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	berlin := mustLoad(t, "Europe/Berlin")
+	tests := []struct {
+		name     string
+		tz       string
+		date     time.Time
+		rule     string
+		from, to time.Time
+		want     string
+	}{
+		// Clocks go forward on March 9, 2025 in New York; the created instant is
+		// still March 8 there although it is March 9 in UTC
+		{"daily over spring forward", "America/New_York", time.Date(2025, time.March, 8, 23, 30, 0, 0, newYork), "FREQ=DAILY",
+			date(2025, time.March, 7), date(2025, time.March, 11), "2025-03-08 2025-03-09 2025-03-10 2025-03-11"},
+		// Clocks go back on October 26, 2025 in Berlin
+		{"weekly over fall back", "Europe/Berlin", time.Date(2025, time.October, 19, 0, 30, 0, 0, berlin), "FREQ=WEEKLY",
+			date(2025, time.October, 1), date(2025, time.November, 3), "2025-10-19 2025-10-26 2025-11-02"},
+		{"once at local midnight", "Europe/Berlin", time.Date(2025, time.March, 30, 0, 0, 0, 0, berlin), "",
+			date(2025, time.March, 29), date(2025, time.March, 31), "2025-03-30"},
+		{"unknown zone reads as UTC", "Mars/Olympus", time.Date(2025, time.March, 8, 23, 30, 0, 0, newYork), "FREQ=DAILY",
+			date(2025, time.March, 8), date(2025, time.March, 10), "2025-03-09 2025-03-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Whisper{Timezone: tt.tz, Date: tt.date, Recurrence: WhisperRecurrenceOnce}
+			if tt.rule != "" {
+				rule, err := ParseRRule(tt.rule)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.SetRule(rule)
+			}
+			if got := dates(w.Occurrences(tt.from, tt.to)); got != tt.want {
+				t.Errorf("occurrences = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhisperCompletedOccurrences(t *testing.T) {
+	day := date(2025, time.March, 1)
+	tests := []struct {
+		name       string
+		recurrence string
+		doneDays   int
+		legacyDone bool // IsDone set without a completion, as before per-occurrence tracking
+		want       int
+	}{
+		{"open one-off", WhisperRecurrenceOnce, 0, false, 0},
+		{"done one-off", WhisperRecurrenceOnce, 1, false, 1},
+		{"legacy done one-off", WhisperRecurrenceOnce, 0, true, 1},
+		{"recurring counts each day", WhisperRecurrenceEveryday, 3, false, 3},
+		{"recurring ignores a stale flag", WhisperRecurrenceWeekly, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Whisper{Recurrence: tt.recurrence, Date: day}
+			for i := 0; i < tt.doneDays; i++ {
+				w.SetOccurrenceDone(day.AddDate(0, 0, i), primitive.NewObjectID(), true)
+			}
+			if tt.legacyDone {
+				w.IsDone = true
+			}
+			if got := w.CompletedOccurrences(); got != tt.want {
+				t.Errorf("CompletedOccurrences = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is the server-side record of an issued refresh token; its ID is the
+// token's jti. Every rotation issues a new record in the same session so that reuse
+// of an already rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID         primitive.ObjectID  `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID  `bson:"userId" json:"userId"`
+	SessionID  primitive.ObjectID  `bson:"sessionId" json:"sessionId"` // shared by all rotations of one login
+	ExpiresAt  time.Time           `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+	RotatedAt  *time.Time          `bson:"rotatedAt,omitempty" json:"rotatedAt,omitempty"`
+	ReplacedBy *primitive.ObjectID `bson:"replacedBy,omitempty" json:"replacedBy,omitempty"`
+	RevokedAt  *time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+func NewRefreshToken(userID, sessionID primitive.ObjectID, expiresAt time.Time) *RefreshToken {
+	return &RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsRotated reports whether the token was already exchanged for a new one
+func (t *RefreshToken) IsRotated() bool {
+	return t.ReplacedBy != nil
+}
+
+// IsActive reports whether the token can still be exchanged
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && t.ReplacedBy == nil && now.Before(t.ExpiresAt)
+}
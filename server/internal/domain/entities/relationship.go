@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,20 +13,92 @@ const (
 	RelationshipStatusPending      RelationshipStatus = "pending"
 	RelationshipStatusActive       RelationshipStatus = "active"
 	RelationshipStatusDisconnected RelationshipStatus = "disconnected"
+	// RelationshipStatusDisconnectRequested is an active relationship one partner asked to end
+	RelationshipStatusDisconnectRequested RelationshipStatus = "disconnect_requested"
 )
 
+// CurrentRelationshipStatuses are the statuses in which partners still share the relationship
+var CurrentRelationshipStatuses = []RelationshipStatus{RelationshipStatusActive, RelationshipStatusDisconnectRequested}
+
+// IsCurrent reports whether partners still share the relationship
+func (s RelationshipStatus) IsCurrent() bool {
+	return s == RelationshipStatusActive || s == RelationshipStatusDisconnectRequested
+}
+
+// Disconnect request states
+const (
+	DisconnectRequested     = "requested"
+	DisconnectConfirmed     = "confirmed"
+	DisconnectCancelled     = "cancelled"
+	DisconnectAutoConfirmed = "auto_confirmed"
+)
+
+var (
+	ErrDisconnectAlreadyRequested = errors.New("disconnect already requested")
+	ErrNoDisconnectRequest        = errors.New("no pending disconnect request")
+	ErrNotDisconnectRequester     = errors.New("only the requesting partner can cancel")
+	ErrCannotConfirmOwnRequest    = errors.New("the other partner has to confirm")
+	ErrUndoWindowClosed           = errors.New("disconnect request can no longer be cancelled")
+)
+
+// DisconnectRequest tracks one partner's request to end the relationship. The
+// requester may undo it until UndoUntil; the other partner confirms it, or it
+// is confirmed automatically at AutoConfirmAt.
+type DisconnectRequest struct {
+	RequestedBy   primitive.ObjectID `bson:"requestedBy" json:"requestedBy"`
+	RequestedAt   time.Time          `bson:"requestedAt" json:"requestedAt"`
+	UndoUntil     time.Time          `bson:"undoUntil" json:"undoUntil"`
+	AutoConfirmAt time.Time          `bson:"autoConfirmAt" json:"autoConfirmAt"`
+	State         string             `bson:"state" json:"state"`
+	ResolvedAt    *time.Time         `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+}
+
 type RelationshipPartner struct {
 	UserID   primitive.ObjectID `bson:"userId" json:"userId"`
 	JoinedAt time.Time          `bson:"joinedAt" json:"joinedAt"`
 }
 
+// RelationshipSchemaVersion is the shape of relationship documents written by this
+// code; older documents are upgraded by a database migration
+const RelationshipSchemaVersion = 2
+
 type Relationship struct {
-	ID         primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
-	Partners   []RelationshipPartner `bson:"partners" json:"partners"`
-	Status     RelationshipStatus    `bson:"status" json:"status"`
-	InviteCode string                `bson:"inviteCode,omitempty" json:"inviteCode,omitempty"`
-	CreatedAt  time.Time             `bson:"createdAt" json:"createdAt"`
-	UpdatedAt  time.Time             `bson:"updatedAt" json:"updatedAt"`
+	ID       primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	Partners []RelationshipPartner `bson:"partners" json:"partners"`
+	// Users mirrors the partners' IDs; the collection validator requires it
+	Users      []primitive.ObjectID `bson:"users" json:"-"`
+	Status     RelationshipStatus   `bson:"status" json:"status"`
+	InviteCode string               `bson:"inviteCode,omitempty" json:"inviteCode,omitempty"`
+	// FirstMeetingDate is the day the couple met, as given by the inviter;
+	// relationship days and anniversaries count from it
+	FirstMeetingDate time.Time `bson:"firstMeetingDate" json:"firstMeetingDate"`
+	// Set while disconnected; not omitempty so Update clears them again on reconnect
+	DisconnectedAt       *time.Time          `bson:"disconnectedAt" json:"disconnectedAt,omitempty"`
+	ReconnectRequestedBy *primitive.ObjectID `bson:"reconnectRequestedBy" json:"reconnectRequestedBy,omitempty"`
+	ReconnectRequestedAt *time.Time          `bson:"reconnectRequestedAt" json:"reconnectRequestedAt,omitempty"`
+	// Latest disconnect request, kept after it is resolved
+	DisconnectRequest *DisconnectRequest `bson:"disconnectRequest" json:"disconnectRequest,omitempty"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+	SchemaVersion     int                `bson:"schemaVersion" json:"-"`
+}
+
+// NewRelationship starts an active relationship between partners who joined now
+func NewRelationship(partners []primitive.ObjectID, inviteCode string, firstMeetingDate time.Time) *Relationship {
+	now := time.Now()
+	r := &Relationship{
+		Status:           RelationshipStatusActive,
+		InviteCode:       inviteCode,
+		FirstMeetingDate: firstMeetingDate,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		SchemaVersion:    RelationshipSchemaVersion,
+	}
+	for _, id := range partners {
+		r.Partners = append(r.Partners, RelationshipPartner{UserID: id, JoinedAt: now})
+	}
+	r.Users = r.PartnerIDs()
+	return r
 }
 
 func NewPendingRelationship(inviter primitive.ObjectID, inviteCode string) *Relationship {
@@ -35,10 +108,12 @@ func NewPendingRelationship(inviter primitive.ObjectID, inviteCode string) *Rela
 		Partners: []RelationshipPartner{
 			{UserID: inviter, JoinedAt: now},
 		},
-		Status:     RelationshipStatusPending,
-		InviteCode: inviteCode,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		Users:         []primitive.ObjectID{inviter},
+		Status:        RelationshipStatusPending,
+		InviteCode:    inviteCode,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		SchemaVersion: RelationshipSchemaVersion,
 	}
 }
 
@@ -51,11 +126,203 @@ func (r *Relationship) ActivateWithPartner(partnerID primitive.ObjectID) {
 		}
 	}
 	r.Partners = append(r.Partners, RelationshipPartner{UserID: partnerID, JoinedAt: now})
+	r.Users = r.PartnerIDs()
 	r.Status = RelationshipStatusActive
 	r.UpdatedAt = now
 }
 
 func (r *Relationship) Disconnect() {
+	now := time.Now()
 	r.Status = RelationshipStatusDisconnected
+	r.DisconnectedAt = &now
+	r.ReconnectRequestedBy = nil
+	r.ReconnectRequestedAt = nil
+	r.UpdatedAt = now
+}
+
+// RequestDisconnect starts the disconnect consent flow for an active relationship
+func (r *Relationship) RequestDisconnect(userID primitive.ObjectID, undoWindow, autoConfirmAfter time.Duration) error {
+	if r.Status == RelationshipStatusDisconnectRequested {
+		return ErrDisconnectAlreadyRequested
+	}
+	now := time.Now()
+	r.Status = RelationshipStatusDisconnectRequested
+	r.DisconnectRequest = &DisconnectRequest{
+		RequestedBy:   userID,
+		RequestedAt:   now,
+		UndoUntil:     now.Add(undoWindow),
+		AutoConfirmAt: now.Add(autoConfirmAfter),
+		State:         DisconnectRequested,
+	}
+	r.UpdatedAt = now
+	return nil
+}
+
+// CancelDisconnect lets the requester take the request back during the cooling-off window
+func (r *Relationship) CancelDisconnect(userID primitive.ObjectID, now time.Time) error {
+	req, err := r.pendingDisconnect()
+	if err != nil {
+		return err
+	}
+	if req.RequestedBy != userID {
+		return ErrNotDisconnectRequester
+	}
+	if !now.Before(req.UndoUntil) {
+		return ErrUndoWindowClosed
+	}
+	r.Status = RelationshipStatusActive
+	r.resolveDisconnect(DisconnectCancelled, now)
+	return nil
+}
+
+// ConfirmDisconnect records the other partner's consent and ends the relationship
+func (r *Relationship) ConfirmDisconnect(userID primitive.ObjectID, now time.Time) error {
+	req, err := r.pendingDisconnect()
+	if err != nil {
+		return err
+	}
+	if req.RequestedBy == userID {
+		return ErrCannotConfirmOwnRequest
+	}
+	r.Disconnect()
+	r.resolveDisconnect(DisconnectConfirmed, now)
+	return nil
+}
+
+// AutoConfirmDisconnect ends the relationship once the request went unanswered
+// past AutoConfirmAt; it reports whether it did
+func (r *Relationship) AutoConfirmDisconnect(now time.Time) bool {
+	req, err := r.pendingDisconnect()
+	if err != nil || now.Before(req.AutoConfirmAt) {
+		return false
+	}
+	r.Disconnect()
+	r.resolveDisconnect(DisconnectAutoConfirmed, now)
+	return true
+}
+
+func (r *Relationship) pendingDisconnect() (*DisconnectRequest, error) {
+	if r.Status != RelationshipStatusDisconnectRequested || r.DisconnectRequest == nil {
+		return nil, ErrNoDisconnectRequest
+	}
+	return r.DisconnectRequest, nil
+}
+
+func (r *Relationship) resolveDisconnect(state string, now time.Time) {
+	r.DisconnectRequest.State = state
+	r.DisconnectRequest.ResolvedAt = &now
+	r.UpdatedAt = now
+}
+
+// PartnerIDs lists the partners' user IDs in join order
+func (r *Relationship) PartnerIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(r.Partners))
+	for _, p := range r.Partners {
+		ids = append(ids, p.UserID)
+	}
+	return ids
+}
+
+// Since is the date the relationship counts from: the first meeting date, or
+// the earliest join date for relationships where it is unknown
+func (r *Relationship) Since() time.Time {
+	if !r.FirstMeetingDate.IsZero() {
+		return r.FirstMeetingDate
+	}
+	var earliest time.Time
+	for _, p := range r.Partners {
+		if earliest.IsZero() || p.JoinedAt.Before(earliest) {
+			earliest = p.JoinedAt
+		}
+	}
+	return earliest
+}
+
+// DaysTogether counts whole days since the relationship started
+func (r *Relationship) DaysTogether(now time.Time) int {
+	return DaysSince(r.Since(), now)
+}
+
+// StartDate is the calendar day the relationship counts from. FirstMeetingDate
+// is stored as midnight UTC of that day; a join date is an instant and is read
+// in UTC.
+func (r *Relationship) StartDate() time.Time {
+	return DateOf(r.Since(), time.UTC)
+}
+
+// Anniversary returns the calendar date of the n-th anniversary as midnight UTC.
+// Couples who met on February 29 celebrate on February 28 in common years.
+func (r *Relationship) Anniversary(n int) time.Time {
+	start := r.StartDate()
+	year, month, day := start.Year()+n, start.Month(), start.Day()
+	if month == time.February && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// YearsTogether counts the anniversaries reached by now, taking the day to
+// change at midnight in loc, the couple's timezone
+func (r *Relationship) YearsTogether(now time.Time, loc *time.Location) int {
+	if r.Since().IsZero() {
+		return 0
+	}
+	start, today := r.StartDate(), DateOf(now, loc)
+	if today.Before(start) {
+		return 0
+	}
+	years := today.Year() - start.Year()
+	if years > 0 && today.Before(r.Anniversary(years)) {
+		years--
+	}
+	return years
+}
+
+// NextAnniversary returns the first anniversary after today in loc
+func (r *Relationship) NextAnniversary(now time.Time, loc *time.Location) time.Time {
+	return r.Anniversary(r.YearsTogether(now, loc) + 1)
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// HasPartner reports whether the user is (or was) part of the relationship
+func (r *Relationship) HasPartner(userID primitive.ObjectID) bool {
+	for _, p := range r.Partners {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestReconnect records that the user wants a disconnected relationship back.
+// When the other partner already asked, the relationship becomes active again
+// and true is returned.
+func (r *Relationship) RequestReconnect(userID primitive.ObjectID) bool {
+	now := time.Now()
+	r.UpdatedAt = now
+	if r.ReconnectRequestedBy != nil && *r.ReconnectRequestedBy != userID {
+		r.Status = RelationshipStatusActive
+		r.DisconnectedAt = nil
+		r.DisconnectRequest = nil
+		r.ReconnectRequestedBy = nil
+		r.ReconnectRequestedAt = nil
+		return true
+	}
+	r.ReconnectRequestedBy = &userID
+	r.ReconnectRequestedAt = &now
+	return false
+}
+
+// CancelReconnect withdraws the user's own reconnect request
+func (r *Relationship) CancelReconnect(userID primitive.ObjectID) bool {
+	if r.ReconnectRequestedBy == nil || *r.ReconnectRequestedBy != userID {
+		return false
+	}
+	r.ReconnectRequestedBy = nil
+	r.ReconnectRequestedAt = nil
 	r.UpdatedAt = time.Now()
+	return true
 }
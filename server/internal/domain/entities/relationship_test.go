@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone data for %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRelationshipAnniversaries(t *testing.T) {
+	tehran := mustLoad(t, "Asia/Tehran")
+	newYork := mustLoad(t, "America/New_York")
+	tests := []struct {
+		name      string
+		met       time.Time
+		now       time.Time
+		loc       *time.Location
+		wantYears int
+		wantNext  time.Time
+	}{
+		{"before the first anniversary", date(2020, time.June, 15), date(2021, time.June, 14), time.UTC, 0, date(2021, time.June, 15)},
+		{"on the anniversary", date(2020, time.June, 15), date(2021, time.June, 15), time.UTC, 1, date(2022, time.June, 15)},
+		{"day after", date(2020, time.June, 15), date(2021, time.June, 16), time.UTC, 1, date(2022, time.June, 15)},
+		{"same day they met", date(2020, time.June, 15), date(2020, time.June, 15).Add(20 * time.Hour), time.UTC, 0, date(2021, time.June, 15)},
+		{"not yet met", date(2020, time.June, 15), date(2019, time.January, 1), time.UTC, 0, date(2021, time.June, 15)},
+		{"leap day in a common year", date(2020, time.February, 29), date(2021, time.February, 28), time.UTC, 1, date(2022, time.February, 28)},
+		{"leap day the day before", date(2020, time.February, 29), date(2021, time.February, 27), time.UTC, 0, date(2021, time.February, 28)},
+		{"leap day in a leap year", date(2020, time.February, 29), date(2024, time.February, 29), time.UTC, 4, date(2025, time.February, 28)},
+		{"leap day before it in a leap year", date(2020, time.February, 29), date(2024, time.February, 28), time.UTC, 3, date(2024, time.February, 29)},
+		{"new year's eve", date(2019, time.December, 31), date(2020, time.December, 31), time.UTC, 1, date(2021, time.December, 31)},
+		{"new year's day after new year's eve", date(2019, time.December, 31), date(2021, time.January, 1), time.UTC, 1, date(2021, time.December, 31)},
+		{"new year's day", date(2020, time.January, 1), date(2020, time.December, 31), time.UTC, 0, date(2021, time.January, 1)},
+		// 21:00 UTC on June 14 is already June 15 in Tehran, and still June 14 in New York
+		{"anniversary already began east of UTC", date(2020, time.June, 15), time.Date(2021, time.June, 14, 21, 0, 0, 0, time.UTC), tehran, 1, date(2022, time.June, 15)},
+		{"anniversary not yet west of UTC", date(2020, time.June, 15), time.Date(2021, time.June, 15, 2, 0, 0, 0, time.UTC), newYork, 0, date(2021, time.June, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Relationship{FirstMeetingDate: tt.met}
+			if got := r.YearsTogether(tt.now, tt.loc); got != tt.wantYears {
+				t.Errorf("YearsTogether = %d, want %d", got, tt.wantYears)
+			}
+			if got := r.NextAnniversary(tt.now, tt.loc); !got.Equal(tt.wantNext) {
+				t.Errorf("NextAnniversary = %s, want %s", got.Format(DateLayout), tt.wantNext.Format(DateLayout))
+			}
+		})
+	}
+}
+
+func TestRelationshipStartDate(t *testing.T) {
+	joined := time.Date(2021, time.March, 3, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rel  Relationship
+		want time.Time
+	}{
+		{"first meeting date", Relationship{FirstMeetingDate: date(2020, time.June, 15), Partners: []RelationshipPartner{{JoinedAt: joined}}}, date(2020, time.June, 15)},
+		{"earliest join date", Relationship{Partners: []RelationshipPartner{{JoinedAt: joined.AddDate(0, 0, 2)}, {JoinedAt: joined}}}, date(2021, time.March, 3)},
+		{"unknown", Relationship{}, date(1, time.January, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rel.StartDate(); !got.Equal(tt.want) {
+				t.Errorf("StartDate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
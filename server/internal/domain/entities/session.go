@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one logged-in device. It is created at login and lives as long as its
+// refresh token chain; access tokens carry the session ID so revoking the session
+// cuts off the device immediately.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	DeviceName string             `bson:"deviceName" json:"deviceName"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastSeenAt time.Time          `bson:"lastSeenAt" json:"lastSeenAt"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"` // expiry of the newest refresh token
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+func NewSession(userID primitive.ObjectID, deviceName, userAgent, ip string, expiresAt time.Time) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		DeviceName: deviceName,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// IsActive reports whether requests authenticated by this session are accepted
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+// EventStats is an aggregate view over the events of a relationship
+type EventStats struct {
+	Total          int            `bson:"total" json:"total"`
+	PublicCount    int            `bson:"publicCount" json:"publicCount"`
+	ByType         map[string]int `bson:"byType" json:"byType"`
+	ByCategory     map[string]int `bson:"byCategory" json:"byCategory"`
+	FirstMemoryAt  *time.Time     `bson:"firstMemoryAt,omitempty" json:"firstMemoryAt,omitempty"`
+	LastMemoryAt   *time.Time     `bson:"lastMemoryAt,omitempty" json:"lastMemoryAt,omitempty"`
+	LongestGapDays int            `bson:"longestGapDays" json:"longestGapDays"`
+	LongestGapFrom *time.Time     `bson:"longestGapFrom,omitempty" json:"longestGapFrom,omitempty"`
+	LongestGapTo   *time.Time     `bson:"longestGapTo,omitempty" json:"longestGapTo,omitempty"`
+}
+
+// WhisperStats is an aggregate view over the whispers of a relationship. Done
+// counts completed occurrences, as in Whisper.CompletedOccurrences, so a
+// recurring whisper adds one for every day it was done. Open counts one-off
+// whispers still to do; recurring ones never close.
+type WhisperStats struct {
+	Total int `bson:"total" json:"total"`
+	Done  int `bson:"done" json:"done"`
+	Open  int `bson:"open" json:"open"`
+}
+
+// DaysSince returns whole days elapsed between t and now, never negative
+func DaysSince(t, now time.Time) int {
+	if t.IsZero() || now.Before(t) {
+		return 0
+	}
+	return int(now.Sub(t).Hours() / 24)
+}
@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Todo is a shared task between partners; completing it turns it into a memory event
+type Todo struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Title          string              `bson:"title" json:"title"`
+	Description    string              `bson:"description,omitempty" json:"description,omitempty"`
+	Priority       string              `bson:"priority" json:"priority"` // low, medium, high
+	DueDate        *time.Time          `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
+	AssignedTo     *primitive.ObjectID `bson:"assignedTo,omitempty" json:"assignedTo,omitempty"`
+	RelationshipID primitive.ObjectID  `bson:"relationshipId" json:"relationshipId"`
+	CreatedBy      primitive.ObjectID  `bson:"createdBy" json:"createdBy"`
+
+	// Completion
+	IsCompleted bool                `bson:"isCompleted" json:"isCompleted"`
+	CompletedAt *time.Time          `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	CompletedBy *primitive.ObjectID `bson:"completedBy,omitempty" json:"completedBy,omitempty"`
+	EventID     *primitive.ObjectID `bson:"eventId,omitempty" json:"eventId,omitempty"` // event created on completion
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+const (
+	TodoPriorityLow    = "low"
+	TodoPriorityMedium = "medium"
+	TodoPriorityHigh   = "high"
+)
+
+func NewTodo(title, priority string, relationshipID, createdBy primitive.ObjectID) *Todo {
+	now := time.Now()
+	if priority == "" {
+		priority = TodoPriorityMedium
+	}
+	return &Todo{
+		ID:             primitive.NewObjectID(),
+		Title:          title,
+		Priority:       priority,
+		RelationshipID: relationshipID,
+		CreatedBy:      createdBy,
+		IsCompleted:    false,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func (t *Todo) Update(title, description, priority string) {
+	if title != "" {
+		t.Title = title
+	}
+	if description != "" {
+		t.Description = description
+	}
+	if priority != "" {
+		t.Priority = priority
+	}
+	t.UpdatedAt = time.Now()
+}
+
+func (t *Todo) SetDueDate(dueDate *time.Time) {
+	t.DueDate = dueDate
+	t.UpdatedAt = time.Now()
+}
+
+func (t *Todo) AssignTo(userID *primitive.ObjectID) {
+	t.AssignedTo = userID
+	t.UpdatedAt = time.Now()
+}
+
+// Complete marks the todo as done and links it to the event created from it
+func (t *Todo) Complete(userID, eventID primitive.ObjectID) {
+	now := time.Now()
+	t.IsCompleted = true
+	t.CompletedAt = &now
+	t.CompletedBy = &userID
+	t.EventID = &eventID
+	t.UpdatedAt = now
+}
+
+func (t *Todo) IsOverdue(now time.Time) bool {
+	return !t.IsCompleted && t.DueDate != nil && t.DueDate.Before(now)
+}
@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TwoFactor holds a user's TOTP enrollment. It is created unconfirmed at enrollment
+// and becomes Enabled once the user proves the authenticator works.
+type TwoFactor struct {
+	UserID        primitive.ObjectID `bson:"_id" json:"userId"`
+	Secret        string             `bson:"secret" json:"-"`        // TOTP key, encrypted
+	RecoveryCodes []string           `bson:"recoveryCodes" json:"-"` // hashes of unused recovery codes
+	LastUsedStep  int64              `bson:"lastUsedStep" json:"-"`  // newest accepted time step, blocks code replay
+	Enabled       bool               `bson:"enabled" json:"enabled"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	EnabledAt     *time.Time         `bson:"enabledAt,omitempty" json:"enabledAt,omitempty"`
+}
+
+func NewTwoFactor(userID primitive.ObjectID, sealedSecret string) *TwoFactor {
+	return &TwoFactor{
+		UserID:        userID,
+		Secret:        sealedSecret,
+		RecoveryCodes: []string{},
+		CreatedAt:     time.Now(),
+	}
+}
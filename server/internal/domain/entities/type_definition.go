@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TypeDefinition describes a whisper or event type of the catalog with localized copy.
+// Whisper types live in whisper_types and event types in event_types.
+type TypeDefinition struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key               string             `bson:"key" json:"key"` // e.g. watch_sunset, MEETING
+	Category          string             `bson:"category" json:"category"`
+	Icon              string             `bson:"icon" json:"icon"`
+	Color             string             `bson:"color,omitempty" json:"color,omitempty"`
+	Labels            map[string]string  `bson:"labels" json:"labels"`                                 // language -> label
+	DefaultTexts      map[string]string  `bson:"defaultTexts,omitempty" json:"defaultTexts,omitempty"` // language -> whisper text
+	DefaultRecurrence string             `bson:"defaultRecurrence,omitempty" json:"defaultRecurrence,omitempty"`
+	RequiresText      bool               `bson:"requiresText" json:"requiresText"` // custom types have no default text
+	IsSystem          bool               `bson:"isSystem" json:"isSystem"`         // assigned by the server, not selectable by users
+	IsActive          bool               `bson:"isActive" json:"isActive"`
+	SortOrder         int                `bson:"sortOrder" json:"sortOrder"`
+	CreatedAt         time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Label returns the label in lang, falling back to English and then the key
+func (t *TypeDefinition) Label(lang string) string {
+	return localized(t.Labels, lang, t.Key)
+}
+
+// DefaultText returns the default whisper text in lang, falling back to English
+func (t *TypeDefinition) DefaultText(lang string) string {
+	return localized(t.DefaultTexts, lang, "")
+}
+
+// IsSelectable reports whether users may pick this type when creating content
+func (t *TypeDefinition) IsSelectable() bool {
+	return t.IsActive && !t.IsSystem
+}
+
+func localized(values map[string]string, lang, fallback string) string {
+	if v, ok := values[lang]; ok && v != "" {
+		return v
+	}
+	if v, ok := values[LanguageEnglish]; ok && v != "" {
+		return v
+	}
+	return fallback
+}
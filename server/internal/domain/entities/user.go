@@ -13,8 +13,11 @@ type User struct {
 	Email        string             `bson:"email,omitempty" json:"email" validate:"omitempty,email"`
 	PasswordHash string             `bson:"passwordHash" json:"-" validate:"required"`
 
+	// Email verification; set once the user proved ownership of Email
+	EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty" json:"emailVerifiedAt,omitempty"`
+
 	// Avatar information
-	Avatar *Avatar `bson:"avatar,omitempty" json:"avatar"`
+	Avatar *Avatar `bson:"avatar" json:"avatar"` // not omitempty so $set can remove it
 
 	// Settings & Preferences
 	Settings UserSettings `bson:"settings" json:"settings"`
@@ -30,14 +33,22 @@ type User struct {
 	UpdatedAt    time.Time `bson:"updatedAt" json:"updatedAt"`
 	LastActiveAt time.Time `bson:"lastActiveAt" json:"lastActiveAt"`
 	IsActive     bool      `bson:"isActive" json:"isActive"`
+
+	// Account deletion; a deleted account is hidden at once and purged for good at PurgeAt
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"-"`
+	PurgeAt   *time.Time `bson:"purgeAt,omitempty" json:"-"`
 }
 
 type Avatar struct {
-	Type       string    `bson:"type" json:"type"` // "base64" or "url"
-	Data       string    `bson:"data" json:"data"`
-	Filename   string    `bson:"filename" json:"filename"`
-	Size       int64     `bson:"size" json:"size"`
-	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
+	Type        string              `bson:"type" json:"type"`                           // "media", or legacy "base64"/"url"
+	Data        string              `bson:"data,omitempty" json:"data"`                 // base64 payload or external URL
+	MediaID     *primitive.ObjectID `bson:"mediaId,omitempty" json:"mediaId,omitempty"` // set for type "media"
+	Key         string              `bson:"key,omitempty" json:"key,omitempty"`         // storage key of the media object
+	ContentType string              `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	Variants    []MediaVariant      `bson:"variants,omitempty" json:"variants,omitempty"` // thumbnails of a media image
+	Filename    string              `bson:"filename" json:"filename"`
+	Size        int64               `bson:"size" json:"size"`
+	UploadedAt  time.Time           `bson:"uploadedAt" json:"uploadedAt"`
 }
 
 type UserSettings struct {
@@ -53,6 +64,23 @@ type NotificationSettings struct {
 	Partner  bool `bson:"partner" json:"partner"`
 }
 
+// Supported interface languages
+const (
+	LanguageEnglish = "en"
+	LanguagePersian = "fa"
+)
+
+var SupportedLanguages = []string{LanguageEnglish, LanguagePersian}
+
+func IsSupportedLanguage(lang string) bool {
+	for _, l := range SupportedLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
 type UserStats struct {
 	MemoriesCount     int `bson:"memoriesCount" json:"memoriesCount"`
 	WhispersCount     int `bson:"whispersCount" json:"whispersCount"`
@@ -71,7 +99,7 @@ func NewUser(username, name, email, passwordHash string) *User {
 		PasswordHash: passwordHash,
 		Settings: UserSettings{
 			AutoPublic: false,
-			Language:   "en",
+			Language:   LanguageEnglish,
 			Timezone:   "UTC",
 			Notifications: NotificationSettings{
 				Whispers: true,
@@ -131,6 +159,10 @@ func (u *User) SetRelationship(relationshipID primitive.ObjectID, relationshipDa
 	u.UpdatedAt = time.Now()
 }
 
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 func (u *User) HasRelationship() bool {
 	return u.RelationshipID != nil
 }
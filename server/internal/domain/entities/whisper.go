@@ -8,22 +8,34 @@ import (
 
 // Whisper represents a lightweight, repeatable suggestion/reminder between partners
 type Whisper struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Type           string             `bson:"type" json:"type"`                     // e.g. watch_sunset, cook_together, custom
-	Text           string             `bson:"text,omitempty" json:"text,omitempty"` // resolved display text or custom text
-	Recurrence     string             `bson:"recurrence" json:"recurrence"`         // once, everyday, weekly (future)
-	Date           time.Time          `bson:"date" json:"date"`                     // for once: the day; for everyday: start date
-	RelationshipID primitive.ObjectID `bson:"relationshipId" json:"relationshipId"`
-	CreatedBy      primitive.ObjectID `bson:"createdBy" json:"createdBy"`
-	IsDone         bool               `bson:"isDone" json:"isDone"`
-	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type           string              `bson:"type" json:"type"`                     // e.g. watch_sunset, cook_together, custom
+	Text           string              `bson:"text,omitempty" json:"text,omitempty"` // resolved display text or custom text
+	Recurrence     string              `bson:"recurrence" json:"recurrence"`         // once, everyday, weekly, monthly, custom
+	Rule           *RecurrenceRule     `bson:"rule" json:"rule,omitempty"`           // explicit RRULE; derived from Recurrence when nil
+	Timezone       string              `bson:"timezone,omitempty" json:"timezone"`   // IANA zone used for day boundaries
+	Date           time.Time           `bson:"date" json:"date"`                     // for once: the day; for recurring: start date
+	Completions    []WhisperCompletion `bson:"completions" json:"completions"`       // per-occurrence completion; not omitempty so $set clears it
+	RelationshipID primitive.ObjectID  `bson:"relationshipId" json:"relationshipId"`
+	CreatedBy      primitive.ObjectID  `bson:"createdBy" json:"createdBy"`
+	IsDone         bool                `bson:"isDone" json:"isDone"` // once whispers only; mirrors the single completion
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// WhisperCompletion records that one occurrence of a whisper was done
+type WhisperCompletion struct {
+	Date        string             `bson:"date" json:"date"` // YYYY-MM-DD in the whisper's timezone
+	CompletedBy primitive.ObjectID `bson:"completedBy" json:"completedBy"`
+	CompletedAt time.Time          `bson:"completedAt" json:"completedAt"`
 }
 
 const (
 	WhisperRecurrenceOnce     = "once"
 	WhisperRecurrenceEveryday = "everyday"
 	WhisperRecurrenceWeekly   = "weekly"
+	WhisperRecurrenceMonthly  = "monthly"
+	WhisperRecurrenceCustom   = "custom"
 )
 
 func NewWhisper(whisperType, text, recurrence string, date time.Time, relationshipID, createdBy primitive.ObjectID) *Whisper {
@@ -33,6 +45,7 @@ func NewWhisper(whisperType, text, recurrence string, date time.Time, relationsh
 		Type:           whisperType,
 		Text:           text,
 		Recurrence:     recurrence,
+		Timezone:       "UTC",
 		Date:           date,
 		RelationshipID: relationshipID,
 		CreatedBy:      createdBy,
@@ -53,9 +66,121 @@ func (w *Whisper) Update(text string, recurrence string, date time.Time) {
 	}
 	if recurrence != "" {
 		w.Recurrence = recurrence
+		w.Rule = nil
 	}
 	if !date.IsZero() {
 		w.Date = date
 	}
 	w.UpdatedAt = time.Now()
 }
+
+// SetRule replaces the recurrence with an explicit rule; nil reverts to a one-off whisper
+func (w *Whisper) SetRule(rule *RecurrenceRule) {
+	w.Rule = rule
+	if rule == nil {
+		w.Recurrence = WhisperRecurrenceOnce
+	} else {
+		w.Recurrence = rule.Summary()
+	}
+	w.UpdatedAt = time.Now()
+}
+
+// EffectiveRule returns the explicit rule or the one implied by the legacy Recurrence label.
+// A nil result means the whisper happens once, on its start date.
+func (w *Whisper) EffectiveRule() *RecurrenceRule {
+	if w.Rule != nil {
+		return w.Rule
+	}
+	switch w.Recurrence {
+	case WhisperRecurrenceEveryday:
+		return &RecurrenceRule{Freq: FreqDaily}
+	case WhisperRecurrenceWeekly:
+		return &RecurrenceRule{Freq: FreqWeekly}
+	case WhisperRecurrenceMonthly:
+		return &RecurrenceRule{Freq: FreqMonthly}
+	}
+	return nil
+}
+
+func (w *Whisper) IsRecurring() bool {
+	return w.EffectiveRule() != nil
+}
+
+// Location resolves the whisper timezone, falling back to UTC
+func (w *Whisper) Location() *time.Location {
+	return LoadLocation(w.Timezone)
+}
+
+// StartDate is the calendar date of the first possible occurrence
+func (w *Whisper) StartDate() time.Time {
+	return DateOf(w.Date, w.Location())
+}
+
+// Occurrences returns the calendar dates in [from, to] on which the whisper is due
+func (w *Whisper) Occurrences(from, to time.Time) []time.Time {
+	start := w.StartDate()
+	rule := w.EffectiveRule()
+	if rule == nil {
+		if start.Before(from) || start.After(to) {
+			return nil
+		}
+		return []time.Time{start}
+	}
+	return rule.Occurrences(start, from, to)
+}
+
+// OccursOn reports whether the whisper is due on calendar date d
+func (w *Whisper) OccursOn(d time.Time) bool {
+	return len(w.Occurrences(d, d)) == 1
+}
+
+// CompletionOn returns the completion recorded for calendar date d, if any
+func (w *Whisper) CompletionOn(d time.Time) *WhisperCompletion {
+	key := d.Format(DateLayout)
+	for i := range w.Completions {
+		if w.Completions[i].Date == key {
+			return &w.Completions[i]
+		}
+	}
+	return nil
+}
+
+// IsDoneOn reports whether the occurrence on calendar date d was completed; one-off
+// whispers created before per-occurrence tracking only carry the IsDone flag
+func (w *Whisper) IsDoneOn(d time.Time) bool {
+	if w.CompletionOn(d) != nil {
+		return true
+	}
+	return !w.IsRecurring() && w.IsDone
+}
+
+// CompletedOccurrences counts the occurrences done: every completion of a
+// recurring whisper, or one for a one-off whisper that is done
+func (w *Whisper) CompletedOccurrences() int {
+	if w.IsRecurring() {
+		return len(w.Completions)
+	}
+	if w.IsDone {
+		return 1
+	}
+	return 0
+}
+
+// SetOccurrenceDone marks or clears completion of the occurrence on calendar date d
+func (w *Whisper) SetOccurrenceDone(d time.Time, userID primitive.ObjectID, done bool) {
+	key := d.Format(DateLayout)
+	kept := w.Completions[:0]
+	for _, c := range w.Completions {
+		if c.Date != key {
+			kept = append(kept, c)
+		}
+	}
+	w.Completions = kept
+	if done {
+		w.Completions = append(w.Completions, WhisperCompletion{Date: key, CompletedBy: userID, CompletedAt: time.Now()})
+	}
+	if !w.IsRecurring() {
+		w.IsDone = done
+	}
+	w.UpdatedAt = time.Now()
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+)
+
+// CatalogRepository defines data access for whisper and event type definitions
+type CatalogRepository interface {
+	FindAllWhisperTypes(ctx context.Context) ([]*entities.TypeDefinition, error)
+	FindAllEventTypes(ctx context.Context) ([]*entities.TypeDefinition, error)
+	// Seed* insert definitions whose key is missing and leave existing ones untouched
+	SeedWhisperTypes(ctx context.Context, defs []*entities.TypeDefinition) error
+	SeedEventTypes(ctx context.Context, defs []*entities.TypeDefinition) error
+}
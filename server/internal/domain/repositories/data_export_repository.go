@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DataExportRepository tracks data export requests and their archives
+type DataExportRepository interface {
+	Create(ctx context.Context, export *entities.DataExport) error
+	FindLatestByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.DataExport, error)
+	// Claim moves a pending export, or one stuck in processing since before
+	// staleBefore, to processing; false means another worker has it
+	Claim(ctx context.Context, id primitive.ObjectID, staleBefore time.Time) (bool, error)
+	MarkReady(ctx context.Context, id primitive.ObjectID, size int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error
+	// FindUnfinished lists exports that are pending or stuck in processing
+	FindUnfinished(ctx context.Context, staleBefore time.Time) ([]*entities.DataExport, error)
+	// FindExpired lists ready exports whose archive should be removed
+	FindExpired(ctx context.Context, now time.Time) ([]*entities.DataExport, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.DataExport, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
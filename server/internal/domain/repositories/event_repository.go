@@ -15,4 +15,15 @@ type EventRepository interface {
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	FindAllByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]*entities.Event, error)
 	FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, limit, offset int64) ([]*entities.Event, error)
+
+	// Public (explore) operations
+	FindPublic(ctx context.Context, eventType, sortBy string, limit, offset int64) ([]*entities.Event, error)
+	// IncrementViewCount atomically bumps viewCount of a public event and returns the updated event
+	IncrementViewCount(ctx context.Context, id primitive.ObjectID) (*entities.Event, error)
 }
+
+// Sort orders for public events
+const (
+	PublicEventSortRecent  = "recent"
+	PublicEventSortPopular = "popular"
+)
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrExternalIdentityNotFound = errors.New("external identity not found")
+
+// ExternalIdentityRepository persists links between users and OIDC provider accounts
+type ExternalIdentityRepository interface {
+	// Create fails with a duplicate key error if the provider account or the
+	// user's slot for that provider is already taken
+	Create(ctx context.Context, identity *entities.ExternalIdentity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*entities.ExternalIdentity, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.ExternalIdentity, error)
+	TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// OIDCStateRepository stores pending OIDC sign-ins between start and callback
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *entities.OIDCState) error
+	// Consume removes and returns an unexpired state so each one is redeemed once
+	Consume(ctx context.Context, stateHash string) (*entities.OIDCState, error)
+}
@@ -2,12 +2,31 @@ package repositories
 
 import (
     "context"
+    "errors"
+    "time"
     "whisper-server/internal/domain/entities"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInviteUnavailable means the code is used, revoked, expired or unknown
+var ErrInviteUnavailable = errors.New("invite code is not available")
+
 type InviteRepository interface {
+    // Create fails with a duplicate key error when the code is taken, or when
+    // inv is current and the creator already has a current code
     Create(ctx context.Context, inv *entities.InviteCode) error
     FindByCode(ctx context.Context, code string) (*entities.InviteCode, error)
-    MarkUsed(ctx context.Context, inv *entities.InviteCode) error
+    // Redeem marks an active code as used by userID in a single compare-and-set,
+    // so only one of several concurrent redemptions succeeds; the others get ErrInviteUnavailable
+    Redeem(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) (*entities.InviteCode, error)
+    // FindByCreator lists the codes a user generated, newest first
+    FindByCreator(ctx context.Context, userID primitive.ObjectID) ([]*entities.InviteCode, error)
+    DeleteByCreator(ctx context.Context, userID primitive.ObjectID) error
+    // Revoke withdraws one unused code of the creator
+    Revoke(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) error
+    // RevokeActiveByCreator withdraws every code of the user that could still be
+    // redeemed and leaves the user without a current code
+    RevokeActiveByCreator(ctx context.Context, userID primitive.ObjectID, at time.Time) error
 }
 
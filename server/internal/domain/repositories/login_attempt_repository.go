@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"whisper-server/internal/domain/entities"
+)
+
+// LoginAttemptRepository tracks failed logins for brute-force protection
+type LoginAttemptRepository interface {
+	FindByKey(ctx context.Context, key string) (*entities.LoginAttempt, error)
+	// RecordFailure increments the failure count, restarting it when the previous
+	// failure is older than window, and returns the updated record
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entities.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MediaRepository defines data access for uploaded media metadata
+type MediaRepository interface {
+	Create(ctx context.Context, media *entities.Media) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Media, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Attach records target (an event or user) as the one reference to the media,
+	// provided it has none or already has target; false means another holds it
+	Attach(ctx context.Context, id, target primitive.ObjectID) (bool, error)
+	// Detach clears the reference if it is still target
+	Detach(ctx context.Context, id, target primitive.ObjectID) error
+}
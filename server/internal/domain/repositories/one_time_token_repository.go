@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OneTimeTokenRepository persists hashed single-use tokens (password reset and
+// email verification links)
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *entities.OneTimeToken) error
+	FindByHash(ctx context.Context, purpose, tokenHash string) (*entities.OneTimeToken, error)
+	// FindLatestByUserID returns the most recently issued token of the purpose
+	FindLatestByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) (*entities.OneTimeToken, error)
+	// Consume atomically marks an unused, unexpired token as used; false means it
+	// was already redeemed or expired
+	Consume(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// DeleteByUserID drops outstanding tokens so only the newest link works
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshTokenRepository persists issued refresh tokens for rotation and revocation
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entities.RefreshToken) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*entities.RefreshToken, error)
+	// MarkRotated atomically links an active token to its successor; false means the
+	// token was already rotated or revoked (a concurrent or replayed refresh)
+	MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID) (bool, error)
+	RevokeBySessionID(ctx context.Context, sessionID primitive.ObjectID) error
+	RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -2,17 +2,39 @@ package repositories
 
 import (
     "context"
+    "errors"
     "time"
     "whisper-server/internal/domain/entities"
 
     "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrRelationshipStateChanged is returned by the conditional updates when the
+// stored relationship is no longer in the expected state, e.g. because the
+// partner answered the same request concurrently
+var ErrRelationshipStateChanged = errors.New("relationship changed concurrently")
+
 type RelationshipRepository interface {
+    // Create stores a new relationship at the current schema version
     Create(ctx context.Context, r *entities.Relationship) error
-    // CreateWithDetails writes fields required by DB schema (users, firstMeetingDate)
-    CreateWithDetails(ctx context.Context, r *entities.Relationship, users []primitive.ObjectID, firstMeetingDate time.Time) error
+    FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Relationship, error)
     FindByInviteCode(ctx context.Context, code string) (*entities.Relationship, error)
+    // FindCurrentByUserID returns the relationship the user shares, including one with a pending disconnect request
     FindCurrentByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.Relationship, error)
     Update(ctx context.Context, r *entities.Relationship) error
+    // UpdateDisconnectRequest stores r provided the stored relationship still has a
+    // pending disconnect request made by from, or none pending and is active when
+    // from is nil; otherwise ErrRelationshipStateChanged
+    UpdateDisconnectRequest(ctx context.Context, r *entities.Relationship, from *primitive.ObjectID) error
+    // UpdateReconnectRequest stores r provided the stored relationship is still
+    // disconnected with its reconnect request made by from (nil for none);
+    // otherwise ErrRelationshipStateChanged
+    UpdateReconnectRequest(ctx context.Context, r *entities.Relationship, from *primitive.ObjectID) error
+    // FindAllByUserID lists every relationship the user was part of, in any status
+    FindAllByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Relationship, error)
+    // FindPastByUserID lists the user's disconnected relationships, most recently ended first
+    FindPastByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Relationship, error)
+    // FindDisconnectsDue lists disconnect requests left unanswered past their auto-confirm time
+    FindDisconnectsDue(ctx context.Context, now time.Time) ([]*entities.Relationship, error)
+    Delete(ctx context.Context, id primitive.ObjectID) error
 }
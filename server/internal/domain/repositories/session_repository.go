@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionRepository persists logged-in devices
+type SessionRepository interface {
+	Create(ctx context.Context, session *entities.Session) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Session, error)
+	// FindActiveByUserID returns unrevoked, unexpired sessions, most recently seen first
+	FindActiveByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Session, error)
+	// Touch records activity from the session's device
+	Touch(ctx context.Context, id primitive.ObjectID, ip string, seenAt time.Time) error
+	// Extend records a refresh token rotation, moving the session expiry forward
+	Extend(ctx context.Context, id primitive.ObjectID, ip string, seenAt, expiresAt time.Time) error
+	Revoke(ctx context.Context, id primitive.ObjectID) error
+	RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error
+}
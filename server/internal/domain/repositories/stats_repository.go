@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StatsRepository computes aggregates over relationship content
+type StatsRepository interface {
+	AggregateEventStats(ctx context.Context, relationshipID primitive.ObjectID) (*entities.EventStats, error)
+	AggregateWhisperStats(ctx context.Context, relationshipID primitive.ObjectID) (*entities.WhisperStats, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TodoRepository defines data access for todos
+type TodoRepository interface {
+	Create(ctx context.Context, todo *entities.Todo) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Todo, error)
+	Update(ctx context.Context, todo *entities.Todo) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// FindAllByRelationshipID lists todos of a relationship; completed filters by state when non-nil
+	FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, completed *bool, limit, offset int64) ([]*entities.Todo, error)
+	DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"whisper-server/internal/domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrTwoFactorNotFound lets callers tell "not enrolled" apart from storage failures,
+// which must not be mistaken for 2FA being off
+var ErrTwoFactorNotFound = errors.New("two-factor enrollment not found")
+
+// TwoFactorRepository persists TOTP enrollments, one per user
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.TwoFactor, error)
+	// Save creates or replaces the user's enrollment
+	Save(ctx context.Context, tf *entities.TwoFactor) error
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+	// UseStep records an accepted TOTP step; false means the step (or a newer one)
+	// was already used
+	UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error)
+	// UseRecoveryCode removes a recovery code hash; false means it was not present
+	UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error)
+}
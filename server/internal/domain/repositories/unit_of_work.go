@@ -0,0 +1,10 @@
+package repositories
+
+import "context"
+
+// UnitOfWork runs a group of repository calls atomically. Repositories called
+// with the context handed to fn take part in the transaction; when fn returns an
+// error every write made through that context is rolled back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
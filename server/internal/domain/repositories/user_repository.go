@@ -2,10 +2,16 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"time"
 	"whisper-server/internal/domain/entities"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrRelationshipChanged is returned by SetRelationship when the user's relationship
+// pointer no longer has the expected value, e.g. because of a concurrent join
+var ErrRelationshipChanged = errors.New("user relationship changed concurrently")
+
 type UserRepository interface {
 	// Create user
 	Create(ctx context.Context, user *entities.User) error
@@ -18,11 +24,29 @@ type UserRepository interface {
 	// Update operations
 	Update(ctx context.Context, user *entities.User) error
 	UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error
+	UpdateStats(ctx context.Context, userID primitive.ObjectID, stats entities.UserStats) error
+	MarkEmailVerified(ctx context.Context, userID primitive.ObjectID, verifiedAt time.Time) error
+	// UpdateSettings replaces the settings only, leaving fields written concurrently intact
+	UpdateSettings(ctx context.Context, userID primitive.ObjectID, settings entities.UserSettings) error
+	// SetRelationship points the user at a relationship, provided the pointer still
+	// equals from (nil for none); otherwise ErrRelationshipChanged
+	SetRelationship(ctx context.Context, userID primitive.ObjectID, from *primitive.ObjectID, to primitive.ObjectID, relationshipDays int) error
+	// ClearRelationship removes the pointer if it still refers to relationshipID
+	ClearRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID) error
 	
-	// Check existence
+	// Check existence. Soft-deleted users count: their username and email stay
+	// reserved until the purge so the account can still be restored
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	
-	// Soft delete
-	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Soft delete; the account disappears from all lookups and is purged after purgeAt
+	Delete(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) error
+	// Restore undoes a soft delete whose purge time has not passed yet
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	// FindDueForPurge lists soft-deleted users whose purge time has passed
+	FindDueForPurge(ctx context.Context, now time.Time, limit int64) ([]*entities.User, error)
+	// Exists reports whether the user document is still stored, soft-deleted or not
+	Exists(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// Purge removes a soft-deleted user document for good
+	Purge(ctx context.Context, id primitive.ObjectID) error
 }
\ No newline at end of file
@@ -14,4 +14,5 @@ type WhisperRepository interface {
 	Update(ctx context.Context, whisper *entities.Whisper) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, limit, offset int64) ([]*entities.Whisper, error)
+	DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error
 }
@@ -3,12 +3,19 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	App          AppConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	Auth         AuthConfig
+	Media        MediaConfig
+	Mail         MailConfig
+	OIDC         OIDCConfig
+	Account      AccountConfig
+	Relationship RelationshipConfig
 }
 
 type AppConfig struct {
@@ -17,6 +24,9 @@ type AppConfig struct {
 	Environment string
 	Port        string
 	BaseURL     string
+	// TrustedProxies are the addresses or CIDRs of reverse proxies whose
+	// X-Forwarded-For is believed; with none the peer address is the client
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -25,6 +35,12 @@ type DatabaseConfig struct {
 	ConnectTimeout int
 	MaxPoolSize    uint64
 	MinPoolSize    uint64
+	// MigrationsMode decides what the API does about pending migrations at startup:
+	// strict refuses to start, auto applies them, ignore only logs them
+	MigrationsMode string
+	// RequireTransactions refuses standalone servers, where multi-document
+	// writes cannot be rolled back
+	RequireTransactions bool
 }
 
 type JWTConfig struct {
@@ -33,27 +49,150 @@ type JWTConfig struct {
 	RefreshExpiresIn string // تغییر شده
 }
 
+type AuthConfig struct {
+	PasswordResetTTL             string   // lifetime of password reset links
+	EmailVerificationTTL         string   // lifetime of email verification links
+	EmailVerificationResendAfter string   // minimum time between verification emails
+	EmailVerificationRequiredFor []string // features gated behind a verified email, e.g. "invite_codes"
+	LoginMaxFailures             int      // failed logins before an account is locked
+	LoginMaxFailuresPerIP        int      // failed logins before a client address is locked
+	LoginLockoutBase             string   // first lockout, doubled on every further failure
+	LoginLockoutMax              string
+	LoginFailureWindow           string // failures older than this are forgotten
+	MFAEncryptionKey             string // encrypts stored TOTP secrets; required in production
+}
+
+type AccountConfig struct {
+	DeletionGracePeriod string // time to restore a deleted account before it is purged
+	PurgeInterval       string // how often the purge job looks for due accounts
+	ExportTTL           string // how long a finished data export can be downloaded
+}
+
+type RelationshipConfig struct {
+	DisconnectUndoWindow       string // how long the requester can take a disconnect request back
+	DisconnectAutoConfirmAfter string // unanswered disconnect requests are confirmed after this
+	InviteCodeTTL              string // lifetime of a generated invite code
+}
+
+type MediaConfig struct {
+	Storage        string // "local" or "s3"
+	LocalDir       string
+	URLTTL         string // lifetime of signed media URLs
+	SigningSecret  string // HMAC key for local signed URLs; falls back to JWT_SECRET
+	MaxImageBytes  int64
+	MaxAvatarBytes int64
+	S3             S3Config
+}
+
+type S3Config struct {
+	Endpoint       string // e.g. http://localhost:9000 for MinIO
+	PublicEndpoint string // host used in signed URLs when clients reach storage differently
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+}
+
+type MailConfig struct {
+	Driver  string // "log", "file" or "smtp"
+	From    string
+	FileDir string // where the file driver writes .eml files
+	SMTP    SMTPConfig
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+}
+
+type OIDCProviderConfig struct {
+	Name         string // used in URLs, e.g. "google"
+	Issuer       string // discovery is fetched from Issuer + /.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string // optional for public clients, PKCE is always used
+	Scopes       []string
+}
+
 func Load() *Config {
 	return &Config{
 		App: AppConfig{
-			Name:        getEnv("APP_NAME", "Whisper Server"),
-			Version:     getEnv("APP_VERSION", "1.0.0"),
-			Environment: getEnv("ENVIRONMENT", "development"),
-			Port:        getEnv("PORT", "8080"),
-			BaseURL:     getEnv("BASE_URL", "http://localhost:8080"),
+			Name:           getEnv("APP_NAME", "Whisper Server"),
+			Version:        getEnv("APP_VERSION", "1.0.0"),
+			Environment:    getEnv("ENVIRONMENT", "development"),
+			Port:           getEnv("PORT", "8080"),
+			BaseURL:        getEnv("BASE_URL", "http://localhost:8080"),
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
-			URI:            getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-			Name:           getEnv("MONGODB_NAME", "whisper_db"),
-			ConnectTimeout: getEnvAsInt("MONGODB_CONNECT_TIMEOUT", 30),
-			MaxPoolSize:    uint64(getEnvAsInt("MONGODB_MAX_POOL_SIZE", 100)),
-			MinPoolSize:    uint64(getEnvAsInt("MONGODB_MIN_POOL_SIZE", 10)),
+			URI:                 getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+			Name:                getEnv("MONGODB_NAME", "whisper_db"),
+			ConnectTimeout:      getEnvAsInt("MONGODB_CONNECT_TIMEOUT", 30),
+			MaxPoolSize:         uint64(getEnvAsInt("MONGODB_MAX_POOL_SIZE", 100)),
+			MinPoolSize:         uint64(getEnvAsInt("MONGODB_MIN_POOL_SIZE", 10)),
+			MigrationsMode:      getEnv("MIGRATIONS_MODE", "strict"),
+			RequireTransactions: getEnvAsBool("MONGODB_REQUIRE_TRANSACTIONS", true),
 		},
 		JWT: JWTConfig{
 			Secret:           getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
 			AccessExpiresIn:  getEnv("JWT_ACCESS_EXPIRES_IN", "24h"),   // تغییر شده
 			RefreshExpiresIn: getEnv("JWT_REFRESH_EXPIRES_IN", "720h"), // تغییر شده
 		},
+		Auth: AuthConfig{
+			PasswordResetTTL:             getEnv("PASSWORD_RESET_TTL", "1h"),
+			EmailVerificationTTL:         getEnv("EMAIL_VERIFICATION_TTL", "48h"),
+			EmailVerificationResendAfter: getEnv("EMAIL_VERIFICATION_RESEND_AFTER", "2m"),
+			EmailVerificationRequiredFor: getEnvAsList("EMAIL_VERIFICATION_REQUIRED_FOR", []string{"invite_codes"}),
+			LoginMaxFailures:             getEnvAsInt("LOGIN_MAX_FAILURES", 5),
+			LoginMaxFailuresPerIP:        getEnvAsInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+			LoginLockoutBase:             getEnv("LOGIN_LOCKOUT_BASE", "30s"),
+			LoginLockoutMax:              getEnv("LOGIN_LOCKOUT_MAX", "1h"),
+			LoginFailureWindow:           getEnv("LOGIN_FAILURE_WINDOW", "15m"),
+			MFAEncryptionKey:             getEnv("MFA_ENCRYPTION_KEY", ""),
+		},
+		Media: MediaConfig{
+			Storage:        getEnv("MEDIA_STORAGE", "local"),
+			LocalDir:       getEnv("MEDIA_LOCAL_DIR", "./uploads"),
+			URLTTL:         getEnv("MEDIA_URL_TTL", "1h"),
+			SigningSecret:  getEnv("MEDIA_SIGNING_SECRET", ""),
+			MaxImageBytes:  int64(getEnvAsInt("MEDIA_MAX_IMAGE_BYTES", 10<<20)),
+			MaxAvatarBytes: int64(getEnvAsInt("MEDIA_MAX_AVATAR_BYTES", 2<<20)),
+			S3: S3Config{
+				Endpoint:       getEnv("S3_ENDPOINT", "http://localhost:9000"),
+				PublicEndpoint: getEnv("S3_PUBLIC_ENDPOINT", ""),
+				Region:         getEnv("S3_REGION", "us-east-1"),
+				Bucket:         getEnv("S3_BUCKET", "whisper-media"),
+				AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+				SecretKey:      getEnv("S3_SECRET_KEY", ""),
+			},
+		},
+		Mail: MailConfig{
+			Driver:  getEnv("MAIL_DRIVER", "log"),
+			From:    getEnv("MAIL_FROM", "Whisper <no-reply@whisper.local>"),
+			FileDir: getEnv("MAIL_FILE_DIR", "./mail"),
+			SMTP: SMTPConfig{
+				Host:     getEnv("SMTP_HOST", "localhost"),
+				Port:     getEnvAsInt("SMTP_PORT", 587),
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+			},
+		},
+		OIDC: loadOIDCConfig(),
+		Account: AccountConfig{
+			DeletionGracePeriod: getEnv("ACCOUNT_DELETION_GRACE_PERIOD", "720h"),
+			PurgeInterval:       getEnv("ACCOUNT_PURGE_INTERVAL", "1h"),
+			ExportTTL:           getEnv("DATA_EXPORT_TTL", "72h"),
+		},
+		Relationship: RelationshipConfig{
+			DisconnectUndoWindow:       getEnv("DISCONNECT_UNDO_WINDOW", "24h"),
+			DisconnectAutoConfirmAfter: getEnv("DISCONNECT_AUTO_CONFIRM_AFTER", "168h"),
+			InviteCodeTTL:              getEnv("INVITE_CODE_TTL", "168h"),
+		},
 	}
 }
 
@@ -71,3 +210,43 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsList reads a comma-separated list; set the variable to "none" for an empty list
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, v := range strings.Split(valueStr, ",") {
+		if v = strings.TrimSpace(v); v != "" && v != "none" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// loadOIDCConfig reads the providers named in OIDC_PROVIDERS; each provider NAME is
+// configured through OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and _SCOPES
+func loadOIDCConfig() OIDCConfig {
+	var cfg OIDCConfig
+	for _, name := range getEnvAsList("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg.Providers = append(cfg.Providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return cfg
+}
@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexSet is a group of indexes on one collection
+type indexSet struct {
+	collection string
+	indexes    []mongo.IndexModel
+}
+
+func asc(field string) bson.E  { return bson.E{Key: field, Value: 1} }
+func desc(field string) bson.E { return bson.E{Key: field, Value: -1} }
+
+// initialIndexes are the indexes the API used to create at every boot
+var initialIndexes = []indexSet{
+	{"users", []mongo.IndexModel{
+		{Keys: bson.D{asc("username")}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{asc("email")}, Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: bson.D{asc("relationshipId")}},
+		{Keys: bson.D{asc("purgeAt")}, Options: options.Index().SetSparse(true)},
+	}},
+	{"relationships", []mongo.IndexModel{
+		{Keys: bson.D{asc("partners.userId")}},
+		{Keys: bson.D{asc("inviteCode")}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{asc("status")}},
+		{Keys: bson.D{asc("disconnectRequest.autoConfirmAt")}, Options: options.Index().SetSparse(true)},
+	}},
+	{"events", []mongo.IndexModel{
+		{Keys: bson.D{asc("relationshipId"), desc("date")}},
+		{Keys: bson.D{asc("visibility.isPublic"), desc("viewCount")}},
+		{Keys: bson.D{asc("visibility.isPublic"), desc("visibility.publishedAt")}},
+		{Keys: bson.D{asc("createdBy"), desc("createdAt")}},
+		{Keys: bson.D{asc("type")}},
+	}},
+	{"whispers", []mongo.IndexModel{
+		{Keys: bson.D{asc("relationshipId"), asc("date")}},
+		{Keys: bson.D{asc("isDone"), asc("date")}},
+		{Keys: bson.D{asc("type")}},
+	}},
+	{"todos", []mongo.IndexModel{
+		{Keys: bson.D{asc("relationshipId"), asc("isCompleted")}},
+		{Keys: bson.D{asc("priority"), desc("createdAt")}},
+		{Keys: bson.D{asc("dueDate")}},
+	}},
+	{"invitecodes", []mongo.IndexModel{
+		{Keys: bson.D{asc("code")}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{asc("createdBy")}},
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{asc("isUsed")}},
+	}},
+	{"media", []mongo.IndexModel{
+		{Keys: bson.D{asc("ownerId"), desc("createdAt")}},
+	}},
+	{"refresh_tokens", []mongo.IndexModel{
+		{Keys: bson.D{asc("userId")}},
+		{Keys: bson.D{asc("sessionId")}},
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"sessions", []mongo.IndexModel{
+		{Keys: bson.D{asc("userId"), desc("lastSeenAt")}},
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"one_time_tokens", []mongo.IndexModel{
+		{Keys: bson.D{asc("tokenHash")}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{asc("userId"), asc("purpose")}},
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"login_attempts", []mongo.IndexModel{
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"external_identities", []mongo.IndexModel{
+		{Keys: bson.D{asc("provider"), asc("subject")}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{asc("userId"), asc("provider")}, Options: options.Index().SetUnique(true)},
+	}},
+	{"oidc_states", []mongo.IndexModel{
+		{Keys: bson.D{asc("expiresAt")}, Options: options.Index().SetExpireAfterSeconds(0)},
+	}},
+	{"data_exports", []mongo.IndexModel{
+		{Keys: bson.D{asc("userId"), desc("createdAt")}},
+		{Keys: bson.D{asc("status")}},
+	}},
+	// Type catalogs are looked up by key
+	{"event_types", []mongo.IndexModel{
+		{Keys: bson.D{asc("key")}, Options: options.Index().SetUnique(true)},
+	}},
+	{"whisper_types", []mongo.IndexModel{
+		{Keys: bson.D{asc("key")}, Options: options.Index().SetUnique(true)},
+	}},
+}
+
+func createIndexes(sets []indexSet) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, set := range sets {
+			if _, err := db.Collection(set.collection).Indexes().CreateMany(ctx, set.indexes); err != nil {
+				return fmt.Errorf("create %s indexes: %w", set.collection, err)
+			}
+		}
+		return nil
+	}
+}
+
+func dropIndexes(sets []indexSet) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, set := range sets {
+			for _, idx := range set.indexes {
+				_, err := db.Collection(set.collection).Indexes().DropOne(ctx, indexName(idx.Keys.(bson.D)))
+				var cmdErr mongo.CommandError
+				if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound")) {
+					return fmt.Errorf("drop %s index: %w", set.collection, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// indexName is the name MongoDB generates for keys, e.g. "relationshipId_1_date_-1"
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		parts = append(parts, k.Key, fmt.Sprint(k.Value))
+	}
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+)
+
+// relationshipSchemaV2 is the version this backfill produces; later schema
+// versions get migrations of their own
+const relationshipSchemaV2 = 2
+
+// legacyRelationship is the part of a relationship document written before
+// schema version 2 that the upgrade needs. Documents created through the plain
+// insert path lack users and firstMeetingDate.
+type legacyRelationship struct {
+	ID               primitive.ObjectID             `bson:"_id"`
+	InviteCode       string                         `bson:"inviteCode"`
+	FirstMeetingDate *time.Time                     `bson:"firstMeetingDate"`
+	Partners         []entities.RelationshipPartner `bson:"partners"`
+	CreatedAt        time.Time                      `bson:"createdAt"`
+}
+
+// upgradeRelationships brings relationship documents below version 2 to the
+// shape of entities.Relationship: users mirrors the partners and
+// firstMeetingDate is filled in from the invite code, or failing that from the
+// earliest join date. It is idempotent and only touches outdated documents.
+func upgradeRelationships(ctx context.Context, db *mongo.Database) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"schemaVersion": bson.M{"$exists": false}},
+		bson.M{"schemaVersion": bson.M{"$lt": relationshipSchemaV2}},
+	}}
+	cursor, err := db.Collection("relationships").Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	upgraded := 0
+	for cursor.Next(ctx) {
+		var doc legacyRelationship
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		users := make([]primitive.ObjectID, 0, len(doc.Partners))
+		for _, p := range doc.Partners {
+			users = append(users, p.UserID)
+		}
+		update := bson.M{"$set": bson.M{
+			"users":            users,
+			"firstMeetingDate": legacyFirstMeetingDate(ctx, db, &doc),
+			"schemaVersion":    relationshipSchemaV2,
+		}}
+		if _, err := db.Collection("relationships").UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+			return fmt.Errorf("relationship %s: %w", doc.ID.Hex(), err)
+		}
+		upgraded++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("[DB][MIGRATE][BACKFILL] relationships upgraded=%d schemaVersion=%d", upgraded, relationshipSchemaV2)
+	return nil
+}
+
+// downgradeRelationships marks every document as older than version 2 so the
+// backfill runs again on the next up; the fields it filled in are kept
+func downgradeRelationships(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("relationships").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"schemaVersion": ""}})
+	return err
+}
+
+func legacyFirstMeetingDate(ctx context.Context, db *mongo.Database, doc *legacyRelationship) time.Time {
+	if doc.FirstMeetingDate != nil && !doc.FirstMeetingDate.IsZero() {
+		return *doc.FirstMeetingDate
+	}
+	if doc.InviteCode != "" {
+		var inv struct {
+			FirstMeetingDate time.Time `bson:"firstMeetingDate"`
+		}
+		// Used codes may already be gone through the expiry TTL index
+		if err := db.Collection("invitecodes").FindOne(ctx, bson.M{"code": doc.InviteCode}).Decode(&inv); err == nil && !inv.FirstMeetingDate.IsZero() {
+			return inv.FirstMeetingDate
+		}
+	}
+	rel := entities.Relationship{Partners: doc.Partners}
+	if since := rel.Since(); !since.IsZero() {
+		return since
+	}
+	return doc.CreatedAt
+}
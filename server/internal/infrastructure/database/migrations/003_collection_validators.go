@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+)
+
+// collectionValidators replace the validators scripts/mongo-init.js used to set
+// on first container start. They follow what the Go entities actually write:
+// email is optional (accounts from identity providers may have none) and events
+// keep isPublic under visibility.
+var collectionValidators = map[string]bson.M{
+	"users": {
+		"bsonType": "object",
+		"required": bson.A{"username", "name", "passwordHash", "createdAt"},
+		"properties": bson.M{
+			"username":     bson.M{"bsonType": "string", "minLength": 3, "maxLength": 30},
+			"email":        bson.M{"bsonType": "string", "pattern": `^[^@\s]+@[^@\s]+$`},
+			"name":         bson.M{"bsonType": "string", "minLength": 1, "maxLength": 100},
+			"passwordHash": bson.M{"bsonType": "string"},
+		},
+	},
+	"relationships": {
+		"bsonType": "object",
+		"required": bson.A{"partners", "users", "status", "firstMeetingDate", "createdAt"},
+		"properties": bson.M{
+			"users":            bson.M{"bsonType": "array", "minItems": 1, "maxItems": 2, "items": bson.M{"bsonType": "objectId"}},
+			"firstMeetingDate": bson.M{"bsonType": "date"},
+			"status": bson.M{"enum": bson.A{
+				entities.RelationshipStatusPending, entities.RelationshipStatusActive,
+				entities.RelationshipStatusDisconnectRequested, entities.RelationshipStatusDisconnected,
+			}},
+			"schemaVersion": bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+	"events": {
+		"bsonType": "object",
+		"required": bson.A{"relationshipId", "title", "date", "type", "createdAt"},
+		"properties": bson.M{
+			"title": bson.M{"bsonType": "string", "minLength": 1, "maxLength": 200},
+			"type":  bson.M{"bsonType": "string"},
+			"visibility": bson.M{
+				"bsonType":   "object",
+				"properties": bson.M{"isPublic": bson.M{"bsonType": "bool"}},
+			},
+		},
+	},
+	"whispers": {
+		"bsonType": "object",
+		"required": bson.A{"relationshipId", "type", "date", "recurrence", "createdAt"},
+		"properties": bson.M{
+			"type": bson.M{"bsonType": "string"},
+			"recurrence": bson.M{"enum": bson.A{
+				entities.WhisperRecurrenceOnce, entities.WhisperRecurrenceEveryday, entities.WhisperRecurrenceWeekly,
+				entities.WhisperRecurrenceMonthly, entities.WhisperRecurrenceCustom,
+			}},
+			"isDone": bson.M{"bsonType": "bool"},
+		},
+	},
+	"todos": {
+		"bsonType": "object",
+		"required": bson.A{"relationshipId", "title", "priority", "createdAt"},
+		"properties": bson.M{
+			"title":       bson.M{"bsonType": "string", "minLength": 1, "maxLength": 200},
+			"priority":    bson.M{"enum": bson.A{entities.TodoPriorityLow, entities.TodoPriorityMedium, entities.TodoPriorityHigh}},
+			"isCompleted": bson.M{"bsonType": "bool"},
+		},
+	},
+	"invitecodes": {
+		"bsonType": "object",
+		"required": bson.A{"code", "createdBy", "firstMeetingDate", "createdAt"},
+		"properties": bson.M{
+			"code":      bson.M{"bsonType": "string", "minLength": 6, "maxLength": 10},
+			"isUsed":    bson.M{"bsonType": "bool"},
+			"expiresAt": bson.M{"bsonType": "date"},
+		},
+	},
+}
+
+// setValidators installs the validators. Moderate validation checks inserts and
+// updates of documents that are already valid, so older documents that do not
+// match yet can still be updated.
+func setValidators(ctx context.Context, db *mongo.Database) error {
+	for name, schema := range collectionValidators {
+		if err := ensureCollection(ctx, db, name); err != nil {
+			return fmt.Errorf("create %s: %w", name, err)
+		}
+		cmd := bson.D{
+			{Key: "collMod", Value: name},
+			{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+			{Key: "validationLevel", Value: "moderate"},
+			{Key: "validationAction", Value: "error"},
+		}
+		if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+			return fmt.Errorf("set %s validator: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func removeValidators(ctx context.Context, db *mongo.Database) error {
+	for name := range collectionValidators {
+		cmd := bson.D{
+			{Key: "collMod", Value: name},
+			{Key: "validator", Value: bson.M{}},
+			{Key: "validationLevel", Value: "off"},
+		}
+		if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+			return fmt.Errorf("remove %s validator: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// grandfatheredField marks users whose email was treated as verified by this
+// migration rather than through a verification link, so Down can tell them apart
+const grandfatheredField = "emailVerificationGrandfathered"
+
+// grandfatherEmailVerification treats every account that existed before email
+// verification as verified since it was created. Without it features listed in
+// EMAIL_VERIFICATION_REQUIRED_FOR, invite codes by default, would be taken away
+// from all existing users on deploy. Accounts registered later verify as usual.
+func grandfatherEmailVerification(ctx context.Context, db *mongo.Database) error {
+	filter := bson.M{"emailVerifiedAt": nil}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"emailVerifiedAt":  "$createdAt",
+		grandfatheredField: true,
+	}}}}
+	res, err := db.Collection("users").UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DB][MIGRATE][BACKFILL] users treated as email-verified=%d", res.ModifiedCount)
+	return nil
+}
+
+// ungrandfatherEmailVerification removes the verification this migration granted;
+// addresses verified through a link are kept
+func ungrandfatherEmailVerification(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{grandfatheredField: true},
+		bson.M{"$unset": bson.M{"emailVerifiedAt": "", grandfatheredField: ""}},
+	)
+	return err
+}
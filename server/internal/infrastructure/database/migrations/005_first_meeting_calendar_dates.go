@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// calendarDateFirstMeetings stores every firstMeetingDate as midnight UTC of the
+// calendar day it stands for. Older documents hold the client's local midnight
+// as an instant, which reads as the day before (east of UTC) or after midnight
+// (west of it); rounding to the nearest UTC midnight recovers the day for every
+// offset below twelve hours. Dates already at midnight are left alone.
+func calendarDateFirstMeetings(ctx context.Context, db *mongo.Database) error {
+	filter := bson.M{"firstMeetingDate": bson.M{"$type": "date"}, "$expr": bson.M{
+		"$ne": bson.A{"$firstMeetingDate", bson.M{"$dateTrunc": bson.M{"date": "$firstMeetingDate", "unit": "day"}}},
+	}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"firstMeetingDate": bson.M{"$dateTrunc": bson.M{
+			"date": bson.M{"$dateAdd": bson.M{"startDate": "$firstMeetingDate", "unit": "hour", "amount": 12}},
+			"unit": "day",
+		}},
+	}}}}
+	for _, coll := range []string{"relationships", "invitecodes"} {
+		res, err := db.Collection(coll).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		log.Printf("[DB][MIGRATE][BACKFILL] %s firstMeetingDate rounded to calendar dates=%d", coll, res.ModifiedCount)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attachMedia records on each media document the event or user referencing it,
+// so that it can no longer be claimed by a second event. Media that two events
+// already share stays attached to one of them; the other's Discard then skips it.
+func attachMedia(ctx context.Context, db *mongo.Database) error {
+	refs := []struct {
+		coll  string
+		field string
+	}{
+		{"events", "image.mediaId"},
+		{"users", "avatar.mediaId"},
+	}
+	for _, ref := range refs {
+		cursor, err := db.Collection(ref.coll).Find(ctx, bson.M{ref.field: bson.M{"$type": "objectId"}},
+			options.Find().SetProjection(bson.M{ref.field: 1}))
+		if err != nil {
+			return err
+		}
+		attached := 0
+		for cursor.Next(ctx) {
+			var doc struct {
+				ID    primitive.ObjectID `bson:"_id"`
+				Image struct {
+					MediaID primitive.ObjectID `bson:"mediaId"`
+				} `bson:"image"`
+				Avatar struct {
+					MediaID primitive.ObjectID `bson:"mediaId"`
+				} `bson:"avatar"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				cursor.Close(ctx)
+				return err
+			}
+			mediaID := doc.Image.MediaID
+			if ref.coll == "users" {
+				mediaID = doc.Avatar.MediaID
+			}
+			res, err := db.Collection("media").UpdateOne(ctx,
+				bson.M{"_id": mediaID, "attachedTo": nil},
+				bson.M{"$set": bson.M{"attachedTo": doc.ID}})
+			if err != nil {
+				cursor.Close(ctx)
+				return err
+			}
+			attached += int(res.ModifiedCount)
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("[DB][MIGRATE][BACKFILL] media attached to %s=%d", ref.coll, attached)
+	}
+	return nil
+}
+
+func detachMedia(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("media").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"attachedTo": ""}})
+	return err
+}
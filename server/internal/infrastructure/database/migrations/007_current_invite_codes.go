@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// currentInviteIndexes allow a single current code per creator, so two
+// concurrent rotations cannot both leave a new code behind
+var currentInviteIndexes = []indexSet{
+	{"invitecodes", []mongo.IndexModel{
+		{Keys: bson.D{asc("createdBy"), asc("current")}, Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"current": true})},
+	}},
+}
+
+// markCurrentInvites marks the newest active code of every creator as current
+// and revokes the older active ones that concurrent rotations left behind, then
+// creates the index
+func markCurrentInvites(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("invitecodes")
+	if _, err := coll.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"current": false}}); err != nil {
+		return err
+	}
+	now := time.Now()
+	filter := bson.M{
+		"isUsed":    false,
+		"revokedAt": nil,
+		"$or":       bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": now}}},
+	}
+	cursor, err := coll.Find(ctx, filter, options.Find().
+		SetSort(bson.D{asc("createdBy"), desc("createdAt")}).
+		SetProjection(bson.M{"createdBy": 1}))
+	if err != nil {
+		return err
+	}
+	var current, revoked int
+	var last primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			CreatedBy primitive.ObjectID `bson:"createdBy"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			cursor.Close(ctx)
+			return err
+		}
+		update := bson.M{"$set": bson.M{"revokedAt": now, "updatedAt": now}}
+		if current == 0 || doc.CreatedBy != last {
+			update = bson.M{"$set": bson.M{"current": true}}
+			current++
+		} else {
+			revoked++
+		}
+		last = doc.CreatedBy
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+			cursor.Close(ctx)
+			return err
+		}
+	}
+	err = cursor.Err()
+	cursor.Close(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DB][MIGRATE][BACKFILL] current invite codes=%d extra active codes revoked=%d", current, revoked)
+	return createIndexes(currentInviteIndexes)(ctx, db)
+}
+
+func unmarkCurrentInvites(ctx context.Context, db *mongo.Database) error {
+	if err := dropIndexes(currentInviteIndexes)(ctx, db); err != nil {
+		return err
+	}
+	_, err := db.Collection("invitecodes").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"current": ""}})
+	return err
+}
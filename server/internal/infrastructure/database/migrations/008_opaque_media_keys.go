@@ -0,0 +1,181 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// ErrNoMediaStorage is returned when stored objects must be moved but the
+// caller did not pass the media storage with WithMediaStorage
+var ErrNoMediaStorage = errors.New("migration moves media objects but no media storage was given")
+
+type mediaStorageKey struct{}
+
+// WithMediaStorage gives the migrations that move stored objects access to the
+// media storage the API uses
+func WithMediaStorage(ctx context.Context, storage services.MediaStorage) context.Context {
+	return context.WithValue(ctx, mediaStorageKey{}, storage)
+}
+
+func mediaStorageFrom(ctx context.Context) services.MediaStorage {
+	storage, _ := ctx.Value(mediaStorageKey{}).(services.MediaStorage)
+	return storage
+}
+
+// mediaKeys computes the original's key and a rendition's key of a media document
+type mediaKeys struct {
+	original func(m *entities.Media) string
+	variant  func(m *entities.Media, name, contentType string) string
+}
+
+// opaqueMediaKeys name the media only, as entities.NewMedia does now
+var opaqueMediaKeys = mediaKeys{
+	original: func(m *entities.Media) string { return entities.MediaKey(m.Kind, m.ID, m.ContentType) },
+	variant:  func(m *entities.Media, name, contentType string) string { return m.VariantKey(name, contentType) },
+}
+
+// ownerMediaKeys are the keys media was stored under before, with the owner's ID
+var ownerMediaKeys = mediaKeys{
+	original: func(m *entities.Media) string {
+		return m.Kind + "/" + m.OwnerID.Hex() + "/" + m.ID.Hex() + entities.ImageExtensions[m.ContentType]
+	},
+	variant: func(m *entities.Media, name, contentType string) string {
+		return m.Kind + "/" + m.OwnerID.Hex() + "/" + m.ID.Hex() + "_" + name + entities.ImageExtensions[contentType]
+	},
+}
+
+// opaqueMediaKeysUp moves media objects away from keys containing the owner's
+// ID, which signed URLs on the public feed would otherwise reveal
+func opaqueMediaKeysUp(ctx context.Context, db *mongo.Database) error {
+	return renameMediaObjects(ctx, db, opaqueMediaKeys)
+}
+
+func opaqueMediaKeysDown(ctx context.Context, db *mongo.Database) error {
+	return renameMediaObjects(ctx, db, ownerMediaKeys)
+}
+
+// renameMediaObjects copies every object whose key differs from the wanted one,
+// points the media document and the event or avatar referencing it at the new
+// keys and then deletes the old objects. A run that fails half-way leaves the
+// old objects in place, so it can be retried.
+func renameMediaObjects(ctx context.Context, db *mongo.Database, want mediaKeys) error {
+	cursor, err := db.Collection("media").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	storage := mediaStorageFrom(ctx)
+	moved := 0
+	for cursor.Next(ctx) {
+		var m entities.Media
+		if err := cursor.Decode(&m); err != nil {
+			return err
+		}
+		var renames []mediaRename
+		if key := want.original(&m); key != m.Key {
+			renames = append(renames, mediaRename{m.Key, key, m.ContentType})
+			m.Key = key
+		}
+		for i, v := range m.Variants {
+			if key := want.variant(&m, v.Name, v.ContentType); key != v.Key {
+				renames = append(renames, mediaRename{v.Key, key, v.ContentType})
+				m.Variants[i].Key = key
+			}
+		}
+		if len(renames) == 0 {
+			continue
+		}
+		if storage == nil {
+			return ErrNoMediaStorage
+		}
+		for _, rn := range renames {
+			if err := copyMediaObject(ctx, storage, rn); err != nil {
+				return fmt.Errorf("copy media %s to %s: %w", rn.from, rn.to, err)
+			}
+		}
+		if err := pointAtMediaKeys(ctx, db, &m); err != nil {
+			return err
+		}
+		for _, rn := range renames {
+			if err := storage.Delete(ctx, rn.from); err != nil {
+				log.Printf("[DB][MIGRATE][WARN] media=%s old object %s left behind err=%v", m.ID.Hex(), rn.from, err)
+			}
+		}
+		moved++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	log.Printf("[DB][MIGRATE][BACKFILL] media moved to new keys=%d", moved)
+	return nil
+}
+
+type mediaRename struct {
+	from, to    string
+	contentType string
+}
+
+// copyMediaObject copies an object to its new key; a missing object was either
+// moved by an earlier run or lost before, and in both cases there is nothing to copy
+func copyMediaObject(ctx context.Context, storage services.MediaStorage, rn mediaRename) error {
+	r, err := storage.Get(ctx, rn.from)
+	if errors.Is(err, services.ErrMediaObjectNotFound) {
+		log.Printf("[DB][MIGRATE][WARN] media object %s not found, only its key is updated", rn.from)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	return storage.Put(ctx, rn.to, bytes.NewReader(data), int64(len(data)), rn.contentType)
+}
+
+// pointAtMediaKeys stores the new keys on the media document and on the event
+// image or avatar referencing it
+func pointAtMediaKeys(ctx context.Context, db *mongo.Database, m *entities.Media) error {
+	set := bson.M{"key": m.Key}
+	if len(m.Variants) > 0 {
+		set["variants"] = m.Variants
+	}
+	if _, err := db.Collection("media").UpdateOne(ctx, bson.M{"_id": m.ID}, bson.M{"$set": set}); err != nil {
+		return err
+	}
+	refs := []struct {
+		coll  string
+		field string
+	}{
+		{"events", "image"},
+		{"users", "avatar"},
+	}
+	for _, ref := range refs {
+		if err := pointRefAtMediaKeys(ctx, db.Collection(ref.coll), ref.field, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pointRefAtMediaKeys(ctx context.Context, coll *mongo.Collection, field string, m *entities.Media) error {
+	filter := bson.M{field + ".mediaId": m.ID}
+	if _, err := coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field + ".key": m.Key}}); err != nil {
+		return err
+	}
+	// Only references that copied the renditions carry their keys
+	filter[field+".variants.0"] = bson.M{"$exists": true}
+	_, err := coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field + ".variants": m.Variants}})
+	return err
+}
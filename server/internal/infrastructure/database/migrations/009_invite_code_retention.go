@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// inviteCodeRetention is how long invite codes are kept after they expire.
+// The TTL index used to remove them at expiry, so the expired status never
+// showed and invite histories lost every code after INVITE_CODE_TTL.
+const inviteCodeRetention = 90 * 24 * time.Hour
+
+func setInviteCodeTTL(after time.Duration) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		cmd := bson.D{
+			{Key: "collMod", Value: "invitecodes"},
+			{Key: "index", Value: bson.M{
+				"keyPattern":         bson.D{asc("expiresAt")},
+				"expireAfterSeconds": int64(after / time.Second),
+			}},
+		}
+		if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+			return fmt.Errorf("set invitecodes expiry index: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,306 @@
+// Package migrations versions the database schema: indexes, collection
+// validators and data backfills are ordered Go migrations, and the ones applied
+// are recorded in the schema_migrations collection.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one schema change. Up must be idempotent: when a run fails
+// half-way the migration is retried against the partly changed database.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	// Down reverts Up; nil when the change cannot be undone
+	Down func(ctx context.Context, db *mongo.Database) error
+}
+
+// CollectionName is where applied migrations are recorded
+const CollectionName = "schema_migrations"
+
+// Migration states as reported by Status
+const (
+	StateApplied = "applied"
+	StatePending = "pending"
+	StateRunning = "running" // claimed by a runner, or left behind by one that crashed
+	StateUnknown = "unknown" // recorded in the database but not known to this build
+)
+
+// Startup modes deciding what the API does about pending migrations
+const (
+	ModeStrict = "strict" // refuse to start
+	ModeAuto   = "auto"   // apply them
+	ModeIgnore = "ignore" // log a warning and start anyway
+)
+
+var (
+	ErrIrreversible = errors.New("migration cannot be reverted")
+	ErrLocked       = errors.New("migration is marked running")
+	ErrMissingRole  = errors.New("database user lacks the roles migrations need")
+)
+
+// codeUnauthorized is the server error for a command the user has no privilege for
+const codeUnauthorized = 13
+
+// explainUnauthorized names the missing roles when err is the server refusing
+// a command, e.g. collMod without dbAdmin on a volume created before
+// scripts/mongo-init.js granted it
+func explainUnauthorized(db *mongo.Database, err error) error {
+	var se mongo.ServerError
+	if !errors.As(err, &se) || !se.HasErrorCode(codeUnauthorized) {
+		return err
+	}
+	return fmt.Errorf("%w: grant readWrite and dbAdmin on %s to the MONGODB_URI user "+
+		"(scripts/grant-migration-roles.js does it for the docker-compose setup): %v", ErrMissingRole, db.Name(), err)
+}
+
+// record is a schema_migrations document
+type record struct {
+	Version     int        `bson:"_id"`
+	Description string     `bson:"description"`
+	State       string     `bson:"state"`
+	StartedAt   time.Time  `bson:"startedAt"`
+	AppliedAt   *time.Time `bson:"appliedAt,omitempty"`
+}
+
+// Status describes one migration
+type Status struct {
+	Version     int
+	Description string
+	State       string
+	AppliedAt   *time.Time
+}
+
+type Runner struct {
+	db         *mongo.Database
+	migrations []Migration
+}
+
+// NewRunner runs the given migrations, or all registered ones when none are given
+func NewRunner(db *mongo.Database, migrations ...Migration) *Runner {
+	if len(migrations) == 0 {
+		migrations = All()
+	}
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			panic(fmt.Sprintf("migrations: duplicate version %d", sorted[i].Version))
+		}
+	}
+	return &Runner{db: db, migrations: sorted}
+}
+
+func (r *Runner) collection() *mongo.Collection {
+	return r.db.Collection(CollectionName)
+}
+
+func (r *Runner) records(ctx context.Context) (map[int]record, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var recs []record
+	if err := cursor.All(ctx, &recs); err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]record, len(recs))
+	for _, rec := range recs {
+		byVersion[rec.Version] = rec
+	}
+	return byVersion, nil
+}
+
+// Status lists every known migration in order, followed by versions recorded in
+// the database that this build does not know
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	recs, err := r.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		st := Status{Version: m.Version, Description: m.Description, State: StatePending}
+		if rec, ok := recs[m.Version]; ok {
+			st.State = rec.State
+			st.AppliedAt = rec.AppliedAt
+			delete(recs, m.Version)
+		}
+		out = append(out, st)
+	}
+	var unknown []Status
+	for _, rec := range recs {
+		unknown = append(unknown, Status{Version: rec.Version, Description: rec.Description, State: StateUnknown, AppliedAt: rec.AppliedAt})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(out, unknown...), nil
+}
+
+// Pending lists the migrations not applied yet, in order
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	recs, err := r.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range r.migrations {
+		if rec, ok := recs[m.Version]; !ok || rec.State != StateApplied {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns how many it applied.
+// Each one is claimed with an insert first, so concurrent runners never apply
+// the same migration twice.
+func (r *Runner) Up(ctx context.Context) (int, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, m := range pending {
+		if err := r.apply(ctx, m); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func (r *Runner) apply(ctx context.Context, m Migration) error {
+	claim := record{Version: m.Version, Description: m.Description, State: StateRunning, StartedAt: time.Now()}
+	if _, err := r.collection().InsertOne(ctx, claim); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %d %s; if no other process is migrating, delete its %s record and retry",
+				ErrLocked, m.Version, m.Description, CollectionName)
+		}
+		return explainUnauthorized(r.db, err)
+	}
+	log.Printf("[DB][MIGRATE][START] version=%d %s", m.Version, m.Description)
+	if err := m.Up(ctx, r.db); err != nil {
+		// Release the claim so the migration can be retried; Up is idempotent
+		if _, delErr := r.collection().DeleteOne(context.Background(), bson.M{"_id": m.Version}); delErr != nil {
+			log.Printf("[DB][MIGRATE][ERROR] version=%d release claim err=%v", m.Version, delErr)
+		}
+		return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, explainUnauthorized(r.db, err))
+	}
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"state": StateApplied, "appliedAt": now}}
+	if _, err := r.collection().UpdateOne(ctx, bson.M{"_id": m.Version}, update); err != nil {
+		return err
+	}
+	log.Printf("[DB][MIGRATE][DONE] version=%d took=%s", m.Version, now.Sub(claim.StartedAt).Round(time.Millisecond))
+	return nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// how many it reverted
+func (r *Runner) Down(ctx context.Context, steps int) (int, error) {
+	recs, err := r.records(ctx)
+	if err != nil {
+		return 0, err
+	}
+	reverted := 0
+	for i := len(r.migrations) - 1; i >= 0 && reverted < steps; i-- {
+		m := r.migrations[i]
+		rec, ok := recs[m.Version]
+		if !ok {
+			continue
+		}
+		if rec.State != StateApplied {
+			return reverted, fmt.Errorf("%w: %d %s", ErrLocked, m.Version, m.Description)
+		}
+		if m.Down == nil {
+			return reverted, fmt.Errorf("%w: %d %s", ErrIrreversible, m.Version, m.Description)
+		}
+		if err := r.revert(ctx, m); err != nil {
+			return reverted, err
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+func (r *Runner) revert(ctx context.Context, m Migration) error {
+	// Claim the record so a concurrent Up or Down leaves it alone
+	filter := bson.M{"_id": m.Version, "state": StateApplied}
+	res, err := r.collection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"state": StateRunning}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("%w: %d %s", ErrLocked, m.Version, m.Description)
+	}
+	log.Printf("[DB][MIGRATE][DOWN] version=%d %s", m.Version, m.Description)
+	if err := m.Down(ctx, r.db); err != nil {
+		restore := bson.M{"$set": bson.M{"state": StateApplied}}
+		if _, resErr := r.collection().UpdateOne(context.Background(), bson.M{"_id": m.Version}, restore); resErr != nil {
+			log.Printf("[DB][MIGRATE][ERROR] version=%d restore record err=%v", m.Version, resErr)
+		}
+		return fmt.Errorf("revert migration %d (%s): %w", m.Version, m.Description, explainUnauthorized(r.db, err))
+	}
+	_, err = r.collection().DeleteOne(ctx, bson.M{"_id": m.Version})
+	return err
+}
+
+// EnsureApplied checks for pending migrations before the API starts. In strict
+// mode it fails when any are pending, in auto mode it applies them and in
+// ignore mode it only logs them.
+func EnsureApplied(ctx context.Context, db *mongo.Database, mode string) error {
+	r := NewRunner(db)
+	switch mode {
+	case ModeAuto:
+		_, err := r.Up(ctx)
+		return err
+	case ModeStrict, ModeIgnore:
+	default:
+		return fmt.Errorf("unknown migrations mode %q (want %s, %s or %s)", mode, ModeStrict, ModeAuto, ModeIgnore)
+	}
+
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if mode == ModeIgnore {
+		log.Printf("[DB][MIGRATE][WARN] %d pending migrations, starting anyway (first: %d %s)", len(pending), pending[0].Version, pending[0].Description)
+		return nil
+	}
+	return fmt.Errorf("%d pending migrations (first: %d %s); run `go run ./cmd/migrate up` or set MIGRATIONS_MODE=auto",
+		len(pending), pending[0].Version, pending[0].Description)
+}
+
+// ensureCollection creates the collection when it does not exist yet, so collMod
+// can be run against it
+func ensureCollection(ctx context.Context, db *mongo.Database, name string) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+	err = db.CreateCollection(ctx, name, options.CreateCollection())
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists" {
+		return nil // created concurrently
+	}
+	return err
+}
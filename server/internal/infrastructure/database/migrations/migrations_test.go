@@ -0,0 +1,390 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/infrastructure/repotest"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// mongoURI is the server the runner tests use; empty skips them
+var mongoURI string
+
+// TestMain uses TEST_MONGODB_URI when set and otherwise starts a throwaway mongod
+func TestMain(m *testing.M) {
+	mongoURI = os.Getenv("TEST_MONGODB_URI")
+	if mongoURI != "" {
+		os.Exit(m.Run())
+	}
+	mongod, err := repotest.StartMongod()
+	if err != nil {
+		if !errors.Is(err, repotest.ErrNoMongod) {
+			fmt.Fprintf(os.Stderr, "mongod: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(m.Run())
+	}
+	mongoURI = mongod.URI
+	code := m.Run()
+	mongod.Stop()
+	os.Exit(code)
+}
+
+// testDatabase returns an empty database dropped when the test ends; it skips
+// the test without a server
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	if mongoURI == "" {
+		t.Skip("no mongod binary found; install MongoDB, set MONGOD_BIN or set TEST_MONGODB_URI")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("whisper_migrations_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return db
+}
+
+// steps are migrations that log what they did to the steps collection
+func steps(versions ...int) []Migration {
+	var ms []Migration
+	for _, v := range versions {
+		v := v
+		ms = append(ms, Migration{
+			Version:     v,
+			Description: fmt.Sprintf("step %d", v),
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("steps").InsertOne(ctx, bson.M{"version": v, "op": "up"})
+				return err
+			},
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("steps").InsertOne(ctx, bson.M{"version": v, "op": "down"})
+				return err
+			},
+		})
+	}
+	return ms
+}
+
+// ran lists the steps logged so far, e.g. "up1 up2 down2"
+func ran(t *testing.T, db *mongo.Database) string {
+	t.Helper()
+	cursor, err := db.Collection("steps").Find(context.Background(), bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []struct {
+		Version int    `bson:"version"`
+		Op      string `bson:"op"`
+	}
+	if err := cursor.All(context.Background(), &docs); err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, d := range docs {
+		out = append(out, fmt.Sprintf("%s%d", d.Op, d.Version))
+	}
+	return strings.Join(out, " ")
+}
+
+func states(t *testing.T, r *Runner) string {
+	t.Helper()
+	statuses, err := r.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, st := range statuses {
+		out = append(out, fmt.Sprintf("%d:%s", st.Version, st.State))
+	}
+	return strings.Join(out, " ")
+}
+
+func TestUpAppliesPendingInOrder(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+
+	r := NewRunner(db, steps(2, 1)...)
+	if n, err := r.Up(ctx); err != nil || n != 2 {
+		t.Fatalf("up = %d, %v; want 2", n, err)
+	}
+	if got := ran(t, db); got != "up1 up2" {
+		t.Errorf("ran %q", got)
+	}
+	if n, err := r.Up(ctx); err != nil || n != 0 {
+		t.Errorf("second up = %d, %v; want nothing to do", n, err)
+	}
+
+	// A newer build applies only what is new, and an older one sees it as unknown
+	if n, err := NewRunner(db, steps(1, 2, 3)...).Up(ctx); err != nil || n != 1 {
+		t.Fatalf("up with a new migration = %d, %v; want 1", n, err)
+	}
+	if got := states(t, NewRunner(db, steps(1, 2)...)); got != "1:applied 2:applied 3:unknown" {
+		t.Errorf("status from an older build = %q", got)
+	}
+}
+
+func TestUpClaimsEachMigration(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	// Another runner holds version 2
+	claim := record{Version: 2, Description: "step 2", State: StateRunning, StartedAt: time.Now()}
+	if _, err := db.Collection(CollectionName).InsertOne(ctx, claim); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(db, steps(1, 2, 3)...)
+	n, err := r.Up(ctx)
+	if !errors.Is(err, ErrLocked) || n != 1 {
+		t.Fatalf("up = %d, %v; want 1 then %v", n, err, ErrLocked)
+	}
+	if got := ran(t, db); got != "up1" {
+		t.Errorf("ran %q; a claimed migration must not run twice", got)
+	}
+	if got := states(t, r); got != "1:applied 2:running 3:pending" {
+		t.Errorf("status = %q", got)
+	}
+	if _, err := r.Down(ctx, 5); !errors.Is(err, ErrLocked) {
+		t.Errorf("down past a running migration: %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestUpReleasesFailedClaim(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	fail := true
+	ms := steps(1)
+	up := ms[0].Up
+	ms[0].Up = func(ctx context.Context, db *mongo.Database) error {
+		if fail {
+			return errors.New("boom")
+		}
+		return up(ctx, db)
+	}
+
+	r := NewRunner(db, ms...)
+	if _, err := r.Up(ctx); err == nil || !strings.Contains(err.Error(), "migration 1 (step 1): boom") {
+		t.Fatalf("up = %v", err)
+	}
+	if got := states(t, r); got != "1:pending" {
+		t.Fatalf("status after failure = %q; the claim must be released", got)
+	}
+	fail = false
+	if n, err := r.Up(ctx); err != nil || n != 1 {
+		t.Errorf("retry = %d, %v", n, err)
+	}
+}
+
+func TestUpExplainsMissingRoles(t *testing.T) {
+	db := testDatabase(t)
+	ms := []Migration{{Version: 1, Description: "validators", Up: func(ctx context.Context, db *mongo.Database) error {
+		return mongo.CommandError{Code: codeUnauthorized, Name: "Unauthorized", Message: "not authorized on whisper_db to execute command { collMod: ... }"}
+	}}}
+	_, err := NewRunner(db, ms...).Up(context.Background())
+	if !errors.Is(err, ErrMissingRole) || !strings.Contains(err.Error(), "dbAdmin on "+db.Name()) {
+		t.Errorf("up = %v, want %v naming the role", err, ErrMissingRole)
+	}
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	ms := steps(1, 2, 3)
+	ms[0].Down = nil
+	r := NewRunner(db, ms...)
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := r.Down(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("down 1 = %d, %v", n, err)
+	}
+	if got := states(t, r); got != "1:applied 2:applied 3:pending" {
+		t.Errorf("status = %q", got)
+	}
+	n, err := r.Down(ctx, 5)
+	if !errors.Is(err, ErrIrreversible) || n != 1 {
+		t.Fatalf("down 5 = %d, %v; want 1 then %v", n, err, ErrIrreversible)
+	}
+	if got := ran(t, db); got != "up1 up2 up3 down3 down2" {
+		t.Errorf("ran %q", got)
+	}
+	if got := states(t, r); got != "1:applied 2:pending 3:pending" {
+		t.Errorf("status = %q", got)
+	}
+}
+
+func TestEnsureApplied(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+
+	if err := EnsureApplied(ctx, db, "sometimes"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if err := EnsureApplied(ctx, db, ModeStrict); err == nil || !strings.Contains(err.Error(), "pending migrations") {
+		t.Errorf("strict with pending migrations = %v", err)
+	}
+	if err := EnsureApplied(ctx, db, ModeIgnore); err != nil {
+		t.Errorf("ignore = %v", err)
+	}
+	if pending, _ := NewRunner(db).Pending(ctx); len(pending) != len(All()) {
+		t.Fatalf("%d pending after strict and ignore, want all %d", len(pending), len(All()))
+	}
+
+	if err := EnsureApplied(ctx, db, ModeAuto); err != nil {
+		t.Fatalf("auto = %v", err)
+	}
+	if err := EnsureApplied(ctx, db, ModeStrict); err != nil {
+		t.Errorf("strict after auto = %v", err)
+	}
+	// The registered migrations revert down to the first irreversible one and
+	// apply again
+	r := NewRunner(db)
+	if _, err := r.Down(ctx, len(All())); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("down all = %v, want %v", err, ErrIrreversible)
+	}
+	if n, err := r.Up(ctx); err != nil || n == 0 {
+		t.Errorf("up again = %d, %v", n, err)
+	}
+}
+
+func TestOpaqueMediaKeys(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	storage, err := services.NewLocalMediaStorage(t.TempDir(), "http://localhost/media", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, mediaID := primitive.NewObjectID(), primitive.NewObjectID()
+	legacy := &entities.Media{ID: mediaID, OwnerID: owner, Kind: entities.MediaKindEventImage, ContentType: "image/png"}
+	oldKey := ownerMediaKeys.original(legacy)
+	oldSmall := ownerMediaKeys.variant(legacy, entities.MediaVariantSmall, "image/jpeg")
+	for key, body := range map[string]string{oldKey: "original", oldSmall: "small"} {
+		if err := storage.Put(ctx, key, strings.NewReader(body), int64(len(body)), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	variants := bson.A{bson.M{"name": entities.MediaVariantSmall, "key": oldSmall, "contentType": "image/jpeg"}}
+	insert := func(coll string, doc bson.M) {
+		if _, err := db.Collection(coll).InsertOne(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("media", bson.M{"_id": mediaID, "ownerId": owner, "kind": legacy.Kind, "key": oldKey, "contentType": "image/png", "variants": variants})
+	insert("events", bson.M{"image": bson.M{"type": "media", "mediaId": mediaID, "key": oldKey, "variants": variants}})
+
+	if err := opaqueMediaKeysUp(ctx, db); !errors.Is(err, ErrNoMediaStorage) {
+		t.Fatalf("without storage = %v, want %v", err, ErrNoMediaStorage)
+	}
+	ctx = WithMediaStorage(ctx, storage)
+	for run := 0; run < 2; run++ {
+		if err := opaqueMediaKeysUp(ctx, db); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	var m entities.Media
+	if err := db.Collection("media").FindOne(ctx, bson.M{"_id": mediaID}).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	newSmall := m.VariantKey(entities.MediaVariantSmall, "image/jpeg")
+	if m.Key != entities.MediaKey(m.Kind, mediaID, "image/png") || len(m.Variants) != 1 || m.Variants[0].Key != newSmall {
+		t.Fatalf("media keys %q %+v", m.Key, m.Variants)
+	}
+	if strings.Contains(m.Key+newSmall, owner.Hex()) {
+		t.Errorf("keys %q and %q contain the owner", m.Key, newSmall)
+	}
+	var ev struct {
+		Image entities.EventImage `bson:"image"`
+	}
+	if err := db.Collection("events").FindOne(ctx, bson.M{"image.mediaId": mediaID}).Decode(&ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Image.Key != m.Key || len(ev.Image.Variants) != 1 || ev.Image.Variants[0].Key != newSmall {
+		t.Errorf("event image keys %q %+v", ev.Image.Key, ev.Image.Variants)
+	}
+	for key, want := range map[string]string{m.Key: "original", newSmall: "small"} {
+		r, err := storage.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		body, _ := io.ReadAll(r)
+		r.Close()
+		if string(body) != want {
+			t.Errorf("%s holds %q, want %q", key, body, want)
+		}
+	}
+	for _, key := range []string{oldKey, oldSmall} {
+		if _, err := storage.Get(ctx, key); !errors.Is(err, services.ErrMediaObjectNotFound) {
+			t.Errorf("old object %s: %v, want it deleted", key, err)
+		}
+	}
+
+	if err := opaqueMediaKeysDown(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := storage.Get(ctx, oldKey); err != nil {
+		t.Errorf("down did not move the original back: %v", err)
+	} else {
+		r.Close()
+	}
+}
+
+func TestInviteCodeRetention(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	r := NewRunner(db)
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expireAfter := func() int64 {
+		t.Helper()
+		cursor, err := db.Collection("invitecodes").Indexes().List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var indexes []bson.M
+		if err := cursor.All(ctx, &indexes); err != nil {
+			t.Fatal(err)
+		}
+		for _, idx := range indexes {
+			if idx["name"] == "expiresAt_1" {
+				v, _ := idx["expireAfterSeconds"].(int64)
+				if i, ok := idx["expireAfterSeconds"].(int32); ok {
+					v = int64(i)
+				}
+				return v
+			}
+		}
+		t.Fatal("no expiresAt index")
+		return 0
+	}
+	if got, want := expireAfter(), int64(inviteCodeRetention/time.Second); got != want {
+		t.Errorf("expireAfterSeconds = %d, want %d", got, want)
+	}
+	if _, err := r.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := expireAfter(); got != 0 {
+		t.Errorf("expireAfterSeconds after down = %d, want 0", got)
+	}
+}
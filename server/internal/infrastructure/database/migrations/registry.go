@@ -0,0 +1,61 @@
+package migrations
+
+// All returns every migration in the order they are applied. New migrations get
+// the next version and a file named after it; applied ones are never edited.
+func All() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "create initial indexes",
+			Up:          createIndexes(initialIndexes),
+			Down:        dropIndexes(initialIndexes),
+		},
+		{
+			Version:     2,
+			Description: "backfill users and firstMeetingDate on relationships",
+			Up:          upgradeRelationships,
+			Down:        downgradeRelationships,
+		},
+		{
+			Version:     3,
+			Description: "set collection validators",
+			Up:          setValidators,
+			Down:        removeValidators,
+		},
+		{
+			Version:     4,
+			Description: "treat users created before email verification as verified",
+			Up:          grandfatherEmailVerification,
+			Down:        ungrandfatherEmailVerification,
+		},
+		{
+			Version:     5,
+			Description: "store first meeting dates as calendar dates",
+			Up:          calendarDateFirstMeetings,
+		},
+		{
+			Version:     6,
+			Description: "attach media to the event or user referencing it",
+			Up:          attachMedia,
+			Down:        detachMedia,
+		},
+		{
+			Version:     7,
+			Description: "keep one current invite code per creator",
+			Up:          markCurrentInvites,
+			Down:        unmarkCurrentInvites,
+		},
+		{
+			Version:     8,
+			Description: "store media under keys without the owner's ID",
+			Up:          opaqueMediaKeysUp,
+			Down:        opaqueMediaKeysDown,
+		},
+		{
+			Version:     9,
+			Description: "keep invite codes for a retention period after they expire",
+			Up:          setInviteCodeTTL(inviteCodeRetention),
+			Down:        setInviteCodeTTL(0),
+		},
+	}
+}
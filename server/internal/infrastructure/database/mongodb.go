@@ -7,7 +7,6 @@ import (
 
 	"whisper-server/internal/infrastructure/config"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -17,6 +16,8 @@ type MongoDB struct {
 	client   *mongo.Client
 	database *mongo.Database
 	config   config.DatabaseConfig
+	// transactions is false on standalone servers, which cannot run them
+	transactions bool
 }
 
 func NewMongoDB(cfg config.DatabaseConfig) (*MongoDB, error) {
@@ -50,11 +51,13 @@ func NewMongoDB(cfg config.DatabaseConfig) (*MongoDB, error) {
 		config:   cfg,
 	}
 
-	// Create indexes
-	if err := db.createIndexes(ctx); err != nil {
-		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	db.transactions = db.detectTransactions(ctx)
+	if !db.transactions && cfg.RequireTransactions {
+		client.Disconnect(context.Background())
+		return nil, ErrNoTransactions
 	}
 
+	// Indexes, validators and backfills are applied by the migrations package
 	return db, nil
 }
 
@@ -102,122 +105,46 @@ func (m *MongoDB) InviteCodes() *mongo.Collection {
     return m.database.Collection("invitecodes")
 }
 
-func (m *MongoDB) EventTypes() *mongo.Collection {
-	return m.database.Collection("event_types")
+func (m *MongoDB) Media() *mongo.Collection {
+	return m.database.Collection("media")
 }
 
-func (m *MongoDB) WhisperTypes() *mongo.Collection {
-	return m.database.Collection("whisper_types")
+func (m *MongoDB) RefreshTokens() *mongo.Collection {
+	return m.database.Collection("refresh_tokens")
 }
 
-// Create database indexes
-func (m *MongoDB) createIndexes(ctx context.Context) error {
-	// Users indexes
-	usersIndexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{"username", 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys:    bson.D{{"email", 1}},
-			Options: options.Index().SetUnique(true).SetSparse(true),
-		},
-		{
-			Keys: bson.D{{"relationshipId", 1}},
-		},
-	}
-	if _, err := m.Users().Indexes().CreateMany(ctx, usersIndexes); err != nil {
-		return fmt.Errorf("failed to create users indexes: %w", err)
-	}
+func (m *MongoDB) Sessions() *mongo.Collection {
+	return m.database.Collection("sessions")
+}
 
-	// Relationships indexes
-	relationshipsIndexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{{"partners.userId", 1}},
-		},
-		{
-			Keys:    bson.D{{"inviteCode", 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{"status", 1}},
-		},
-	}
-	if _, err := m.Relationships().Indexes().CreateMany(ctx, relationshipsIndexes); err != nil {
-		return fmt.Errorf("failed to create relationships indexes: %w", err)
-	}
+func (m *MongoDB) OneTimeTokens() *mongo.Collection {
+	return m.database.Collection("one_time_tokens")
+}
 
-	// Events indexes
-	eventsIndexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{{"relationshipId", 1}, {"date", -1}},
-		},
-		{
-			Keys: bson.D{{"visibility.isPublic", 1}, {"viewCount", -1}},
-		},
-		{
-			Keys: bson.D{{"createdBy", 1}, {"createdAt", -1}},
-		},
-		{
-			Keys: bson.D{{"type", 1}},
-		},
-	}
-	if _, err := m.Events().Indexes().CreateMany(ctx, eventsIndexes); err != nil {
-		return fmt.Errorf("failed to create events indexes: %w", err)
-	}
+func (m *MongoDB) LoginAttempts() *mongo.Collection {
+	return m.database.Collection("login_attempts")
+}
 
-	// Whispers indexes
-	whispersIndexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{{"relationshipId", 1}, {"date", 1}},
-		},
-		{
-			Keys: bson.D{{"isDone", 1}, {"date", 1}},
-		},
-		{
-			Keys: bson.D{{"type", 1}},
-		},
-	}
-	if _, err := m.Whispers().Indexes().CreateMany(ctx, whispersIndexes); err != nil {
-		return fmt.Errorf("failed to create whispers indexes: %w", err)
-	}
+func (m *MongoDB) TwoFactor() *mongo.Collection {
+	return m.database.Collection("two_factor")
+}
 
-	// Todos indexes
-	todosIndexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{{"relationshipId", 1}, {"isCompleted", 1}},
-		},
-		{
-			Keys: bson.D{{"priority", 1}, {"createdAt", -1}},
-		},
-		{
-			Keys: bson.D{{"dueDate", 1}},
-		},
-	}
-	if _, err := m.Todos().Indexes().CreateMany(ctx, todosIndexes); err != nil {
-		return fmt.Errorf("failed to create todos indexes: %w", err)
-	}
+func (m *MongoDB) ExternalIdentities() *mongo.Collection {
+	return m.database.Collection("external_identities")
+}
 
-	// Invite codes indexes
-	inviteCodesIndexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{"code", 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{"createdBy", 1}},
-		},
-		{
-			Keys:    bson.D{{"expiresAt", 1}},
-			Options: options.Index().SetExpireAfterSeconds(0),
-		},
-		{
-			Keys: bson.D{{"isUsed", 1}},
-		},
-	}
-	if _, err := m.InviteCodes().Indexes().CreateMany(ctx, inviteCodesIndexes); err != nil {
-		return fmt.Errorf("failed to create invite codes indexes: %w", err)
-	}
+func (m *MongoDB) OIDCStates() *mongo.Collection {
+	return m.database.Collection("oidc_states")
+}
 
-	return nil
+func (m *MongoDB) DataExports() *mongo.Collection {
+	return m.database.Collection("data_exports")
+}
+
+func (m *MongoDB) EventTypes() *mongo.Collection {
+	return m.database.Collection("event_types")
+}
+
+func (m *MongoDB) WhisperTypes() *mongo.Collection {
+	return m.database.Collection("whisper_types")
 }
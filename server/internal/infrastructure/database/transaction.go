@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNoTransactions is returned by NewMongoDB for a standalone server unless
+// DatabaseConfig.RequireTransactions is off
+var ErrNoTransactions = errors.New("MongoDB is a standalone server and cannot run transactions; " +
+	"run it as a replica set (a single member is enough) or set MONGODB_REQUIRE_TRANSACTIONS=false")
+
+// detectTransactions reports whether the deployment is a replica set or a
+// sharded cluster; standalone servers reject multi-document transactions
+func (m *MongoDB) detectTransactions(ctx context.Context) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := m.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		log.Printf("[DB][TX][ERROR] could not detect deployment type: %v", err)
+		return false
+	}
+	if hello.SetName != "" || hello.Msg == "isdbgrid" {
+		return true
+	}
+	return false
+}
+
+// SupportsTransactions reports whether WithTransaction runs fn in a real transaction
+func (m *MongoDB) SupportsTransactions() bool {
+	return m.transactions
+}
+
+// WithTransaction runs fn in a session transaction, committing when it returns
+// nil and aborting otherwise. Collection calls must use the context passed to fn
+// to join the transaction. Transient errors make the driver retry fn, so it must
+// be safe to run more than once.
+//
+// Standalone servers are only accepted with RequireTransactions off. There fn
+// runs directly and every call says so: callers keep single-document atomicity,
+// which their compare-and-set writes rely on, but a failure half-way leaves the
+// earlier writes in place.
+func (m *MongoDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !m.transactions {
+		log.Printf("[DB][TX][WARN] standalone server: running multi-document write without a transaction")
+		return fn(ctx)
+	}
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
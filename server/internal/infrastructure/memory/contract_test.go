@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"testing"
+
+	"whisper-server/internal/infrastructure/repotest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		s := NewStore()
+		return repotest.Repos{
+			Users:              NewUserRepository(s),
+			Relationships:      NewRelationshipRepository(s),
+			Invites:            NewInviteRepository(s),
+			Events:             NewEventRepository(s),
+			Whispers:           NewWhisperRepository(s),
+			Sessions:           NewSessionRepository(s),
+			RefreshTokens:      NewRefreshTokenRepository(s),
+			OneTimeTokens:      NewOneTimeTokenRepository(s),
+			LoginAttempts:      NewLoginAttemptRepository(s),
+			DataExports:        NewDataExportRepository(s),
+			TwoFactor:          NewTwoFactorRepository(s),
+			Media:              NewMediaRepository(s),
+			ExternalIdentities: NewExternalIdentityRepository(s),
+			OIDCStates:         NewOIDCStateRepository(s),
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type dataExportRepository struct {
+	s *Store
+}
+
+func NewDataExportRepository(s *Store) repositories.DataExportRepository {
+	return &dataExportRepository{s: s}
+}
+
+func (r *dataExportRepository) put(ctx context.Context, export entities.DataExport) {
+	r.s.record(ctx, restorer(r.s.dataExports, export.ID))
+	r.s.dataExports[export.ID] = export
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *entities.DataExport) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.dataExports[export.ID]; ok {
+		return errors.New("data export already exists")
+	}
+	r.put(ctx, *export)
+	return nil
+}
+
+func (r *dataExportRepository) FindLatestByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.DataExport, error) {
+	exports := r.find(func(e *entities.DataExport) bool { return e.UserID == userID })
+	if len(exports) == 0 {
+		return nil, errors.New("data export not found")
+	}
+	return exports[len(exports)-1], nil
+}
+
+// claimable reports whether a worker may take the export; the caller holds the lock
+func claimable(e *entities.DataExport, staleBefore time.Time) bool {
+	return e.Status == entities.DataExportStatusPending ||
+		(e.Status == entities.DataExportStatusProcessing && e.StartedAt != nil && e.StartedAt.Before(staleBefore))
+}
+
+func (r *dataExportRepository) Claim(ctx context.Context, id primitive.ObjectID, staleBefore time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	export, ok := r.s.dataExports[id]
+	if !ok || !claimable(&export, staleBefore) {
+		return false, nil
+	}
+	now := time.Now()
+	export.Status = entities.DataExportStatusProcessing
+	export.StartedAt = &now
+	r.put(ctx, export)
+	return true, nil
+}
+
+func (r *dataExportRepository) MarkReady(ctx context.Context, id primitive.ObjectID, size int64, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if export, ok := r.s.dataExports[id]; ok {
+		now := time.Now()
+		export.Status = entities.DataExportStatusReady
+		export.Size = size
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+		r.put(ctx, export)
+	}
+	return nil
+}
+
+func (r *dataExportRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if export, ok := r.s.dataExports[id]; ok {
+		now := time.Now()
+		export.Status = entities.DataExportStatusFailed
+		export.Error = reason
+		export.CompletedAt = &now
+		r.put(ctx, export)
+	}
+	return nil
+}
+
+func (r *dataExportRepository) FindUnfinished(ctx context.Context, staleBefore time.Time) ([]*entities.DataExport, error) {
+	return r.find(func(e *entities.DataExport) bool { return claimable(e, staleBefore) }), nil
+}
+
+func (r *dataExportRepository) FindExpired(ctx context.Context, now time.Time) ([]*entities.DataExport, error) {
+	return r.find(func(e *entities.DataExport) bool {
+		return e.Status == entities.DataExportStatusReady && e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+	}), nil
+}
+
+func (r *dataExportRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.DataExport, error) {
+	return r.find(func(e *entities.DataExport) bool { return e.UserID == userID }), nil
+}
+
+// find returns the matching exports, oldest first
+func (r *dataExportRepository) find(match func(e *entities.DataExport) bool) []*entities.DataExport {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var exports []*entities.DataExport
+	for _, e := range r.s.dataExports {
+		if match(&e) {
+			e := e
+			exports = append(exports, &e)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		if !exports[i].CreatedAt.Equal(exports[j].CreatedAt) {
+			return exports[i].CreatedAt.Before(exports[j].CreatedAt)
+		}
+		return idLess(exports[i].ID, exports[j].ID)
+	})
+	return exports
+}
+
+func (r *dataExportRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.dataExports[id]; ok {
+		r.s.record(ctx, restorer(r.s.dataExports, id))
+		delete(r.s.dataExports, id)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type eventRepository struct {
+	s *Store
+}
+
+func NewEventRepository(s *Store) repositories.EventRepository {
+	return &eventRepository{s: s}
+}
+
+// storedEvent copies ev so later changes by the caller do not leak into the store
+func storedEvent(ev *entities.Event) entities.Event {
+	c := *ev
+	if ev.Image != nil {
+		img := *ev.Image
+		img.Variants = append([]entities.MediaVariant(nil), ev.Image.Variants...)
+		c.Image = &img
+	}
+	c.Visibility.SharedWith = append([]primitive.ObjectID(nil), ev.Visibility.SharedWith...)
+	return c
+}
+
+func (r *eventRepository) put(ctx context.Context, ev *entities.Event) {
+	r.s.record(ctx, restorer(r.s.events, ev.ID))
+	r.s.events[ev.ID] = storedEvent(ev)
+}
+
+func (r *eventRepository) Create(ctx context.Context, event *entities.Event) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.s.events[event.ID]; ok {
+		return errors.New("event already exists")
+	}
+	event.CreatedAt = time.Now()
+	event.UpdatedAt = time.Now()
+	r.put(ctx, event)
+	return nil
+}
+
+func (r *eventRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ev, ok := r.s.events[id]
+	if !ok {
+		return nil, errors.New("event not found")
+	}
+	c := storedEvent(&ev)
+	return &c, nil
+}
+
+func (r *eventRepository) Update(ctx context.Context, event *entities.Event) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event.UpdatedAt = time.Now()
+	stored, ok := r.s.events[event.ID]
+	if !ok {
+		return nil
+	}
+	next := *event
+	// Like $set with omitempty tags, empty top-level optional fields keep their stored value
+	if next.Description == "" {
+		next.Description = stored.Description
+	}
+	if next.Image == nil {
+		next.Image = stored.Image
+	}
+	r.put(ctx, &next)
+	return nil
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.events[id]; ok {
+		r.s.record(ctx, restorer(r.s.events, id))
+		delete(r.s.events, id)
+	}
+	return nil
+}
+
+// filter returns copies of the events matching; the caller holds the lock
+func (r *eventRepository) filter(match func(ev *entities.Event) bool) []*entities.Event {
+	var events []*entities.Event
+	for _, ev := range r.s.events {
+		if match(&ev) {
+			c := storedEvent(&ev)
+			events = append(events, &c)
+		}
+	}
+	return events
+}
+
+// byDateDesc orders events newest first
+func byDateDesc(events []*entities.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+		return idLess(a.ID, b.ID)
+	})
+}
+
+func (r *eventRepository) FindAllByUserID(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]*entities.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	events := r.filter(func(ev *entities.Event) bool { return ev.CreatedBy == userID })
+	byDateDesc(events)
+	return page(events, limit, offset), nil
+}
+
+func (r *eventRepository) FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, limit, offset int64) ([]*entities.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	events := r.filter(func(ev *entities.Event) bool { return ev.RelationshipID == relationshipID })
+	byDateDesc(events)
+	return page(events, limit, offset), nil
+}
+
+func (r *eventRepository) FindPublic(ctx context.Context, eventType, sortBy string, limit, offset int64) ([]*entities.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	events := r.filter(func(ev *entities.Event) bool {
+		return ev.Visibility.IsPublic && (eventType == "" || ev.Type == eventType)
+	})
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if sortBy == repositories.PublicEventSortPopular && a.ViewCount != b.ViewCount {
+			return a.ViewCount > b.ViewCount
+		}
+		if c := comparePublishedAt(a.Visibility.PublishedAt, b.Visibility.PublishedAt); c != 0 {
+			return c > 0
+		}
+		if sortBy != repositories.PublicEventSortPopular && !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+		return idLess(a.ID, b.ID)
+	})
+	return page(events, limit, offset), nil
+}
+
+// comparePublishedAt compares like MongoDB, where a missing date sorts below any date
+func comparePublishedAt(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+func (r *eventRepository) IncrementViewCount(ctx context.Context, id primitive.ObjectID) (*entities.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ev, ok := r.s.events[id]
+	if !ok || !ev.Visibility.IsPublic {
+		return nil, errors.New("event not found")
+	}
+	ev.ViewCount++
+	r.put(ctx, &ev)
+	c := storedEvent(&ev)
+	return &c, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// errDuplicateIdentity is what the unique indexes on external_identities raise,
+// so callers checking mongo.IsDuplicateKeyError see the same error
+var errDuplicateIdentity = mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error collection: external_identities"}}}
+
+type externalIdentityRepository struct {
+	s *Store
+}
+
+func NewExternalIdentityRepository(s *Store) repositories.ExternalIdentityRepository {
+	return &externalIdentityRepository{s: s}
+}
+
+func (r *externalIdentityRepository) put(ctx context.Context, identity entities.ExternalIdentity) {
+	r.s.record(ctx, restorer(r.s.externalIdentities, identity.ID))
+	r.s.externalIdentities[identity.ID] = identity
+}
+
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *entities.ExternalIdentity) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// Like the unique indexes on provider+subject and userId+provider
+	for _, existing := range r.s.externalIdentities {
+		if existing.Provider == identity.Provider && (existing.Subject == identity.Subject || existing.UserID == identity.UserID) {
+			return errDuplicateIdentity
+		}
+	}
+	r.put(ctx, *identity)
+	return nil
+}
+
+func (r *externalIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*entities.ExternalIdentity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, identity := range r.s.externalIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, repositories.ErrExternalIdentityNotFound
+}
+
+func (r *externalIdentityRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.ExternalIdentity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var identities []*entities.ExternalIdentity
+	for _, identity := range r.s.externalIdentities {
+		if identity.UserID == userID {
+			identities = append(identities, &identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].CreatedAt.Before(identities[j].CreatedAt) })
+	return identities, nil
+}
+
+func (r *externalIdentityRepository) TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if identity, ok := r.s.externalIdentities[id]; ok {
+		identity.LastLoginAt = &at
+		r.put(ctx, identity)
+	}
+	return nil
+}
+
+func (r *externalIdentityRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, identity := range r.s.externalIdentities {
+		if identity.UserID == userID {
+			r.s.record(ctx, restorer(r.s.externalIdentities, id))
+			delete(r.s.externalIdentities, id)
+		}
+	}
+	return nil
+}
+
+type oidcStateRepository struct {
+	s *Store
+}
+
+func NewOIDCStateRepository(s *Store) repositories.OIDCStateRepository {
+	return &oidcStateRepository{s: s}
+}
+
+func (r *oidcStateRepository) Create(ctx context.Context, state *entities.OIDCState) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.oidcStates[state.StateHash]; ok {
+		return errors.New("oidc state already exists")
+	}
+	r.s.record(ctx, restorer(r.s.oidcStates, state.StateHash))
+	r.s.oidcStates[state.StateHash] = *state
+	return nil
+}
+
+func (r *oidcStateRepository) Consume(ctx context.Context, stateHash string) (*entities.OIDCState, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	state, ok := r.s.oidcStates[stateHash]
+	if !ok || !state.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("oidc state not found")
+	}
+	r.s.record(ctx, restorer(r.s.oidcStates, stateHash))
+	delete(r.s.oidcStates, stateHash)
+	return &state, nil
+}
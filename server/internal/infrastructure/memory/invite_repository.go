@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// errDuplicateInvite is what the unique indexes on invitecodes raise, so the
+// code generation retry sees the same error
+var errDuplicateInvite = mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error collection: invitecodes"}}}
+
+type inviteRepository struct {
+	s *Store
+}
+
+func NewInviteRepository(s *Store) repositories.InviteRepository {
+	return &inviteRepository{s: s}
+}
+
+func (r *inviteRepository) put(ctx context.Context, inv entities.InviteCode) {
+	r.s.record(ctx, restorer(r.s.invites, inv.Code))
+	r.s.invites[inv.Code] = inv
+}
+
+func (r *inviteRepository) Create(ctx context.Context, inv *entities.InviteCode) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.invites[inv.Code]; ok {
+		return errDuplicateInvite
+	}
+	// Like the partial unique index on createdBy and current
+	for _, existing := range r.s.invites {
+		if inv.Current && existing.Current && existing.CreatedBy == inv.CreatedBy {
+			return errDuplicateInvite
+		}
+	}
+	if inv.ID.IsZero() {
+		inv.ID = primitive.NewObjectID()
+	}
+	inv.CreatedAt = time.Now()
+	inv.UpdatedAt = time.Now()
+	r.put(ctx, *inv)
+	return nil
+}
+
+func (r *inviteRepository) FindByCode(ctx context.Context, code string) (*entities.InviteCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	inv, ok := r.s.invites[code]
+	if !ok {
+		return nil, errors.New("invite code not found")
+	}
+	return &inv, nil
+}
+
+func (r *inviteRepository) Redeem(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) (*entities.InviteCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	inv, ok := r.s.invites[code]
+	if !ok || !inv.IsActive(at) {
+		return nil, repositories.ErrInviteUnavailable
+	}
+	inv.IsUsed = true
+	inv.UsedBy = &userID
+	inv.UsedAt = &at
+	inv.Current = false
+	inv.UpdatedAt = at
+	r.put(ctx, inv)
+	return &inv, nil
+}
+
+func (r *inviteRepository) FindByCreator(ctx context.Context, userID primitive.ObjectID) ([]*entities.InviteCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var invites []*entities.InviteCode
+	for _, inv := range r.s.invites {
+		if inv.CreatedBy == userID {
+			invites = append(invites, &inv)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.After(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+func (r *inviteRepository) DeleteByCreator(ctx context.Context, userID primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for code, inv := range r.s.invites {
+		if inv.CreatedBy == userID {
+			r.s.record(ctx, restorer(r.s.invites, code))
+			delete(r.s.invites, code)
+		}
+	}
+	return nil
+}
+
+func (r *inviteRepository) Revoke(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	inv, ok := r.s.invites[code]
+	if !ok || inv.CreatedBy != userID || inv.IsUsed || inv.RevokedAt != nil {
+		return errors.New("invite code not found")
+	}
+	inv.RevokedAt = &at
+	inv.Current = false
+	inv.UpdatedAt = at
+	r.put(ctx, inv)
+	return nil
+}
+
+func (r *inviteRepository) RevokeActiveByCreator(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, inv := range r.s.invites {
+		if inv.CreatedBy != userID || (!inv.Current && !inv.IsActive(at)) {
+			continue
+		}
+		// An expired code is no longer current but keeps its status
+		if inv.IsActive(at) {
+			inv.RevokedAt = &at
+		}
+		inv.Current = false
+		inv.UpdatedAt = at
+		r.put(ctx, inv)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// loginAttemptRetention matches how long the MongoDB records outlive their last failure
+const loginAttemptRetention = 24 * time.Hour
+
+type loginAttemptRepository struct {
+	s *Store
+}
+
+func NewLoginAttemptRepository(s *Store) repositories.LoginAttemptRepository {
+	return &loginAttemptRepository{s: s}
+}
+
+func (r *loginAttemptRepository) put(ctx context.Context, a entities.LoginAttempt) {
+	r.s.record(ctx, restorer(r.s.loginAttempts, a.Key))
+	r.s.loginAttempts[a.Key] = a
+}
+
+func (r *loginAttemptRepository) FindByKey(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.loginAttempts[key]
+	if !ok {
+		return nil, errors.New("login attempt not found")
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*entities.LoginAttempt, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.loginAttempts[key]
+	if !ok {
+		a = entities.LoginAttempt{Key: key}
+	}
+	if a.LastFailureAt.After(now.Add(-window)) {
+		a.Failures++
+	} else {
+		a.Failures = 1
+	}
+	a.LastFailureAt = now
+	a.ExpiresAt = now.Add(loginAttemptRetention)
+	r.put(ctx, a)
+	return &a, nil
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if a, ok := r.s.loginAttempts[key]; ok {
+		a.LockedUntil = &until
+		a.ExpiresAt = until.Add(loginAttemptRetention)
+		r.put(ctx, a)
+	}
+	return nil
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.loginAttempts[key]; ok {
+		r.s.record(ctx, restorer(r.s.loginAttempts, key))
+		delete(r.s.loginAttempts, key)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type mediaRepository struct {
+	s *Store
+}
+
+func NewMediaRepository(s *Store) repositories.MediaRepository {
+	return &mediaRepository{s: s}
+}
+
+func (r *mediaRepository) put(ctx context.Context, media entities.Media) {
+	r.s.record(ctx, restorer(r.s.media, media.ID))
+	media.Variants = append([]entities.MediaVariant{}, media.Variants...)
+	r.s.media[media.ID] = media
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *entities.Media) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.media[media.ID]; ok {
+		return errors.New("media already exists")
+	}
+	r.put(ctx, *media)
+	return nil
+}
+
+func (r *mediaRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Media, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	media, ok := r.s.media[id]
+	if !ok {
+		return nil, errors.New("media not found")
+	}
+	media.Variants = append([]entities.MediaVariant{}, media.Variants...)
+	return &media, nil
+}
+
+func (r *mediaRepository) Attach(ctx context.Context, id, target primitive.ObjectID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	media, ok := r.s.media[id]
+	if !ok || (media.AttachedTo != nil && *media.AttachedTo != target) {
+		return false, nil
+	}
+	media.AttachedTo = &target
+	r.put(ctx, media)
+	return true, nil
+}
+
+func (r *mediaRepository) Detach(ctx context.Context, id, target primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	media, ok := r.s.media[id]
+	if ok && media.AttachedTo != nil && *media.AttachedTo == target {
+		media.AttachedTo = nil
+		r.put(ctx, media)
+	}
+	return nil
+}
+
+func (r *mediaRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.media[id]; !ok {
+		return errors.New("media not found")
+	}
+	r.s.record(ctx, restorer(r.s.media, id))
+	delete(r.s.media, id)
+	return nil
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type oneTimeTokenRepository struct {
+	s *Store
+}
+
+func NewOneTimeTokenRepository(s *Store) repositories.OneTimeTokenRepository {
+	return &oneTimeTokenRepository{s: s}
+}
+
+func (r *oneTimeTokenRepository) put(ctx context.Context, token entities.OneTimeToken) {
+	r.s.record(ctx, restorer(r.s.oneTimeTokens, token.ID))
+	r.s.oneTimeTokens[token.ID] = token
+}
+
+func (r *oneTimeTokenRepository) Create(ctx context.Context, token *entities.OneTimeToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.oneTimeTokens[token.ID]; ok {
+		return errors.New("token already exists")
+	}
+	r.put(ctx, *token)
+	return nil
+}
+
+func (r *oneTimeTokenRepository) FindByHash(ctx context.Context, purpose, tokenHash string) (*entities.OneTimeToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, token := range r.s.oneTimeTokens {
+		if token.TokenHash == tokenHash && token.Purpose == purpose {
+			return &token, nil
+		}
+	}
+	return nil, errors.New("token not found")
+}
+
+func (r *oneTimeTokenRepository) FindLatestByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) (*entities.OneTimeToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var latest *entities.OneTimeToken
+	for _, token := range r.s.oneTimeTokens {
+		if token.UserID != userID || token.Purpose != purpose {
+			continue
+		}
+		if latest == nil || token.CreatedAt.After(latest.CreatedAt) {
+			c := token
+			latest = &c
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("token not found")
+	}
+	return latest, nil
+}
+
+func (r *oneTimeTokenRepository) Consume(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	token, ok := r.s.oneTimeTokens[id]
+	if !ok || !token.IsUsable(now) {
+		return false, nil
+	}
+	token.UsedAt = &now
+	r.put(ctx, token)
+	return true, nil
+}
+
+func (r *oneTimeTokenRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, token := range r.s.oneTimeTokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			r.s.record(ctx, restorer(r.s.oneTimeTokens, id))
+			delete(r.s.oneTimeTokens, id)
+		}
+	}
+	return nil
+}
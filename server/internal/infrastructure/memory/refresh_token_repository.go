@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type refreshTokenRepository struct {
+	s *Store
+}
+
+func NewRefreshTokenRepository(s *Store) repositories.RefreshTokenRepository {
+	return &refreshTokenRepository{s: s}
+}
+
+func (r *refreshTokenRepository) put(ctx context.Context, token entities.RefreshToken) {
+	r.s.record(ctx, restorer(r.s.refreshTokens, token.ID))
+	r.s.refreshTokens[token.ID] = token
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entities.RefreshToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.refreshTokens[token.ID]; ok {
+		return errors.New("refresh token already exists")
+	}
+	r.put(ctx, *token)
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token, ok := r.s.refreshTokens[id]
+	if !ok {
+		return nil, errors.New("refresh token not found")
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token, ok := r.s.refreshTokens[id]
+	if !ok || token.ReplacedBy != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.ReplacedBy, token.RotatedAt = &replacedBy, &now
+	r.put(ctx, token)
+	return true, nil
+}
+
+func (r *refreshTokenRepository) RevokeBySessionID(ctx context.Context, sessionID primitive.ObjectID) error {
+	return r.revoke(ctx, func(token *entities.RefreshToken) bool { return token.SessionID == sessionID })
+}
+
+func (r *refreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	return r.revoke(ctx, func(token *entities.RefreshToken) bool { return token.UserID == userID })
+}
+
+// revoke stamps the unrevoked tokens matching; revoked ones keep their time
+func (r *refreshTokenRepository) revoke(ctx context.Context, match func(token *entities.RefreshToken) bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	for _, token := range r.s.refreshTokens {
+		if token.RevokedAt == nil && match(&token) {
+			token.RevokedAt = &now
+			r.put(ctx, token)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type relationshipRepository struct {
+	s *Store
+}
+
+func NewRelationshipRepository(s *Store) repositories.RelationshipRepository {
+	return &relationshipRepository{s: s}
+}
+
+// stored copies rel so later changes by the caller do not leak into the store
+func storedRelationship(rel *entities.Relationship) entities.Relationship {
+	c := *rel
+	c.Partners = append([]entities.RelationshipPartner(nil), rel.Partners...)
+	c.Users = append([]primitive.ObjectID(nil), rel.Users...)
+	// The conditional updates compare these, so the caller must not share them
+	if rel.DisconnectRequest != nil {
+		req := *rel.DisconnectRequest
+		c.DisconnectRequest = &req
+	}
+	if rel.ReconnectRequestedBy != nil {
+		by := *rel.ReconnectRequestedBy
+		c.ReconnectRequestedBy = &by
+	}
+	return c
+}
+
+func (r *relationshipRepository) put(ctx context.Context, rel *entities.Relationship) {
+	r.s.record(ctx, restorer(r.s.relationships, rel.ID))
+	r.s.relationships[rel.ID] = storedRelationship(rel)
+}
+
+func (r *relationshipRepository) Create(ctx context.Context, rel *entities.Relationship) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// Like the unique index on inviteCode, one code starts at most one relationship
+	for _, existing := range r.s.relationships {
+		if rel.InviteCode != "" && existing.InviteCode == rel.InviteCode {
+			return errors.New("invite code already used")
+		}
+	}
+	if rel.ID.IsZero() {
+		rel.ID = primitive.NewObjectID()
+	}
+	rel.CreatedAt = time.Now()
+	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = entities.RelationshipSchemaVersion
+	r.put(ctx, rel)
+	return nil
+}
+
+// filter returns copies of the relationships matching; the caller holds the lock
+func (r *relationshipRepository) filter(match func(rel *entities.Relationship) bool) []*entities.Relationship {
+	var rels []*entities.Relationship
+	for _, rel := range r.s.relationships {
+		if match(&rel) {
+			c := storedRelationship(&rel)
+			rels = append(rels, &c)
+		}
+	}
+	return rels
+}
+
+func (r *relationshipRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rel, ok := r.s.relationships[id]
+	if !ok {
+		return nil, errors.New("relationship not found")
+	}
+	c := storedRelationship(&rel)
+	return &c, nil
+}
+
+func (r *relationshipRepository) FindByInviteCode(ctx context.Context, code string) (*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rels := r.filter(func(rel *entities.Relationship) bool { return rel.InviteCode == code })
+	if len(rels) == 0 {
+		return nil, errors.New("invite code not found")
+	}
+	return rels[0], nil
+}
+
+func (r *relationshipRepository) FindCurrentByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rels := r.filter(func(rel *entities.Relationship) bool { return rel.HasPartner(userID) && rel.Status.IsCurrent() })
+	if len(rels) == 0 {
+		return nil, errors.New("no active relationship")
+	}
+	return rels[0], nil
+}
+
+func (r *relationshipRepository) Update(ctx context.Context, rel *entities.Relationship) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = entities.RelationshipSchemaVersion
+	if _, ok := r.s.relationships[rel.ID]; ok {
+		r.put(ctx, rel)
+	}
+	return nil
+}
+
+func (r *relationshipRepository) UpdateDisconnectRequest(ctx context.Context, rel *entities.Relationship, from *primitive.ObjectID) error {
+	return r.updateIf(ctx, rel, func(stored *entities.Relationship) bool { return pendingDisconnectFrom(stored, from) })
+}
+
+func (r *relationshipRepository) UpdateReconnectRequest(ctx context.Context, rel *entities.Relationship, from *primitive.ObjectID) error {
+	return r.updateIf(ctx, rel, func(stored *entities.Relationship) bool {
+		by := stored.ReconnectRequestedBy
+		return stored.Status == entities.RelationshipStatusDisconnected &&
+			(by == nil) == (from == nil) && (by == nil || *by == *from)
+	})
+}
+
+// updateIf stores rel when the stored relationship matches
+func (r *relationshipRepository) updateIf(ctx context.Context, rel *entities.Relationship, match func(stored *entities.Relationship) bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	stored, ok := r.s.relationships[rel.ID]
+	if !ok || !match(&stored) {
+		return repositories.ErrRelationshipStateChanged
+	}
+	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = entities.RelationshipSchemaVersion
+	r.put(ctx, rel)
+	return nil
+}
+
+// pendingDisconnectFrom reports whether rel has a pending disconnect request by
+// from, or is active without one when from is nil
+func pendingDisconnectFrom(rel *entities.Relationship, from *primitive.ObjectID) bool {
+	if from == nil {
+		return rel.Status == entities.RelationshipStatusActive
+	}
+	req := rel.DisconnectRequest
+	return rel.Status == entities.RelationshipStatusDisconnectRequested && req != nil &&
+		req.RequestedBy == *from && req.State == entities.DisconnectRequested
+}
+
+func (r *relationshipRepository) FindAllByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rels := r.filter(func(rel *entities.Relationship) bool { return rel.HasPartner(userID) })
+	sort.Slice(rels, func(i, j int) bool { return rels[i].CreatedAt.Before(rels[j].CreatedAt) })
+	return rels, nil
+}
+
+func (r *relationshipRepository) FindPastByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	rels := r.filter(func(rel *entities.Relationship) bool {
+		return rel.HasPartner(userID) && rel.Status == entities.RelationshipStatusDisconnected
+	})
+	sort.Slice(rels, func(i, j int) bool {
+		a, b := rels[i].DisconnectedAt, rels[j].DisconnectedAt
+		if a != nil && b != nil && !a.Equal(*b) {
+			return a.After(*b)
+		}
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		return rels[i].UpdatedAt.After(rels[j].UpdatedAt)
+	})
+	return rels, nil
+}
+
+func (r *relationshipRepository) FindDisconnectsDue(ctx context.Context, now time.Time) ([]*entities.Relationship, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.filter(func(rel *entities.Relationship) bool {
+		return rel.Status == entities.RelationshipStatusDisconnectRequested &&
+			rel.DisconnectRequest != nil && !rel.DisconnectRequest.AutoConfirmAt.After(now)
+	}), nil
+}
+
+func (r *relationshipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.relationships[id]; ok {
+		r.s.record(ctx, restorer(r.s.relationships, id))
+		delete(r.s.relationships, id)
+	}
+	return nil
+}
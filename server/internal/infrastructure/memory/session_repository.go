@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type sessionRepository struct {
+	s *Store
+}
+
+func NewSessionRepository(s *Store) repositories.SessionRepository {
+	return &sessionRepository{s: s}
+}
+
+func (r *sessionRepository) put(ctx context.Context, session entities.Session) {
+	r.s.record(ctx, restorer(r.s.sessions, session.ID))
+	r.s.sessions[session.ID] = session
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entities.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.sessions[session.ID]; ok {
+		return errors.New("session already exists")
+	}
+	r.put(ctx, *session)
+	return nil
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	session, ok := r.s.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID primitive.ObjectID) ([]*entities.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	var sessions []*entities.Session
+	for _, session := range r.s.sessions {
+		if session.UserID == userID && session.IsActive(now) {
+			c := session
+			sessions = append(sessions, &c)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		if !a.LastSeenAt.Equal(b.LastSeenAt) {
+			return a.LastSeenAt.After(b.LastSeenAt)
+		}
+		return idLess(a.ID, b.ID)
+	})
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id primitive.ObjectID, ip string, seenAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if session, ok := r.s.sessions[id]; ok {
+		session.LastSeenAt, session.IP = seenAt, ip
+		r.put(ctx, session)
+	}
+	return nil
+}
+
+func (r *sessionRepository) Extend(ctx context.Context, id primitive.ObjectID, ip string, seenAt, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if session, ok := r.s.sessions[id]; ok && session.RevokedAt == nil {
+		session.LastSeenAt, session.IP, session.ExpiresAt = seenAt, ip, expiresAt
+		r.put(ctx, session)
+	}
+	return nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	return r.revoke(ctx, func(session *entities.Session) bool { return session.ID == id })
+}
+
+func (r *sessionRepository) RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	return r.revoke(ctx, func(session *entities.Session) bool { return session.UserID == userID })
+}
+
+// revoke stamps the unrevoked sessions matching; revoked ones keep their time
+func (r *sessionRepository) revoke(ctx context.Context, match func(session *entities.Session) bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	for _, session := range r.s.sessions {
+		if session.RevokedAt == nil && match(&session) {
+			session.RevokedAt = &now
+			r.put(ctx, session)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package memory implements the domain repositories in process memory for use
+// case tests. Writes made with a context from UnitOfWork.Do are undone when the
+// unit fails; there is no isolation between concurrent units, so conditional
+// writes (invite redemption, relationship pointers) are what keeps them apart,
+// just as on a standalone MongoDB server.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// Store holds the documents shared by the repositories created from it
+type Store struct {
+	mu                 sync.Mutex
+	users              map[primitive.ObjectID]entities.User
+	relationships      map[primitive.ObjectID]entities.Relationship
+	invites            map[string]entities.InviteCode
+	events             map[primitive.ObjectID]entities.Event
+	whispers           map[primitive.ObjectID]entities.Whisper
+	sessions           map[primitive.ObjectID]entities.Session
+	refreshTokens      map[primitive.ObjectID]entities.RefreshToken
+	oneTimeTokens      map[primitive.ObjectID]entities.OneTimeToken
+	loginAttempts      map[string]entities.LoginAttempt
+	dataExports        map[primitive.ObjectID]entities.DataExport
+	twoFactor          map[primitive.ObjectID]entities.TwoFactor
+	media              map[primitive.ObjectID]entities.Media
+	externalIdentities map[primitive.ObjectID]entities.ExternalIdentity
+	oidcStates         map[string]entities.OIDCState
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:              make(map[primitive.ObjectID]entities.User),
+		relationships:      make(map[primitive.ObjectID]entities.Relationship),
+		invites:            make(map[string]entities.InviteCode),
+		events:             make(map[primitive.ObjectID]entities.Event),
+		whispers:           make(map[primitive.ObjectID]entities.Whisper),
+		sessions:           make(map[primitive.ObjectID]entities.Session),
+		refreshTokens:      make(map[primitive.ObjectID]entities.RefreshToken),
+		oneTimeTokens:      make(map[primitive.ObjectID]entities.OneTimeToken),
+		loginAttempts:      make(map[string]entities.LoginAttempt),
+		dataExports:        make(map[primitive.ObjectID]entities.DataExport),
+		twoFactor:          make(map[primitive.ObjectID]entities.TwoFactor),
+		media:              make(map[primitive.ObjectID]entities.Media),
+		externalIdentities: make(map[primitive.ObjectID]entities.ExternalIdentity),
+		oidcStates:         make(map[string]entities.OIDCState),
+	}
+}
+
+type journalKey struct{}
+
+// journal collects undo steps of one unit of work; guarded by Store.mu
+type journal struct {
+	undo []func()
+}
+
+// record remembers how to revert a write about to be made; the caller holds s.mu
+func (s *Store) record(ctx context.Context, undo func()) {
+	if j, ok := ctx.Value(journalKey{}).(*journal); ok {
+		j.undo = append(j.undo, undo)
+	}
+}
+
+// restorer returns an undo step putting back the current entry for key
+func restorer[K comparable, V any](m map[K]V, key K) func() {
+	old, existed := m[key]
+	return func() {
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	}
+}
+
+// page applies skip and limit like a Mongo find; zero means no limit or offset
+func page[T any](items []T, limit, offset int64) []T {
+	if offset > 0 {
+		if offset >= int64(len(items)) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// idLess breaks ties between equal sort keys so results are stable
+func idLess(a, b primitive.ObjectID) bool {
+	return a.Hex() < b.Hex()
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) repositories.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Nested units join the outer one, like calls inside a session transaction
+	if _, ok := ctx.Value(journalKey{}).(*journal); ok {
+		return fn(ctx)
+	}
+	j := &journal{}
+	err := fn(context.WithValue(ctx, journalKey{}, j))
+	if err != nil {
+		u.store.mu.Lock()
+		for i := len(j.undo) - 1; i >= 0; i-- {
+			j.undo[i]()
+		}
+		u.store.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type twoFactorRepository struct {
+	s *Store
+}
+
+func NewTwoFactorRepository(s *Store) repositories.TwoFactorRepository {
+	return &twoFactorRepository{s: s}
+}
+
+func (r *twoFactorRepository) put(ctx context.Context, tf entities.TwoFactor) {
+	r.s.record(ctx, restorer(r.s.twoFactor, tf.UserID))
+	tf.RecoveryCodes = append([]string{}, tf.RecoveryCodes...)
+	r.s.twoFactor[tf.UserID] = tf
+}
+
+func (r *twoFactorRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*entities.TwoFactor, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	tf, ok := r.s.twoFactor[userID]
+	if !ok {
+		return nil, repositories.ErrTwoFactorNotFound
+	}
+	tf.RecoveryCodes = append([]string{}, tf.RecoveryCodes...)
+	return &tf, nil
+}
+
+func (r *twoFactorRepository) Save(ctx context.Context, tf *entities.TwoFactor) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.put(ctx, *tf)
+	return nil
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.twoFactor[userID]; ok {
+		r.s.record(ctx, restorer(r.s.twoFactor, userID))
+		delete(r.s.twoFactor, userID)
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	tf, ok := r.s.twoFactor[userID]
+	if !ok || tf.LastUsedStep >= step {
+		return false, nil
+	}
+	tf.LastUsedStep = step
+	r.put(ctx, tf)
+	return true, nil
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	tf, ok := r.s.twoFactor[userID]
+	if !ok {
+		return false, nil
+	}
+	// Like $pull, every copy of the hash goes
+	remaining := make([]string, 0, len(tf.RecoveryCodes))
+	for _, h := range tf.RecoveryCodes {
+		if h != codeHash {
+			remaining = append(remaining, h)
+		}
+	}
+	if len(remaining) == len(tf.RecoveryCodes) {
+		return false, nil
+	}
+	tf.RecoveryCodes = remaining
+	r.put(ctx, tf)
+	return true, nil
+}
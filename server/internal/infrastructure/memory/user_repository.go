@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type userRepository struct {
+	s *Store
+}
+
+func NewUserRepository(s *Store) repositories.UserRepository {
+	return &userRepository{s: s}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *entities.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if u.Username == user.Username || (user.Email != "" && u.Email == user.Email) {
+			return errors.New("username or email already exists")
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	r.s.record(ctx, restorer(r.s.users, user.ID))
+	r.s.users[user.ID] = *user
+	return nil
+}
+
+// find returns the first live user matching; the caller holds the lock
+func (r *userRepository) find(match func(u *entities.User) bool) (*entities.User, error) {
+	for _, u := range r.s.users {
+		if u.DeletedAt == nil && match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.find(func(u *entities.User) bool { return u.ID == id })
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entities.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.find(func(u *entities.User) bool { return u.Username == username })
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.find(func(u *entities.User) bool { return u.Email == email })
+}
+
+// modify applies fn to a stored user, recording the undo step; missing users are ignored
+// like an update matching no document. The caller holds the lock.
+func (r *userRepository) modify(ctx context.Context, id primitive.ObjectID, fn func(u *entities.User)) bool {
+	u, ok := r.s.users[id]
+	if !ok {
+		return false
+	}
+	r.s.record(ctx, restorer(r.s.users, id))
+	fn(&u)
+	r.s.users[id] = u
+	return true
+}
+
+func (r *userRepository) Update(ctx context.Context, user *entities.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	user.UpdatedAt = time.Now()
+	r.modify(ctx, user.ID, func(u *entities.User) {
+		next := *user
+		// Like $set with omitempty tags, empty optional fields keep their stored value
+		if next.Email == "" {
+			next.Email = u.Email
+		}
+		if next.EmailVerifiedAt == nil {
+			next.EmailVerifiedAt = u.EmailVerifiedAt
+		}
+		if next.RelationshipID == nil {
+			next.RelationshipID = u.RelationshipID
+		}
+		if next.DeletedAt == nil {
+			next.DeletedAt, next.PurgeAt = u.DeletedAt, u.PurgeAt
+		}
+		*u = next
+	})
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.PasswordHash = hashedPassword
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) UpdateStats(ctx context.Context, userID primitive.ObjectID, stats entities.UserStats) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.Stats = stats
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID primitive.ObjectID, verifiedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.EmailVerifiedAt = &verifiedAt
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) UpdateSettings(ctx context.Context, userID primitive.ObjectID, settings entities.UserSettings) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.Settings = settings
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) SetRelationship(ctx context.Context, userID primitive.ObjectID, from *primitive.ObjectID, to primitive.ObjectID, relationshipDays int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[userID]
+	if !ok || u.DeletedAt != nil || !sameID(u.RelationshipID, from) {
+		return repositories.ErrRelationshipChanged
+	}
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.RelationshipID = &to
+		u.Stats.RelationshipDays = relationshipDays
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) ClearRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if u, ok := r.s.users[userID]; !ok || !sameID(u.RelationshipID, &relationshipID) {
+		return nil
+	}
+	r.modify(ctx, userID, func(u *entities.User) {
+		u.RelationshipID = nil
+		u.Stats.RelationshipDays = 0
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func sameID(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (r *userRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	return r.stored(func(u *entities.User) bool { return u.Username == username }), nil
+}
+
+func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	return r.stored(func(u *entities.User) bool { return u.Email == email }), nil
+}
+
+// stored reports whether any user matches, soft-deleted ones included
+func (r *userRepository) stored(match func(u *entities.User) bool) bool {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if match(&u) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *userRepository) Delete(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.modify(ctx, id, func(u *entities.User) {
+		now := time.Now()
+		u.DeletedAt = &now
+		u.PurgeAt = &purgeAt
+	})
+	return nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[id]
+	if !ok || u.DeletedAt == nil || u.PurgeAt == nil || !u.PurgeAt.After(time.Now()) {
+		return errors.New("user not found")
+	}
+	r.modify(ctx, id, func(u *entities.User) {
+		u.DeletedAt, u.PurgeAt = nil, nil
+		u.UpdatedAt = time.Now()
+	})
+	return nil
+}
+
+func (r *userRepository) FindDueForPurge(ctx context.Context, now time.Time, limit int64) ([]*entities.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var users []*entities.User
+	for _, u := range r.s.users {
+		if limit > 0 && int64(len(users)) >= limit {
+			break
+		}
+		if u.DeletedAt != nil && u.PurgeAt != nil && !u.PurgeAt.After(now) {
+			u := u
+			users = append(users, &u)
+		}
+	}
+	return users, nil
+}
+
+func (r *userRepository) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	_, ok := r.s.users[id]
+	return ok, nil
+}
+
+func (r *userRepository) Purge(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if u, ok := r.s.users[id]; ok && u.DeletedAt != nil {
+		r.s.record(ctx, restorer(r.s.users, id))
+		delete(r.s.users, id)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+type whisperRepository struct {
+	s *Store
+}
+
+func NewWhisperRepository(s *Store) repositories.WhisperRepository {
+	return &whisperRepository{s: s}
+}
+
+// storedWhisper copies w so later changes by the caller do not leak into the store
+func storedWhisper(w *entities.Whisper) entities.Whisper {
+	c := *w
+	if w.Rule != nil {
+		rule := *w.Rule
+		rule.ByDay = append([]string(nil), w.Rule.ByDay...)
+		rule.ByMonthDay = append([]int(nil), w.Rule.ByMonthDay...)
+		c.Rule = &rule
+	}
+	c.Completions = append([]entities.WhisperCompletion(nil), w.Completions...)
+	return c
+}
+
+func (r *whisperRepository) put(ctx context.Context, w *entities.Whisper) {
+	r.s.record(ctx, restorer(r.s.whispers, w.ID))
+	r.s.whispers[w.ID] = storedWhisper(w)
+}
+
+func (r *whisperRepository) Create(ctx context.Context, whisper *entities.Whisper) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if whisper.ID.IsZero() {
+		whisper.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.s.whispers[whisper.ID]; ok {
+		return errors.New("whisper already exists")
+	}
+	whisper.CreatedAt = time.Now()
+	whisper.UpdatedAt = time.Now()
+	r.put(ctx, whisper)
+	return nil
+}
+
+func (r *whisperRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Whisper, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	w, ok := r.s.whispers[id]
+	if !ok {
+		return nil, errors.New("whisper not found")
+	}
+	c := storedWhisper(&w)
+	return &c, nil
+}
+
+func (r *whisperRepository) Update(ctx context.Context, whisper *entities.Whisper) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	whisper.UpdatedAt = time.Now()
+	stored, ok := r.s.whispers[whisper.ID]
+	if !ok {
+		return nil
+	}
+	next := *whisper
+	// Like $set with omitempty tags, empty optional fields keep their stored value
+	if next.Text == "" {
+		next.Text = stored.Text
+	}
+	if next.Timezone == "" {
+		next.Timezone = stored.Timezone
+	}
+	r.put(ctx, &next)
+	return nil
+}
+
+func (r *whisperRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.whispers[id]; ok {
+		r.s.record(ctx, restorer(r.s.whispers, id))
+		delete(r.s.whispers, id)
+	}
+	return nil
+}
+
+func (r *whisperRepository) DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, w := range r.s.whispers {
+		if w.RelationshipID == relationshipID {
+			r.s.record(ctx, restorer(r.s.whispers, id))
+			delete(r.s.whispers, id)
+		}
+	}
+	return nil
+}
+
+func (r *whisperRepository) FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, limit, offset int64) ([]*entities.Whisper, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var whispers []*entities.Whisper
+	for _, w := range r.s.whispers {
+		if w.RelationshipID == relationshipID {
+			c := storedWhisper(&w)
+			whispers = append(whispers, &c)
+		}
+	}
+	// Oldest first, like the whisper list in the app
+	sort.Slice(whispers, func(i, j int) bool {
+		a, b := whispers[i], whispers[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return idLess(a.ID, b.ID)
+	})
+	return page(whispers, limit, offset), nil
+}
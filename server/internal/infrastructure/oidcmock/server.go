@@ -0,0 +1,199 @@
+// Package oidcmock is a minimal OpenID Connect provider for tests and local
+// development. Its authorize endpoint signs in the configured user without a login
+// page and redirects straight back with an authorization code.
+package oidcmock
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const keyID = "mock-key"
+
+// User is the identity the mock provider signs in
+type User struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type authRequest struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	user          User
+}
+
+// Server implements discovery, authorize, token and JWKS endpoints
+type Server struct {
+	Issuer   string
+	ClientID string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	user  User
+	codes map[string]authRequest
+}
+
+// New creates a provider for issuer; serve it with ServeHTTP at that address
+func New(issuer, clientID string, user User) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Issuer: issuer, ClientID: clientID, key: key, user: user, codes: map[string]authRequest{}}, nil
+}
+
+// Start runs a provider on a local test listener; call Close on the returned server
+func Start(clientID string, user User) (*Server, *httptest.Server, error) {
+	s, err := New("", clientID, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts := httptest.NewServer(s)
+	s.Issuer = ts.URL
+	return s, ts, nil
+}
+
+// SetUser changes who is signed in by the next authorize request
+func (s *Server) SetUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = u
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                s.Issuer,
+			"authorization_endpoint":                s.Issuer + "/authorize",
+			"token_endpoint":                        s.Issuer + "/token",
+			"jwks_uri":                              s.Issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case "/authorize":
+		s.authorize(w, r)
+	case "/token":
+		s.token(w, r)
+	case "/jwks":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != s.ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = authRequest{
+		clientID:      q.Get("client_id"),
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		user:          s.user,
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	req, ok := s.codes[code]
+	delete(s.codes, code) // codes are single-use
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok, req.clientID != r.PostForm.Get("client_id"), req.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != req.codeChallenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                s.Issuer,
+		"sub":                req.user.Subject,
+		"aud":                req.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              req.nonce,
+		"email":              req.user.Email,
+		"email_verified":     req.user.EmailVerified,
+		"name":               req.user.Name,
+		"preferred_username": req.user.PreferredUsername,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type catalogRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewCatalogRepository(db *database.MongoDB) domainRepos.CatalogRepository {
+	return &catalogRepositoryImpl{db: db}
+}
+
+func (r *catalogRepositoryImpl) FindAllWhisperTypes(ctx context.Context) ([]*domainEntities.TypeDefinition, error) {
+	return r.findAll(ctx, r.db.WhisperTypes())
+}
+
+func (r *catalogRepositoryImpl) FindAllEventTypes(ctx context.Context) ([]*domainEntities.TypeDefinition, error) {
+	return r.findAll(ctx, r.db.EventTypes())
+}
+
+func (r *catalogRepositoryImpl) SeedWhisperTypes(ctx context.Context, defs []*domainEntities.TypeDefinition) error {
+	return r.seed(ctx, r.db.WhisperTypes(), defs)
+}
+
+func (r *catalogRepositoryImpl) SeedEventTypes(ctx context.Context, defs []*domainEntities.TypeDefinition) error {
+	return r.seed(ctx, r.db.EventTypes(), defs)
+}
+
+func (r *catalogRepositoryImpl) findAll(ctx context.Context, coll *mongo.Collection) ([]*domainEntities.TypeDefinition, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "sortOrder", Value: 1}, {Key: "key", Value: 1}})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var list []*domainEntities.TypeDefinition
+	for cursor.Next(ctx) {
+		var t domainEntities.TypeDefinition
+		if err := cursor.Decode(&t); err != nil {
+			return nil, err
+		}
+		list = append(list, &t)
+	}
+	return list, cursor.Err()
+}
+
+// seed upserts by key with $setOnInsert so definitions edited in the database are kept
+func (r *catalogRepositoryImpl) seed(ctx context.Context, coll *mongo.Collection, defs []*domainEntities.TypeDefinition) error {
+	if len(defs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(defs))
+	for _, d := range defs {
+		doc := *d
+		doc.CreatedAt = now
+		doc.UpdatedAt = now
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"key": d.Key}).
+			SetUpdate(bson.M{"$setOnInsert": doc}).
+			SetUpsert(true))
+	}
+	_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
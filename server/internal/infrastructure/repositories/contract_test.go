@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/database"
+	"whisper-server/internal/infrastructure/database/migrations"
+	"whisper-server/internal/infrastructure/repotest"
+)
+
+// mongoURI is the server the contract tests run against; empty skips them
+var mongoURI string
+
+// TestMain uses TEST_MONGODB_URI when set and otherwise starts a throwaway mongod
+func TestMain(m *testing.M) {
+	mongoURI = os.Getenv("TEST_MONGODB_URI")
+	if mongoURI != "" {
+		os.Exit(m.Run())
+	}
+	mongod, err := repotest.StartMongod()
+	if err != nil {
+		if !errors.Is(err, repotest.ErrNoMongod) {
+			fmt.Fprintf(os.Stderr, "mongod: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(m.Run())
+	}
+	mongoURI = mongod.URI
+	code := m.Run()
+	mongod.Stop()
+	os.Exit(code)
+}
+
+// testDatabase returns a fresh database with the production indexes and
+// validators, dropped when the test ends; it skips the test without a server
+func testDatabase(t *testing.T) *database.MongoDB {
+	t.Helper()
+	if mongoURI == "" {
+		t.Skip("no mongod binary found; install MongoDB, set MONGOD_BIN or set TEST_MONGODB_URI")
+	}
+	db, err := database.NewMongoDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "whisper_contract_" + primitive.NewObjectID().Hex(),
+		ConnectTimeout: 10,
+		MaxPoolSize:    4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.GetDatabase().Drop(context.Background())
+		db.Disconnect()
+	})
+	if _, err := migrations.NewRunner(db.GetDatabase()).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRepositoryContract(t *testing.T) {
+	if mongoURI == "" {
+		t.Skip("no mongod binary found; install MongoDB, set MONGOD_BIN or set TEST_MONGODB_URI")
+	}
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		// Every case gets its own database
+		db := testDatabase(t)
+		return repotest.Repos{
+			Users:              NewUserRepository(db),
+			Relationships:      NewRelationshipRepository(db),
+			Invites:            NewInviteRepository(db),
+			Events:             NewEventRepository(db),
+			Whispers:           NewWhisperRepository(db),
+			Sessions:           NewSessionRepository(db),
+			RefreshTokens:      NewRefreshTokenRepository(db),
+			OneTimeTokens:      NewOneTimeTokenRepository(db),
+			LoginAttempts:      NewLoginAttemptRepository(db),
+			DataExports:        NewDataExportRepository(db),
+			TwoFactor:          NewTwoFactorRepository(db),
+			Media:              NewMediaRepository(db),
+			ExternalIdentities: NewExternalIdentityRepository(db),
+			OIDCStates:         NewOIDCStateRepository(db),
+		}
+	})
+}
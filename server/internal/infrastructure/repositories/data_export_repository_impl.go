@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type dataExportRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewDataExportRepository(db *database.MongoDB) domainRepos.DataExportRepository {
+	return &dataExportRepositoryImpl{db: db}
+}
+
+func (r *dataExportRepositoryImpl) Create(ctx context.Context, export *domainEntities.DataExport) error {
+	_, err := r.db.DataExports().InsertOne(ctx, export)
+	return err
+}
+
+func (r *dataExportRepositoryImpl) FindLatestByUserID(ctx context.Context, userID primitive.ObjectID) (*domainEntities.DataExport, error) {
+	var export domainEntities.DataExport
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := r.db.DataExports().FindOne(ctx, bson.M{"userId": userID}, opts).Decode(&export)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("data export not found")
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepositoryImpl) Claim(ctx context.Context, id primitive.ObjectID, staleBefore time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"status": domainEntities.DataExportStatusPending},
+		bson.M{"status": domainEntities.DataExportStatusProcessing, "startedAt": bson.M{"$lt": staleBefore}},
+	}}
+	update := bson.M{"$set": bson.M{"status": domainEntities.DataExportStatusProcessing, "startedAt": time.Now()}}
+	res, err := r.db.DataExports().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *dataExportRepositoryImpl) MarkReady(ctx context.Context, id primitive.ObjectID, size int64, expiresAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":      domainEntities.DataExportStatusReady,
+		"size":        size,
+		"completedAt": time.Now(),
+		"expiresAt":   expiresAt,
+	}}
+	_, err := r.db.DataExports().UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *dataExportRepositoryImpl) MarkFailed(ctx context.Context, id primitive.ObjectID, reason string) error {
+	update := bson.M{"$set": bson.M{
+		"status":      domainEntities.DataExportStatusFailed,
+		"error":       reason,
+		"completedAt": time.Now(),
+	}}
+	_, err := r.db.DataExports().UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *dataExportRepositoryImpl) FindUnfinished(ctx context.Context, staleBefore time.Time) ([]*domainEntities.DataExport, error) {
+	return r.find(ctx, bson.M{"$or": bson.A{
+		bson.M{"status": domainEntities.DataExportStatusPending},
+		bson.M{"status": domainEntities.DataExportStatusProcessing, "startedAt": bson.M{"$lt": staleBefore}},
+	}})
+}
+
+func (r *dataExportRepositoryImpl) FindExpired(ctx context.Context, now time.Time) ([]*domainEntities.DataExport, error) {
+	return r.find(ctx, bson.M{"status": domainEntities.DataExportStatusReady, "expiresAt": bson.M{"$lte": now}})
+}
+
+func (r *dataExportRepositoryImpl) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.DataExport, error) {
+	return r.find(ctx, bson.M{"userId": userID})
+}
+
+func (r *dataExportRepositoryImpl) find(ctx context.Context, filter bson.M) ([]*domainEntities.DataExport, error) {
+	cursor, err := r.db.DataExports().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exports []*domainEntities.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *dataExportRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.DataExports().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
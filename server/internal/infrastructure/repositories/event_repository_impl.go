@@ -118,3 +118,56 @@ func (r *eventRepositoryImpl) FindAllByRelationshipID(ctx context.Context, relat
 	}
 	return events, nil
 }
+
+func (r *eventRepositoryImpl) FindPublic(ctx context.Context, eventType, sortBy string, limit, offset int64) ([]*domainEntities.Event, error) {
+	findOpts := options.Find()
+	if sortBy == domainRepos.PublicEventSortPopular {
+		findOpts.SetSort(bson.D{{Key: "viewCount", Value: -1}, {Key: "visibility.publishedAt", Value: -1}})
+	} else {
+		findOpts.SetSort(bson.D{{Key: "visibility.publishedAt", Value: -1}, {Key: "date", Value: -1}})
+	}
+	if limit > 0 {
+		findOpts.SetLimit(limit)
+	}
+	if offset > 0 {
+		findOpts.SetSkip(offset)
+	}
+	filter := bson.M{"visibility.isPublic": true}
+	if eventType != "" {
+		filter["type"] = eventType
+	}
+	cursor, err := r.db.Events().Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []*domainEntities.Event
+	for cursor.Next(ctx) {
+		var ev domainEntities.Event
+		if err := cursor.Decode(&ev); err != nil {
+			return nil, err
+		}
+		events = append(events, &ev)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *eventRepositoryImpl) IncrementViewCount(ctx context.Context, id primitive.ObjectID) (*domainEntities.Event, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var ev domainEntities.Event
+	err := r.db.Events().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "visibility.isPublic": true},
+		bson.M{"$inc": bson.M{"viewCount": 1}},
+		opts,
+	).Decode(&ev)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("event not found")
+		}
+		return nil, err
+	}
+	return &ev, nil
+}
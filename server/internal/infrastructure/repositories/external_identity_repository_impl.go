@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type externalIdentityRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewExternalIdentityRepository(db *database.MongoDB) domainRepos.ExternalIdentityRepository {
+	return &externalIdentityRepositoryImpl{db: db}
+}
+
+func (r *externalIdentityRepositoryImpl) Create(ctx context.Context, identity *domainEntities.ExternalIdentity) error {
+	_, err := r.db.ExternalIdentities().InsertOne(ctx, identity)
+	return err
+}
+
+func (r *externalIdentityRepositoryImpl) FindByProviderSubject(ctx context.Context, provider, subject string) (*domainEntities.ExternalIdentity, error) {
+	var identity domainEntities.ExternalIdentity
+	err := r.db.ExternalIdentities().FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domainRepos.ErrExternalIdentityNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *externalIdentityRepositoryImpl) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.ExternalIdentity, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := r.db.ExternalIdentities().Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var identities []*domainEntities.ExternalIdentity
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *externalIdentityRepositoryImpl) TouchLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := r.db.ExternalIdentities().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastLoginAt": at}})
+	return err
+}
+
+func (r *externalIdentityRepositoryImpl) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.db.ExternalIdentities().DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+type oidcStateRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewOIDCStateRepository(db *database.MongoDB) domainRepos.OIDCStateRepository {
+	return &oidcStateRepositoryImpl{db: db}
+}
+
+func (r *oidcStateRepositoryImpl) Create(ctx context.Context, state *domainEntities.OIDCState) error {
+	_, err := r.db.OIDCStates().InsertOne(ctx, state)
+	return err
+}
+
+func (r *oidcStateRepositoryImpl) Consume(ctx context.Context, stateHash string) (*domainEntities.OIDCState, error) {
+	var state domainEntities.OIDCState
+	filter := bson.M{"_id": stateHash, "expiresAt": bson.M{"$gt": time.Now()}}
+	err := r.db.OIDCStates().FindOneAndDelete(ctx, filter).Decode(&state)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("oidc state not found")
+		}
+		return nil, err
+	}
+	return &state, nil
+}
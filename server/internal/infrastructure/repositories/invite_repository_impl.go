@@ -6,7 +6,9 @@ import (
     "time"
 
     "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
     "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 
     domainEntities "whisper-server/internal/domain/entities"
     domainRepos "whisper-server/internal/domain/repositories"
@@ -40,10 +42,71 @@ func (r *inviteRepositoryImpl) FindByCode(ctx context.Context, code string) (*do
     return &inv, nil
 }
 
-func (r *inviteRepositoryImpl) MarkUsed(ctx context.Context, inv *domainEntities.InviteCode) error {
-    inv.IsUsed = true
-    inv.UpdatedAt = time.Now()
-    _, err := r.db.InviteCodes().UpdateOne(ctx, bson.M{"code": inv.Code}, bson.M{"$set": bson.M{"isUsed": true, "updatedAt": inv.UpdatedAt}})
+func (r *inviteRepositoryImpl) Redeem(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) (*domainEntities.InviteCode, error) {
+    // Only an unused, unrevoked, unexpired code matches; concurrent callers race on this single update
+    filter := bson.M{
+        "code":      code,
+        "isUsed":    false,
+        "revokedAt": nil,
+        "$or":       bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": at}}},
+    }
+    update := bson.M{"$set": bson.M{"isUsed": true, "usedBy": userID, "usedAt": at, "current": false, "updatedAt": at}}
+    opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+    var inv domainEntities.InviteCode
+    err := r.db.InviteCodes().FindOneAndUpdate(ctx, filter, update, opts).Decode(&inv)
+    if err != nil {
+        if err == mongo.ErrNoDocuments {
+            return nil, domainRepos.ErrInviteUnavailable
+        }
+        return nil, err
+    }
+    return &inv, nil
+}
+
+func (r *inviteRepositoryImpl) FindByCreator(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.InviteCode, error) {
+    opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+    cursor, err := r.db.InviteCodes().Find(ctx, bson.M{"createdBy": userID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var invites []*domainEntities.InviteCode
+    if err := cursor.All(ctx, &invites); err != nil {
+        return nil, err
+    }
+    return invites, nil
+}
+
+func (r *inviteRepositoryImpl) DeleteByCreator(ctx context.Context, userID primitive.ObjectID) error {
+    _, err := r.db.InviteCodes().DeleteMany(ctx, bson.M{"createdBy": userID})
     return err
 }
 
+func (r *inviteRepositoryImpl) Revoke(ctx context.Context, code string, userID primitive.ObjectID, at time.Time) error {
+    filter := bson.M{"code": code, "createdBy": userID, "isUsed": false, "revokedAt": nil}
+    res, err := r.db.InviteCodes().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": at, "current": false, "updatedAt": at}})
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return errors.New("invite code not found")
+    }
+    return nil
+}
+
+func (r *inviteRepositoryImpl) RevokeActiveByCreator(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+    filter := bson.M{
+        "createdBy": userID,
+        "isUsed":    false,
+        "revokedAt": nil,
+        "$or":       bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": at}}},
+    }
+    if _, err := r.db.InviteCodes().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": at, "current": false, "updatedAt": at}}); err != nil {
+        return err
+    }
+    // An expired code the TTL monitor has not removed yet is no longer current either
+    _, err := r.db.InviteCodes().UpdateMany(ctx, bson.M{"createdBy": userID, "current": true}, bson.M{"$set": bson.M{"current": false, "updatedAt": at}})
+    return err
+}
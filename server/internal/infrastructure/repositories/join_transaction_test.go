@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/services"
+)
+
+// These run the invite join of the relationship use case against MongoDB,
+// where the unit of work is a real transaction, instead of the in-memory fakes
+
+type joinFixture struct {
+	users domainRepos.UserRepository
+	rels  domainRepos.RelationshipRepository
+	invs  domainRepos.InviteRepository
+	uc    usecases.RelationshipUseCase
+}
+
+func newJoinFixture(t *testing.T, wrapUsers func(domainRepos.UserRepository) domainRepos.UserRepository) *joinFixture {
+	t.Helper()
+	db := testDatabase(t)
+	if !db.SupportsTransactions() {
+		t.Skip("server is standalone; the harness mongod runs as a replica set")
+	}
+	f := &joinFixture{
+		users: NewUserRepository(db),
+		rels:  NewRelationshipRepository(db),
+		invs:  NewInviteRepository(db),
+	}
+	users := f.users
+	if wrapUsers != nil {
+		users = wrapUsers(users)
+	}
+	f.uc = usecases.NewRelationshipUseCase(f.rels, users, f.invs, NewUnitOfWork(db), usecases.NewEmailVerificationPolicy(nil),
+		services.NewLogMailer(), usecases.InvitePolicy{TTL: time.Hour}, usecases.DisconnectPolicy{UndoWindow: time.Hour, AutoConfirmAfter: 24 * time.Hour})
+	return f
+}
+
+func (f *joinFixture) createUser(t *testing.T, username string) primitive.ObjectID {
+	t.Helper()
+	u := entities.NewUser(username, username, username+"@example.com", "hash")
+	if err := f.users.Create(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+func (f *joinFixture) invite(t *testing.T, inviter primitive.ObjectID) string {
+	t.Helper()
+	res, err := f.uc.GenerateInvitationCode(context.Background(), inviter, time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.InviteCode
+}
+
+func (f *joinFixture) relationshipID(t *testing.T, userID primitive.ObjectID) *primitive.ObjectID {
+	t.Helper()
+	u, err := f.users.FindByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.RelationshipID
+}
+
+func TestJoinConcurrentJoinersOneWins(t *testing.T) {
+	const joiners = 16
+	ctx := context.Background()
+	f := newJoinFixture(t, nil)
+	inviter := f.createUser(t, "inviter")
+	code := f.invite(t, inviter)
+	ids := make([]primitive.ObjectID, joiners)
+	for i := range ids {
+		ids[i] = f.createUser(t, fmt.Sprintf("joiner%d", i))
+	}
+
+	errs := make([]error, joiners)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = f.uc.JoinWithInviteCode(ctx, ids[i], code)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner >= 0 {
+				t.Fatalf("joiners %d and %d both joined", winner, i)
+			}
+			winner = i
+		case errors.Is(err, usecases.ErrInviteInactive), errors.Is(err, usecases.ErrPartnerInRelationship):
+		default:
+			t.Errorf("joiner %d: unexpected error %v", i, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("no joiner succeeded")
+	}
+
+	rels, err := f.rels.FindAllByUserID(ctx, inviter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 1 || !rels[0].HasPartner(ids[winner]) {
+		t.Fatalf("inviter has %d relationships, want one with the winner", len(rels))
+	}
+	for i, id := range ids {
+		got := f.relationshipID(t, id)
+		if i == winner && (got == nil || *got != rels[0].ID) {
+			t.Errorf("winner relationshipId = %v, want %s", got, rels[0].ID.Hex())
+		}
+		if i != winner && got != nil {
+			t.Errorf("losing joiner %d has relationshipId %s", i, got.Hex())
+		}
+	}
+}
+
+// takenPointerRepo refuses to move one user's relationship pointer, as if they
+// had joined someone else in the meantime
+type takenPointerRepo struct {
+	domainRepos.UserRepository
+	userID primitive.ObjectID
+}
+
+func (r *takenPointerRepo) SetRelationship(ctx context.Context, userID primitive.ObjectID, from *primitive.ObjectID, to primitive.ObjectID, relationshipDays int) error {
+	if userID == r.userID {
+		return domainRepos.ErrRelationshipChanged
+	}
+	return r.UserRepository.SetRelationship(ctx, userID, from, to, relationshipDays)
+}
+
+func TestJoinRollsBackTransaction(t *testing.T) {
+	ctx := context.Background()
+	taken := &takenPointerRepo{}
+	f := newJoinFixture(t, func(users domainRepos.UserRepository) domainRepos.UserRepository {
+		taken.UserRepository = users
+		return taken
+	})
+	inviter := f.createUser(t, "inviter")
+	joiner := f.createUser(t, "joiner")
+	code := f.invite(t, inviter)
+	// The inviter's pointer is set first, then the joiner's one fails
+	taken.userID = joiner
+
+	if _, err := f.uc.JoinWithInviteCode(ctx, joiner, code); !errors.Is(err, usecases.ErrAlreadyInRelationship) {
+		t.Fatalf("join = %v, want %v", err, usecases.ErrAlreadyInRelationship)
+	}
+	inv, err := f.invs.FindByCode(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inv.IsActive(time.Now()) {
+		t.Errorf("invite %s after the rolled back join, want active", inv.Status(time.Now()))
+	}
+	if rels, _ := f.rels.FindAllByUserID(ctx, inviter); len(rels) != 0 {
+		t.Errorf("%d relationships left behind", len(rels))
+	}
+	if got := f.relationshipID(t, inviter); got != nil {
+		t.Errorf("inviter kept relationshipId %s", got.Hex())
+	}
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+// loginAttemptRetention is how long a record outlives its last failure or lockout
+const loginAttemptRetention = 24 * time.Hour
+
+type loginAttemptRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewLoginAttemptRepository(db *database.MongoDB) domainRepos.LoginAttemptRepository {
+	return &loginAttemptRepositoryImpl{db: db}
+}
+
+func (r *loginAttemptRepositoryImpl) FindByKey(ctx context.Context, key string) (*domainEntities.LoginAttempt, error) {
+	var a domainEntities.LoginAttempt
+	err := r.db.LoginAttempts().FindOne(ctx, bson.M{"_id": key}).Decode(&a)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("login attempt not found")
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepositoryImpl) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*domainEntities.LoginAttempt, error) {
+	// Pipeline update so the window check and increment happen atomically
+	recent := bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$lastFailureAt", time.Time{}}}, now.Add(-window)}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				recent,
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+				1,
+			}},
+			"lastFailureAt": now,
+			"expiresAt":     now.Add(loginAttemptRetention),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var a domainEntities.LoginAttempt
+	if err := r.db.LoginAttempts().FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepositoryImpl) Lock(ctx context.Context, key string, until time.Time) error {
+	update := bson.M{"$set": bson.M{"lockedUntil": until, "expiresAt": until.Add(loginAttemptRetention)}}
+	_, err := r.db.LoginAttempts().UpdateOne(ctx, bson.M{"_id": key}, update)
+	return err
+}
+
+func (r *loginAttemptRepositoryImpl) Reset(ctx context.Context, key string) error {
+	_, err := r.db.LoginAttempts().DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
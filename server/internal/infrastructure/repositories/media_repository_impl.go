@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type mediaRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewMediaRepository(db *database.MongoDB) domainRepos.MediaRepository {
+	return &mediaRepositoryImpl{db: db}
+}
+
+func (r *mediaRepositoryImpl) Create(ctx context.Context, media *domainEntities.Media) error {
+	if media.CreatedAt.IsZero() {
+		media.CreatedAt = time.Now()
+	}
+	res, err := r.db.Media().InsertOne(ctx, media)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		media.ID = oid
+	}
+	return nil
+}
+
+func (r *mediaRepositoryImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*domainEntities.Media, error) {
+	var m domainEntities.Media
+	err := r.db.Media().FindOne(ctx, bson.M{"_id": id}).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("media not found")
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mediaRepositoryImpl) Attach(ctx context.Context, id, target primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"attachedTo": nil},
+		bson.M{"attachedTo": target},
+	}}
+	res, err := r.db.Media().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"attachedTo": target}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (r *mediaRepositoryImpl) Detach(ctx context.Context, id, target primitive.ObjectID) error {
+	_, err := r.db.Media().UpdateOne(ctx, bson.M{"_id": id, "attachedTo": target}, bson.M{"$unset": bson.M{"attachedTo": ""}})
+	return err
+}
+
+func (r *mediaRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.db.Media().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("media not found")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type oneTimeTokenRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewOneTimeTokenRepository(db *database.MongoDB) domainRepos.OneTimeTokenRepository {
+	return &oneTimeTokenRepositoryImpl{db: db}
+}
+
+func (r *oneTimeTokenRepositoryImpl) Create(ctx context.Context, token *domainEntities.OneTimeToken) error {
+	_, err := r.db.OneTimeTokens().InsertOne(ctx, token)
+	return err
+}
+
+func (r *oneTimeTokenRepositoryImpl) FindByHash(ctx context.Context, purpose, tokenHash string) (*domainEntities.OneTimeToken, error) {
+	var t domainEntities.OneTimeToken
+	err := r.db.OneTimeTokens().FindOne(ctx, bson.M{"tokenHash": tokenHash, "purpose": purpose}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *oneTimeTokenRepositoryImpl) FindLatestByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) (*domainEntities.OneTimeToken, error) {
+	var t domainEntities.OneTimeToken
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := r.db.OneTimeTokens().FindOne(ctx, bson.M{"userId": userID, "purpose": purpose}, opts).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *oneTimeTokenRepositoryImpl) Consume(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	filter := bson.M{"_id": id, "usedAt": nil, "expiresAt": bson.M{"$gt": now}}
+	res, err := r.db.OneTimeTokens().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": now}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *oneTimeTokenRepositoryImpl) DeleteByUserID(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	_, err := r.db.OneTimeTokens().DeleteMany(ctx, bson.M{"userId": userID, "purpose": purpose})
+	return err
+}
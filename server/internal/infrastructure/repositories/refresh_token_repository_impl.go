@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type refreshTokenRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewRefreshTokenRepository(db *database.MongoDB) domainRepos.RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+func (r *refreshTokenRepositoryImpl) Create(ctx context.Context, token *domainEntities.RefreshToken) error {
+	_, err := r.db.RefreshTokens().InsertOne(ctx, token)
+	return err
+}
+
+func (r *refreshTokenRepositoryImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*domainEntities.RefreshToken, error) {
+	var t domainEntities.RefreshToken
+	err := r.db.RefreshTokens().FindOne(ctx, bson.M{"_id": id}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("refresh token not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepositoryImpl) MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": id, "replacedBy": nil, "revokedAt": nil}
+	update := bson.M{"$set": bson.M{"replacedBy": replacedBy, "rotatedAt": time.Now()}}
+	res, err := r.db.RefreshTokens().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeBySessionID(ctx context.Context, sessionID primitive.ObjectID) error {
+	return r.revoke(ctx, bson.M{"sessionId": sessionID})
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	return r.revoke(ctx, bson.M{"userId": userID})
+}
+
+func (r *refreshTokenRepositoryImpl) revoke(ctx context.Context, filter bson.M) error {
+	filter["revokedAt"] = nil
+	_, err := r.db.RefreshTokens().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
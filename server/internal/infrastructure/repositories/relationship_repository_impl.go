@@ -8,6 +8,7 @@ import (
     "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/bson/primitive"
     "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
 
     domainEntities "whisper-server/internal/domain/entities"
     domainRepos "whisper-server/internal/domain/repositories"
@@ -25,6 +26,8 @@ func NewRelationshipRepository(db *database.MongoDB) domainRepos.RelationshipRep
 func (r *relationshipRepositoryImpl) Create(ctx context.Context, rel *domainEntities.Relationship) error {
 	rel.CreatedAt = time.Now()
 	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = domainEntities.RelationshipSchemaVersion
 	res, err := r.db.Relationships().InsertOne(ctx, rel)
 	if err != nil {
 		return err
@@ -35,26 +38,16 @@ func (r *relationshipRepositoryImpl) Create(ctx context.Context, rel *domainEnti
 	return nil
 }
 
-func (r *relationshipRepositoryImpl) CreateWithDetails(ctx context.Context, rel *domainEntities.Relationship, users []primitive.ObjectID, firstMeetingDate time.Time) error {
-    rel.CreatedAt = time.Now()
-    rel.UpdatedAt = time.Now()
-    doc := bson.M{
-        "partners": rel.Partners,
-        "users": users,
-        "firstMeetingDate": firstMeetingDate,
-        "status": rel.Status,
-        "inviteCode": rel.InviteCode,
-        "createdAt": rel.CreatedAt,
-        "updatedAt": rel.UpdatedAt,
-    }
-    res, err := r.db.Relationships().InsertOne(ctx, doc)
-    if err != nil {
-        return err
-    }
-    if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
-        rel.ID = oid
-    }
-    return nil
+func (r *relationshipRepositoryImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*domainEntities.Relationship, error) {
+	var rel domainEntities.Relationship
+	err := r.db.Relationships().FindOne(ctx, bson.M{"_id": id}).Decode(&rel)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("relationship not found")
+		}
+		return nil, err
+	}
+	return &rel, nil
 }
 
 func (r *relationshipRepositoryImpl) FindByInviteCode(ctx context.Context, code string) (*domainEntities.Relationship, error) {
@@ -72,7 +65,7 @@ func (r *relationshipRepositoryImpl) FindByInviteCode(ctx context.Context, code
 func (r *relationshipRepositoryImpl) FindCurrentByUserID(ctx context.Context, userID primitive.ObjectID) (*domainEntities.Relationship, error) {
 	filter := bson.M{
 		"partners": bson.M{"$elemMatch": bson.M{"userId": userID}},
-		"status":   domainEntities.RelationshipStatusActive,
+		"status":   bson.M{"$in": domainEntities.CurrentRelationshipStatuses},
 	}
 	var rel domainEntities.Relationship
 	err := r.db.Relationships().FindOne(ctx, filter).Decode(&rel)
@@ -87,6 +80,102 @@ func (r *relationshipRepositoryImpl) FindCurrentByUserID(ctx context.Context, us
 
 func (r *relationshipRepositoryImpl) Update(ctx context.Context, rel *domainEntities.Relationship) error {
 	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = domainEntities.RelationshipSchemaVersion
 	_, err := r.db.Relationships().UpdateOne(ctx, bson.M{"_id": rel.ID}, bson.M{"$set": rel})
 	return err
 }
+
+func (r *relationshipRepositoryImpl) UpdateDisconnectRequest(ctx context.Context, rel *domainEntities.Relationship, from *primitive.ObjectID) error {
+	filter := bson.M{"_id": rel.ID, "status": domainEntities.RelationshipStatusActive}
+	if from != nil {
+		filter = bson.M{
+			"_id":                           rel.ID,
+			"status":                        domainEntities.RelationshipStatusDisconnectRequested,
+			"disconnectRequest.requestedBy": *from,
+			"disconnectRequest.state":       domainEntities.DisconnectRequested,
+		}
+	}
+	return r.updateIf(ctx, rel, filter)
+}
+
+func (r *relationshipRepositoryImpl) UpdateReconnectRequest(ctx context.Context, rel *domainEntities.Relationship, from *primitive.ObjectID) error {
+	// null matches the field being null or missing
+	filter := bson.M{"_id": rel.ID, "status": domainEntities.RelationshipStatusDisconnected, "reconnectRequestedBy": nil}
+	if from != nil {
+		filter["reconnectRequestedBy"] = *from
+	}
+	return r.updateIf(ctx, rel, filter)
+}
+
+// updateIf stores rel when the stored document matches filter
+func (r *relationshipRepositoryImpl) updateIf(ctx context.Context, rel *domainEntities.Relationship, filter bson.M) error {
+	rel.UpdatedAt = time.Now()
+	rel.Users = rel.PartnerIDs()
+	rel.SchemaVersion = domainEntities.RelationshipSchemaVersion
+	res, err := r.db.Relationships().UpdateOne(ctx, filter, bson.M{"$set": rel})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domainRepos.ErrRelationshipStateChanged
+	}
+	return nil
+}
+
+func (r *relationshipRepositoryImpl) FindAllByUserID(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.Relationship, error) {
+	filter := bson.M{"partners": bson.M{"$elemMatch": bson.M{"userId": userID}}}
+	cursor, err := r.db.Relationships().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rels []*domainEntities.Relationship
+	if err := cursor.All(ctx, &rels); err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+func (r *relationshipRepositoryImpl) FindPastByUserID(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.Relationship, error) {
+	filter := bson.M{
+		"partners": bson.M{"$elemMatch": bson.M{"userId": userID}},
+		"status":   domainEntities.RelationshipStatusDisconnected,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "disconnectedAt", Value: -1}, {Key: "updatedAt", Value: -1}})
+	cursor, err := r.db.Relationships().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rels []*domainEntities.Relationship
+	if err := cursor.All(ctx, &rels); err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+func (r *relationshipRepositoryImpl) FindDisconnectsDue(ctx context.Context, now time.Time) ([]*domainEntities.Relationship, error) {
+	filter := bson.M{
+		"status":                          domainEntities.RelationshipStatusDisconnectRequested,
+		"disconnectRequest.autoConfirmAt": bson.M{"$lte": now},
+	}
+	cursor, err := r.db.Relationships().Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rels []*domainEntities.Relationship
+	if err := cursor.All(ctx, &rels); err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+func (r *relationshipRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Relationships().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type sessionRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewSessionRepository(db *database.MongoDB) domainRepos.SessionRepository {
+	return &sessionRepositoryImpl{db: db}
+}
+
+func (r *sessionRepositoryImpl) Create(ctx context.Context, session *domainEntities.Session) error {
+	_, err := r.db.Sessions().InsertOne(ctx, session)
+	return err
+}
+
+func (r *sessionRepositoryImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*domainEntities.Session, error) {
+	var s domainEntities.Session
+	err := r.db.Sessions().FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepositoryImpl) FindActiveByUserID(ctx context.Context, userID primitive.ObjectID) ([]*domainEntities.Session, error) {
+	filter := bson.M{"userId": userID, "revokedAt": nil, "expiresAt": bson.M{"$gt": time.Now()}}
+	opts := options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}})
+	cur, err := r.db.Sessions().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var sessions []*domainEntities.Session
+	if err := cur.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepositoryImpl) Touch(ctx context.Context, id primitive.ObjectID, ip string, seenAt time.Time) error {
+	_, err := r.db.Sessions().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastSeenAt": seenAt, "ip": ip}})
+	return err
+}
+
+func (r *sessionRepositoryImpl) Extend(ctx context.Context, id primitive.ObjectID, ip string, seenAt, expiresAt time.Time) error {
+	update := bson.M{"$set": bson.M{"lastSeenAt": seenAt, "ip": ip, "expiresAt": expiresAt}}
+	_, err := r.db.Sessions().UpdateOne(ctx, bson.M{"_id": id, "revokedAt": nil}, update)
+	return err
+}
+
+func (r *sessionRepositoryImpl) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	return r.revoke(ctx, bson.M{"_id": id})
+}
+
+func (r *sessionRepositoryImpl) RevokeAllByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	return r.revoke(ctx, bson.M{"userId": userID})
+}
+
+func (r *sessionRepositoryImpl) revoke(ctx context.Context, filter bson.M) error {
+	filter["revokedAt"] = nil
+	_, err := r.db.Sessions().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type statsRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewStatsRepository(db *database.MongoDB) domainRepos.StatsRepository {
+	return &statsRepositoryImpl{db: db}
+}
+
+type countBucket struct {
+	ID    string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+func (r *statsRepositoryImpl) AggregateEventStats(ctx context.Context, relationshipID primitive.ObjectID) (*domainEntities.EventStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"relationshipId": relationshipID}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":         nil,
+					"total":       bson.M{"$sum": 1},
+					"publicCount": bson.M{"$sum": bson.M{"$cond": bson.A{"$visibility.isPublic", 1, 0}}},
+					"first":       bson.M{"$min": "$date"},
+					"last":        bson.M{"$max": "$date"},
+				}},
+			},
+			"byType": bson.A{
+				bson.M{"$group": bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}},
+			},
+			"byCategory": bson.A{
+				bson.M{"$group": bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}},
+			},
+			// Pair every memory with the previous one by date and keep the widest distance
+			"longestGap": bson.A{
+				bson.M{"$setWindowFields": bson.M{
+					"sortBy": bson.M{"date": 1},
+					"output": bson.M{"prevDate": bson.M{"$shift": bson.M{"output": "$date", "by": -1}}},
+				}},
+				bson.M{"$match": bson.M{"prevDate": bson.M{"$ne": nil}}},
+				bson.M{"$project": bson.M{
+					"_id":   0,
+					"from":  "$prevDate",
+					"to":    "$date",
+					"gapMs": bson.M{"$subtract": bson.A{"$date", "$prevDate"}},
+				}},
+				bson.M{"$sort": bson.M{"gapMs": -1}},
+				bson.M{"$limit": 1},
+			},
+		}}},
+	}
+
+	cursor, err := r.db.Events().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var out []struct {
+		Totals []struct {
+			Total       int        `bson:"total"`
+			PublicCount int        `bson:"publicCount"`
+			First       *time.Time `bson:"first"`
+			Last        *time.Time `bson:"last"`
+		} `bson:"totals"`
+		ByType     []countBucket `bson:"byType"`
+		ByCategory []countBucket `bson:"byCategory"`
+		LongestGap []struct {
+			From  time.Time `bson:"from"`
+			To    time.Time `bson:"to"`
+			GapMs int64     `bson:"gapMs"`
+		} `bson:"longestGap"`
+	}
+	if err := cursor.All(ctx, &out); err != nil {
+		return nil, err
+	}
+
+	stats := &domainEntities.EventStats{
+		ByType:     map[string]int{},
+		ByCategory: map[string]int{},
+	}
+	if len(out) == 0 {
+		return stats, nil
+	}
+	facet := out[0]
+	if len(facet.Totals) > 0 {
+		stats.Total = facet.Totals[0].Total
+		stats.PublicCount = facet.Totals[0].PublicCount
+		stats.FirstMemoryAt = facet.Totals[0].First
+		stats.LastMemoryAt = facet.Totals[0].Last
+	}
+	for _, b := range facet.ByType {
+		stats.ByType[b.ID] = b.Count
+	}
+	for _, b := range facet.ByCategory {
+		stats.ByCategory[b.ID] = b.Count
+	}
+	if len(facet.LongestGap) > 0 {
+		gap := facet.LongestGap[0]
+		stats.LongestGapDays = int(time.Duration(gap.GapMs) * time.Millisecond / (24 * time.Hour))
+		stats.LongestGapFrom = &gap.From
+		stats.LongestGapTo = &gap.To
+	}
+	return stats, nil
+}
+
+func (r *statsRepositoryImpl) AggregateWhisperStats(ctx context.Context, relationshipID primitive.ObjectID) (*domainEntities.WhisperStats, error) {
+	// Mirrors Whisper.IsRecurring and Whisper.CompletedOccurrences: isDone only
+	// describes one-off whispers, recurring ones record completions per day
+	recurring := bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$rule", nil}}, nil}},
+		bson.M{"$in": bson.A{"$recurrence", bson.A{
+			domainEntities.WhisperRecurrenceEveryday,
+			domainEntities.WhisperRecurrenceWeekly,
+			domainEntities.WhisperRecurrenceMonthly,
+		}}},
+	}}
+	isDone := bson.M{"$eq": bson.A{"$isDone", true}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"relationshipId": relationshipID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": 1},
+			"done": bson.M{"$sum": bson.M{"$cond": bson.A{recurring,
+				bson.M{"$size": bson.M{"$ifNull": bson.A{"$completions", bson.A{}}}},
+				bson.M{"$cond": bson.A{isDone, 1, 0}},
+			}}},
+			"open": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$or": bson.A{recurring, isDone}}, 0, 1,
+			}}},
+		}}},
+	}
+	cursor, err := r.db.Whispers().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var out []domainEntities.WhisperStats
+	if err := cursor.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return &domainEntities.WhisperStats{}, nil
+	}
+	return &out[0], nil
+}
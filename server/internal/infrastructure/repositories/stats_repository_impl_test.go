@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+func TestAggregateWhisperStats(t *testing.T) {
+	ctx := context.Background()
+	db := testDatabase(t)
+	whispers := NewWhisperRepository(db)
+	relID, alice := primitive.NewObjectID(), primitive.NewObjectID()
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	create := func(recurrence string, rule *entities.RecurrenceRule, doneDays int, relationshipID primitive.ObjectID) {
+		t.Helper()
+		w := entities.NewWhisper("custom", "Call", recurrence, day, relationshipID, alice)
+		if rule != nil {
+			w.SetRule(rule)
+		}
+		for i := 0; i < doneDays; i++ {
+			w.SetOccurrenceDone(day.AddDate(0, 0, i), alice, true)
+		}
+		if err := whispers.Create(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create(entities.WhisperRecurrenceOnce, nil, 1, relID)
+	create(entities.WhisperRecurrenceOnce, nil, 0, relID)
+	create(entities.WhisperRecurrenceOnce, nil, 0, relID)
+	create(entities.WhisperRecurrenceEveryday, nil, 3, relID)
+	create(entities.WhisperRecurrenceOnce, &entities.RecurrenceRule{Freq: entities.FreqWeekly, Interval: 2}, 2, relID)
+	create(entities.WhisperRecurrenceWeekly, nil, 0, relID)
+	create(entities.WhisperRecurrenceOnce, nil, 1, primitive.NewObjectID())
+
+	stats, err := NewStatsRepository(db).AggregateWhisperStats(ctx, relID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := entities.WhisperStats{Total: 6, Done: 6, Open: 2}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+
+	empty, err := NewStatsRepository(db).AggregateWhisperStats(ctx, primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *empty != (entities.WhisperStats{}) {
+		t.Errorf("stats without whispers = %+v", *empty)
+	}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type todoRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewTodoRepository(db *database.MongoDB) domainRepos.TodoRepository {
+	return &todoRepositoryImpl{db: db}
+}
+
+func (r *todoRepositoryImpl) Create(ctx context.Context, todo *domainEntities.Todo) error {
+	todo.CreatedAt = time.Now()
+	todo.UpdatedAt = time.Now()
+	res, err := r.db.Todos().InsertOne(ctx, todo)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		todo.ID = oid
+	}
+	return nil
+}
+
+func (r *todoRepositoryImpl) FindByID(ctx context.Context, id primitive.ObjectID) (*domainEntities.Todo, error) {
+	var t domainEntities.Todo
+	err := r.db.Todos().FindOne(ctx, bson.M{"_id": id}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("todo not found")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *todoRepositoryImpl) Update(ctx context.Context, todo *domainEntities.Todo) error {
+	todo.UpdatedAt = time.Now()
+	update := bson.M{"$set": todo}
+	_, err := r.db.Todos().UpdateOne(ctx, bson.M{"_id": todo.ID}, update)
+	return err
+}
+
+func (r *todoRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Todos().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *todoRepositoryImpl) DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error {
+	_, err := r.db.Todos().DeleteMany(ctx, bson.M{"relationshipId": relationshipID})
+	return err
+}
+
+func (r *todoRepositoryImpl) FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, completed *bool, limit, offset int64) ([]*domainEntities.Todo, error) {
+	// Open todos first, then newest first
+	findOpts := options.Find()
+	findOpts.SetSort(bson.D{{Key: "isCompleted", Value: 1}, {Key: "createdAt", Value: -1}})
+	if limit > 0 {
+		findOpts.SetLimit(limit)
+	}
+	if offset > 0 {
+		findOpts.SetSkip(offset)
+	}
+	filter := bson.M{"relationshipId": relationshipID}
+	if completed != nil {
+		filter["isCompleted"] = *completed
+	}
+	cursor, err := r.db.Todos().Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var todos []*domainEntities.Todo
+	for cursor.Next(ctx) {
+		var t domainEntities.Todo
+		if err := cursor.Decode(&t); err != nil {
+			return nil, err
+		}
+		todos = append(todos, &t)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domainEntities "whisper-server/internal/domain/entities"
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type twoFactorRepositoryImpl struct {
+	db *database.MongoDB
+}
+
+func NewTwoFactorRepository(db *database.MongoDB) domainRepos.TwoFactorRepository {
+	return &twoFactorRepositoryImpl{db: db}
+}
+
+func (r *twoFactorRepositoryImpl) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*domainEntities.TwoFactor, error) {
+	var tf domainEntities.TwoFactor
+	err := r.db.TwoFactor().FindOne(ctx, bson.M{"_id": userID}).Decode(&tf)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domainRepos.ErrTwoFactorNotFound
+		}
+		return nil, err
+	}
+	return &tf, nil
+}
+
+func (r *twoFactorRepositoryImpl) Save(ctx context.Context, tf *domainEntities.TwoFactor) error {
+	_, err := r.db.TwoFactor().ReplaceOne(ctx, bson.M{"_id": tf.UserID}, tf, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *twoFactorRepositoryImpl) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.db.TwoFactor().DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
+func (r *twoFactorRepositoryImpl) UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{"_id": userID, "lastUsedStep": bson.M{"$lt": step}}
+	res, err := r.db.TwoFactor().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastUsedStep": step}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *twoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": userID, "recoveryCodes": codeHash}
+	res, err := r.db.TwoFactor().UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recoveryCodes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
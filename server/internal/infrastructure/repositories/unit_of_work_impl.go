@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	domainRepos "whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/database"
+)
+
+type unitOfWorkImpl struct {
+	db *database.MongoDB
+}
+
+func NewUnitOfWork(db *database.MongoDB) domainRepos.UnitOfWork {
+	return &unitOfWorkImpl{db: db}
+}
+
+func (u *unitOfWorkImpl) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.db.WithTransaction(ctx, fn)
+}
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"whisper-server/internal/domain/entities"
 	"whisper-server/internal/domain/repositories"
@@ -25,7 +26,7 @@ func NewUserRepository(db *database.MongoDB) repositories.UserRepository {
 func (r *userRepositoryImpl) Create(ctx context.Context, user *entities.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
-	
+
 	result, err := r.db.Users().InsertOne(ctx, user)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
@@ -33,7 +34,7 @@ func (r *userRepositoryImpl) Create(ctx context.Context, user *entities.User) er
 		}
 		return err
 	}
-	
+
 	user.ID = result.InsertedID.(primitive.ObjectID)
 	return nil
 }
@@ -76,7 +77,7 @@ func (r *userRepositoryImpl) FindByEmail(ctx context.Context, email string) (*en
 
 func (r *userRepositoryImpl) Update(ctx context.Context, user *entities.User) error {
 	user.UpdatedAt = time.Now()
-	
+
 	update := bson.M{"$set": user}
 	_, err := r.db.Users().UpdateOne(ctx, bson.M{"_id": user.ID}, update)
 	return err
@@ -93,22 +94,127 @@ func (r *userRepositoryImpl) UpdatePassword(ctx context.Context, userID primitiv
 	return err
 }
 
+func (r *userRepositoryImpl) MarkEmailVerified(ctx context.Context, userID primitive.ObjectID, verifiedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"emailVerifiedAt": verifiedAt,
+			"updatedAt":       time.Now(),
+		},
+	}
+	_, err := r.db.Users().UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func (r *userRepositoryImpl) UpdateSettings(ctx context.Context, userID primitive.ObjectID, settings entities.UserSettings) error {
+	update := bson.M{
+		"$set": bson.M{
+			"settings":  settings,
+			"updatedAt": time.Now(),
+		},
+	}
+	_, err := r.db.Users().UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func (r *userRepositoryImpl) SetRelationship(ctx context.Context, userID primitive.ObjectID, from *primitive.ObjectID, to primitive.ObjectID, relationshipDays int) error {
+	filter := bson.M{"_id": userID, "deletedAt": nil, "relationshipId": from}
+	if from == nil {
+		filter["relationshipId"] = nil
+	}
+	update := bson.M{"$set": bson.M{
+		"relationshipId":         to,
+		"stats.relationshipDays": relationshipDays,
+		"updatedAt":              time.Now(),
+	}}
+	res, err := r.db.Users().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repositories.ErrRelationshipChanged
+	}
+	return nil
+}
+
+func (r *userRepositoryImpl) ClearRelationship(ctx context.Context, userID, relationshipID primitive.ObjectID) error {
+	filter := bson.M{"_id": userID, "relationshipId": relationshipID}
+	update := bson.M{
+		"$unset": bson.M{"relationshipId": ""},
+		"$set":   bson.M{"stats.relationshipDays": 0, "updatedAt": time.Now()},
+	}
+	_, err := r.db.Users().UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *userRepositoryImpl) UpdateStats(ctx context.Context, userID primitive.ObjectID, stats entities.UserStats) error {
+	update := bson.M{
+		"$set": bson.M{
+			"stats":     stats,
+			"updatedAt": time.Now(),
+		},
+	}
+	_, err := r.db.Users().UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
 func (r *userRepositoryImpl) ExistsByUsername(ctx context.Context, username string) (bool, error) {
-	count, err := r.db.Users().CountDocuments(ctx, bson.M{"username": username, "deletedAt": nil})
+	count, err := r.db.Users().CountDocuments(ctx, bson.M{"username": username})
 	return count > 0, err
 }
 
 func (r *userRepositoryImpl) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-	count, err := r.db.Users().CountDocuments(ctx, bson.M{"email": email, "deletedAt": nil})
+	count, err := r.db.Users().CountDocuments(ctx, bson.M{"email": email})
 	return count > 0, err
 }
 
-func (r *userRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (r *userRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID, purgeAt time.Time) error {
 	update := bson.M{
 		"$set": bson.M{
 			"deletedAt": time.Now(),
+			"purgeAt":   purgeAt,
 		},
 	}
 	_, err := r.db.Users().UpdateOne(ctx, bson.M{"_id": id}, update)
 	return err
-}
\ No newline at end of file
+}
+
+func (r *userRepositoryImpl) Restore(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}, "purgeAt": bson.M{"$gt": time.Now()}}
+	update := bson.M{
+		"$unset": bson.M{"deletedAt": "", "purgeAt": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}
+	res, err := r.db.Users().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+func (r *userRepositoryImpl) FindDueForPurge(ctx context.Context, now time.Time, limit int64) ([]*entities.User, error) {
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}, "purgeAt": bson.M{"$lte": now}}
+	cursor, err := r.db.Users().Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*entities.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepositoryImpl) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := r.db.Users().CountDocuments(ctx, bson.M{"_id": id})
+	return count > 0, err
+}
+
+func (r *userRepositoryImpl) Purge(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Users().DeleteOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}})
+	return err
+}
@@ -60,6 +60,11 @@ func (r *whisperRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectI
 	return err
 }
 
+func (r *whisperRepositoryImpl) DeleteByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID) error {
+	_, err := r.db.Whispers().DeleteMany(ctx, bson.M{"relationshipId": relationshipID})
+	return err
+}
+
 func (r *whisperRepositoryImpl) FindAllByRelationshipID(ctx context.Context, relationshipID primitive.ObjectID, limit, offset int64) ([]*domainEntities.Whisper, error) {
 	findOpts := options.Find()
 	findOpts.SetSort(bson.D{{Key: "date", Value: 1}})
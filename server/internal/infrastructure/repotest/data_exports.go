@@ -0,0 +1,110 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+func createDataExport(t *testing.T, r Repos, userID primitive.ObjectID) *entities.DataExport {
+	t.Helper()
+	export := entities.NewDataExport(userID)
+	check(t, r.DataExports.Create(context.Background(), export))
+	return export
+}
+
+var dataExportCases = []contractCase{
+	{"latest by user", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		createDataExport(t, r, alice)
+		tick()
+		newer := createDataExport(t, r, alice)
+		createDataExport(t, r, primitive.NewObjectID())
+
+		got, err := r.DataExports.FindLatestByUserID(ctx, alice)
+		check(t, err)
+		if got.ID != newer.ID || got.Status != entities.DataExportStatusPending || got.Key != newer.Key {
+			t.Errorf("latest = %s %s %q, want %s", got.ID.Hex(), got.Status, got.Key, newer.ID.Hex())
+		}
+		all, err := r.DataExports.FindByUserID(ctx, alice)
+		check(t, err)
+		if len(all) != 2 {
+			t.Errorf("alice has %d exports, want 2", len(all))
+		}
+		_, err = r.DataExports.FindLatestByUserID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "data export not found")
+	}},
+	{"claim once until stale", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		export := createDataExport(t, r, primitive.NewObjectID())
+		staleBefore := time.Now().Add(-time.Minute)
+
+		claimed, err := r.DataExports.Claim(ctx, export.ID, staleBefore)
+		check(t, err)
+		if !claimed {
+			t.Fatal("pending export not claimed")
+		}
+		if claimed, _ := r.DataExports.Claim(ctx, export.ID, staleBefore); claimed {
+			t.Fatal("export claimed twice")
+		}
+		unfinished, err := r.DataExports.FindUnfinished(ctx, staleBefore)
+		check(t, err)
+		if len(unfinished) != 0 {
+			t.Errorf("fresh processing export listed as unfinished")
+		}
+
+		// A worker that died mid-build leaves the export to the next one
+		tick()
+		later := time.Now().Add(time.Second)
+		unfinished, err = r.DataExports.FindUnfinished(ctx, later)
+		check(t, err)
+		if len(unfinished) != 1 || unfinished[0].ID != export.ID {
+			t.Errorf("stale export not listed as unfinished")
+		}
+		if claimed, _ := r.DataExports.Claim(ctx, export.ID, later); !claimed {
+			t.Error("stale export not reclaimed")
+		}
+	}},
+	{"ready, failed and expired", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		userID := primitive.NewObjectID()
+		ready := createDataExport(t, r, userID)
+		failed := createDataExport(t, r, userID)
+		expiresAt := time.Now().Add(-time.Second)
+		check(t, r.DataExports.MarkReady(ctx, ready.ID, 42, expiresAt))
+		check(t, r.DataExports.MarkFailed(ctx, failed.ID, "boom"))
+
+		got, err := r.DataExports.FindByUserID(ctx, userID)
+		check(t, err)
+		for _, e := range got {
+			switch e.ID {
+			case ready.ID:
+				if e.Status != entities.DataExportStatusReady || e.Size != 42 || e.CompletedAt == nil || e.ExpiresAt == nil || !sameTime(*e.ExpiresAt, expiresAt) {
+					t.Errorf("ready export = %+v", e)
+				}
+			case failed.ID:
+				if e.Status != entities.DataExportStatusFailed || e.Error != "boom" || e.CompletedAt == nil {
+					t.Errorf("failed export = %+v", e)
+				}
+			}
+		}
+		if claimed, _ := r.DataExports.Claim(ctx, ready.ID, time.Now()); claimed {
+			t.Error("ready export claimed")
+		}
+
+		expired, err := r.DataExports.FindExpired(ctx, time.Now())
+		check(t, err)
+		if len(expired) != 1 || expired[0].ID != ready.ID {
+			t.Errorf("expired = %d exports, want the ready one", len(expired))
+		}
+		check(t, r.DataExports.Delete(ctx, ready.ID))
+		if expired, _ := r.DataExports.FindExpired(ctx, time.Now()); len(expired) != 0 {
+			t.Error("deleted export still listed")
+		}
+	}},
+}
@@ -0,0 +1,166 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// eventDay is the date of the first event in listing tests; later ones follow a day apart
+var eventDay = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func createEvent(t *testing.T, r Repos, title, eventType string, date time.Time, relID, createdBy primitive.ObjectID) *entities.Event {
+	t.Helper()
+	ev := entities.NewEvent(title, eventType, date, relID, createdBy)
+	check(t, r.Events.Create(context.Background(), ev))
+	return ev
+}
+
+// publish makes ev public as of at and stores it
+func publish(t *testing.T, r Repos, ev *entities.Event, at time.Time) {
+	t.Helper()
+	ev.MakePublic()
+	ev.Visibility.PublishedAt = &at
+	check(t, r.Events.Update(context.Background(), ev))
+}
+
+func eventTitles(events []*entities.Event) []string {
+	titles := make([]string, 0, len(events))
+	for _, ev := range events {
+		titles = append(titles, ev.Title)
+	}
+	return titles
+}
+
+var eventCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID, alice := primitive.NewObjectID(), primitive.NewObjectID()
+		ev := createEvent(t, r, "First date", entities.EventTypeDate, eventDay, relID, alice)
+		got, err := r.Events.FindByID(ctx, ev.ID)
+		check(t, err)
+		if got.Title != "First date" || got.RelationshipID != relID || got.CreatedBy != alice || !sameTime(got.Date, eventDay) {
+			t.Errorf("stored title=%q relationship=%s createdBy=%s date=%s", got.Title, got.RelationshipID.Hex(), got.CreatedBy.Hex(), got.Date)
+		}
+		if got.Visibility.IsPublic || got.Source.Type != entities.SourceTypeManual {
+			t.Errorf("stored public=%t source=%s", got.Visibility.IsPublic, got.Source.Type)
+		}
+		_, err = r.Events.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "event not found")
+	}},
+	{"update keeps omitted fields", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		ev := createEvent(t, r, "First date", entities.EventTypeDate, eventDay, primitive.NewObjectID(), primitive.NewObjectID())
+		ev.Update("", "Dinner by the river", time.Time{}, "")
+		check(t, r.Events.Update(ctx, ev))
+
+		// Like the $set of a struct with omitempty tags, an empty description keeps its value
+		edit := *ev
+		edit.Title = "Our first date"
+		edit.Description = ""
+		check(t, r.Events.Update(ctx, &edit))
+		got, err := r.Events.FindByID(ctx, ev.ID)
+		check(t, err)
+		if got.Title != "Our first date" || got.Description != "Dinner by the river" {
+			t.Errorf("after update title=%q description=%q", got.Title, got.Description)
+		}
+	}},
+	{"delete", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		ev := createEvent(t, r, "First date", entities.EventTypeDate, eventDay, primitive.NewObjectID(), primitive.NewObjectID())
+		check(t, r.Events.Delete(ctx, ev.ID))
+		_, err := r.Events.FindByID(ctx, ev.ID)
+		wantErrText(t, err, "event not found")
+		check(t, r.Events.Delete(ctx, ev.ID))
+	}},
+	{"lists newest first", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID, alice, bob := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		for i, author := range []primitive.ObjectID{alice, bob, alice, bob, alice} {
+			day := eventDay.AddDate(0, 0, i)
+			createEvent(t, r, day.Format("Jan 2"), entities.EventTypeDate, day, relID, author)
+		}
+		createEvent(t, r, "Elsewhere", entities.EventTypeDate, eventDay, primitive.NewObjectID(), primitive.NewObjectID())
+
+		tests := []struct {
+			name          string
+			user          bool
+			limit, offset int64
+			want          []string
+		}{
+			{"relationship", false, 0, 0, []string{"Mar 5", "Mar 4", "Mar 3", "Mar 2", "Mar 1"}},
+			{"relationship page", false, 2, 1, []string{"Mar 4", "Mar 3"}},
+			{"relationship past the end", false, 2, 5, nil},
+			{"author", true, 0, 0, []string{"Mar 5", "Mar 3", "Mar 1"}},
+			{"author page", true, 1, 1, []string{"Mar 3"}},
+		}
+		for _, tt := range tests {
+			var events []*entities.Event
+			var err error
+			if tt.user {
+				events, err = r.Events.FindAllByUserID(ctx, alice, tt.limit, tt.offset)
+			} else {
+				events, err = r.Events.FindAllByRelationshipID(ctx, relID, tt.limit, tt.offset)
+			}
+			check(t, err)
+			wantOrder(t, tt.name, eventTitles(events), tt.want)
+		}
+	}},
+	{"public events", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID, alice := primitive.NewObjectID(), primitive.NewObjectID()
+		published := time.Now().Add(-time.Hour)
+		trip := createEvent(t, r, "Trip", entities.EventTypeTrip, eventDay, relID, alice)
+		dinner := createEvent(t, r, "Dinner", entities.EventTypeDate, eventDay.AddDate(0, 0, 1), relID, alice)
+		picnic := createEvent(t, r, "Picnic", entities.EventTypeDate, eventDay.AddDate(0, 0, 2), relID, alice)
+		createEvent(t, r, "Private", entities.EventTypeDate, eventDay, relID, alice)
+		publish(t, r, trip, published.Add(3*time.Minute))
+		publish(t, r, dinner, published.Add(2*time.Minute))
+		publish(t, r, picnic, published.Add(time.Minute))
+		for i := 0; i < 2; i++ {
+			_, err := r.Events.IncrementViewCount(ctx, picnic.ID)
+			check(t, err)
+		}
+		got, err := r.Events.IncrementViewCount(ctx, dinner.ID)
+		check(t, err)
+		if got.ViewCount != 1 {
+			t.Errorf("view count after increment = %d, want 1", got.ViewCount)
+		}
+
+		tests := []struct {
+			name          string
+			eventType     string
+			sortBy        string
+			limit, offset int64
+			want          []string
+		}{
+			{"recent", "", repositories.PublicEventSortRecent, 0, 0, []string{"Trip", "Dinner", "Picnic"}},
+			{"popular", "", repositories.PublicEventSortPopular, 0, 0, []string{"Picnic", "Dinner", "Trip"}},
+			{"by type", entities.EventTypeDate, repositories.PublicEventSortRecent, 0, 0, []string{"Dinner", "Picnic"}},
+			{"page", "", repositories.PublicEventSortPopular, 1, 1, []string{"Dinner"}},
+		}
+		for _, tt := range tests {
+			events, err := r.Events.FindPublic(ctx, tt.eventType, tt.sortBy, tt.limit, tt.offset)
+			check(t, err)
+			wantOrder(t, tt.name, eventTitles(events), tt.want)
+		}
+	}},
+	{"views only count on public events", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		ev := createEvent(t, r, "First date", entities.EventTypeDate, eventDay, primitive.NewObjectID(), primitive.NewObjectID())
+		_, err := r.Events.IncrementViewCount(ctx, ev.ID)
+		wantErrText(t, err, "event not found")
+		_, err = r.Events.IncrementViewCount(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "event not found")
+		got, err := r.Events.FindByID(ctx, ev.ID)
+		check(t, err)
+		if got.ViewCount != 0 {
+			t.Errorf("private event view count = %d", got.ViewCount)
+		}
+	}},
+}
@@ -0,0 +1,112 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+func createIdentity(t *testing.T, r Repos, userID primitive.ObjectID, provider, subject string) *entities.ExternalIdentity {
+	t.Helper()
+	identity := entities.NewExternalIdentity(userID, provider, subject, subject+"@example.com")
+	check(t, r.ExternalIdentities.Create(context.Background(), identity))
+	return identity
+}
+
+var externalIdentityCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		google := createIdentity(t, r, alice, "google", "g-alice")
+		tick()
+		github := createIdentity(t, r, alice, "github", "gh-alice")
+		createIdentity(t, r, primitive.NewObjectID(), "google", "g-bob")
+
+		got, err := r.ExternalIdentities.FindByProviderSubject(ctx, "google", "g-alice")
+		check(t, err)
+		if got.ID != google.ID || got.UserID != alice || got.Email != "g-alice@example.com" {
+			t.Errorf("stored %+v", got)
+		}
+		if _, err := r.ExternalIdentities.FindByProviderSubject(ctx, "github", "g-alice"); !errors.Is(err, repositories.ErrExternalIdentityNotFound) {
+			t.Errorf("subject of another provider: %v, want %v", err, repositories.ErrExternalIdentityNotFound)
+		}
+
+		all, err := r.ExternalIdentities.FindByUserID(ctx, alice)
+		check(t, err)
+		if len(all) != 2 || all[0].ID != google.ID || all[1].ID != github.ID {
+			t.Fatalf("identities of alice = %d, want google then github", len(all))
+		}
+	}},
+	{"one link per provider account and per user", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		createIdentity(t, r, alice, "google", "g-alice")
+		for name, identity := range map[string]*entities.ExternalIdentity{
+			"same provider account": entities.NewExternalIdentity(bob, "google", "g-alice", ""),
+			"second google account": entities.NewExternalIdentity(alice, "google", "g-other", ""),
+		} {
+			if err := r.ExternalIdentities.Create(ctx, identity); !mongo.IsDuplicateKeyError(err) {
+				t.Errorf("%s: %v, want a duplicate key error", name, err)
+			}
+		}
+	}},
+	{"touch login and delete", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		identity := createIdentity(t, r, alice, "google", "g-alice")
+		createIdentity(t, r, bob, "google", "g-bob")
+
+		at := time.Now().Add(-time.Minute)
+		check(t, r.ExternalIdentities.TouchLogin(ctx, identity.ID, at))
+		got, err := r.ExternalIdentities.FindByProviderSubject(ctx, "google", "g-alice")
+		check(t, err)
+		if got.LastLoginAt == nil || !sameTime(*got.LastLoginAt, at) {
+			t.Errorf("lastLoginAt = %v, want %s", got.LastLoginAt, at)
+		}
+
+		check(t, r.ExternalIdentities.DeleteByUserID(ctx, alice))
+		if left, _ := r.ExternalIdentities.FindByUserID(ctx, alice); len(left) != 0 {
+			t.Errorf("%d identities left after delete", len(left))
+		}
+		if _, err := r.ExternalIdentities.FindByProviderSubject(ctx, "google", "g-bob"); err != nil {
+			t.Errorf("bob's identity: %v", err)
+		}
+	}},
+}
+
+var oidcStateCases = []contractCase{
+	{"consumed once", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		now := time.Now()
+		check(t, r.OIDCStates.Create(ctx, &entities.OIDCState{
+			StateHash: "state-hash", Provider: "google", CodeVerifier: "verifier", Nonce: "nonce",
+			BrowserHash: "browser-hash", LinkUserID: &alice, CreatedAt: now, ExpiresAt: now.Add(time.Minute),
+		}))
+
+		got, err := r.OIDCStates.Consume(ctx, "state-hash")
+		check(t, err)
+		if got.Provider != "google" || got.CodeVerifier != "verifier" || got.Nonce != "nonce" ||
+			got.BrowserHash != "browser-hash" || got.LinkUserID == nil || *got.LinkUserID != alice {
+			t.Errorf("consumed %+v", got)
+		}
+		_, err = r.OIDCStates.Consume(ctx, "state-hash")
+		wantErrText(t, err, "oidc state not found")
+	}},
+	{"expired state", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		now := time.Now()
+		check(t, r.OIDCStates.Create(ctx, &entities.OIDCState{
+			StateHash: "state-hash", Provider: "google", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute),
+		}))
+		_, err := r.OIDCStates.Consume(ctx, "state-hash")
+		wantErrText(t, err, "oidc state not found")
+	}},
+}
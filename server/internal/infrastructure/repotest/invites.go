@@ -0,0 +1,198 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// createInvite stores a code that is not the creator's current one, so a case
+// can store several codes of one creator
+func createInvite(t *testing.T, r Repos, code string, createdBy primitive.ObjectID, expiresAt *time.Time) *entities.InviteCode {
+	t.Helper()
+	inv := entities.NewInviteCode(code, createdBy, firstMet, expiresAt)
+	inv.Current = false
+	check(t, r.Invites.Create(context.Background(), inv))
+	return inv
+}
+
+func createCurrentInvite(ctx context.Context, r Repos, code string, createdBy primitive.ObjectID, expiresAt *time.Time) error {
+	return r.Invites.Create(ctx, entities.NewInviteCode(code, createdBy, firstMet, expiresAt))
+}
+
+func inviteCodes(invites []*entities.InviteCode) []string {
+	codes := make([]string, 0, len(invites))
+	for _, inv := range invites {
+		codes = append(codes, inv.Code)
+	}
+	return codes
+}
+
+var inviteCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		createInvite(t, r, "ABC123", alice, nil)
+		got, err := r.Invites.FindByCode(ctx, "ABC123")
+		check(t, err)
+		if got.CreatedBy != alice || got.IsUsed || !sameTime(got.FirstMeetingDate, firstMet) || !got.IsActive(time.Now()) {
+			t.Errorf("stored createdBy=%s used=%t firstMeetingDate=%s", got.CreatedBy.Hex(), got.IsUsed, got.FirstMeetingDate)
+		}
+		_, err = r.Invites.FindByCode(ctx, "XYZ789")
+		wantErrText(t, err, "invite code not found")
+
+		dup := entities.NewInviteCode("ABC123", primitive.NewObjectID(), firstMet, nil)
+		if err := r.Invites.Create(ctx, dup); err == nil {
+			t.Error("duplicate code was created")
+		}
+	}},
+	{"redeem once", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		createInvite(t, r, "ABC123", alice, nil)
+		at := time.Now()
+		inv, err := r.Invites.Redeem(ctx, "ABC123", bob, at)
+		check(t, err)
+		if !inv.IsUsed || inv.UsedBy == nil || *inv.UsedBy != bob || inv.UsedAt == nil || !sameTime(*inv.UsedAt, at) {
+			t.Errorf("redeemed used=%t usedBy=%v usedAt=%v", inv.IsUsed, inv.UsedBy, inv.UsedAt)
+		}
+		if _, err := r.Invites.Redeem(ctx, "ABC123", carol, time.Now()); !errors.Is(err, repositories.ErrInviteUnavailable) {
+			t.Errorf("second redeem: %v, want %v", err, repositories.ErrInviteUnavailable)
+		}
+	}},
+	{"unavailable codes", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		expiresAt := time.Now().Add(time.Hour)
+		createInvite(t, r, "EXPIRE", alice, &expiresAt)
+		createInvite(t, r, "REVOKE", alice, nil)
+		check(t, r.Invites.Revoke(ctx, "REVOKE", alice, time.Now()))
+
+		tests := []struct {
+			name string
+			code string
+			at   time.Time
+		}{
+			{"expired", "EXPIRE", expiresAt.Add(time.Second)},
+			{"expiring now", "EXPIRE", expiresAt},
+			{"revoked", "REVOKE", time.Now()},
+			{"unknown", "XYZ789", time.Now()},
+		}
+		for _, tt := range tests {
+			if _, err := r.Invites.Redeem(ctx, tt.code, primitive.NewObjectID(), tt.at); !errors.Is(err, repositories.ErrInviteUnavailable) {
+				t.Errorf("%s: %v, want %v", tt.name, err, repositories.ErrInviteUnavailable)
+			}
+		}
+		if _, err := r.Invites.Redeem(ctx, "EXPIRE", primitive.NewObjectID(), expiresAt.Add(-time.Second)); err != nil {
+			t.Errorf("redeem before expiry: %v", err)
+		}
+	}},
+	{"codes of a creator", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		for _, code := range []string{"FIRST1", "SECOND", "THIRD3"} {
+			createInvite(t, r, code, alice, nil)
+			tick()
+		}
+		createInvite(t, r, "BOBS01", bob, nil)
+
+		invites, err := r.Invites.FindByCreator(ctx, alice)
+		check(t, err)
+		got, want := inviteCodes(invites), []string{"THIRD3", "SECOND", "FIRST1"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+			t.Fatalf("codes = %v, want %v", got, want)
+		}
+
+		check(t, r.Invites.DeleteByCreator(ctx, alice))
+		if invites, _ := r.Invites.FindByCreator(ctx, alice); len(invites) != 0 {
+			t.Errorf("%d codes left after delete", len(invites))
+		}
+		if _, err := r.Invites.FindByCode(ctx, "BOBS01"); err != nil {
+			t.Errorf("delete removed another creator's code: %v", err)
+		}
+	}},
+	{"revoke", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		createInvite(t, r, "ABC123", alice, nil)
+		createInvite(t, r, "USED01", alice, nil)
+		_, err := r.Invites.Redeem(ctx, "USED01", bob, time.Now())
+		check(t, err)
+
+		// Only the creator can revoke, and only a code that is still open
+		wantErrText(t, r.Invites.Revoke(ctx, "ABC123", bob, time.Now()), "invite code not found")
+		wantErrText(t, r.Invites.Revoke(ctx, "USED01", alice, time.Now()), "invite code not found")
+		wantErrText(t, r.Invites.Revoke(ctx, "XYZ789", alice, time.Now()), "invite code not found")
+
+		at := time.Now()
+		check(t, r.Invites.Revoke(ctx, "ABC123", alice, at))
+		inv, err := r.Invites.FindByCode(ctx, "ABC123")
+		check(t, err)
+		if inv.RevokedAt == nil || !sameTime(*inv.RevokedAt, at) {
+			t.Errorf("revokedAt = %v, want %s", inv.RevokedAt, at)
+		}
+		wantErrText(t, r.Invites.Revoke(ctx, "ABC123", alice, time.Now()), "invite code not found")
+	}},
+	{"revoke all active", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		expiresAt := time.Now().Add(time.Hour)
+		createInvite(t, r, "OPEN01", alice, nil)
+		createInvite(t, r, "OPEN02", alice, &expiresAt)
+		createInvite(t, r, "USED01", alice, nil)
+		createInvite(t, r, "BOBS01", bob, nil)
+		_, err := r.Invites.Redeem(ctx, "USED01", bob, time.Now())
+		check(t, err)
+
+		check(t, r.Invites.RevokeActiveByCreator(ctx, alice, time.Now()))
+		want := map[string]string{
+			"OPEN01": entities.InviteStatusRevoked,
+			"OPEN02": entities.InviteStatusRevoked,
+			"USED01": entities.InviteStatusUsed,
+			"BOBS01": entities.InviteStatusActive,
+		}
+		for code, status := range want {
+			inv, err := r.Invites.FindByCode(ctx, code)
+			check(t, err)
+			if got := inv.Status(time.Now()); got != status {
+				t.Errorf("%s status = %s, want %s", code, got, status)
+			}
+		}
+	}},
+	{"one current code per creator", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		check(t, createCurrentInvite(ctx, r, "FIRST1", alice, nil))
+		check(t, createCurrentInvite(ctx, r, "BOBS01", bob, nil))
+		if err := createCurrentInvite(ctx, r, "SECOND", alice, nil); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second current code: %v, want a duplicate key error", err)
+		}
+
+		// Using, revoking or replacing the current code makes room for the next
+		_, err := r.Invites.Redeem(ctx, "FIRST1", bob, time.Now())
+		check(t, err)
+		check(t, createCurrentInvite(ctx, r, "SECOND", alice, nil))
+		check(t, r.Invites.Revoke(ctx, "SECOND", alice, time.Now()))
+		expiresAt := time.Now().Add(time.Second)
+		check(t, createCurrentInvite(ctx, r, "THIRD3", alice, &expiresAt))
+		check(t, r.Invites.RevokeActiveByCreator(ctx, alice, expiresAt.Add(time.Second)))
+		check(t, createCurrentInvite(ctx, r, "FOURTH", alice, nil))
+
+		// An expired code is no longer current but is not marked revoked
+		inv, err := r.Invites.FindByCode(ctx, "THIRD3")
+		check(t, err)
+		if inv.Current || inv.RevokedAt != nil {
+			t.Errorf("expired code current=%t revokedAt=%v", inv.Current, inv.RevokedAt)
+		}
+		if inv, _ := r.Invites.FindByCode(ctx, "BOBS01"); inv == nil || !inv.Current {
+			t.Error("bob's code is no longer current")
+		}
+	}},
+}
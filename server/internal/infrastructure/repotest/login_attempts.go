@@ -0,0 +1,52 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var loginAttemptCases = []contractCase{
+	{"failures count within the window", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		now := time.Now()
+		window := 15 * time.Minute
+		tests := []struct {
+			name string
+			at   time.Time
+			want int
+		}{
+			{"first", now, 1},
+			{"second", now.Add(time.Minute), 2},
+			{"third", now.Add(2 * time.Minute), 3},
+			{"after a quiet window", now.Add(2*time.Minute + window + time.Second), 1},
+		}
+		for _, tt := range tests {
+			a, err := r.LoginAttempts.RecordFailure(ctx, "user:alice", tt.at, window)
+			check(t, err)
+			if a.Failures != tt.want || !sameTime(a.LastFailureAt, tt.at) {
+				t.Errorf("%s: failures=%d lastFailureAt=%s, want %d at %s", tt.name, a.Failures, a.LastFailureAt, tt.want, tt.at)
+			}
+		}
+		_, err := r.LoginAttempts.FindByKey(ctx, "ip:10.0.0.1")
+		wantErrText(t, err, "login attempt not found")
+	}},
+	{"lock and reset", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		now := time.Now()
+		_, err := r.LoginAttempts.RecordFailure(ctx, "user:alice", now, time.Minute)
+		check(t, err)
+		until := now.Add(time.Hour)
+		check(t, r.LoginAttempts.Lock(ctx, "user:alice", until))
+		a, err := r.LoginAttempts.FindByKey(ctx, "user:alice")
+		check(t, err)
+		if !a.IsLocked(now) || a.IsLocked(until) || a.Failures != 1 {
+			t.Errorf("after lock lockedUntil=%v failures=%d", a.LockedUntil, a.Failures)
+		}
+
+		check(t, r.LoginAttempts.Reset(ctx, "user:alice"))
+		_, err = r.LoginAttempts.FindByKey(ctx, "user:alice")
+		wantErrText(t, err, "login attempt not found")
+		check(t, r.LoginAttempts.Reset(ctx, "user:alice"))
+	}},
+}
@@ -0,0 +1,63 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+var mediaCases = []contractCase{
+	{"create, find and delete", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		m := entities.NewMedia(primitive.NewObjectID(), entities.MediaKindAvatar, "image/jpeg", "me.jpg", 1234)
+		m.AddVariant(entities.MediaVariantSmall, "image/jpeg", 64, 64, 200)
+		check(t, r.Media.Create(ctx, m))
+
+		got, err := r.Media.FindByID(ctx, m.ID)
+		check(t, err)
+		if got.Key != m.Key || got.OwnerID != m.OwnerID || len(got.Variants) != 1 || got.AttachedTo != nil {
+			t.Errorf("got key=%q owner=%s variants=%d attachedTo=%v", got.Key, got.OwnerID.Hex(), len(got.Variants), got.AttachedTo)
+		}
+
+		check(t, r.Media.Delete(ctx, m.ID))
+		_, err = r.Media.FindByID(ctx, m.ID)
+		wantErrText(t, err, "media not found")
+		wantErrText(t, r.Media.Delete(ctx, m.ID), "media not found")
+	}},
+	{"attach compare-and-set", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		m := entities.NewMedia(primitive.NewObjectID(), entities.MediaKindEventImage, "image/png", "", 10)
+		check(t, r.Media.Create(ctx, m))
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+		attach := func(target primitive.ObjectID, want bool) {
+			t.Helper()
+			ok, err := r.Media.Attach(ctx, m.ID, target)
+			check(t, err)
+			if ok != want {
+				t.Errorf("attach to %s = %t, want %t", target.Hex(), ok, want)
+			}
+		}
+		attach(first, true)
+		attach(first, true) // saving the same event again keeps its image
+		attach(second, false)
+
+		// Only the holder can let go
+		check(t, r.Media.Detach(ctx, m.ID, second))
+		attach(second, false)
+		check(t, r.Media.Detach(ctx, m.ID, first))
+		got, err := r.Media.FindByID(ctx, m.ID)
+		check(t, err)
+		if got.AttachedTo != nil {
+			t.Fatalf("attachedTo = %s after detach", got.AttachedTo.Hex())
+		}
+		attach(second, true)
+
+		if ok, err := r.Media.Attach(ctx, primitive.NewObjectID(), first); err != nil || ok {
+			t.Errorf("attach unknown media = %t, %v", ok, err)
+		}
+	}},
+}
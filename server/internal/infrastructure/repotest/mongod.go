@@ -0,0 +1,132 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNoMongod is returned by StartMongod when no mongod binary is installed
+var ErrNoMongod = errors.New("mongod binary not found")
+
+// Mongod is a throwaway mongod process for tests
+type Mongod struct {
+	URI    string
+	cmd    *exec.Cmd
+	dbPath string
+	exited chan struct{} // closed once the process is gone
+	err    error         // exit status, set before exited is closed
+}
+
+// replicaSet is the name of the single-member replica set StartMongod runs, so
+// the tests get multi-document transactions like production does
+const replicaSet = "rs0"
+
+// StartMongod starts mongod as a single-member replica set on a free local port
+// with an empty data directory and waits until it is primary. The binary is
+// MONGOD_BIN if set, otherwise mongod from PATH.
+func StartMongod() (*Mongod, error) {
+	bin := os.Getenv("MONGOD_BIN")
+	if bin == "" {
+		path, err := exec.LookPath("mongod")
+		if err != nil {
+			return nil, ErrNoMongod
+		}
+		bin = path
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	dbPath, err := os.MkdirTemp("", "whisper-mongod-")
+	if err != nil {
+		return nil, err
+	}
+	m := &Mongod{
+		URI:    fmt.Sprintf("mongodb://127.0.0.1:%d/?directConnection=true", port),
+		dbPath: dbPath,
+		cmd: exec.Command(bin, "--dbpath", dbPath, "--port", strconv.Itoa(port), "--bind_ip", "127.0.0.1",
+			"--replSet", replicaSet, "--quiet"),
+		exited: make(chan struct{}),
+	}
+	if err := m.cmd.Start(); err != nil {
+		os.RemoveAll(dbPath)
+		return nil, fmt.Errorf("start mongod: %w", err)
+	}
+	go func() {
+		m.err = m.cmd.Wait()
+		close(m.exited)
+	}()
+	if err := m.waitReady(fmt.Sprintf("127.0.0.1:%d", port), 30*time.Second); err != nil {
+		m.Stop()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Stop kills mongod and removes its data directory
+func (m *Mongod) Stop() {
+	m.cmd.Process.Kill()
+	<-m.exited
+	os.RemoveAll(m.dbPath)
+}
+
+// waitReady initiates the replica set once mongod accepts connections and
+// waits until it has elected itself primary
+func (m *Mongod) waitReady(host string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(m.URI))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.Background())
+	admin := client.Database("admin")
+	initiated := false
+	for {
+		var hello struct {
+			IsWritablePrimary bool `bson:"isWritablePrimary"`
+		}
+		helloCtx, cancelHello := context.WithTimeout(ctx, time.Second)
+		err := admin.RunCommand(helloCtx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+		cancelHello()
+		switch {
+		case err != nil:
+		case hello.IsWritablePrimary:
+			return nil
+		case !initiated:
+			config := bson.M{"_id": replicaSet, "members": bson.A{bson.M{"_id": 0, "host": host}}}
+			if err := admin.RunCommand(ctx, bson.D{{Key: "replSetInitiate", Value: config}}).Err(); err != nil {
+				return fmt.Errorf("initiate replica set: %w", err)
+			}
+			initiated = true
+		}
+		select {
+		case <-m.exited:
+			return fmt.Errorf("mongod exited before accepting connections: %v", m.err)
+		case <-ctx.Done():
+			return fmt.Errorf("mongod at %s not primary after %s", m.URI, timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// freePort asks the kernel for an unused local port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,95 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+func createOneTimeToken(t *testing.T, r Repos, userID primitive.ObjectID, purpose, hash string, expiresAt time.Time) *entities.OneTimeToken {
+	t.Helper()
+	token := entities.NewOneTimeToken(userID, purpose, hash, expiresAt)
+	check(t, r.OneTimeTokens.Create(context.Background(), token))
+	return token
+}
+
+var oneTimeTokenCases = []contractCase{
+	{"find by hash and purpose", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		token := createOneTimeToken(t, r, alice, entities.OneTimeTokenPasswordReset, "hash-1", time.Now().Add(time.Hour))
+		got, err := r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenPasswordReset, "hash-1")
+		check(t, err)
+		if got.ID != token.ID || got.UserID != alice || got.UsedAt != nil {
+			t.Errorf("stored id=%s user=%s usedAt=%v", got.ID.Hex(), got.UserID.Hex(), got.UsedAt)
+		}
+		_, err = r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenEmailVerification, "hash-1")
+		wantErrText(t, err, "token not found")
+	}},
+	{"latest by user", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		expiresAt := time.Now().Add(time.Hour)
+		createOneTimeToken(t, r, alice, entities.OneTimeTokenEmailVerification, "older", expiresAt)
+		tick()
+		newer := createOneTimeToken(t, r, alice, entities.OneTimeTokenEmailVerification, "newer", expiresAt)
+		tick()
+		createOneTimeToken(t, r, alice, entities.OneTimeTokenPasswordReset, "reset", expiresAt)
+		got, err := r.OneTimeTokens.FindLatestByUserID(ctx, alice, entities.OneTimeTokenEmailVerification)
+		check(t, err)
+		if got.ID != newer.ID {
+			t.Errorf("latest token = %s, want %s", got.TokenHash, newer.TokenHash)
+		}
+		_, err = r.OneTimeTokens.FindLatestByUserID(ctx, primitive.NewObjectID(), entities.OneTimeTokenEmailVerification)
+		wantErrText(t, err, "token not found")
+	}},
+	{"consume once", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		token := createOneTimeToken(t, r, alice, entities.OneTimeTokenPasswordReset, "live", time.Now().Add(time.Hour))
+		expired := createOneTimeToken(t, r, alice, entities.OneTimeTokenPasswordReset, "expired", time.Now().Add(-time.Minute))
+
+		tests := []struct {
+			name string
+			id   primitive.ObjectID
+			want bool
+		}{
+			{"first use", token.ID, true},
+			{"second use", token.ID, false},
+			{"expired", expired.ID, false},
+			{"unknown", primitive.NewObjectID(), false},
+		}
+		for _, tt := range tests {
+			got, err := r.OneTimeTokens.Consume(ctx, tt.id)
+			check(t, err)
+			if got != tt.want {
+				t.Errorf("%s: consumed = %t, want %t", tt.name, got, tt.want)
+			}
+		}
+		got, err := r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenPasswordReset, "live")
+		check(t, err)
+		if got.UsedAt == nil {
+			t.Error("consumed token has no usedAt")
+		}
+	}},
+	{"delete by user and purpose", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		expiresAt := time.Now().Add(time.Hour)
+		createOneTimeToken(t, r, alice, entities.OneTimeTokenPasswordReset, "alice-reset", expiresAt)
+		createOneTimeToken(t, r, alice, entities.OneTimeTokenEmailVerification, "alice-verify", expiresAt)
+		createOneTimeToken(t, r, bob, entities.OneTimeTokenPasswordReset, "bob-reset", expiresAt)
+		check(t, r.OneTimeTokens.DeleteByUserID(ctx, alice, entities.OneTimeTokenPasswordReset))
+
+		_, err := r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenPasswordReset, "alice-reset")
+		wantErrText(t, err, "token not found")
+		_, err = r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenEmailVerification, "alice-verify")
+		check(t, err)
+		_, err = r.OneTimeTokens.FindByHash(ctx, entities.OneTimeTokenPasswordReset, "bob-reset")
+		check(t, err)
+	}},
+}
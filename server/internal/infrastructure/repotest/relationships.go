@@ -0,0 +1,231 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+// firstMet is the first meeting date of the relationships created by the suite
+var firstMet = time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+
+func createRelationship(t *testing.T, r Repos, code string, partners ...primitive.ObjectID) *entities.Relationship {
+	t.Helper()
+	rel := entities.NewRelationship(partners, code, firstMet)
+	check(t, r.Relationships.Create(context.Background(), rel))
+	return rel
+}
+
+// disconnectAt ends rel as of at and stores it
+func disconnectAt(t *testing.T, r Repos, rel *entities.Relationship, at time.Time) {
+	t.Helper()
+	rel.Disconnect()
+	rel.DisconnectedAt = &at
+	check(t, r.Relationships.Update(context.Background(), rel))
+}
+
+func relationshipIDs(rels []*entities.Relationship) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(rels))
+	for _, rel := range rels {
+		ids = append(ids, rel.ID)
+	}
+	return ids
+}
+
+func wantIDs(t *testing.T, what string, got, want []primitive.ObjectID) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d ids, want %d", what, len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: id %d is %s, want %s", what, i, got[i].Hex(), want[i].Hex())
+		}
+	}
+}
+
+var relationshipCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		rel := createRelationship(t, r, "LOVE2024", alice, bob)
+		if rel.ID.IsZero() {
+			t.Fatal("create left the id empty")
+		}
+
+		got, err := r.Relationships.FindByID(ctx, rel.ID)
+		check(t, err)
+		if got.Status != entities.RelationshipStatusActive || len(got.Users) != 2 || !got.HasPartner(alice) || !got.HasPartner(bob) {
+			t.Errorf("stored status=%s users=%v", got.Status, got.Users)
+		}
+		if got.SchemaVersion != entities.RelationshipSchemaVersion || !sameTime(got.FirstMeetingDate, firstMet) {
+			t.Errorf("stored schemaVersion=%d firstMeetingDate=%s", got.SchemaVersion, got.FirstMeetingDate)
+		}
+		if got, err := r.Relationships.FindByInviteCode(ctx, "LOVE2024"); err != nil || got.ID != rel.ID {
+			t.Errorf("by invite code: %v", err)
+		}
+
+		_, err = r.Relationships.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "relationship not found")
+		_, err = r.Relationships.FindByInviteCode(ctx, "NOPE2024")
+		wantErrText(t, err, "invite code not found")
+	}},
+	{"invite code starts one relationship", func(t *testing.T, r Repos) {
+		createRelationship(t, r, "LOVE2024", primitive.NewObjectID(), primitive.NewObjectID())
+		rel := entities.NewRelationship([]primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID()}, "LOVE2024", firstMet)
+		if err := r.Relationships.Create(context.Background(), rel); err == nil {
+			t.Fatal("second relationship with the same invite code was created")
+		}
+	}},
+	{"current relationship", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		rel := createRelationship(t, r, "LOVE2024", alice, bob)
+
+		// A pending disconnect keeps the partners together
+		check(t, rel.RequestDisconnect(alice, time.Minute, time.Hour))
+		check(t, r.Relationships.Update(ctx, rel))
+		if got, err := r.Relationships.FindCurrentByUserID(ctx, bob); err != nil || got.ID != rel.ID {
+			t.Fatalf("current with disconnect requested: %v", err)
+		}
+
+		disconnectAt(t, r, rel, time.Now())
+		for _, user := range []primitive.ObjectID{alice, bob, carol} {
+			_, err := r.Relationships.FindCurrentByUserID(ctx, user)
+			wantErrText(t, err, "no active relationship")
+		}
+	}},
+	{"history of a user", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		first := createRelationship(t, r, "FIRST001", alice, primitive.NewObjectID())
+		tick()
+		second := createRelationship(t, r, "SECOND01", primitive.NewObjectID(), alice)
+		tick()
+		third := createRelationship(t, r, "THIRD001", alice, primitive.NewObjectID())
+		createRelationship(t, r, "OTHERS01", primitive.NewObjectID(), primitive.NewObjectID())
+
+		base := time.Now().Add(-time.Hour)
+		disconnectAt(t, r, first, base.Add(2*time.Minute))
+		disconnectAt(t, r, second, base.Add(5*time.Minute))
+
+		all, err := r.Relationships.FindAllByUserID(ctx, alice)
+		check(t, err)
+		wantIDs(t, "all, oldest first", relationshipIDs(all), []primitive.ObjectID{first.ID, second.ID, third.ID})
+
+		past, err := r.Relationships.FindPastByUserID(ctx, alice)
+		check(t, err)
+		wantIDs(t, "past, latest disconnect first", relationshipIDs(past), []primitive.ObjectID{second.ID, first.ID})
+
+		none, err := r.Relationships.FindAllByUserID(ctx, primitive.NewObjectID())
+		check(t, err)
+		if len(none) != 0 {
+			t.Errorf("stranger has %d relationships", len(none))
+		}
+	}},
+	{"disconnects due", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, carol := primitive.NewObjectID(), primitive.NewObjectID()
+		soon := createRelationship(t, r, "SOON0001", alice, primitive.NewObjectID())
+		later := createRelationship(t, r, "LATER001", carol, primitive.NewObjectID())
+		createRelationship(t, r, "ACTIVE01", primitive.NewObjectID(), primitive.NewObjectID())
+		check(t, soon.RequestDisconnect(alice, time.Minute, time.Hour))
+		check(t, r.Relationships.Update(ctx, soon))
+		check(t, later.RequestDisconnect(carol, time.Minute, 48*time.Hour))
+		check(t, r.Relationships.Update(ctx, later))
+
+		due, err := r.Relationships.FindDisconnectsDue(ctx, time.Now().Add(2*time.Hour))
+		check(t, err)
+		wantIDs(t, "due in two hours", relationshipIDs(due), []primitive.ObjectID{soon.ID})
+		due, err = r.Relationships.FindDisconnectsDue(ctx, time.Now())
+		check(t, err)
+		if len(due) != 0 {
+			t.Errorf("due now: %d relationships", len(due))
+		}
+	}},
+	{"disconnect request compare-and-set", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		rel := createRelationship(t, r, "LOVE2024", alice, bob)
+
+		// Requesting only succeeds while no request is pending
+		check(t, rel.RequestDisconnect(alice, time.Hour, 24*time.Hour))
+		check(t, r.Relationships.UpdateDisconnectRequest(ctx, rel, nil))
+		if err := r.Relationships.UpdateDisconnectRequest(ctx, rel, nil); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Fatalf("second request: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		// Answers must name the requester of the pending request
+		if err := r.Relationships.UpdateDisconnectRequest(ctx, rel, &bob); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Fatalf("answer to bob's request: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		check(t, rel.CancelDisconnect(alice, time.Now()))
+		check(t, r.Relationships.UpdateDisconnectRequest(ctx, rel, &alice))
+		got, err := r.Relationships.FindByID(ctx, rel.ID)
+		check(t, err)
+		if got.Status != entities.RelationshipStatusActive || got.DisconnectRequest == nil || got.DisconnectRequest.State != entities.DisconnectCancelled {
+			t.Fatalf("after cancel status=%s request=%+v", got.Status, got.DisconnectRequest)
+		}
+		// The cancelled request cannot be answered any more
+		if err := r.Relationships.UpdateDisconnectRequest(ctx, rel, &alice); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Errorf("answer to a cancelled request: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		if err := r.Relationships.UpdateDisconnectRequest(ctx, &entities.Relationship{ID: primitive.NewObjectID()}, nil); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Errorf("unknown relationship: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+	}},
+	{"reconnect request compare-and-set", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		rel := createRelationship(t, r, "LOVE2024", alice, bob)
+		if err := r.Relationships.UpdateReconnectRequest(ctx, rel, nil); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Fatalf("request on an active relationship: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		disconnectAt(t, r, rel, time.Now())
+
+		rel.RequestReconnect(alice)
+		check(t, r.Relationships.UpdateReconnectRequest(ctx, rel, nil))
+		// A second request made without seeing alice's must not replace it
+		if err := r.Relationships.UpdateReconnectRequest(ctx, rel, nil); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Fatalf("request over alice's: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		if err := r.Relationships.UpdateReconnectRequest(ctx, rel, &bob); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Fatalf("update expecting bob's request: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+		if !rel.RequestReconnect(bob) {
+			t.Fatal("bob's request did not reactivate")
+		}
+		check(t, r.Relationships.UpdateReconnectRequest(ctx, rel, &alice))
+		got, err := r.Relationships.FindByID(ctx, rel.ID)
+		check(t, err)
+		if got.Status != entities.RelationshipStatusActive || got.ReconnectRequestedBy != nil {
+			t.Fatalf("after reactivation status=%s reconnectRequestedBy=%v", got.Status, got.ReconnectRequestedBy)
+		}
+		// Once active, alice's request can no longer be withdrawn
+		if err := r.Relationships.UpdateReconnectRequest(ctx, rel, &alice); !errors.Is(err, repositories.ErrRelationshipStateChanged) {
+			t.Errorf("cancel after reactivation: %v, want %v", err, repositories.ErrRelationshipStateChanged)
+		}
+	}},
+	{"update and delete", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		rel := createRelationship(t, r, "LOVE2024", alice, bob)
+		rel.FirstMeetingDate = firstMet.AddDate(-1, 0, 0)
+		check(t, r.Relationships.Update(ctx, rel))
+		got, err := r.Relationships.FindByID(ctx, rel.ID)
+		check(t, err)
+		if !sameTime(got.FirstMeetingDate, rel.FirstMeetingDate) {
+			t.Errorf("firstMeetingDate = %s, want %s", got.FirstMeetingDate, rel.FirstMeetingDate)
+		}
+
+		check(t, r.Relationships.Delete(ctx, rel.ID))
+		_, err = r.Relationships.FindByID(ctx, rel.ID)
+		wantErrText(t, err, "relationship not found")
+		check(t, r.Relationships.Delete(ctx, rel.ID))
+	}},
+}
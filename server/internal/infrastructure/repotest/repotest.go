@@ -0,0 +1,111 @@
+// Package repotest is the contract suite for repository implementations. The
+// MongoDB repositories and the in-memory fakes both run it, so use case tests
+// written against the fakes hold for the real database too.
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"whisper-server/internal/domain/repositories"
+)
+
+// Repos is one implementation of the repositories under test
+type Repos struct {
+	Users              repositories.UserRepository
+	Relationships      repositories.RelationshipRepository
+	Invites            repositories.InviteRepository
+	Events             repositories.EventRepository
+	Whispers           repositories.WhisperRepository
+	Sessions           repositories.SessionRepository
+	RefreshTokens      repositories.RefreshTokenRepository
+	OneTimeTokens      repositories.OneTimeTokenRepository
+	LoginAttempts      repositories.LoginAttemptRepository
+	DataExports        repositories.DataExportRepository
+	TwoFactor          repositories.TwoFactorRepository
+	Media              repositories.MediaRepository
+	ExternalIdentities repositories.ExternalIdentityRepository
+	OIDCStates         repositories.OIDCStateRepository
+}
+
+// NewRepos returns repositories backed by an empty database; it is called once
+// per test case
+type NewRepos func(t *testing.T) Repos
+
+// contractCase is one behavior every implementation must show
+type contractCase struct {
+	name string
+	run  func(t *testing.T, r Repos)
+}
+
+// Run runs the whole contract suite against the implementation from newRepos
+func Run(t *testing.T, newRepos NewRepos) {
+	suites := []struct {
+		name  string
+		cases []contractCase
+	}{
+		{"Users", userCases},
+		{"Relationships", relationshipCases},
+		{"Invites", inviteCases},
+		{"Events", eventCases},
+		{"Whispers", whisperCases},
+		{"Sessions", sessionCases},
+		{"RefreshTokens", refreshTokenCases},
+		{"OneTimeTokens", oneTimeTokenCases},
+		{"LoginAttempts", loginAttemptCases},
+		{"DataExports", dataExportCases},
+		{"TwoFactor", twoFactorCases},
+		{"Media", mediaCases},
+		{"ExternalIdentities", externalIdentityCases},
+		{"OIDCStates", oidcStateCases},
+	}
+	for _, suite := range suites {
+		t.Run(suite.name, func(t *testing.T) {
+			for _, c := range suite.cases {
+				t.Run(c.name, func(t *testing.T) {
+					c.run(t, newRepos(t))
+				})
+			}
+		})
+	}
+}
+
+// tick waits long enough for the next timestamp to differ from the last one
+// after MongoDB rounds both to milliseconds
+func tick() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+// sameTime compares times at the millisecond precision MongoDB stores
+func sameTime(a, b time.Time) bool {
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+// wantErrText fails unless err carries exactly the message callers match on
+func wantErrText(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+}
+
+// wantOrder fails unless got lists exactly want, in order
+func wantOrder(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %v, want %v", what, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got %v, want %v", what, got, want)
+		}
+	}
+}
+
+// check fails the test on an unexpected error
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,198 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+func createSession(t *testing.T, r Repos, userID primitive.ObjectID, lastSeenAt, expiresAt time.Time) *entities.Session {
+	t.Helper()
+	s := entities.NewSession(userID, "Phone", "whisper-tests/1.0", "127.0.0.1", expiresAt)
+	s.LastSeenAt = lastSeenAt
+	check(t, r.Sessions.Create(context.Background(), s))
+	return s
+}
+
+func sessionIDs(sessions []*entities.Session) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+var sessionCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		s := createSession(t, r, alice, time.Now(), time.Now().Add(time.Hour))
+		got, err := r.Sessions.FindByID(ctx, s.ID)
+		check(t, err)
+		if got.UserID != alice || got.DeviceName != "Phone" || !sameTime(got.ExpiresAt, s.ExpiresAt) || got.RevokedAt != nil {
+			t.Errorf("stored user=%s device=%q expiresAt=%s revokedAt=%v", got.UserID.Hex(), got.DeviceName, got.ExpiresAt, got.RevokedAt)
+		}
+		_, err = r.Sessions.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "session not found")
+	}},
+	{"active sessions", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		now := time.Now()
+		older := createSession(t, r, alice, now.Add(-2*time.Hour), now.Add(time.Hour))
+		newer := createSession(t, r, alice, now.Add(-time.Minute), now.Add(time.Hour))
+		revoked := createSession(t, r, alice, now, now.Add(time.Hour))
+		createSession(t, r, alice, now.Add(-3*time.Hour), now.Add(-time.Minute))
+		createSession(t, r, primitive.NewObjectID(), now, now.Add(time.Hour))
+		check(t, r.Sessions.Revoke(ctx, revoked.ID))
+
+		active, err := r.Sessions.FindActiveByUserID(ctx, alice)
+		check(t, err)
+		wantIDs(t, "active, last seen first", sessionIDs(active), []primitive.ObjectID{newer.ID, older.ID})
+
+		check(t, r.Sessions.Touch(ctx, older.ID, "10.0.0.1", now))
+		active, err = r.Sessions.FindActiveByUserID(ctx, alice)
+		check(t, err)
+		wantIDs(t, "active after touch", sessionIDs(active), []primitive.ObjectID{older.ID, newer.ID})
+		if active[0].IP != "10.0.0.1" {
+			t.Errorf("touched session ip = %q", active[0].IP)
+		}
+	}},
+	{"extend", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		now := time.Now()
+		s := createSession(t, r, alice, now.Add(-time.Hour), now.Add(time.Hour))
+		revoked := createSession(t, r, alice, now.Add(-time.Hour), now.Add(time.Hour))
+		check(t, r.Sessions.Revoke(ctx, revoked.ID))
+
+		expiresAt := now.Add(24 * time.Hour)
+		check(t, r.Sessions.Extend(ctx, s.ID, "10.0.0.1", now, expiresAt))
+		check(t, r.Sessions.Extend(ctx, revoked.ID, "10.0.0.1", now, expiresAt))
+		got, err := r.Sessions.FindByID(ctx, s.ID)
+		check(t, err)
+		if !sameTime(got.ExpiresAt, expiresAt) || !sameTime(got.LastSeenAt, now) || got.IP != "10.0.0.1" {
+			t.Errorf("extended expiresAt=%s lastSeenAt=%s ip=%q", got.ExpiresAt, got.LastSeenAt, got.IP)
+		}
+		// A revoked session stays as it was
+		got, err = r.Sessions.FindByID(ctx, revoked.ID)
+		check(t, err)
+		if sameTime(got.ExpiresAt, expiresAt) || got.IP == "10.0.0.1" {
+			t.Errorf("revoked session was extended to %s", got.ExpiresAt)
+		}
+	}},
+	{"revoke keeps the first revocation time", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		now := time.Now()
+		first := createSession(t, r, alice, now, now.Add(time.Hour))
+		second := createSession(t, r, alice, now, now.Add(time.Hour))
+		bobs := createSession(t, r, bob, now, now.Add(time.Hour))
+
+		check(t, r.Sessions.Revoke(ctx, first.ID))
+		got, err := r.Sessions.FindByID(ctx, first.ID)
+		check(t, err)
+		if got.RevokedAt == nil {
+			t.Fatal("revoke left revokedAt empty")
+		}
+		revokedAt := *got.RevokedAt
+		tick()
+		check(t, r.Sessions.RevokeAllByUserID(ctx, alice))
+
+		got, err = r.Sessions.FindByID(ctx, first.ID)
+		check(t, err)
+		if !sameTime(*got.RevokedAt, revokedAt) {
+			t.Errorf("revokedAt moved from %s to %s", revokedAt, *got.RevokedAt)
+		}
+		if got, _ := r.Sessions.FindByID(ctx, second.ID); got.RevokedAt == nil {
+			t.Error("revoke all left a session active")
+		}
+		if got, _ := r.Sessions.FindByID(ctx, bobs.ID); got.RevokedAt != nil {
+			t.Error("revoke all reached another user's session")
+		}
+	}},
+}
+
+func createRefreshToken(t *testing.T, r Repos, userID, sessionID primitive.ObjectID) *entities.RefreshToken {
+	t.Helper()
+	token := entities.NewRefreshToken(userID, sessionID, time.Now().Add(time.Hour))
+	check(t, r.RefreshTokens.Create(context.Background(), token))
+	return token
+}
+
+var refreshTokenCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, sessionID := primitive.NewObjectID(), primitive.NewObjectID()
+		token := createRefreshToken(t, r, alice, sessionID)
+		got, err := r.RefreshTokens.FindByID(ctx, token.ID)
+		check(t, err)
+		if got.UserID != alice || got.SessionID != sessionID || got.IsRotated() || got.RevokedAt != nil {
+			t.Errorf("stored user=%s session=%s rotated=%t revokedAt=%v", got.UserID.Hex(), got.SessionID.Hex(), got.IsRotated(), got.RevokedAt)
+		}
+		_, err = r.RefreshTokens.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "refresh token not found")
+	}},
+	{"rotate once", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, sessionID := primitive.NewObjectID(), primitive.NewObjectID()
+		token := createRefreshToken(t, r, alice, sessionID)
+		next, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+		if ok, err := r.RefreshTokens.MarkRotated(ctx, token.ID, next); err != nil || !ok {
+			t.Fatalf("first rotation = %t, %v", ok, err)
+		}
+		if ok, err := r.RefreshTokens.MarkRotated(ctx, token.ID, other); err != nil || ok {
+			t.Fatalf("second rotation = %t, %v", ok, err)
+		}
+		got, err := r.RefreshTokens.FindByID(ctx, token.ID)
+		check(t, err)
+		if got.ReplacedBy == nil || *got.ReplacedBy != next || got.RotatedAt == nil {
+			t.Errorf("replacedBy=%v rotatedAt=%v, want %s", got.ReplacedBy, got.RotatedAt, next.Hex())
+		}
+
+		if ok, err := r.RefreshTokens.MarkRotated(ctx, primitive.NewObjectID(), next); err != nil || ok {
+			t.Errorf("rotating an unknown token = %t, %v", ok, err)
+		}
+		revoked := createRefreshToken(t, r, alice, sessionID)
+		check(t, r.RefreshTokens.RevokeBySessionID(ctx, sessionID))
+		if ok, err := r.RefreshTokens.MarkRotated(ctx, revoked.ID, next); err != nil || ok {
+			t.Errorf("rotating a revoked token = %t, %v", ok, err)
+		}
+	}},
+	{"revoke", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		phone, laptop := primitive.NewObjectID(), primitive.NewObjectID()
+		phoneToken := createRefreshToken(t, r, alice, phone)
+		laptopToken := createRefreshToken(t, r, alice, laptop)
+		bobsToken := createRefreshToken(t, r, bob, primitive.NewObjectID())
+
+		check(t, r.RefreshTokens.RevokeBySessionID(ctx, phone))
+		if got, _ := r.RefreshTokens.FindByID(ctx, laptopToken.ID); got.RevokedAt != nil {
+			t.Fatal("revoking one session reached another")
+		}
+		got, err := r.RefreshTokens.FindByID(ctx, phoneToken.ID)
+		check(t, err)
+		if got.RevokedAt == nil {
+			t.Fatal("session token not revoked")
+		}
+		revokedAt := *got.RevokedAt
+		tick()
+
+		check(t, r.RefreshTokens.RevokeAllByUserID(ctx, alice))
+		if got, _ := r.RefreshTokens.FindByID(ctx, laptopToken.ID); got.RevokedAt == nil {
+			t.Error("revoke all left a token active")
+		}
+		if got, _ := r.RefreshTokens.FindByID(ctx, phoneToken.ID); !sameTime(*got.RevokedAt, revokedAt) {
+			t.Errorf("revokedAt moved from %s to %s", revokedAt, *got.RevokedAt)
+		}
+		if got, _ := r.RefreshTokens.FindByID(ctx, bobsToken.ID); got.RevokedAt != nil {
+			t.Error("revoke all reached another user's token")
+		}
+	}},
+}
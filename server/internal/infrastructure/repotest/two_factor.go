@@ -0,0 +1,84 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+func saveTwoFactor(t *testing.T, r Repos, userID primitive.ObjectID, recoveryCodes ...string) *entities.TwoFactor {
+	t.Helper()
+	tf := entities.NewTwoFactor(userID, "sealed-secret")
+	tf.Enabled = true
+	tf.RecoveryCodes = recoveryCodes
+	check(t, r.TwoFactor.Save(context.Background(), tf))
+	return tf
+}
+
+var twoFactorCases = []contractCase{
+	{"save replaces the enrollment", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		_, err := r.TwoFactor.FindByUserID(ctx, alice)
+		if !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+			t.Fatalf("find before enrollment: %v, want %v", err, repositories.ErrTwoFactorNotFound)
+		}
+
+		check(t, r.TwoFactor.Save(ctx, entities.NewTwoFactor(alice, "first")))
+		saveTwoFactor(t, r, alice, "code-1")
+		got, err := r.TwoFactor.FindByUserID(ctx, alice)
+		check(t, err)
+		if got.Secret != "sealed-secret" || !got.Enabled || len(got.RecoveryCodes) != 1 {
+			t.Errorf("enrollment = %+v", got)
+		}
+
+		check(t, r.TwoFactor.Delete(ctx, alice))
+		_, err = r.TwoFactor.FindByUserID(ctx, alice)
+		if !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+			t.Errorf("find after delete: %v", err)
+		}
+	}},
+	{"steps only move forward", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		saveTwoFactor(t, r, alice)
+		for _, tt := range []struct {
+			step int64
+			want bool
+		}{{100, true}, {100, false}, {99, false}, {101, true}} {
+			ok, err := r.TwoFactor.UseStep(ctx, alice, tt.step)
+			check(t, err)
+			if ok != tt.want {
+				t.Errorf("UseStep(%d) = %t, want %t", tt.step, ok, tt.want)
+			}
+		}
+		if ok, _ := r.TwoFactor.UseStep(ctx, primitive.NewObjectID(), 1); ok {
+			t.Error("step used without an enrollment")
+		}
+	}},
+	{"recovery codes are single-use", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		alice := primitive.NewObjectID()
+		saveTwoFactor(t, r, alice, "code-1", "code-2")
+
+		if ok, err := r.TwoFactor.UseRecoveryCode(ctx, alice, "code-1"); err != nil || !ok {
+			t.Fatalf("first use = %t, %v", ok, err)
+		}
+		if ok, _ := r.TwoFactor.UseRecoveryCode(ctx, alice, "code-1"); ok {
+			t.Error("code used twice")
+		}
+		if ok, _ := r.TwoFactor.UseRecoveryCode(ctx, primitive.NewObjectID(), "code-2"); ok {
+			t.Error("another user's code accepted")
+		}
+		got, err := r.TwoFactor.FindByUserID(ctx, alice)
+		check(t, err)
+		if len(got.RecoveryCodes) != 1 || got.RecoveryCodes[0] != "code-2" {
+			t.Errorf("remaining codes = %v", got.RecoveryCodes)
+		}
+	}},
+}
@@ -0,0 +1,233 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+)
+
+func createUser(t *testing.T, r Repos, username, email string) *entities.User {
+	t.Helper()
+	u := entities.NewUser(username, username, email, "hash")
+	check(t, r.Users.Create(context.Background(), u))
+	return u
+}
+
+var userCases = []contractCase{
+	{"lookups", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		lookups := []struct {
+			name string
+			find func() (*entities.User, error)
+		}{
+			{"id", func() (*entities.User, error) { return r.Users.FindByID(ctx, u.ID) }},
+			{"username", func() (*entities.User, error) { return r.Users.FindByUsername(ctx, "alice") }},
+			{"email", func() (*entities.User, error) { return r.Users.FindByEmail(ctx, "alice@example.com") }},
+		}
+		for _, l := range lookups {
+			got, err := l.find()
+			check(t, err)
+			if got.ID != u.ID || got.Username != "alice" || got.Email != "alice@example.com" {
+				t.Errorf("by %s: got %s %q %q", l.name, got.ID.Hex(), got.Username, got.Email)
+			}
+		}
+
+		_, err := r.Users.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "user not found")
+		_, err = r.Users.FindByUsername(ctx, "nobody")
+		wantErrText(t, err, "user not found")
+		_, err = r.Users.FindByEmail(ctx, "nobody@example.com")
+		wantErrText(t, err, "user not found")
+
+		if ok, err := r.Users.ExistsByUsername(ctx, "alice"); err != nil || !ok {
+			t.Errorf("ExistsByUsername(alice) = %t, %v", ok, err)
+		}
+		if ok, err := r.Users.ExistsByEmail(ctx, "bob@example.com"); err != nil || ok {
+			t.Errorf("ExistsByEmail(bob) = %t, %v", ok, err)
+		}
+	}},
+	{"unique username and email", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		createUser(t, r, "alice", "alice@example.com")
+		err := r.Users.Create(ctx, entities.NewUser("alice", "Other", "other@example.com", "hash"))
+		wantErrText(t, err, "username or email already exists")
+		err = r.Users.Create(ctx, entities.NewUser("other", "Other", "alice@example.com", "hash"))
+		wantErrText(t, err, "username or email already exists")
+
+		// Email is optional; any number of accounts may have none
+		createUser(t, r, "noemail1", "")
+		createUser(t, r, "noemail2", "")
+	}},
+	{"update keeps omitted fields", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		relID := primitive.NewObjectID()
+		check(t, r.Users.SetRelationship(ctx, u.ID, nil, relID, 3))
+
+		// Like the $set of a struct with omitempty tags, empty optional fields keep their value
+		edit := *u
+		edit.Name = "Alice Liddell"
+		edit.Email = ""
+		edit.RelationshipID = nil
+		check(t, r.Users.Update(ctx, &edit))
+
+		got, err := r.Users.FindByID(ctx, u.ID)
+		check(t, err)
+		if got.Name != "Alice Liddell" || got.Email != "alice@example.com" || got.RelationshipID == nil || *got.RelationshipID != relID {
+			t.Errorf("after update name=%q email=%q relationshipId=%v", got.Name, got.Email, got.RelationshipID)
+		}
+	}},
+	{"field updates", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		verifiedAt := time.Now().Add(-time.Hour)
+		check(t, r.Users.UpdatePassword(ctx, u.ID, "new-hash"))
+		check(t, r.Users.MarkEmailVerified(ctx, u.ID, verifiedAt))
+		check(t, r.Users.UpdateStats(ctx, u.ID, entities.UserStats{MemoriesCount: 4, WhispersCount: 2}))
+
+		got, err := r.Users.FindByID(ctx, u.ID)
+		check(t, err)
+		if got.PasswordHash != "new-hash" || got.EmailVerifiedAt == nil || !sameTime(*got.EmailVerifiedAt, verifiedAt) {
+			t.Errorf("passwordHash=%q emailVerifiedAt=%v", got.PasswordHash, got.EmailVerifiedAt)
+		}
+		if got.Stats.MemoriesCount != 4 || got.Stats.WhispersCount != 2 {
+			t.Errorf("stats = %+v", got.Stats)
+		}
+
+		// Updates of unknown users match nothing and are not errors
+		check(t, r.Users.UpdatePassword(ctx, primitive.NewObjectID(), "hash"))
+	}},
+	{"update settings only", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		stale := *u
+
+		// Written after the settings request read the user
+		relID := primitive.NewObjectID()
+		verifiedAt := time.Now().Add(-time.Minute)
+		check(t, r.Users.SetRelationship(ctx, u.ID, nil, relID, 1))
+		check(t, r.Users.MarkEmailVerified(ctx, u.ID, verifiedAt))
+
+		settings := stale.Settings
+		settings.Language = "fa"
+		settings.Timezone = "Asia/Tehran"
+		settings.Notifications.Whispers = false
+		check(t, r.Users.UpdateSettings(ctx, u.ID, settings))
+
+		got, err := r.Users.FindByID(ctx, u.ID)
+		check(t, err)
+		if got.Settings != settings {
+			t.Errorf("settings = %+v, want %+v", got.Settings, settings)
+		}
+		if got.RelationshipID == nil || *got.RelationshipID != relID || got.EmailVerifiedAt == nil || !sameTime(*got.EmailVerifiedAt, verifiedAt) {
+			t.Errorf("settings update overwrote relationshipId=%v emailVerifiedAt=%v", got.RelationshipID, got.EmailVerifiedAt)
+		}
+		check(t, r.Users.UpdateSettings(ctx, primitive.NewObjectID(), settings))
+	}},
+	{"relationship pointer compare-and-set", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+		check(t, r.Users.SetRelationship(ctx, u.ID, nil, first, 10))
+		if err := r.Users.SetRelationship(ctx, u.ID, nil, second, 0); !errors.Is(err, repositories.ErrRelationshipChanged) {
+			t.Fatalf("set from stale nil: %v, want %v", err, repositories.ErrRelationshipChanged)
+		}
+		if err := r.Users.SetRelationship(ctx, u.ID, &second, first, 0); !errors.Is(err, repositories.ErrRelationshipChanged) {
+			t.Fatalf("set from wrong id: %v, want %v", err, repositories.ErrRelationshipChanged)
+		}
+		if err := r.Users.SetRelationship(ctx, primitive.NewObjectID(), nil, first, 0); !errors.Is(err, repositories.ErrRelationshipChanged) {
+			t.Fatalf("set on unknown user: %v, want %v", err, repositories.ErrRelationshipChanged)
+		}
+		got, err := r.Users.FindByID(ctx, u.ID)
+		check(t, err)
+		if got.RelationshipID == nil || *got.RelationshipID != first || got.Stats.RelationshipDays != 10 {
+			t.Fatalf("relationshipId=%v days=%d, want %s and 10", got.RelationshipID, got.Stats.RelationshipDays, first.Hex())
+		}
+
+		// Clearing only removes the pointer it names
+		check(t, r.Users.ClearRelationship(ctx, u.ID, second))
+		if got, _ := r.Users.FindByID(ctx, u.ID); got.RelationshipID == nil {
+			t.Fatal("clear with another relationship removed the pointer")
+		}
+		check(t, r.Users.ClearRelationship(ctx, u.ID, first))
+		got, err = r.Users.FindByID(ctx, u.ID)
+		check(t, err)
+		if got.RelationshipID != nil || got.Stats.RelationshipDays != 0 {
+			t.Errorf("after clear relationshipId=%v days=%d", got.RelationshipID, got.Stats.RelationshipDays)
+		}
+	}},
+	{"soft delete and purge", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		u := createUser(t, r, "alice", "alice@example.com")
+		purgeAt := time.Now().Add(time.Hour)
+		check(t, r.Users.Delete(ctx, u.ID, purgeAt))
+
+		// A deleted account disappears from every lookup but stays stored, and
+		// keeps its username and email until the purge
+		_, err := r.Users.FindByID(ctx, u.ID)
+		wantErrText(t, err, "user not found")
+		_, err = r.Users.FindByUsername(ctx, "alice")
+		wantErrText(t, err, "user not found")
+		if ok, _ := r.Users.ExistsByUsername(ctx, "alice"); !ok {
+			t.Error("deleted username is free again")
+		}
+		if ok, _ := r.Users.ExistsByEmail(ctx, "alice@example.com"); !ok {
+			t.Error("deleted email is free again")
+		}
+		if ok, _ := r.Users.Exists(ctx, u.ID); !ok {
+			t.Error("deleted user no longer stored")
+		}
+		if err := r.Users.SetRelationship(ctx, u.ID, nil, primitive.NewObjectID(), 0); !errors.Is(err, repositories.ErrRelationshipChanged) {
+			t.Errorf("set relationship of deleted user: %v, want %v", err, repositories.ErrRelationshipChanged)
+		}
+
+		due, err := r.Users.FindDueForPurge(ctx, time.Now(), 10)
+		check(t, err)
+		if len(due) != 0 {
+			t.Errorf("due before purge time: %d users", len(due))
+		}
+		due, err = r.Users.FindDueForPurge(ctx, purgeAt.Add(time.Second), 10)
+		check(t, err)
+		if len(due) != 1 || due[0].ID != u.ID {
+			t.Errorf("due after purge time: %d users, want alice", len(due))
+		}
+
+		check(t, r.Users.Restore(ctx, u.ID))
+		if _, err := r.Users.FindByID(ctx, u.ID); err != nil {
+			t.Errorf("restored user: %v", err)
+		}
+		wantErrText(t, r.Users.Restore(ctx, u.ID), "user not found")
+
+		// Purge only removes soft-deleted accounts, and restore is over once purgeAt passed
+		check(t, r.Users.Purge(ctx, u.ID))
+		if ok, _ := r.Users.Exists(ctx, u.ID); !ok {
+			t.Fatal("purge removed a live user")
+		}
+		check(t, r.Users.Delete(ctx, u.ID, time.Now().Add(-time.Second)))
+		wantErrText(t, r.Users.Restore(ctx, u.ID), "user not found")
+		check(t, r.Users.Purge(ctx, u.ID))
+		if ok, _ := r.Users.Exists(ctx, u.ID); ok {
+			t.Error("purged user still stored")
+		}
+	}},
+	{"purge batch limit", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		for _, name := range []string{"alice", "bob", "carol"} {
+			u := createUser(t, r, name, "")
+			check(t, r.Users.Delete(ctx, u.ID, time.Now().Add(-time.Minute)))
+		}
+		due, err := r.Users.FindDueForPurge(ctx, time.Now(), 2)
+		check(t, err)
+		if len(due) != 2 {
+			t.Errorf("due with limit 2: %d users", len(due))
+		}
+	}},
+}
@@ -0,0 +1,112 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/domain/entities"
+)
+
+// whisperDay is the date of the first whisper in listing tests; later ones follow a day apart
+var whisperDay = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func createWhisper(t *testing.T, r Repos, text string, date time.Time, relID primitive.ObjectID) *entities.Whisper {
+	t.Helper()
+	w := entities.NewWhisper("custom", text, entities.WhisperRecurrenceOnce, date, relID, primitive.NewObjectID())
+	check(t, r.Whispers.Create(context.Background(), w))
+	return w
+}
+
+func whisperTexts(whispers []*entities.Whisper) []string {
+	texts := make([]string, 0, len(whispers))
+	for _, w := range whispers {
+		texts = append(texts, w.Text)
+	}
+	return texts
+}
+
+var whisperCases = []contractCase{
+	{"create and find", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID := primitive.NewObjectID()
+		w := createWhisper(t, r, "Picnic", whisperDay, relID)
+		got, err := r.Whispers.FindByID(ctx, w.ID)
+		check(t, err)
+		if got.Text != "Picnic" || got.RelationshipID != relID || got.Recurrence != entities.WhisperRecurrenceOnce || !sameTime(got.Date, whisperDay) {
+			t.Errorf("stored text=%q relationship=%s recurrence=%s date=%s", got.Text, got.RelationshipID.Hex(), got.Recurrence, got.Date)
+		}
+		_, err = r.Whispers.FindByID(ctx, primitive.NewObjectID())
+		wantErrText(t, err, "whisper not found")
+	}},
+	{"update", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		w := createWhisper(t, r, "Picnic", whisperDay, primitive.NewObjectID())
+		w.SetOccurrenceDone(whisperDay, w.CreatedBy, true)
+		check(t, r.Whispers.Update(ctx, w))
+		got, err := r.Whispers.FindByID(ctx, w.ID)
+		check(t, err)
+		if !got.IsDone || len(got.Completions) != 1 {
+			t.Fatalf("after completing done=%t completions=%d", got.IsDone, len(got.Completions))
+		}
+
+		// Empty text keeps its value like other omitempty fields, while completions
+		// are replaced even when emptied
+		edit := *w
+		edit.Text = ""
+		edit.Completions = nil
+		edit.IsDone = false
+		check(t, r.Whispers.Update(ctx, &edit))
+		got, err = r.Whispers.FindByID(ctx, w.ID)
+		check(t, err)
+		if got.Text != "Picnic" || got.IsDone || len(got.Completions) != 0 {
+			t.Errorf("after update text=%q done=%t completions=%d", got.Text, got.IsDone, len(got.Completions))
+		}
+	}},
+	{"lists oldest first", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID := primitive.NewObjectID()
+		for _, offset := range []int{3, 1, 0, 2} {
+			day := whisperDay.AddDate(0, 0, offset)
+			createWhisper(t, r, day.Format("Jan 2"), day, relID)
+		}
+		createWhisper(t, r, "Elsewhere", whisperDay, primitive.NewObjectID())
+
+		tests := []struct {
+			name          string
+			limit, offset int64
+			want          []string
+		}{
+			{"all", 0, 0, []string{"Jun 1", "Jun 2", "Jun 3", "Jun 4"}},
+			{"page", 2, 1, []string{"Jun 2", "Jun 3"}},
+			{"past the end", 2, 4, nil},
+		}
+		for _, tt := range tests {
+			whispers, err := r.Whispers.FindAllByRelationshipID(ctx, relID, tt.limit, tt.offset)
+			check(t, err)
+			wantOrder(t, tt.name, whisperTexts(whispers), tt.want)
+		}
+	}},
+	{"delete", func(t *testing.T, r Repos) {
+		ctx := context.Background()
+		relID, otherRelID := primitive.NewObjectID(), primitive.NewObjectID()
+		one := createWhisper(t, r, "One", whisperDay, relID)
+		createWhisper(t, r, "Two", whisperDay.AddDate(0, 0, 1), relID)
+		other := createWhisper(t, r, "Other", whisperDay, otherRelID)
+
+		check(t, r.Whispers.Delete(ctx, one.ID))
+		_, err := r.Whispers.FindByID(ctx, one.ID)
+		wantErrText(t, err, "whisper not found")
+		check(t, r.Whispers.Delete(ctx, one.ID))
+
+		check(t, r.Whispers.DeleteByRelationshipID(ctx, relID))
+		if left, _ := r.Whispers.FindAllByRelationshipID(ctx, relID, 0, 0); len(left) != 0 {
+			t.Errorf("%d whispers left after deleting the relationship's", len(left))
+		}
+		if _, err := r.Whispers.FindByID(ctx, other.ID); err != nil {
+			t.Errorf("delete removed another relationship's whisper: %v", err)
+		}
+	}},
+}
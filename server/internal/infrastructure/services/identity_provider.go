@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ExternalIdentity is the user information an identity provider vouches for
+type ExternalIdentity struct {
+	Provider          string
+	Subject           string // stable user ID at the provider
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// IdentityProvider signs users in through an external authorization server using
+// the authorization-code flow with PKCE
+type IdentityProvider interface {
+	Name() string
+	// AuthCodeURL is where the browser is sent to sign in
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge, redirectURI string) (string, error)
+	// Exchange redeems the authorization code and returns the verified identity;
+	// nonce must match the one sent in AuthCodeURL
+	Exchange(ctx context.Context, code, codeVerifier, redirectURI, nonce string) (*ExternalIdentity, error)
+}
+
+var ErrIdentityVerification = errors.New("identity provider response could not be verified")
+
+// GeneratePKCE returns a code verifier and its S256 challenge (RFC 7636)
+func GeneratePKCE() (verifier, challenge string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
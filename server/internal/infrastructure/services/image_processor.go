@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// ImageProcessor normalizes uploaded photos: it decodes them, applies the EXIF
+// orientation, re-encodes without any metadata (EXIF, GPS, text chunks) and
+// renders downscaled variants
+type ImageProcessor interface {
+	Process(data []byte) (*ProcessedImage, error)
+}
+
+type ProcessedImage struct {
+	Original ImageVariant
+	Variants []ImageVariant // ordered small to large
+}
+
+type ImageVariant struct {
+	Name        string // "original", "small", "medium"
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// ThumbnailSize is the bounding box (longest edge) of a generated variant
+type ThumbnailSize struct {
+	Name    string
+	MaxEdge int
+}
+
+var (
+	ErrUndecodableImage = errors.New("image could not be decoded")
+	ErrImageDimensions  = errors.New("image dimensions exceed the limit")
+)
+
+const (
+	// maxImagePixels guards against decompression bombs (small files, huge canvases)
+	maxImagePixels   = 50_000_000
+	originalQuality  = 90
+	thumbnailQuality = 80
+)
+
+// DefaultThumbnailSizes are the variants generated for every upload
+var DefaultThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxEdge: 320},
+	{Name: "medium", MaxEdge: 1024},
+}
+
+type imageProcessor struct {
+	sizes []ThumbnailSize
+}
+
+func NewImageProcessor(sizes []ThumbnailSize) ImageProcessor {
+	return &imageProcessor{sizes: sizes}
+}
+
+func (p *imageProcessor) Process(data []byte) (*ProcessedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUndecodableImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageDimensions
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUndecodableImage
+	}
+	// Re-encoding drops metadata, including orientation, so bake it into the pixels first
+	if format == "jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+	opaque := isOpaque(src)
+
+	// JPEG and opaque WebP become JPEG; PNG and WebP with transparency become PNG
+	encodePNG := format == "png" || !opaque
+	original, err := encodeVariant("original", src, encodePNG, originalQuality)
+	if err != nil {
+		return nil, err
+	}
+	out := &ProcessedImage{Original: *original}
+
+	// Scale each variant from the previous one; cheaper and visually equivalent
+	prev := src
+	for i := len(p.sizes) - 1; i >= 0; i-- {
+		size := p.sizes[i]
+		scaled := downscale(prev, size.MaxEdge)
+		v, err := encodeVariant(size.Name, scaled, !opaque, thumbnailQuality)
+		if err != nil {
+			return nil, err
+		}
+		out.Variants = append([]ImageVariant{*v}, out.Variants...)
+		prev = scaled
+	}
+	return out, nil
+}
+
+func encodeVariant(name string, img image.Image, asPNG bool, quality int) (*ImageVariant, error) {
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+	var err error
+	if asPNG {
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	return &ImageVariant{Name: name, Data: buf.Bytes(), ContentType: contentType, Width: b.Dx(), Height: b.Dy()}, nil
+}
+
+// downscale fits img into a maxEdge square box, never upscaling
+func downscale(img image.Image, maxEdge int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxEdge && h <= maxEdge {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxEdge/w)
+		w = maxEdge
+	} else {
+		w = max(1, w*maxEdge/h)
+		h = maxEdge
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// jpegOrientation reads the EXIF Orientation tag (0x0112) from IFD0; 1 when absent
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return 1
+		}
+		segLen := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if segLen < 2 || i+2+segLen > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+segLen]
+		if marker == 0xE1 && len(seg) > 14 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + segLen
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd : ifd+2]))
+	for e := 0; e < n; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[off:off+2]) == 0x0112 {
+			v := int(order.Uint16(tiff[off+8 : off+10]))
+			if v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation transforms img so that it displays upright without EXIF
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirror horizontal
+				dx, dy = w-1-x, y
+			case 3: // rotate 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				dx, dy = x, h-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotate 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
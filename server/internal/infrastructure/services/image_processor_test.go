@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	green = color.NRGBA{G: 255, A: 255}
+	blue  = color.NRGBA{B: 255, A: 255}
+	white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// quadrants is a w×h image with red, green, blue and white quarters, from the
+// top left in reading order
+func quadrants(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := red
+			switch {
+			case x >= w/2 && y < h/2:
+				c = green
+			case x < w/2 && y >= h/2:
+				c = blue
+			case x >= w/2 && y >= h/2:
+				c = white
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment is an APP1 segment with the orientation in IFD0 and a GPS IFD
+// holding a latitude reference
+func exifSegment(orientation int) []byte {
+	be := binary.BigEndian
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entry := func(tag, typ uint16, count, value uint32) []byte {
+		e := make([]byte, 12)
+		be.PutUint16(e[0:], tag)
+		be.PutUint16(e[2:], typ)
+		be.PutUint32(e[4:], count)
+		be.PutUint32(e[8:], value)
+		return e
+	}
+	const gpsIFD = 8 + 2 + 2*12 + 4
+	tiff = append(tiff, 0, 2)
+	tiff = append(tiff, entry(0x0112, 3, 1, uint32(orientation)<<16)...) // SHORT, left-justified
+	tiff = append(tiff, entry(0x8825, 4, 1, gpsIFD)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, 0, 1)
+	tiff = append(tiff, entry(0x0001, 2, 2, uint32('N')<<24)...) // GPSLatitudeRef "N"
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	be.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// jpegWithExif encodes img and inserts the EXIF segment right after SOI
+func jpegWithExif(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+// jpegMarkers lists the markers of the segments before the scan data
+func jpegMarkers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var markers []byte
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			t.Fatalf("no marker at %d", i)
+		}
+		markers = append(markers, data[i+1])
+		if data[i+1] == 0xDA {
+			break
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return markers
+}
+
+func decodeVariant(t *testing.T, v ImageVariant) image.Image {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(v.Data))
+	if err != nil {
+		t.Fatalf("%s: %v", v.Name, err)
+	}
+	if b := img.Bounds(); b.Dx() != v.Width || b.Dy() != v.Height {
+		t.Fatalf("%s is %dx%d, reported %dx%d", v.Name, b.Dx(), b.Dy(), v.Width, v.Height)
+	}
+	return img
+}
+
+func sameColor(got color.Color, want color.NRGBA) bool {
+	r, g, b, _ := got.RGBA()
+	near := func(v uint32, w uint8) bool {
+		d := int(v>>8) - int(w)
+		return d > -48 && d < 48
+	}
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
+
+func TestProcessStripsExifAndGPS(t *testing.T) {
+	data := jpegWithExif(t, quadrants(64, 32), 1)
+	if jpegOrientation(data) != 1 || !bytes.Contains(data, []byte("Exif")) {
+		t.Fatal("test input lacks its EXIF segment")
+	}
+	out, err := NewImageProcessor(DefaultThumbnailSizes).Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range append([]ImageVariant{out.Original}, out.Variants...) {
+		if v.ContentType != "image/jpeg" {
+			t.Fatalf("%s is %s, want image/jpeg", v.Name, v.ContentType)
+		}
+		for _, m := range jpegMarkers(t, v.Data) {
+			if m == 0xE1 {
+				t.Errorf("%s still has an APP1 segment", v.Name)
+			}
+		}
+		if bytes.Contains(v.Data, []byte("Exif")) {
+			t.Errorf("%s still contains EXIF data", v.Name)
+		}
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	// Where each quarter of the stored image ends up when displayed upright,
+	// listed from the top left in reading order
+	tests := []struct {
+		orientation int
+		w, h        int
+		corners     [4]color.NRGBA
+	}{
+		{1, 64, 32, [4]color.NRGBA{red, green, blue, white}},
+		{3, 64, 32, [4]color.NRGBA{white, blue, green, red}},
+		{6, 32, 64, [4]color.NRGBA{blue, red, white, green}},
+		{8, 32, 64, [4]color.NRGBA{green, white, red, blue}},
+	}
+	for _, tt := range tests {
+		out, err := NewImageProcessor(nil).Process(jpegWithExif(t, quadrants(64, 32), tt.orientation))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		img := decodeVariant(t, out.Original)
+		if out.Original.Width != tt.w || out.Original.Height != tt.h {
+			t.Fatalf("orientation %d: %dx%d, want %dx%d", tt.orientation, out.Original.Width, out.Original.Height, tt.w, tt.h)
+		}
+		points := [4]image.Point{{tt.w / 4, tt.h / 4}, {3 * tt.w / 4, tt.h / 4}, {tt.w / 4, 3 * tt.h / 4}, {3 * tt.w / 4, 3 * tt.h / 4}}
+		for i, p := range points {
+			if got := img.At(p.X, p.Y); !sameColor(got, tt.corners[i]) {
+				t.Errorf("orientation %d: pixel %v = %v, want %v", tt.orientation, p, got, tt.corners[i])
+			}
+		}
+	}
+}
+
+func TestProcessVariantSizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		w, h          int
+		small, medium image.Point
+	}{
+		{"landscape", 2000, 1000, image.Pt(320, 160), image.Pt(1024, 512)},
+		{"portrait", 768, 1536, image.Pt(160, 320), image.Pt(512, 1024)},
+		{"smaller than medium", 800, 400, image.Pt(320, 160), image.Pt(800, 400)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, quadrants(tt.w, tt.h), nil); err != nil {
+				t.Fatal(err)
+			}
+			out, err := NewImageProcessor(DefaultThumbnailSizes).Process(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Original.Width != tt.w || out.Original.Height != tt.h {
+				t.Errorf("original %dx%d, want %dx%d", out.Original.Width, out.Original.Height, tt.w, tt.h)
+			}
+			if len(out.Variants) != 2 || out.Variants[0].Name != "small" || out.Variants[1].Name != "medium" {
+				t.Fatalf("variants = %d, want small and medium", len(out.Variants))
+			}
+			for i, want := range []image.Point{tt.small, tt.medium} {
+				v := out.Variants[i]
+				decodeVariant(t, v)
+				if v.Width != want.X || v.Height != want.Y {
+					t.Errorf("%s %dx%d, want %dx%d", v.Name, v.Width, v.Height, want.X, want.Y)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRejectsHugeCanvasBeforeDecoding(t *testing.T) {
+	// A PNG signature and IHDR declaring 10000×10000 pixels, without image data:
+	// decoding it fully would fail as undecodable, so ErrImageDimensions shows the
+	// header check ran first
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 10000)
+	binary.BigEndian.PutUint32(ihdr[4:], 10000)
+	ihdr[8], ihdr[9] = 8, 2 // 8-bit truecolor
+	chunk := append([]byte("IHDR"), ihdr...)
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(ihdr)))
+	data = append(data, chunk...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+	if 10000*10000 <= maxImagePixels {
+		t.Fatal("test canvas is within the limit")
+	}
+
+	if _, err := NewImageProcessor(DefaultThumbnailSizes).Process(data); !errors.Is(err, ErrImageDimensions) {
+		t.Errorf("Process = %v, want %v", err, ErrImageDimensions)
+	}
+}
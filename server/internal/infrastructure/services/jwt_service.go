@@ -12,15 +12,43 @@ import (
 )
 
 type JWTService interface {
-	GenerateTokens(userID primitive.ObjectID, username string) (accessToken, refreshToken string, err error)
+	// GenerateAccessToken signs an access token bound to the given session (sid claim)
+	GenerateAccessToken(userID primitive.ObjectID, username string, sessionID primitive.ObjectID) (string, error)
+	// GenerateRefreshToken signs a refresh token whose jti is the persisted token record ID
+	GenerateRefreshToken(userID primitive.ObjectID, username string, tokenID primitive.ObjectID, expiresAt time.Time) (string, error)
+	// GenerateMFAToken signs the short-lived challenge handed out between password
+	// and second-factor checks
+	GenerateMFAToken(userID primitive.ObjectID, username string) (string, error)
 	ValidateAccessToken(tokenString string) (*Claims, error)
 	ValidateRefreshToken(tokenString string) (*Claims, error)
-	RefreshAccessToken(refreshToken string) (string, error)
+	ValidateMFAToken(tokenString string) (*Claims, error)
+	AccessTokenTTL() time.Duration
+	RefreshTokenTTL() time.Duration
 }
 
+// Token types carried in the typ claim; each type also has its own audience so a
+// token of one kind is never accepted where the other is expected
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeMFA     = "mfa"
+
+	tokenIssuer     = "whisper-server"
+	accessAudience  = "whisper-api"
+	refreshAudience = "whisper-auth-refresh"
+	mfaAudience     = "whisper-auth-mfa"
+)
+
+// MFAChallengeTTL is how long a user has to enter the second factor after the password
+const MFAChallengeTTL = 5 * time.Minute
+
+var ErrWrongTokenType = errors.New("wrong token type")
+
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Type      string `json:"typ"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -41,53 +69,80 @@ func NewJWTService(cfg *config.Config) JWTService {
 	}
 }
 
-func (s *jwtService) GenerateTokens(userID primitive.ObjectID, username string) (accessToken, refreshToken string, err error) {
-	// Generate Access Token
-	accessClaims := &Claims{
-		UserID:   userID.Hex(),
-		Username: username,
+func (s *jwtService) AccessTokenTTL() time.Duration {
+	return s.accessTokenDuration
+}
+
+func (s *jwtService) RefreshTokenTTL() time.Duration {
+	return s.refreshTokenDuration
+}
+
+func (s *jwtService) GenerateAccessToken(userID primitive.ObjectID, username string, sessionID primitive.ObjectID) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID.Hex(),
+		Username:  username,
+		Type:      TokenTypeAccess,
+		SessionID: sessionID.Hex(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{accessAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID.Hex(),
 		},
 	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
+}
 
-	accessTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenObj.SignedString([]byte(s.secretKey))
-	if err != nil {
-		return "", "", err
-	}
-
-	// Generate Refresh Token
-	refreshClaims := &Claims{
+func (s *jwtService) GenerateRefreshToken(userID primitive.ObjectID, username string, tokenID primitive.ObjectID, expiresAt time.Time) (string, error) {
+	claims := &Claims{
 		UserID:   userID.Hex(),
 		Username: username,
+		Type:     TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshTokenDuration)),
+			ID:        tokenID.Hex(),
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{refreshAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   userID.Hex(),
 		},
 	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
+}
 
-	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTokenObj.SignedString([]byte(s.secretKey))
-	if err != nil {
-		return "", "", err
+func (s *jwtService) GenerateMFAToken(userID primitive.ObjectID, username string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:   userID.Hex(),
+		Username: username,
+		Type:     TokenTypeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   userID.Hex(),
+		},
 	}
-
-	return accessToken, refreshToken, nil
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
 }
 
 func (s *jwtService) ValidateAccessToken(tokenString string) (*Claims, error) {
-	return s.validateToken(tokenString)
+	return s.validateToken(tokenString, TokenTypeAccess, accessAudience)
 }
 
 func (s *jwtService) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return s.validateToken(tokenString)
+	return s.validateToken(tokenString, TokenTypeRefresh, refreshAudience)
 }
 
-func (s *jwtService) validateToken(tokenString string) (*Claims, error) {
+func (s *jwtService) ValidateMFAToken(tokenString string) (*Claims, error) {
+	return s.validateToken(tokenString, TokenTypeMFA, mfaAudience)
+}
+
+func (s *jwtService) validateToken(tokenString, tokenType, audience string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
@@ -99,28 +154,13 @@ func (s *jwtService) validateToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, errors.New("invalid token")
-}
-
-func (s *jwtService) RefreshAccessToken(refreshToken string) (string, error) {
-	claims, err := s.ValidateRefreshToken(refreshToken)
-	if err != nil {
-		return "", err
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
-
-	userID, err := primitive.ObjectIDFromHex(claims.UserID)
-	if err != nil {
-		return "", err
+	// Tokens issued before typing carry neither claim and are rejected as well
+	if claims.Type != tokenType || !claims.VerifyAudience(audience, true) {
+		return nil, ErrWrongTokenType
 	}
-
-	newAccessToken, _, err := s.GenerateTokens(userID, claims.Username)
-	if err != nil {
-		return "", err
-	}
-
-	return newAccessToken, nil
+	return claims, nil
 }
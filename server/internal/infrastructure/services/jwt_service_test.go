@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/infrastructure/config"
+)
+
+const testJWTSecret = "test-secret"
+
+func newTestJWTService() JWTService {
+	return NewJWTService(&config.Config{JWT: config.JWTConfig{Secret: testJWTSecret, AccessExpiresIn: "15m", RefreshExpiresIn: "720h"}})
+}
+
+func TestTokensOnlyValidateAsTheirType(t *testing.T) {
+	s := newTestJWTService()
+	userID := primitive.NewObjectID()
+	access, err := s.GenerateAccessToken(userID, "alice", primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err := s.GenerateRefreshToken(userID, "alice", primitive.NewObjectID(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa, err := s.GenerateMFAToken(userID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validators := map[string]func(string) (*Claims, error){
+		TokenTypeAccess:  s.ValidateAccessToken,
+		TokenTypeRefresh: s.ValidateRefreshToken,
+		TokenTypeMFA:     s.ValidateMFAToken,
+	}
+	tokens := map[string]string{TokenTypeAccess: access, TokenTypeRefresh: refresh, TokenTypeMFA: mfa}
+	for tokenType, token := range tokens {
+		for as, validate := range validators {
+			claims, err := validate(token)
+			if tokenType == as {
+				if err != nil || claims.UserID != userID.Hex() || claims.Type != tokenType {
+					t.Errorf("%s token: claims %+v, err %v", tokenType, claims, err)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrWrongTokenType) {
+				t.Errorf("%s token validated as %s: %v, want %v", tokenType, as, err, ErrWrongTokenType)
+			}
+		}
+	}
+}
+
+func TestUntypedTokensAreRejected(t *testing.T) {
+	// Tokens issued before typing carried the user only
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  primitive.NewObjectID().Hex(),
+		"username": "alice",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+		"iat":      time.Now().Unix(),
+	})
+	token, err := legacy.SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestJWTService()
+	if _, err := s.ValidateAccessToken(token); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("as access token: %v, want %v", err, ErrWrongTokenType)
+	}
+	if _, err := s.ValidateRefreshToken(token); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("as refresh token: %v, want %v", err, ErrWrongTokenType)
+	}
+}
+
+func TestTokensOfAnotherKeyAreRejected(t *testing.T) {
+	other := NewJWTService(&config.Config{JWT: config.JWTConfig{Secret: "other-secret", AccessExpiresIn: "15m"}})
+	token, err := other.GenerateAccessToken(primitive.NewObjectID(), "alice", primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestJWTService().ValidateAccessToken(token); err == nil {
+		t.Error("token signed with another key was accepted")
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T, secret string) *LocalMediaStorage {
+	t.Helper()
+	storage, err := NewLocalMediaStorage(t.TempDir(), "https://whisper.test/media", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage
+}
+
+// signedQuery returns the expiry and signature of a URL from SignedURL
+func signedQuery(t *testing.T, s *LocalMediaStorage, key string) (string, string) {
+	t.Helper()
+	raw, err := s.SignedURL(key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(u.Path, "/"+key) {
+		t.Fatalf("URL %s does not point at %s", raw, key)
+	}
+	return u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestLocalSignedURLs(t *testing.T) {
+	const key = "events/abc.jpg"
+	s := newTestLocalStorage(t, "jwt-secret")
+	expires, sig := signedQuery(t, s, key)
+	if err := s.Verify(key, expires, sig); err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+
+	// The signing key is derived from the secret, so an HMAC made with the
+	// secret itself (e.g. by anyone holding the JWT key) does not verify
+	mac := hmac.New(sha256.New, []byte("jwt-secret"))
+	mac.Write([]byte(key + "|" + expires))
+	rawSig := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name    string
+		key     string
+		expires string
+		sig     string
+	}{
+		{"other key", "events/other.jpg", expires, sig},
+		{"other expiry", key, "9999999999", sig},
+		{"expired", key, "1", sig},
+		{"signed with the raw secret", key, expires, rawSig},
+	}
+	for _, tt := range tests {
+		if err := s.Verify(tt.key, tt.expires, tt.sig); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, ErrInvalidSignature)
+		}
+	}
+	if err := newTestLocalStorage(t, "other-secret").Verify(key, expires, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("storage with another secret: Verify = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestLocalStorageNeedsSecret(t *testing.T) {
+	if _, err := NewLocalMediaStorage(t.TempDir(), "https://whisper.test/media", ""); !errors.Is(err, ErrMediaSigningKey) {
+		t.Errorf("NewLocalMediaStorage = %v, want %v", err, ErrMediaSigningKey)
+	}
+}
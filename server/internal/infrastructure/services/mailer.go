@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/infrastructure/config"
+)
+
+// MailMessage is a plain-text email
+type MailMessage struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer delivers transactional email such as password reset links
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
+
+// NewMailer builds the mailer selected by MAIL_DRIVER
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "file":
+		return NewFileMailer(cfg.Mail.FileDir, cfg.Mail.From)
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTP, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
+
+// LogMailer writes messages to the server log; meant for local development only
+// since links in the message grant account access
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg MailMessage) error {
+	log.Printf("[MAIL][SEND][LOG] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// FileMailer stores each message as an .eml file so tests and developers can read it
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail directory: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg MailMessage) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), primitive.NewObjectID().Hex())
+	return os.WriteFile(filepath.Join(m.dir, name), formatMail(m.from, msg), 0o600)
+}
+
+// SMTPMailer delivers through an SMTP relay, upgrading to TLS when the server offers it
+type SMTPMailer struct {
+	cfg  config.SMTPConfig
+	from string
+}
+
+func NewSMTPMailer(cfg config.SMTPConfig, from string) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg, from: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg MailMessage) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := m.cfg.Host + ":" + strconv.Itoa(m.cfg.Port)
+	return smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, formatMail(m.from, msg))
+}
+
+// formatMail renders an RFC 5322 message with a UTF-8 plain-text body
+func formatMail(from string, msg MailMessage) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"whisper-server/internal/infrastructure/config"
+)
+
+// MediaStorage stores binary objects under opaque keys and hands out time-limited URLs
+type MediaStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+var (
+	ErrMediaObjectNotFound = errors.New("media object not found")
+	ErrInvalidMediaKey     = errors.New("invalid media key")
+	ErrInvalidSignature    = errors.New("invalid or expired signature")
+)
+
+// NewMediaStorage builds the storage backend selected by MEDIA_STORAGE
+func NewMediaStorage(cfg *config.Config) (MediaStorage, error) {
+	switch cfg.Media.Storage {
+	case "", "local":
+		secret := cfg.Media.SigningSecret
+		if secret == "" {
+			// Still a different key: the storage derives its own under a separate label
+			secret = cfg.JWT.Secret
+		}
+		return NewLocalMediaStorage(cfg.Media.LocalDir, cfg.App.BaseURL+"/api/v1/media/files", secret)
+	case "s3":
+		return NewS3MediaStorage(cfg.Media.S3)
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", cfg.Media.Storage)
+	}
+}
+
+// validateKey rejects keys that could escape the storage root or bucket prefix
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidMediaKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidMediaKey
+		}
+	}
+	return nil
+}
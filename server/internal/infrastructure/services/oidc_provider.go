@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"whisper-server/internal/infrastructure/config"
+)
+
+// jwksRefreshInterval limits how often unknown key IDs trigger a JWKS download
+const jwksRefreshInterval = time.Minute
+
+// OIDCProvider is an IdentityProvider for any OpenID Connect issuer that publishes
+// discovery metadata; endpoints and signing keys are fetched lazily and cached
+type OIDCProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu            sync.Mutex
+	metadata      *oidcMetadata
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDCProvider(cfg config.OIDCProviderConfig) *OIDCProvider {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{
+		name:         cfg.Name,
+		issuer:       strings.TrimRight(cfg.Issuer, "/"),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		scopes:       scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewIdentityProviders builds the providers listed in OIDC_PROVIDERS, keyed by name
+func NewIdentityProviders(cfg *config.Config) map[string]IdentityProvider {
+	providers := make(map[string]IdentityProvider, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		providers[p.Name] = NewOIDCProvider(p)
+	}
+	return providers
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge, redirectURI string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.clientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("scope", strings.Join(p.scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, redirectURI, nonce string) (*ExternalIdentity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.clientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := p.doJSON(req, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc token exchange: no id_token in response")
+	}
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+type idTokenClaims struct {
+	Nonce             string       `json:"nonce"`
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// flexibleBool accepts both true and "true"; some providers send booleans as strings
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	*b = flexibleBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (*ExternalIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIdentityVerification, err)
+	}
+	if claims.Issuer != p.issuer && claims.Issuer != p.issuer+"/" {
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrIdentityVerification)
+	}
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, fmt.Errorf("%w: audience mismatch", ErrIdentityVerification)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing expiry", ErrIdentityVerification)
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIdentityVerification)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrIdentityVerification)
+	}
+
+	return &ExternalIdentity{
+		Provider:          p.name,
+		Subject:           claims.Subject,
+		Email:             strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta oidcMetadata
+	if err := p.doJSON(req, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.name, err)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer %q does not match configured %q", p.name, meta.Issuer, p.issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery for %s: incomplete metadata", p.name)
+	}
+	p.metadata = &meta
+	return p.metadata, nil
+}
+
+// signingKey returns the issuer's key for kid, refreshing the JWKS when the key
+// is unknown (providers rotate keys) but at most once per jwksRefreshInterval
+func (p *OIDCProvider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key; tokens without kid are accepted when the set has one key
+func (p *OIDCProvider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (p *OIDCProvider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/oidcmock"
+)
+
+const testRedirectURI = "http://app.test/auth/oidc/callback"
+
+// authorize runs the browser leg of the flow against the mock provider and
+// returns the authorization code from the redirect
+func authorize(t *testing.T, p IdentityProvider, state, nonce, challenge string) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, challenge, testRedirectURI)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("redirect location: %v", err)
+	}
+	if got := loc.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return loc.Query().Get("code")
+}
+
+func TestOIDCProviderAuthorizationCodeFlow(t *testing.T) {
+	user := oidcmock.User{
+		Subject:           "sub-123",
+		Email:             "Jane@Example.com",
+		EmailVerified:     true,
+		Name:              "Jane Doe",
+		PreferredUsername: "jane",
+	}
+	mock, server, err := oidcmock.Start("whisper-test", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{Name: "mock", Issuer: mock.Issuer, ClientID: "whisper-test"})
+
+	t.Run("valid exchange", func(t *testing.T) {
+		verifier, challenge, err := GeneratePKCE()
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := authorize(t, provider, "state-1", "nonce-1", challenge)
+
+		identity, err := provider.Exchange(context.Background(), code, verifier, testRedirectURI, "nonce-1")
+		if err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+		want := ExternalIdentity{Provider: "mock", Subject: "sub-123", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe", PreferredUsername: "jane"}
+		if *identity != want {
+			t.Fatalf("identity = %+v, want %+v", *identity, want)
+		}
+
+		// Codes are single-use
+		if _, err := provider.Exchange(context.Background(), code, verifier, testRedirectURI, "nonce-1"); err == nil {
+			t.Fatal("second exchange of the same code succeeded")
+		}
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		_, challenge, _ := GeneratePKCE()
+		otherVerifier, _, _ := GeneratePKCE()
+		code := authorize(t, provider, "state-2", "nonce-2", challenge)
+
+		if _, err := provider.Exchange(context.Background(), code, otherVerifier, testRedirectURI, "nonce-2"); err == nil {
+			t.Fatal("exchange with a foreign PKCE verifier succeeded")
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		verifier, challenge, _ := GeneratePKCE()
+		code := authorize(t, provider, "state-3", "nonce-3", challenge)
+
+		_, err := provider.Exchange(context.Background(), code, verifier, testRedirectURI, "other-nonce")
+		if !errors.Is(err, ErrIdentityVerification) {
+			t.Fatalf("err = %v, want ErrIdentityVerification", err)
+		}
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		other := NewOIDCProvider(config.OIDCProviderConfig{Name: "mock", Issuer: mock.Issuer, ClientID: "someone-else"})
+		verifier, challenge, _ := GeneratePKCE()
+		code := authorize(t, provider, "state-4", "nonce-4", challenge)
+
+		// The mock rejects the client mismatch at the token endpoint already
+		if _, err := other.Exchange(context.Background(), code, verifier, testRedirectURI, "nonce-4"); err == nil {
+			t.Fatal("exchange for another client succeeded")
+		}
+	})
+}
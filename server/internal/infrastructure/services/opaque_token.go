@@ -0,0 +1,26 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken returns a random URL-safe token to hand to the user and the
+// hash to persist; only the hash is stored so a database leak does not expose
+// usable tokens
+func GenerateOpaqueToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken is the lookup key for a token produced by GenerateOpaqueToken
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
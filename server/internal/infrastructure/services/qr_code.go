@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QR codes are encoded in byte mode with error correction level M, which is
+// enough for short links. Only versions 1-10 are supported (up to 213 bytes).
+
+var ErrQRDataTooLong = errors.New("text too long for a QR code")
+
+// qrVersion describes the error correction block layout of one version at level M
+type qrVersion struct {
+	ecPerBlock int
+	blocks     []int // data codewords of each block
+	alignment  []int // alignment pattern center coordinates
+}
+
+var qrVersions = []qrVersion{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// QRCode is an encoded symbol; Modules[y][x] is true for dark modules
+type QRCode struct {
+	Size    int
+	Modules [][]bool
+}
+
+// EncodeQR encodes text into the smallest QR code version that fits it
+func EncodeQR(text string) (*QRCode, error) {
+	data := []byte(text)
+	for version := 1; version < len(qrVersions); version++ {
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > qrVersions[version].dataCodewords()*8 {
+			continue
+		}
+		return newQRCode(version, qrEncodeData(data, countBits, qrVersions[version].dataCodewords())), nil
+	}
+	return nil, ErrQRDataTooLong
+}
+
+// qrEncodeData builds the byte mode bit stream padded to the version's capacity
+func qrEncodeData(data []byte, countBits, capacity int) []byte {
+	var bb qrBitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), countBits)
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	// Terminator, then pad to a byte boundary and fill with alternating pad bytes
+	for i := 0; i < 4 && len(bb) < capacity*8; i++ {
+		bb = append(bb, false)
+	}
+	for len(bb)%8 != 0 {
+		bb = append(bb, false)
+	}
+	for pad := 0xEC; len(bb) < capacity*8; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	out := make([]byte, capacity)
+	for i, bit := range bb {
+		if bit {
+			out[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+	return out
+}
+
+type qrBitBuffer []bool
+
+func (bb *qrBitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
+
+func newQRCode(version int, data []byte) *QRCode {
+	size := version*4 + 17
+	q := &qrBuilder{
+		size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.isFunction[i] = make([]bool, size)
+	}
+
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(qrAddErrorCorrection(qrVersions[version], data))
+
+	// Pick the mask with the lowest penalty
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			bestMask, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // masks are XOR, applying again undoes it
+	}
+	q.applyMask(bestMask)
+	q.drawFormatBits(bestMask)
+
+	return &QRCode{Size: size, Modules: q.modules}
+}
+
+// qrAddErrorCorrection splits data into blocks, appends Reed-Solomon codewords
+// to each and interleaves the result
+func qrAddErrorCorrection(v qrVersion, data []byte) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+	var blocks, ecBlocks [][]byte
+	offset, maxLen := 0, 0
+	for _, n := range v.blocks {
+		block := data[offset : offset+n]
+		offset += n
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		if n > maxLen {
+			maxLen = n
+		}
+	}
+
+	var out []byte
+	for i := 0; i < maxLen; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, b := range ecBlocks {
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the generator polynomial of the given degree, highest
+// coefficient first with the leading 1 omitted
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qrBuilder struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func (q *qrBuilder) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *qrBuilder) drawFunctionPatterns(version int) {
+	// Timing patterns
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with their separators
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	// Alignment patterns, except where they would overlap the finders
+	pos := qrVersions[version].alignment
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; real bits are drawn once the mask is known
+	q.drawFormatBits(0)
+
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 != 0
+			a, b := q.size-11+i%3, i/3
+			q.setFunction(a, b, dark)
+			q.setFunction(b, a, dark)
+		}
+	}
+}
+
+func (q *qrBuilder) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= q.size || y < 0 || y >= q.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawFormatBits writes the error correction level (M) and mask into both format areas
+func (q *qrBuilder) drawFormatBits(mask int) {
+	data := 0<<3 | mask // level M is encoded as 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true) // always-dark module
+}
+
+// drawCodewords places the data in the zigzag order of the standard
+func (q *qrBuilder) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y][x] = (data[i>>3]>>(7-uint(i&7)))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrBuilder) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores a masked symbol with the four rules of the standard; lower is better
+func (q *qrBuilder) penalty() int {
+	score := 0
+	line := make([]bool, q.size)
+	for _, horizontal := range []bool{true, false} {
+		for a := 0; a < q.size; a++ {
+			for b := 0; b < q.size; b++ {
+				if horizontal {
+					line[b] = q.modules[a][b]
+				} else {
+					line[b] = q.modules[b][a]
+				}
+			}
+			score += qrLinePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + k*10
+}
+
+// qrLinePenalty scores runs of equal modules and finder-like patterns in one row or column
+func qrLinePenalty(line []bool) int {
+	score := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += 3 + run - 5
+		}
+		run = 1
+	}
+
+	// 1:1:3:1:1 dark pattern with four light modules on either side (outside counts as light)
+	finder := []bool{true, false, true, true, true, false, true}
+	at := func(i int) bool { return i >= 0 && i < len(line) && line[i] }
+	for i := 0; i+len(finder) <= len(line); i++ {
+		match := true
+		for j, want := range finder {
+			if line[i+j] != want {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		before, after := true, true
+		for j := 1; j <= 4; j++ {
+			before = before && !at(i-j)
+			after = after && !at(i+len(finder)-1+j)
+		}
+		if before {
+			score += 40
+		}
+		if after {
+			score += 40
+		}
+	}
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// PNG renders the code with the given module size in pixels and the standard
+// four-module quiet zone
+func (c *QRCode) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	side := (c.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+border)*scale+dx, (y+border)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
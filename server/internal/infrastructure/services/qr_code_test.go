@@ -0,0 +1,351 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// Block layouts and alignment centers at level M, from the tables of ISO/IEC 18004
+var qrTestLayouts = map[int]struct {
+	ecPerBlock int
+	blocks     []int
+	alignment  []int
+}{
+	1:  {10, []int{16}, nil},
+	3:  {26, []int{44}, []int{6, 22}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// Version information words of the standard
+var qrTestVersionInfo = map[int]int{7: 0x07C94, 10: 0x0A4D3}
+
+func TestRSRemainderKnownVector(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, the worked example of the standard's tutorials
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeQRRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		version int
+	}{
+		{"largest version 1", strings.Repeat("a", 14), 1},
+		{"invite link", "https://whisper.example/invite/LOVE2024", 3},
+		{"smallest version 7", strings.Repeat("b", 107), 7},
+		{"largest version 7", strings.Repeat("c", 122), 7},
+		{"smallest version 10", strings.Repeat("d", 181), 10},
+		{"largest version 10", strings.Repeat("é", 106) + "e", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := EncodeQR(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.version*4 + 17; qr.Size != want {
+				t.Fatalf("size = %d, want %d (version %d)", qr.Size, want, tt.version)
+			}
+			if got := decodeQR(t, qr.Modules, tt.version); got != tt.text {
+				t.Errorf("decoded %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeQRTooLong(t *testing.T) {
+	if _, err := EncodeQR(strings.Repeat("x", 214)); !errors.Is(err, ErrQRDataTooLong) {
+		t.Errorf("214 bytes: %v, want %v", err, ErrQRDataTooLong)
+	}
+}
+
+func TestQRCodePNG(t *testing.T) {
+	const scale = 3
+	qr, err := EncodeQR("https://whisper.example/invite/LOVE2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := qr.PNG(scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := (qr.Size + 8) * scale
+	if b := img.Bounds(); b.Dx() != side || b.Dy() != side {
+		t.Fatalf("image is %dx%d, want %dx%d", b.Dx(), b.Dy(), side, side)
+	}
+	// Read the modules back from the pixels, quiet zone included
+	for y := -4; y < qr.Size+4; y++ {
+		for x := -4; x < qr.Size+4; x++ {
+			want := x >= 0 && y >= 0 && x < qr.Size && y < qr.Size && qr.Modules[y][x]
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					r, _, _, _ := img.At((x+4)*scale+dx, (y+4)*scale+dy).RGBA()
+					if dark := r < 0x8000; dark != want {
+						t.Fatalf("pixel of module (%d,%d) dark=%t, want %t", x, y, dark, want)
+					}
+				}
+			}
+		}
+	}
+	if got := decodeQR(t, sampleModules(img, qr.Size, scale), 3); got != "https://whisper.example/invite/LOVE2024" {
+		t.Errorf("decoded from the PNG: %q", got)
+	}
+}
+
+// sampleModules reads the modules from the centers of their pixel squares
+func sampleModules(img image.Image, size, scale int) [][]bool {
+	modules := make([][]bool, size)
+	for y := range modules {
+		modules[y] = make([]bool, size)
+		for x := range modules[y] {
+			r, _, _, _ := img.At((x+4)*scale+scale/2, (y+4)*scale+scale/2).RGBA()
+			modules[y][x] = r < 0x8000
+		}
+	}
+	return modules
+}
+
+// decodeQR reads a byte mode symbol of the given version at level M back into
+// text, failing on any structural or error correction mismatch
+func decodeQR(t *testing.T, modules [][]bool, version int) string {
+	t.Helper()
+	layout := qrTestLayouts[version]
+	size := len(modules)
+	at := func(x, y int) int {
+		if modules[y][x] {
+			return 1
+		}
+		return 0
+	}
+
+	// Format information, both copies
+	var first, second int
+	for i := 0; i < 15; i++ {
+		var x1, y1, x2, y2 int
+		switch {
+		case i < 6:
+			x1, y1 = 8, i
+		case i < 8:
+			x1, y1 = 8, i+1
+		case i == 8:
+			x1, y1 = 7, 8
+		default:
+			x1, y1 = 14-i, 8
+		}
+		if i < 8 {
+			x2, y2 = size-1-i, 8
+		} else {
+			x2, y2 = 8, size-15+i
+		}
+		first |= at(x1, y1) << i
+		second |= at(x2, y2) << i
+	}
+	if first != second {
+		t.Fatalf("format copies differ: %015b and %015b", first, second)
+	}
+	format := first ^ 0x5412
+	if bchRemainder(format, 0x537, 15) != 0 {
+		t.Fatalf("format %015b fails its BCH check", format)
+	}
+	if level := format >> 13; level != 0 {
+		t.Fatalf("error correction level bits = %02b, want 00 (M)", level)
+	}
+	mask := format >> 10 & 7
+	if !modules[size-8][8] {
+		t.Fatal("dark module missing")
+	}
+
+	if version >= 7 {
+		var top, left int
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			top |= at(a, b) << i
+			left |= at(b, a) << i
+		}
+		if top != qrTestVersionInfo[version] || left != top {
+			t.Fatalf("version information %05X / %05X, want %05X", top, left, qrTestVersionInfo[version])
+		}
+	}
+
+	// Function patterns, which hold no data
+	function := make([][]bool, size)
+	for i := range function {
+		function[i] = make([]bool, size)
+	}
+	mark := func(x0, y0, w, h int) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				function[y][x] = true
+			}
+		}
+	}
+	mark(0, 0, 9, 9)
+	mark(size-8, 0, 8, 9)
+	mark(0, size-8, 9, 8)
+	mark(6, 0, 1, size)
+	mark(0, 6, size, 1)
+	last := len(layout.alignment) - 1
+	for i, cx := range layout.alignment {
+		for j, cy := range layout.alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			mark(cx-2, cy-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		mark(size-11, 0, 3, 6)
+		mark(0, size-11, 6, 3)
+	}
+
+	// Codewords in zigzag order, unmasked
+	var bits []int
+	upward := true
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for _, x := range []int{right, right - 1} {
+				if !function[y][x] {
+					bits = append(bits, at(x, y)^qrMaskBit(mask, x, y))
+				}
+			}
+		}
+		upward = !upward
+	}
+	total := 0
+	for _, n := range layout.blocks {
+		total += n + layout.ecPerBlock
+	}
+	if len(bits) < total*8 {
+		t.Fatalf("%d data modules, want at least %d", len(bits), total*8)
+	}
+	codewords := make([]byte, total)
+	for i := 0; i < total*8; i++ {
+		codewords[i/8] |= byte(bits[i]) << (7 - i%8)
+	}
+
+	// Undo the interleaving and check every block's error correction
+	blocks := make([][]byte, len(layout.blocks))
+	k := 0
+	for i := 0; i < layout.blocks[len(layout.blocks)-1]; i++ {
+		for b, n := range layout.blocks {
+			if i < n {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, block...)
+	}
+	for i := 0; i < layout.ecPerBlock; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[k])
+			k++
+		}
+	}
+	for b, block := range blocks {
+		alpha := byte(1)
+		for i := 0; i < layout.ecPerBlock; i++ {
+			var syndrome byte
+			for _, c := range block {
+				syndrome = gfMultiply(syndrome, alpha) ^ c
+			}
+			if syndrome != 0 {
+				t.Fatalf("block %d: syndrome %d is %d, want 0", b, i, syndrome)
+			}
+			alpha = gfMultiply(alpha, 2)
+		}
+	}
+
+	// Byte mode segment, terminator and pad codewords
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(data[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	if mode := read(4); mode != 0x4 {
+		t.Fatalf("mode = %04b, want byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	text := make([]byte, read(countBits))
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	if pos+4 <= len(data)*8 && read(4) != 0 {
+		t.Fatal("terminator missing")
+	}
+	for pad, i := byte(0xEC), (pos+7)/8; i < len(data); pad, i = pad^0xEC^0x11, i+1 {
+		if data[i] != pad {
+			t.Fatalf("pad codeword %d = %#x, want %#x", i, data[i], pad)
+		}
+	}
+	return string(text)
+}
+
+func qrMaskBit(mask, x, y int) int {
+	var invert bool
+	switch mask {
+	case 0:
+		invert = (y+x)%2 == 0
+	case 1:
+		invert = y%2 == 0
+	case 2:
+		invert = x%3 == 0
+	case 3:
+		invert = (y+x)%3 == 0
+	case 4:
+		invert = (y/2+x/3)%2 == 0
+	case 5:
+		invert = (y*x)%2+(y*x)%3 == 0
+	case 6:
+		invert = ((y*x)%2+(y*x)%3)%2 == 0
+	case 7:
+		invert = ((y+x)%2+(y*x)%3)%2 == 0
+	}
+	if invert {
+		return 1
+	}
+	return 0
+}
+
+// bchRemainder divides the n-bit word by the generator polynomial
+func bchRemainder(word, generator, n int) int {
+	degree := 0
+	for g := generator; g > 1; g >>= 1 {
+		degree++
+	}
+	for i := n - 1; i >= degree; i-- {
+		if word>>i&1 == 1 {
+			word ^= generator << (i - degree)
+		}
+	}
+	return word
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"whisper-server/internal/infrastructure/config"
+)
+
+// S3MediaStorage talks to any S3-compatible service (AWS S3, MinIO) using path-style
+// addressing and AWS Signature Version 4
+type S3MediaStorage struct {
+	endpoint       *url.URL
+	publicEndpoint *url.URL
+	region         string
+	bucket         string
+	accessKey      string
+	secretKey      string
+	client         *http.Client
+	now            func() time.Time
+}
+
+const (
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3UnsignedBody  = "UNSIGNED-PAYLOAD"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	s3MaxPresignTTL = 7 * 24 * time.Hour
+)
+
+func NewS3MediaStorage(cfg config.S3Config) (*S3MediaStorage, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("s3 storage requires S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", cfg.Endpoint)
+	}
+	public := endpoint
+	if cfg.PublicEndpoint != "" {
+		if public, err = url.Parse(cfg.PublicEndpoint); err != nil || public.Host == "" {
+			return nil, fmt.Errorf("invalid S3_PUBLIC_ENDPOINT %q", cfg.PublicEndpoint)
+		}
+	}
+	return &S3MediaStorage{
+		endpoint:       endpoint,
+		publicEndpoint: public,
+		region:         cfg.Region,
+		bucket:         cfg.Bucket,
+		accessKey:      cfg.AccessKey,
+		secretKey:      cfg.SecretKey,
+		client:         &http.Client{Timeout: 60 * time.Second},
+		now:            time.Now,
+	}, nil
+}
+
+func (s *S3MediaStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (s *S3MediaStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *S3MediaStorage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, ErrMediaObjectNotFound) {
+			return nil
+		}
+		return err
+	}
+	return res.Body.Close()
+}
+
+// SignedURL returns a presigned GET URL (query string authentication)
+func (s *S3MediaStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if ttl > s3MaxPresignTTL {
+		ttl = s3MaxPresignTTL
+	}
+	now := s.now().UTC()
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	q.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	u := s.objectURL(s.publicEndpoint, key)
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+	q.Set("X-Amz-Signature", s.signature(now, canonical))
+	u.RawQuery = canonicalQuery(q)
+	return u.String(), nil
+}
+
+func (s *S3MediaStorage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	u := s.objectURL(s.endpoint, key)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// do signs the request with the Authorization header and maps error statuses
+func (s *S3MediaStorage) do(req *http.Request) (*http.Response, error) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	headers := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + s3UnsignedBody + "\n" +
+		"x-amz-date:" + now.Format(s3TimeFormat) + "\n"
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		signed = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		headers = "content-type:" + ct + "\n" + headers
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		strings.Join(signed, ";"),
+		s3UnsignedBody,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), strings.Join(signed, ";"), s.signature(now, canonical)))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrMediaObjectNotFound
+	}
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return res, nil
+}
+
+func (s *S3MediaStorage) objectURL(base *url.URL, key string) *url.URL {
+	segments := append([]string{s.bucket}, strings.Split(key, "/")...)
+	for i, seg := range segments {
+		segments[i] = uriEncode(seg)
+	}
+	escaped := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.Join(segments, "/")
+	u := *base
+	u.Path, _ = url.PathUnescape(escaped)
+	u.RawPath = escaped
+	u.RawQuery = ""
+	return &u
+}
+
+func (s *S3MediaStorage) scope(t time.Time) string {
+	return t.Format(s3DateFormat) + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+func (s *S3MediaStorage) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format(s3TimeFormat),
+		s.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format(s3DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery sorts and encodes query parameters as SigV4 requires
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
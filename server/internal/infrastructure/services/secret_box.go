@@ -0,0 +1,72 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// SecretBox encrypts small secrets (such as TOTP keys) before they are stored
+type SecretBox interface {
+	Seal(plaintext []byte) (string, error)
+	Open(sealed string) ([]byte, error)
+}
+
+var (
+	ErrSecretBoxOpen = errors.New("cannot decrypt secret")
+	ErrSecretBoxKey  = errors.New("secret box key is empty")
+)
+
+// secretBoxLabel separates the box key from any other use of the same secret,
+// such as signing JWTs with it
+const secretBoxLabel = "whisper/secret-box/aes-256-gcm"
+
+type aesSecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox returns an AES-256-GCM box whose key is derived from key with HKDF-SHA256
+func NewSecretBox(key string) (SecretBox, error) {
+	if key == "" {
+		return nil, ErrSecretBoxKey
+	}
+	derived := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(key), nil, []byte(secretBoxLabel)), derived); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesSecretBox{aead: aead}, nil
+}
+
+func (b *aesSecretBox) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (b *aesSecretBox) Open(sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return nil, ErrSecretBoxOpen
+	}
+	plaintext, err := b.aead.Open(nil, raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrSecretBoxOpen
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestBox(t *testing.T, key string) SecretBox {
+	t.Helper()
+	box, err := NewSecretBox(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := newTestBox(t, "mfa-key")
+	secret := []byte("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	a, err := box.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := box.Seal(secret)
+	if a == b {
+		t.Error("sealing twice gave the same ciphertext; the nonce must be random")
+	}
+	if bytes.Contains([]byte(a), secret) {
+		t.Error("ciphertext contains the secret")
+	}
+	for _, sealed := range []string{a, b} {
+		got, err := box.Open(sealed)
+		if err != nil || !bytes.Equal(got, secret) {
+			t.Errorf("Open = %q, %v", got, err)
+		}
+	}
+	// A box from the same key, e.g. after a restart, opens it too
+	if got, err := newTestBox(t, "mfa-key").Open(a); err != nil || !bytes.Equal(got, secret) {
+		t.Errorf("Open with a new box = %q, %v", got, err)
+	}
+}
+
+func TestSecretBoxRejectsTampering(t *testing.T) {
+	box := newTestBox(t, "mfa-key")
+	sealed, err := box.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+
+	tampered := func(i int) string {
+		c := append([]byte{}, raw...)
+		c[i] ^= 0x01
+		return base64.StdEncoding.EncodeToString(c)
+	}
+	tests := []struct {
+		name   string
+		sealed string
+	}{
+		{"flipped nonce bit", tampered(0)},
+		{"flipped ciphertext bit", tampered(12)},
+		{"flipped tag bit", tampered(len(raw) - 1)},
+		{"truncated", base64.StdEncoding.EncodeToString(raw[:len(raw)-1])},
+		{"shorter than a nonce", base64.StdEncoding.EncodeToString(raw[:4])},
+		{"not base64", "%%%"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := box.Open(tt.sealed); !errors.Is(err, ErrSecretBoxOpen) {
+				t.Errorf("Open = %v, want %v", err, ErrSecretBoxOpen)
+			}
+		})
+	}
+
+	if _, err := newTestBox(t, "other-key").Open(sealed); !errors.Is(err, ErrSecretBoxOpen) {
+		t.Errorf("Open with another key = %v, want %v", err, ErrSecretBoxOpen)
+	}
+}
+
+func TestSecretBoxKey(t *testing.T) {
+	if _, err := NewSecretBox(""); !errors.Is(err, ErrSecretBoxKey) {
+		t.Errorf("empty key: %v, want %v", err, ErrSecretBoxKey)
+	}
+
+	// The box key is derived, never the plain hash of a secret that may also sign JWTs
+	sealed, err := newTestBox(t, "shared-secret").Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+	sum := sha256.Sum256([]byte("shared-secret"))
+	block, _ := aes.NewCipher(sum[:])
+	aead, _ := cipher.NewGCM(block)
+	if _, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil); err == nil {
+		t.Error("secret opened with the SHA-256 of the key")
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcKey is the ASCII key "12345678901234567890" used by the RFC 4226 and
+// RFC 6238 test vectors, base32 encoded as authenticator apps receive it
+const rfcKey = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226 appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := totpCode([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("HOTP(counter %d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1; the RFC prints 8 digits, apps use the last 6
+	tests := []struct {
+		unix int64
+		step int64
+		code string
+	}{
+		{59, 0x1, "94287082"},
+		{1111111109, 0x23523EC, "07081804"},
+		{1111111111, 0x23523ED, "14050471"},
+		{1234567890, 0x273EF07, "89005924"},
+		{2000000000, 0x3F940AA, "69279037"},
+		{20000000000, 0x27BC86AA, "65353130"},
+	}
+	for _, tt := range tests {
+		code := tt.code[2:]
+		step, ok := ValidateTOTP(rfcKey, code, time.Unix(tt.unix, 0))
+		if !ok || step != tt.step {
+			t.Errorf("ValidateTOTP(%s at %d) = %d, %t; want step %d", code, tt.unix, step, ok, tt.step)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := "005924" // current step of now, from the RFC 6238 vectors
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{"current step", code, now, true},
+		{"one step of clock drift", code, now.Add(30 * time.Second), true},
+		{"one step early", code, now.Add(-30 * time.Second), true},
+		{"two steps late", code, now.Add(61 * time.Second), false},
+		{"spaced like the app shows it", "005 924", now, true},
+		{"wrong code", "005925", now, false},
+		{"too short", "05924", now, false},
+		{"eight digits", "89005924", now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfcKey, tt.code, tt.at); ok != tt.want {
+				t.Errorf("ValidateTOTP(%q) = %t, want %t", tt.code, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP(strings.ToLower(rfcKey), code, now); !ok {
+		t.Error("lower-case secret rejected")
+	}
+	if _, ok := ValidateTOTP("not base32!", code, now); ok {
+		t.Error("code accepted for a malformed secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateTOTPSecret()
+	key, err := totpEncoding.DecodeString(a)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, %v; want 20", a, len(key), err)
+	}
+	if a == b {
+		t.Error("two secrets are equal")
+	}
+	uri := TOTPURI("Whisper", "alice@example.com", a)
+	if !strings.HasPrefix(uri, "otpauth://totp/Whisper:alice@example.com?") || !strings.Contains(uri, "secret="+a) {
+		t.Errorf("uri = %s", uri)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type AccountHandler struct {
+	accountUseCase usecases.AccountUseCase
+	exportUseCase  usecases.DataExportUseCase
+}
+
+func NewAccountHandler(accountUseCase usecases.AccountUseCase, exportUseCase usecases.DataExportUseCase) *AccountHandler {
+	return &AccountHandler{accountUseCase: accountUseCase, exportUseCase: exportUseCase}
+}
+
+// Delete soft-deletes the logged-in account; it is purged once the grace period ends
+func (h *AccountHandler) Delete(c *gin.Context) {
+	// The body may be empty right after signing in
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(c)
+	sessionID := middleware.GetSessionIDFromContext(c)
+	resp, err := h.accountUseCase.RequestDeletion(c.Request.Context(), userID, sessionID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case usecases.ErrIncorrectPassword, usecases.ErrInvalidTwoFactorCode, usecases.ErrTwoFactorNotEnabled:
+			status = http.StatusBadRequest
+		case usecases.ErrReauthRequired:
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// Restore undoes a deletion using the token from the mailed restore link
+func (h *AccountHandler) Restore(c *gin.Context) {
+	var req dto.RestoreAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	if err := h.accountUseCase.Restore(c.Request.Context(), &req); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrInvalidRestoreToken {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetExport returns the latest data export, starting one if none is usable.
+// Answers 202 while the archive is still being built.
+func (h *AccountHandler) GetExport(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	resp, err := h.exportUseCase.GetOrRequest(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: "could not start data export"})
+		return
+	}
+	c.JSON(exportStatus(resp), resp)
+}
+
+// RequestExport starts a fresh data export, e.g. after the previous one failed or expired
+func (h *AccountHandler) RequestExport(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	resp, err := h.exportUseCase.Request(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: "could not start data export"})
+		return
+	}
+	c.JSON(exportStatus(resp), resp)
+}
+
+func exportStatus(resp *dto.DataExportResponse) int {
+	if resp.Status == entities.DataExportStatusReady {
+		return http.StatusOK
+	}
+	return http.StatusAccepted
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"whisper-server/internal/application/dto"
 	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
 )
 
 type AuthHandler struct {
@@ -19,6 +24,17 @@ func NewAuthHandler(authUseCase usecases.AuthUseCase) *AuthHandler {
 	}
 }
 
+// maxUserAgentLength caps the user agent stored on a session
+const maxUserAgentLength = 512
+
+func clientInfo(c *gin.Context) dto.ClientInfo {
+	ua := c.Request.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = ua[:maxUserAgentLength]
+	}
+	return dto.ClientInfo{UserAgent: ua, IP: c.ClientIP()}
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -30,7 +46,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	result, err := h.authUseCase.Register(c.Request.Context(), &req)
+	result, err := h.authUseCase.Register(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err.Error() == "username already exists" || err.Error() == "email already exists" {
@@ -58,11 +74,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.authUseCase.Login(c.Request.Context(), &req)
+	result, err := h.authUseCase.Login(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
+		var locked *usecases.LoginLockedError
+		if errors.As(err, &locked) {
+			respondLoginLocked(c, locked)
+			return
+		}
 		status := http.StatusUnauthorized
 		if err.Error() == "invalid username or password" {
 			status = http.StatusUnauthorized
+		} else if err == usecases.ErrUserInactive {
+			status = http.StatusForbidden
 		}
 
 		c.JSON(status, dto.ErrorResponse{
@@ -86,14 +109,159 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	result, err := h.authUseCase.RefreshToken(c.Request.Context(), &req)
+	result, err := h.authUseCase.RefreshToken(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
-			Code:    http.StatusUnauthorized,
+		status := http.StatusUnauthorized
+		if err != usecases.ErrInvalidRefreshToken && err != usecases.ErrRefreshTokenReused && err != usecases.ErrUserInactive {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
-}
\ No newline at end of file
+}
+
+// VerifyMFA exchanges an MFA challenge token and a TOTP or recovery code for tokens
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req dto.VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request format",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.authUseCase.VerifyMFA(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		var locked *usecases.LoginLockedError
+		if errors.As(err, &locked) {
+			respondLoginLocked(c, locked)
+			return
+		}
+		status := http.StatusInternalServerError
+		switch err {
+		case usecases.ErrInvalidMFAToken, usecases.ErrInvalidTwoFactorCode, usecases.ErrTwoFactorNotEnabled:
+			status = http.StatusUnauthorized
+		case usecases.ErrUserInactive:
+			status = http.StatusForbidden
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Error codes for lockouts so clients can show a specific message
+const (
+	errorCodeAccountLocked   = "ACCOUNT_LOCKED"
+	errorCodeTooManyAttempts = "TOO_MANY_ATTEMPTS"
+)
+
+func respondLoginLocked(c *gin.Context, locked *usecases.LoginLockedError) {
+	retryAfter := int64(math.Ceil(locked.RetryAfter.Seconds()))
+	code := errorCodeTooManyAttempts
+	if locked.Scope == usecases.LockScopeAccount {
+		code = errorCodeAccountLocked
+	}
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusTooManyRequests, dto.ErrorResponse{
+		Code:       http.StatusTooManyRequests,
+		Message:    locked.Error(),
+		ErrorCode:  code,
+		RetryAfter: retryAfter,
+	})
+}
+
+// Logout revokes the refresh token family of the current device
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req dto.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request format",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(c)
+	if err := h.authUseCase.Logout(c.Request.Context(), userID, &req); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrInvalidRefreshToken {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutAll revokes every refresh token issued to the current user
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if err := h.authUseCase.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListSessions returns the devices the current user is logged in on
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	result, err := h.authUseCase.ListSessions(c.Request.Context(), userID, middleware.GetSessionIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RevokeSession logs out one device; revoking the current session logs out this request's device
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid session ID",
+		})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(c)
+	if err := h.authUseCase.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrSessionNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+)
+
+type CatalogHandler struct {
+	uc usecases.CatalogUseCase
+}
+
+func NewCatalogHandler(uc usecases.CatalogUseCase) *CatalogHandler {
+	return &CatalogHandler{uc: uc}
+}
+
+// Get handles GET /catalog?lang=en|fa; unsupported languages fall back to English
+func (h *CatalogHandler) Get(c *gin.Context) {
+	res, err := h.uc.GetCatalog(c.Request.Context(), c.Query("lang"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type EmailVerificationHandler struct {
+	verificationUseCase usecases.EmailVerificationUseCase
+}
+
+func NewEmailVerificationHandler(verificationUseCase usecases.EmailVerificationUseCase) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verificationUseCase: verificationUseCase}
+}
+
+// Verify confirms an email address with the token from the verification link
+func (h *EmailVerificationHandler) Verify(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	if err := h.verificationUseCase.Verify(c.Request.Context(), &req); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrInvalidVerificationToken {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Resend mails a fresh verification link to the logged-in user
+func (h *EmailVerificationHandler) Resend(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if err := h.verificationUseCase.Resend(c.Request.Context(), userID); err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case usecases.ErrEmailAlreadyVerified:
+			status = http.StatusConflict
+		case usecases.ErrVerificationRateLimited:
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
@@ -57,6 +57,14 @@ func (h *EventHandler) RegisterEvent(c *gin.Context) {
 
 	res, err := h.uc.RegisterEvent(c.Request.Context(), userID, &req)
 	if err != nil {
+		if err == usecases.ErrUnknownEventType {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if status := mediaErrorStatus(err); status != http.StatusInternalServerError {
+			c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
 		return
 	}
@@ -110,6 +118,14 @@ func (h *EventHandler) UpdateEventByID(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.ErrorResponse{Code: http.StatusForbidden, Message: "forbidden"})
 			return
 		}
+		if err == usecases.ErrUnknownEventType {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: err.Error()})
+			return
+		}
+		if status := mediaErrorStatus(err); status != http.StatusInternalServerError {
+			c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{Code: http.StatusNotFound, Message: err.Error()})
 		return
 	}
@@ -159,3 +175,59 @@ func (h *EventHandler) GetAllEventsByUserID(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, res)
 }
+
+// ListArchived handles GET /relationships/:id/events, read-only access to a past relationship
+func (h *EventHandler) ListArchived(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	relID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+
+	res, err := h.uc.GetAllEventsByRelationship(c.Request.Context(), userID, relID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrRelationshipNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// SetVisibility handles PUT /events/:id/visibility
+func (h *EventHandler) SetVisibility(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	var req dto.SetEventVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request", Details: err.Error()})
+		return
+	}
+	res, err := h.uc.SetVisibility(c.Request.Context(), userID, oid, &req)
+	if err != nil {
+		if err == usecases.ErrForbidden {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Code: http.StatusForbidden, Message: "forbidden"})
+			return
+		}
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Code: http.StatusNotFound, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+)
+
+type ExploreHandler struct {
+	uc usecases.ExploreUseCase
+}
+
+func NewExploreHandler(uc usecases.ExploreUseCase) *ExploreHandler {
+	return &ExploreHandler{uc: uc}
+}
+
+// ListEvents handles GET /explore/events?sort=recent|popular&type=&limit=&offset=
+func (h *ExploreHandler) ListEvents(c *gin.Context) {
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	res, err := h.uc.ListPublicEvents(c.Request.Context(), c.Query("type"), c.DefaultQuery("sort", "recent"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GetEvent handles GET /explore/events/:id
+func (h *ExploreHandler) GetEvent(c *gin.Context) {
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	res, err := h.uc.ViewPublicEvent(c.Request.Context(), oid)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "event not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
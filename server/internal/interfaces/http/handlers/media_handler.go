@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type MediaHandler struct {
+	uc usecases.MediaUseCase
+}
+
+func NewMediaHandler(uc usecases.MediaUseCase) *MediaHandler {
+	return &MediaHandler{uc: uc}
+}
+
+// multipartOverhead leaves room for boundaries and form fields around the file part
+const multipartOverhead = 64 << 10
+
+// Upload handles POST /media (multipart form: file, kind=event_image|avatar)
+func (h *MediaHandler) Upload(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	// Cap the body before parsing the form; the per-kind limit is enforced by the use case
+	limit := max(h.uc.MaxBytes(entities.MediaKindEventImage), h.uc.MaxBytes(entities.MediaKindAvatar))
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "request body too large") {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: "file is required", Details: err.Error()})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "unreadable file", Details: err.Error()})
+		return
+	}
+	defer f.Close()
+	kind := c.DefaultPostForm("kind", entities.MediaKindEventImage)
+	res, err := h.uc.Upload(c.Request.Context(), userID, kind, fh.Filename, f)
+	if err != nil {
+		status := mediaErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// Get handles GET /media/:id and returns a freshly signed URL for the caller's upload
+func (h *MediaHandler) Get(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	res, err := h.uc.Get(c.Request.Context(), userID, oid)
+	if err != nil {
+		status := mediaErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// SignedFileStore is implemented by storage backends whose signed URLs point back at the API
+type SignedFileStore interface {
+	Verify(key, expires, sig string) error
+	Open(key string) (*os.File, error)
+}
+
+type MediaFileHandler struct {
+	store SignedFileStore
+}
+
+func NewMediaFileHandler(store SignedFileStore) *MediaFileHandler {
+	return &MediaFileHandler{store: store}
+}
+
+// Serve handles GET /media/files/*key?expires=&sig=; no auth header needed so <img> tags work
+func (h *MediaFileHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := h.store.Verify(key, c.Query("expires"), c.Query("sig")); err != nil {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Code: http.StatusForbidden, Message: err.Error()})
+		return
+	}
+	f, err := h.store.Open(key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Code: http.StatusNotFound, Message: "media not found"})
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+}
+
+// mediaErrorStatus maps media (and shared authorization) errors to HTTP statuses
+func mediaErrorStatus(err error) int {
+	switch {
+	case err == usecases.ErrMediaTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case err == usecases.ErrUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case err == usecases.ErrInvalidMediaKind, err == usecases.ErrInvalidMediaPayload, err == usecases.ErrUnreadableImage:
+		return http.StatusBadRequest
+	case err == usecases.ErrForbidden:
+		return http.StatusForbidden
+	case err.Error() == "media not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+// oidcNonceCookie ties a sign-in attempt to the browser that started it; it is
+// only sent to the OIDC endpoints
+const (
+	oidcNonceCookie = "whisper_oidc_nonce"
+	oidcCookiePath  = "/api/v1/auth/oidc"
+)
+
+type OIDCHandler struct {
+	oidcUseCase usecases.OIDCUseCase
+	// secureCookies marks the nonce cookie Secure when the API is served over HTTPS
+	secureCookies bool
+}
+
+func NewOIDCHandler(oidcUseCase usecases.OIDCUseCase, secureCookies bool) *OIDCHandler {
+	return &OIDCHandler{oidcUseCase: oidcUseCase, secureCookies: secureCookies}
+}
+
+func (h *OIDCHandler) setNonceCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcNonceCookie, value, maxAge, oidcCookiePath, "", h.secureCookies, true)
+}
+
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oidcUseCase.Providers())
+}
+
+// Start returns the provider URL to open for signing in
+func (h *OIDCHandler) Start(c *gin.Context) {
+	result, err := h.oidcUseCase.StartLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	h.setNonceCookie(c, result.BrowserNonce, int(result.ExpiresIn))
+	c.JSON(http.StatusOK, result)
+}
+
+// Link returns the provider URL to open for attaching an account to the current user
+func (h *OIDCHandler) Link(c *gin.Context) {
+	result, err := h.oidcUseCase.StartLink(c.Request.Context(), middleware.GetUserIDFromContext(c), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	h.setNonceCookie(c, result.BrowserNonce, int(result.ExpiresIn))
+	c.JSON(http.StatusOK, result)
+}
+
+// Callback completes a sign-in or link with the code and state the provider
+// redirected back with
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+	// A missing cookie leaves the nonce empty, which the use case rejects
+	req.BrowserNonce, _ = c.Cookie(oidcNonceCookie)
+	h.setNonceCookie(c, "", -1)
+	result, err := h.oidcUseCase.Callback(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	result, err := h.oidcUseCase.ListIdentities(c.Request.Context(), middleware.GetUserIDFromContext(c))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case usecases.ErrUnknownIdentityProvider:
+		status = http.StatusNotFound
+	case usecases.ErrInvalidOIDCState, usecases.ErrOIDCLoginFailed:
+		status = http.StatusUnauthorized
+	case usecases.ErrIdentityAlreadyLinked, usecases.ErrProviderAlreadyLinked, usecases.ErrOIDCAccountExists:
+		status = http.StatusConflict
+	case usecases.ErrUserInactive:
+		status = http.StatusForbidden
+	}
+	c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+}
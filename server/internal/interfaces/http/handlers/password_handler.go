@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type PasswordHandler struct {
+	passwordUseCase usecases.PasswordUseCase
+}
+
+func NewPasswordHandler(passwordUseCase usecases.PasswordUseCase) *PasswordHandler {
+	return &PasswordHandler{passwordUseCase: passwordUseCase}
+}
+
+// Change sets a new password for the logged-in user after checking the current
+// one and logs out every other session
+func (h *PasswordHandler) Change(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(c)
+	sessionID := middleware.GetSessionIDFromContext(c)
+	if err := h.passwordUseCase.ChangePassword(c.Request.Context(), userID, sessionID, &req); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrIncorrectPassword {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Forgot always answers 202 so callers cannot tell whether the email is registered
+func (h *PasswordHandler) Forgot(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	if err := h.passwordUseCase.ForgotPassword(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: "could not process request"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a reset link has been sent"})
+}
+
+// Reset sets a new password using a mailed reset token and logs out every session
+func (h *PasswordHandler) Reset(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+
+	if err := h.passwordUseCase.ResetPassword(c.Request.Context(), &req); err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrInvalidResetToken {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
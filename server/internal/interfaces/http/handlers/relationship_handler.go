@@ -8,6 +8,7 @@ import (
 
 	"whisper-server/internal/application/dto"
 	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/domain/entities"
 )
 
 type RelationshipHandler struct {
@@ -27,6 +28,21 @@ func (h *RelationshipHandler) GenerateInvite(c *gin.Context) {
         return
     }
     res, err := h.uc.GenerateInvitationCode(c.Request.Context(), oid, req.FirstMeetingDate)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrEmailNotVerified {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *RelationshipHandler) ListInvites(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	res, err := h.uc.ListInvites(c.Request.Context(), oid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
 		return
@@ -34,6 +50,41 @@ func (h *RelationshipHandler) GenerateInvite(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+func (h *RelationshipHandler) RevokeInvite(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	if err := h.uc.RevokeInvite(c.Request.Context(), oid, c.Param("code")); err != nil {
+		status := inviteErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// InviteQRCode serves the invite's deep link as a QR code image
+func (h *RelationshipHandler) InviteQRCode(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	img, err := h.uc.InviteQRCode(c.Request.Context(), oid, c.Param("code"))
+	if err != nil {
+		status := inviteErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "image/png", img)
+}
+
+func inviteErrorStatus(err error) int {
+	switch err {
+	case usecases.ErrInviteNotFound:
+		return http.StatusNotFound
+	case usecases.ErrInviteInactive:
+		return http.StatusGone
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *RelationshipHandler) Join(c *gin.Context) {
 	var req dto.JoinWithInviteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -44,7 +95,11 @@ func (h *RelationshipHandler) Join(c *gin.Context) {
 	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
 	res, err := h.uc.JoinWithInviteCode(c.Request.Context(), oid, req.Code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: err.Error()})
+		status := http.StatusBadRequest
+		if err == usecases.ErrEmailNotVerified {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -61,12 +116,106 @@ func (h *RelationshipHandler) Current(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// Disconnect asks to end the relationship; it ends once the partner confirms or
+// the request goes unanswered, so it answers 202 with the pending request
 func (h *RelationshipHandler) Disconnect(c *gin.Context) {
 	userIDStr, _ := c.Get("userID")
 	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
-	if err := h.uc.DisconnectRelationship(c.Request.Context(), oid); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: err.Error()})
+	res, err := h.uc.RequestDisconnect(c.Request.Context(), oid)
+	if err != nil {
+		respondDisconnectError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, res)
+}
+
+// ConfirmDisconnect is the other partner agreeing to end the relationship
+func (h *RelationshipHandler) ConfirmDisconnect(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	res, err := h.uc.ConfirmDisconnect(c.Request.Context(), oid)
+	if err != nil {
+		respondDisconnectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// CancelDisconnect undoes the caller's own request during the cooling-off window
+func (h *RelationshipHandler) CancelDisconnect(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	res, err := h.uc.CancelDisconnect(c.Request.Context(), oid)
+	if err != nil {
+		respondDisconnectError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func respondDisconnectError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if usecases.IsDisconnectFlowError(err) {
+		status = http.StatusConflict
+	}
+	c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+}
+
+func (h *RelationshipHandler) History(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	res, err := h.uc.ListHistory(c.Request.Context(), oid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Code: http.StatusInternalServerError, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Reconnect answers 200 once the relationship is active again, or 202 while it
+// waits for the other partner to reconnect too
+func (h *RelationshipHandler) Reconnect(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	relID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	res, err := h.uc.Reconnect(c.Request.Context(), oid, relID)
+	if err != nil {
+		c.JSON(reconnectErrorStatus(err), dto.ErrorResponse{Code: reconnectErrorStatus(err), Message: err.Error()})
 		return
 	}
-	c.JSON(http.StatusNoContent, nil)
+	status := http.StatusAccepted
+	if res.Status == string(entities.RelationshipStatusActive) {
+		status = http.StatusOK
+	}
+	c.JSON(status, res)
+}
+
+func (h *RelationshipHandler) CancelReconnect(c *gin.Context) {
+	userIDStr, _ := c.Get("userID")
+	oid, _ := primitive.ObjectIDFromHex(userIDStr.(string))
+	relID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	if err := h.uc.CancelReconnect(c.Request.Context(), oid, relID); err != nil {
+		c.JSON(reconnectErrorStatus(err), dto.ErrorResponse{Code: reconnectErrorStatus(err), Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func reconnectErrorStatus(err error) int {
+	switch err {
+	case usecases.ErrRelationshipNotFound:
+		return http.StatusNotFound
+	case usecases.ErrRelationshipNotArchived, usecases.ErrAlreadyInRelationship, usecases.ErrPartnerInRelationship, usecases.ErrNoReconnectRequest,
+		usecases.ErrReconnectConflict:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
 }
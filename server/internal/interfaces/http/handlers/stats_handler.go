@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type StatsHandler struct {
+	uc usecases.StatsUseCase
+}
+
+func NewStatsHandler(uc usecases.StatsUseCase) *StatsHandler {
+	return &StatsHandler{uc: uc}
+}
+
+// Relationship handles GET /stats/relationship
+func (h *StatsHandler) Relationship(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	res, err := h.uc.GetRelationshipStats(c.Request.Context(), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "no active relationship" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type TodoHandler struct {
+	uc usecases.TodoUseCase
+}
+
+func NewTodoHandler(uc usecases.TodoUseCase) *TodoHandler {
+	return &TodoHandler{uc: uc}
+}
+
+func (h *TodoHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	var req dto.CreateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request", Details: err.Error()})
+		return
+	}
+	res, err := h.uc.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		status := todoErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// List handles GET /todos?status=open|completed&limit=&offset=
+func (h *TodoHandler) List(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+
+	var completed *bool
+	switch c.Query("status") {
+	case "open":
+		v := false
+		completed = &v
+	case "completed":
+		v := true
+		completed = &v
+	case "", "all":
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "status must be one of open, completed, all"})
+		return
+	}
+
+	res, err := h.uc.ListByCurrentRelationship(c.Request.Context(), userID, completed, limit, offset)
+	if err != nil {
+		status := todoErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TodoHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	var req dto.UpdateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request", Details: err.Error()})
+		return
+	}
+	res, err := h.uc.Update(c.Request.Context(), userID, oid, &req)
+	if err != nil {
+		status := todoErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TodoHandler) Complete(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	res, err := h.uc.Complete(c.Request.Context(), userID, oid)
+	if err != nil {
+		status := todoErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TodoHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "invalid id"})
+		return
+	}
+	if err := h.uc.Delete(c.Request.Context(), userID, oid); err != nil {
+		status := todoErrorStatus(err)
+		c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func todoErrorStatus(err error) int {
+	switch {
+	case err == usecases.ErrForbidden:
+		return http.StatusForbidden
+	case err == usecases.ErrTodoAlreadyCompleted:
+		return http.StatusConflict
+	case err == usecases.ErrInvalidAssignee:
+		return http.StatusBadRequest
+	case err.Error() == "todo not found":
+		return http.StatusNotFound
+	case err.Error() == "no active relationship":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
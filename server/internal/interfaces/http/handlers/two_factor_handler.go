@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/interfaces/http/middleware"
+)
+
+type TwoFactorHandler struct {
+	twoFactorUseCase usecases.TwoFactorUseCase
+}
+
+func NewTwoFactorHandler(twoFactorUseCase usecases.TwoFactorUseCase) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorUseCase: twoFactorUseCase}
+}
+
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	result, err := h.twoFactorUseCase.Status(c.Request.Context(), middleware.GetUserIDFromContext(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Enroll returns a new secret and otpauth:// URI; 2FA stays off until confirmed
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	result, err := h.twoFactorUseCase.Enroll(c.Request.Context(), middleware.GetUserIDFromContext(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Confirm enables 2FA and returns the recovery codes
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+	result, err := h.twoFactorUseCase.Confirm(c.Request.Context(), middleware.GetUserIDFromContext(c), &req)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req dto.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+	userID := middleware.GetUserIDFromContext(c)
+	sessionID := middleware.GetSessionIDFromContext(c)
+	if err := h.twoFactorUseCase.Disable(c.Request.Context(), userID, sessionID, &req); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Code: http.StatusBadRequest, Message: "Invalid request format", Details: err.Error()})
+		return
+	}
+	result, err := h.twoFactorUseCase.RegenerateRecoveryCodes(c.Request.Context(), middleware.GetUserIDFromContext(c), &req)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case usecases.ErrTwoFactorAlreadyEnabled:
+		status = http.StatusConflict
+	case usecases.ErrTwoFactorNotEnrolled, usecases.ErrTwoFactorNotEnabled, usecases.ErrInvalidTwoFactorCode, usecases.ErrIncorrectPassword:
+		status = http.StatusBadRequest
+	case usecases.ErrPasswordOrFreshLogin:
+		status = http.StatusUnauthorized
+	}
+	c.JSON(status, dto.ErrorResponse{Code: status, Message: err.Error()})
+}
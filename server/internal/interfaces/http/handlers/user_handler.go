@@ -45,9 +45,45 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	profile, err := h.userUseCase.UpdateProfile(c.Request.Context(), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(mediaErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, profile)
 }
+
+// GetSettings handles GET /api/v1/users/settings
+func (h *UserHandler) GetSettings(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	settings, err := h.userUseCase.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/v1/users/settings
+func (h *UserHandler) UpdateSettings(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+
+	var req dto.UpdateUserSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	settings, err := h.userUseCase.UpdateSettings(c.Request.Context(), userID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrUnsupportedLanguage || err == usecases.ErrInvalidTimezone {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
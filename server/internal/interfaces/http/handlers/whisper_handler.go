@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"whisper-server/internal/application/dto"
 	"whisper-server/internal/application/usecases"
+	"whisper-server/internal/domain/entities"
 	"whisper-server/internal/interfaces/http/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -22,9 +24,12 @@ func NewWhisperHandler(uc usecases.WhisperUseCase) *WhisperHandler {
 func (h *WhisperHandler) Register(rg *gin.RouterGroup) {
 	rg.POST("/", h.Create)
 	rg.GET("/", h.List)
+	rg.GET("/today", h.Today)
+	rg.GET("/upcoming", h.Upcoming)
 	rg.PUT(":id", h.Update)
 	rg.DELETE(":id", h.Delete)
 	rg.POST(":id/convert", h.ConvertToEvent)
+	rg.PUT(":id/occurrences/:date", h.SetOccurrenceDone)
 }
 
 func (h *WhisperHandler) Create(c *gin.Context) {
@@ -36,17 +41,48 @@ func (h *WhisperHandler) Create(c *gin.Context) {
 	}
 	res, err := h.uc.Create(c.Request.Context(), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		status := whisperErrorStatus(err)
+		c.JSON(status, gin.H{"message": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, res)
 }
 
+// List handles GET /whispers; with ?from=YYYY-MM-DD&to=YYYY-MM-DD it returns dated occurrences instead
 func (h *WhisperHandler) List(c *gin.Context) {
 	userID := middleware.GetUserIDFromContext(c)
-	res, err := h.uc.ListByCurrentRelationship(c.Request.Context(), userID, 100, 0)
+	if c.Query("from") != "" || c.Query("to") != "" {
+		h.listOccurrences(c)
+		return
+	}
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	res, err := h.uc.ListByCurrentRelationship(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		status := whisperErrorStatus(err)
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ListArchived handles GET /relationships/:id/whispers, read-only access to a past relationship
+func (h *WhisperHandler) ListArchived(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	relID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid id"})
+		return
+	}
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "100"), 10, 64)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	res, err := h.uc.ListByRelationship(c.Request.Context(), userID, relID, limit, offset)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == usecases.ErrRelationshipNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"message": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -70,6 +106,8 @@ func (h *WhisperHandler) Update(c *gin.Context) {
 		status := http.StatusInternalServerError
 		if err == usecases.ErrForbidden {
 			status = http.StatusForbidden
+		} else if usecases.IsWhisperValidationError(err) {
+			status = http.StatusBadRequest
 		}
 		c.JSON(status, gin.H{"message": err.Error()})
 		return
@@ -110,13 +148,103 @@ func (h *WhisperHandler) ConvertToEvent(c *gin.Context) {
 		req = dto.ConvertWhisperRequest{}
 	}
 	res, err := h.uc.ConvertToEvent(c.Request.Context(), userID, oid, req.Image)
+	if err != nil {
+		status := mediaErrorStatus(err) // also maps ErrForbidden
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// Today handles GET /whispers/today
+func (h *WhisperHandler) Today(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	res, err := h.uc.Today(c.Request.Context(), userID)
+	if err != nil {
+		status := whisperErrorStatus(err)
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Upcoming handles GET /whispers/upcoming?days=N
+func (h *WhisperHandler) Upcoming(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "days must be a number"})
+		return
+	}
+	res, err := h.uc.Upcoming(c.Request.Context(), userID, days)
+	if err != nil {
+		status := whisperErrorStatus(err)
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WhisperHandler) listOccurrences(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	from, errFrom := entities.ParseDate(c.Query("from"))
+	to, errTo := entities.ParseDate(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "from and to must both be dates in YYYY-MM-DD format"})
+		return
+	}
+	res, err := h.uc.ListOccurrences(c.Request.Context(), userID, from, to)
+	if err != nil {
+		status := whisperErrorStatus(err)
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// SetOccurrenceDone handles PUT /whispers/:id/occurrences/:date
+func (h *WhisperHandler) SetOccurrenceDone(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	oid, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid id"})
+		return
+	}
+	date, err := entities.ParseDate(c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "date must be in YYYY-MM-DD format"})
+		return
+	}
+	var req dto.SetOccurrenceDoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	res, err := h.uc.SetOccurrenceDone(c.Request.Context(), userID, oid, date, *req.IsDone)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err == usecases.ErrForbidden {
 			status = http.StatusForbidden
+		} else if usecases.IsWhisperValidationError(err) {
+			status = http.StatusBadRequest
 		}
 		c.JSON(status, gin.H{"message": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, res)
+	c.JSON(http.StatusOK, res)
+}
+
+// whisperErrorStatus maps whisper use case errors to HTTP statuses; a user
+// without a current relationship has no whispers to show
+func whisperErrorStatus(err error) int {
+	switch {
+	case usecases.IsWhisperValidationError(err):
+		return http.StatusBadRequest
+	case err == usecases.ErrForbidden:
+		return http.StatusForbidden
+	case err == usecases.ErrRelationshipNotFound, err.Error() == "no active relationship":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -3,15 +3,22 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/repositories"
 	"whisper-server/internal/infrastructure/services"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func AuthMiddleware(jwt services.JWTService) gin.HandlerFunc {
+// sessionTouchInterval limits how often a session's last-seen time is written
+const sessionTouchInterval = time.Minute
+
+// AuthMiddleware accepts only access tokens whose session is still active and
+// rejects users that were deactivated after the token was issued
+func AuthMiddleware(jwt services.JWTService, userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
@@ -24,8 +31,37 @@ func AuthMiddleware(jwt services.JWTService) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "invalid token"})
 			return
 		}
+		userID, err := primitive.ObjectIDFromHex(claims.UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "invalid token"})
+			return
+		}
+		sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "invalid token"})
+			return
+		}
+		now := time.Now()
+		session, err := sessionRepo.FindByID(c.Request.Context(), sessionID)
+		if err != nil || session.UserID != userID || !session.IsActive(now) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "session revoked"})
+			return
+		}
+		user, err := userRepo.FindByID(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "invalid token"})
+			return
+		}
+		if !user.IsActive {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{Code: http.StatusUnauthorized, Message: "account is deactivated"})
+			return
+		}
+		if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+			_ = sessionRepo.Touch(c.Request.Context(), session.ID, c.ClientIP(), now)
+		}
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("sessionID", session.ID)
 		c.Next()
 	}
 }
@@ -48,3 +84,13 @@ func GetUserIDFromContext(c *gin.Context) primitive.ObjectID {
 	}
 	return primitive.NilObjectID
 }
+
+// GetSessionIDFromContext returns the session the request was authenticated with
+func GetSessionIDFromContext(c *gin.Context) primitive.ObjectID {
+	if v, ok := c.Get("sessionID"); ok {
+		if oid, ok := v.(primitive.ObjectID); ok {
+			return oid
+		}
+	}
+	return primitive.NilObjectID
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"whisper-server/internal/application/dto"
+	"whisper-server/internal/domain/entities"
+	"whisper-server/internal/domain/repositories"
+	"whisper-server/internal/infrastructure/config"
+	"whisper-server/internal/infrastructure/memory"
+	"whisper-server/internal/infrastructure/services"
+)
+
+type authFixture struct {
+	jwt      services.JWTService
+	users    repositories.UserRepository
+	sessions repositories.SessionRepository
+	router   *gin.Engine
+}
+
+func newAuthFixture() *authFixture {
+	gin.SetMode(gin.TestMode)
+	store := memory.NewStore()
+	f := &authFixture{
+		jwt:      services.NewJWTService(&config.Config{JWT: config.JWTConfig{Secret: "test-secret", AccessExpiresIn: "15m", RefreshExpiresIn: "720h"}}),
+		users:    memory.NewUserRepository(store),
+		sessions: memory.NewSessionRepository(store),
+		router:   gin.New(),
+	}
+	f.router.GET("/me", AuthMiddleware(f.jwt, f.users, f.sessions), func(c *gin.Context) {
+		c.String(http.StatusOK, GetUserIDFromContext(c).Hex())
+	})
+	return f
+}
+
+// signIn creates a user with an active session and returns its access token
+func (f *authFixture) signIn(t *testing.T, username string) (*entities.User, *entities.Session, string) {
+	t.Helper()
+	ctx := context.Background()
+	u := entities.NewUser(username, username, username+"@example.com", "hash")
+	if err := f.users.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	session := entities.NewSession(u.ID, "test", "go-test", "127.0.0.1", time.Now().Add(time.Hour))
+	if err := f.sessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	token, err := f.jwt.GenerateAccessToken(u.ID, u.Username, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, session, token
+}
+
+func (f *authFixture) get(token string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		return rec.Code, rec.Body.String()
+	}
+	var res dto.ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &res)
+	return rec.Code, res.Message
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		token   func(t *testing.T, f *authFixture) string
+		wantMsg string
+	}{
+		{"missing token", func(t *testing.T, f *authFixture) string { return "" }, "missing bearer token"},
+		{"refresh token", func(t *testing.T, f *authFixture) string {
+			u, _, _ := f.signIn(t, "alice")
+			token, err := f.jwt.GenerateRefreshToken(u.ID, u.Username, primitive.NewObjectID(), time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "invalid token"},
+		{"revoked session", func(t *testing.T, f *authFixture) string {
+			_, session, token := f.signIn(t, "alice")
+			if err := f.sessions.Revoke(ctx, session.ID); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "session revoked"},
+		{"all sessions revoked", func(t *testing.T, f *authFixture) string {
+			u, _, token := f.signIn(t, "alice")
+			if err := f.sessions.RevokeAllByUserID(ctx, u.ID); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "session revoked"},
+		{"session of another user", func(t *testing.T, f *authFixture) string {
+			_, session, _ := f.signIn(t, "alice")
+			mallory, _, _ := f.signIn(t, "mallory")
+			token, err := f.jwt.GenerateAccessToken(mallory.ID, mallory.Username, session.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "session revoked"},
+		{"deactivated user", func(t *testing.T, f *authFixture) string {
+			u, _, token := f.signIn(t, "alice")
+			u.IsActive = false
+			if err := f.users.Update(ctx, u); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "account is deactivated"},
+		{"deleted user", func(t *testing.T, f *authFixture) string {
+			u, _, token := f.signIn(t, "alice")
+			if err := f.users.Delete(ctx, u.ID, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture()
+			if code, msg := f.get(tt.token(t, f)); code != http.StatusUnauthorized || msg != tt.wantMsg {
+				t.Errorf("got %d %q, want 401 %q", code, msg, tt.wantMsg)
+			}
+		})
+	}
+
+	t.Run("active session", func(t *testing.T) {
+		f := newAuthFixture()
+		u, _, token := f.signIn(t, "alice")
+		if code, body := f.get(token); code != http.StatusOK || body != u.ID.Hex() {
+			t.Errorf("got %d %q, want 200 %s", code, body, u.ID.Hex())
+		}
+	})
+}
@@ -1,6 +1,11 @@
 package routes
 
 import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
 	"whisper-server/internal/application/usecases"
 	"whisper-server/internal/infrastructure/config"
 	"whisper-server/internal/infrastructure/database"
@@ -8,11 +13,13 @@ import (
 	"whisper-server/internal/infrastructure/services"
 	"whisper-server/internal/interfaces/http/handlers"
 	"whisper-server/internal/interfaces/http/middleware"
+	"whisper-server/internal/interfaces/jobs"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
+// SetupRoutes wires the API and returns the background jobs for the caller to start
+func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) *jobs.Scheduler {
 	// CORS for browser clients without external dependency
 	router.Use(middleware.CORSMiddleware())
 	// Initialize services
@@ -21,24 +28,127 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	oneTimeTokenRepo := repositories.NewOneTimeTokenRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	twoFactorRepo := repositories.NewTwoFactorRepository(db)
+	externalIdentityRepo := repositories.NewExternalIdentityRepository(db)
+	oidcStateRepo := repositories.NewOIDCStateRepository(db)
 	relationshipRepo := repositories.NewRelationshipRepository(db)
 	inviteRepo := repositories.NewInviteRepository(db)
 	eventRepo := repositories.NewEventRepository(db)
 	whisperRepo := repositories.NewWhisperRepository(db)
+	todoRepo := repositories.NewTodoRepository(db)
+	statsRepo := repositories.NewStatsRepository(db)
+	catalogRepo := repositories.NewCatalogRepository(db)
+	mediaRepo := repositories.NewMediaRepository(db)
+	dataExportRepo := repositories.NewDataExportRepository(db)
+	unitOfWork := repositories.NewUnitOfWork(db)
+
+	mediaStorage, err := services.NewMediaStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+	mediaURLTTL := positiveDuration("MEDIA_URL_TTL", cfg.Media.URLTTL)
+
+	if cfg.App.Environment == "production" && (cfg.Mail.Driver == "" || cfg.Mail.Driver == "log") {
+		// The log mailer would print reset and verification links into the server log
+		log.Fatalf("MAIL_DRIVER must be set to smtp or file in production")
+	}
+	mailer, err := services.NewMailer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	mfaKey := cfg.Auth.MFAEncryptionKey
+	if mfaKey == "" {
+		if cfg.App.Environment == "production" {
+			log.Fatalf("MFA_ENCRYPTION_KEY must be set in production")
+		}
+		// Still a different key: the secret box derives its own under a separate label
+		log.Printf("[CONFIG][MFA][WARN] MFA_ENCRYPTION_KEY is not set; deriving the key from JWT_SECRET")
+		mfaKey = cfg.JWT.Secret
+	}
+	mfaSecretBox, err := services.NewSecretBox(mfaKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize MFA secret encryption: %v", err)
+	}
+	passwordResetTTL := positiveDuration("PASSWORD_RESET_TTL", cfg.Auth.PasswordResetTTL)
+	verificationTTL := positiveDuration("EMAIL_VERIFICATION_TTL", cfg.Auth.EmailVerificationTTL)
+	verificationResendAfter := positiveDuration("EMAIL_VERIFICATION_RESEND_AFTER", cfg.Auth.EmailVerificationResendAfter)
+	loginLockoutBase := positiveDuration("LOGIN_LOCKOUT_BASE", cfg.Auth.LoginLockoutBase)
+	loginLockoutMax := positiveDuration("LOGIN_LOCKOUT_MAX", cfg.Auth.LoginLockoutMax)
+	loginFailureWindow := positiveDuration("LOGIN_FAILURE_WINDOW", cfg.Auth.LoginFailureWindow)
+	deletionGracePeriod := positiveDuration("ACCOUNT_DELETION_GRACE_PERIOD", cfg.Account.DeletionGracePeriod)
+	purgeInterval := positiveDuration("ACCOUNT_PURGE_INTERVAL", cfg.Account.PurgeInterval)
+	exportTTL := positiveDuration("DATA_EXPORT_TTL", cfg.Account.ExportTTL)
+	disconnectUndoWindow := positiveDuration("DISCONNECT_UNDO_WINDOW", cfg.Relationship.DisconnectUndoWindow)
+	disconnectAutoConfirmAfter := positiveDuration("DISCONNECT_AUTO_CONFIRM_AFTER", cfg.Relationship.DisconnectAutoConfirmAfter)
+	inviteCodeTTL := positiveDuration("INVITE_CODE_TTL", cfg.Relationship.InviteCodeTTL)
 
 	// Initialize use cases
-	authUseCase := usecases.NewAuthUseCase(userRepo, jwtService, passwordService)
-	relationshipUseCase := usecases.NewRelationshipUseCase(relationshipRepo, userRepo, inviteRepo)
-	eventUseCase := usecases.NewEventUseCase(eventRepo, relationshipRepo)
-	whisperUsecase := usecases.NewWhisperUseCase(whisperRepo, relationshipRepo, eventRepo)
-	userUseCase := usecases.NewUserUseCase(userRepo)
+	mediaUseCase := usecases.NewMediaUseCase(mediaRepo, mediaStorage, services.NewImageProcessor(services.DefaultThumbnailSizes), mediaURLTTL, cfg.Media.MaxImageBytes, cfg.Media.MaxAvatarBytes)
+	verificationUseCase := usecases.NewEmailVerificationUseCase(userRepo, oneTimeTokenRepo, mailer, cfg.App.BaseURL, verificationTTL, verificationResendAfter)
+	verificationPolicy := usecases.NewEmailVerificationPolicy(cfg.Auth.EmailVerificationRequiredFor)
+	loginThrottle := usecases.NewLoginThrottle(loginAttemptRepo, usecases.LoginThrottleConfig{
+		MaxAccountFailures: cfg.Auth.LoginMaxFailures,
+		MaxIPFailures:      cfg.Auth.LoginMaxFailuresPerIP,
+		BaseLockout:        loginLockoutBase,
+		MaxLockout:         loginLockoutMax,
+		Window:             loginFailureWindow,
+	})
+	twoFactorUseCase := usecases.NewTwoFactorUseCase(twoFactorRepo, userRepo, sessionRepo, passwordService, mfaSecretBox, cfg.App.Name)
+	authUseCase := usecases.NewAuthUseCase(userRepo, refreshTokenRepo, sessionRepo, jwtService, passwordService, verificationUseCase, loginThrottle, twoFactorUseCase, mediaUseCase)
+	oidcUseCase := usecases.NewOIDCUseCase(services.NewIdentityProviders(cfg), externalIdentityRepo, oidcStateRepo, userRepo, passwordService, verificationUseCase, authUseCase, cfg.App.BaseURL+"/auth/oidc/callback")
+	passwordUseCase := usecases.NewPasswordUseCase(userRepo, oneTimeTokenRepo, passwordService, mailer, authUseCase, cfg.App.BaseURL, passwordResetTTL)
+	relationshipUseCase := usecases.NewRelationshipUseCase(relationshipRepo, userRepo, inviteRepo, unitOfWork, verificationPolicy, mailer, usecases.InvitePolicy{
+		TTL:         inviteCodeTTL,
+		LinkBaseURL: cfg.App.BaseURL,
+	}, usecases.DisconnectPolicy{
+		UndoWindow:       disconnectUndoWindow,
+		AutoConfirmAfter: disconnectAutoConfirmAfter,
+	})
+	statsUseCase := usecases.NewStatsUseCase(statsRepo, relationshipRepo, userRepo)
+	catalogUseCase := usecases.NewCatalogUseCase(catalogRepo)
+	eventUseCase := usecases.NewEventUseCase(eventRepo, relationshipRepo, userRepo, catalogUseCase, mediaUseCase, statsUseCase)
+	whisperUsecase := usecases.NewWhisperUseCase(whisperRepo, relationshipRepo, eventRepo, userRepo, catalogUseCase, mediaUseCase, statsUseCase)
+	userUseCase := usecases.NewUserUseCase(userRepo, mediaUseCase)
+	todoUseCase := usecases.NewTodoUseCase(todoRepo, relationshipRepo, eventRepo, mediaUseCase, statsUseCase)
+	exploreUseCase := usecases.NewExploreUseCase(eventRepo, userRepo, mediaUseCase)
+	dataExportUseCase := usecases.NewDataExportUseCase(dataExportRepo, userRepo, relationshipRepo, eventRepo, whisperRepo, inviteRepo, mediaStorage, exportTTL)
+	accountUseCase := usecases.NewAccountUseCase(userRepo, oneTimeTokenRepo, sessionRepo, usecases.AccountPurgeRepos{
+		Relationships: relationshipRepo,
+		Events:        eventRepo,
+		Whispers:      whisperRepo,
+		Todos:         todoRepo,
+		Invites:       inviteRepo,
+		Identities:    externalIdentityRepo,
+		TwoFactor:     twoFactorRepo,
+	}, passwordService, twoFactorUseCase, mailer, authUseCase, relationshipUseCase, dataExportUseCase, mediaUseCase, cfg.App.BaseURL, deletionGracePeriod)
+
+	// Make sure the built-in whisper/event types exist before serving requests
+	seedCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := catalogUseCase.SeedDefaults(seedCtx); err != nil {
+		log.Printf("Failed to seed type catalog: %v", err)
+	}
+	cancel()
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authUseCase)
+	passwordHandler := handlers.NewPasswordHandler(passwordUseCase)
+	verificationHandler := handlers.NewEmailVerificationHandler(verificationUseCase)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorUseCase)
+	oidcHandler := handlers.NewOIDCHandler(oidcUseCase, strings.HasPrefix(cfg.App.BaseURL, "https://"))
 	relationshipHandler := handlers.NewRelationshipHandler(relationshipUseCase)
 	eventHandler := handlers.NewEventHandler(eventUseCase)
 	whisperHandler := handlers.NewWhisperHandler(whisperUsecase)
 	userHandler := handlers.NewUserHandler(userUseCase)
+	todoHandler := handlers.NewTodoHandler(todoUseCase)
+	exploreHandler := handlers.NewExploreHandler(exploreUseCase)
+	statsHandler := handlers.NewStatsHandler(statsUseCase)
+	catalogHandler := handlers.NewCatalogHandler(catalogUseCase)
+	mediaHandler := handlers.NewMediaHandler(mediaUseCase)
+	accountHandler := handlers.NewAccountHandler(accountUseCase, dataExportUseCase)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -49,6 +159,8 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 		})
 	})
 
+	requireAuth := middleware.AuthMiddleware(jwtService, userRepo, sessionRepo)
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -58,11 +170,32 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 			authRoutes.POST("/register", authHandler.Register)
 			authRoutes.POST("/login", authHandler.Login)
 			authRoutes.POST("/refresh", authHandler.RefreshToken)
+			authRoutes.POST("/logout", requireAuth, authHandler.Logout)
+			authRoutes.POST("/logout-all", requireAuth, authHandler.LogoutAll)
+			authRoutes.GET("/sessions", requireAuth, authHandler.ListSessions)
+			authRoutes.DELETE("/sessions/:id", requireAuth, authHandler.RevokeSession)
+			authRoutes.POST("/password/change", requireAuth, passwordHandler.Change)
+			authRoutes.POST("/password/forgot", passwordHandler.Forgot)
+			authRoutes.POST("/password/reset", passwordHandler.Reset)
+			authRoutes.POST("/email/verify", verificationHandler.Verify)
+			authRoutes.POST("/email/resend", requireAuth, verificationHandler.Resend)
+			authRoutes.POST("/2fa/verify", authHandler.VerifyMFA)
+			authRoutes.GET("/2fa", requireAuth, twoFactorHandler.Status)
+			authRoutes.POST("/2fa/enroll", requireAuth, twoFactorHandler.Enroll)
+			authRoutes.POST("/2fa/confirm", requireAuth, twoFactorHandler.Confirm)
+			authRoutes.POST("/2fa/disable", requireAuth, twoFactorHandler.Disable)
+			authRoutes.POST("/2fa/recovery-codes", requireAuth, twoFactorHandler.RegenerateRecoveryCodes)
+			authRoutes.GET("/oidc/providers", oidcHandler.Providers)
+			authRoutes.POST("/oidc/callback", oidcHandler.Callback)
+			authRoutes.POST("/oidc/:provider/start", oidcHandler.Start)
+			authRoutes.POST("/oidc/:provider/link", requireAuth, oidcHandler.Link)
+			authRoutes.GET("/identities", requireAuth, oidcHandler.ListIdentities)
+			authRoutes.POST("/account/restore", accountHandler.Restore)
 		}
 
 		// Protected group
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(jwtService))
+		protected.Use(requireAuth)
 		{
 			// Events routes
 			eventRoutes := protected.Group("/events")
@@ -73,6 +206,7 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 				eventRoutes.GET("/:id", eventHandler.GetEventByID)
 				eventRoutes.PUT("/:id", eventHandler.UpdateEventByID)
 				eventRoutes.DELETE("/:id", eventHandler.DeleteEventByID)
+				eventRoutes.PUT("/:id/visibility", eventHandler.SetVisibility)
 				eventRoutes.GET("/", eventHandler.GetAllEventsByUserID)
 				eventRoutes.GET("", eventHandler.GetAllEventsByUserID)
 			}
@@ -83,11 +217,14 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 				// Support both with and without trailing slash
 				whisperRoutes.GET("/", whisperHandler.List)
 				whisperRoutes.GET("", whisperHandler.List)
+				whisperRoutes.GET("/today", whisperHandler.Today)
+				whisperRoutes.GET("/upcoming", whisperHandler.Upcoming)
 				whisperRoutes.POST("/", whisperHandler.Create)
 				whisperRoutes.POST("", whisperHandler.Create)
 				whisperRoutes.PUT(":id", whisperHandler.Update)
 				whisperRoutes.DELETE(":id", whisperHandler.Delete)
 				whisperRoutes.POST(":id/convert", whisperHandler.ConvertToEvent)
+				whisperRoutes.PUT(":id/occurrences/:date", whisperHandler.SetOccurrenceDone)
 			}
 		}
 
@@ -96,55 +233,108 @@ func SetupRoutes(router *gin.Engine, db *database.MongoDB, cfg *config.Config) {
 		{
 			userRoutes.GET("/profile", userHandler.GetProfile)
 			userRoutes.PUT("/profile", userHandler.UpdateProfile)
-			userRoutes.PUT("/settings", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Settings not implemented yet"})
-			})
+			userRoutes.GET("/settings", userHandler.GetSettings)
+			userRoutes.PUT("/settings", userHandler.UpdateSettings)
+			userRoutes.DELETE("/me", accountHandler.Delete)
+			userRoutes.GET("/me/export", accountHandler.GetExport)
+			userRoutes.POST("/me/export", accountHandler.RequestExport)
 		}
 
 		// Relationship routes (protected)
 		relationshipRoutes := v1.Group("/relationships")
-		relationshipRoutes.Use(middleware.AuthMiddleware(jwtService))
+		relationshipRoutes.Use(requireAuth)
 		{
 			relationshipRoutes.POST("/invite", relationshipHandler.GenerateInvite)
+			relationshipRoutes.GET("/invites", relationshipHandler.ListInvites)
+			relationshipRoutes.DELETE("/invites/:code", relationshipHandler.RevokeInvite)
+			relationshipRoutes.GET("/invites/:code/qr.png", relationshipHandler.InviteQRCode)
 			relationshipRoutes.POST("/join", relationshipHandler.Join)
 			relationshipRoutes.GET("/current", relationshipHandler.Current)
 			relationshipRoutes.DELETE("/disconnect", relationshipHandler.Disconnect)
+			relationshipRoutes.POST("/disconnect/confirm", relationshipHandler.ConfirmDisconnect)
+			relationshipRoutes.POST("/disconnect/cancel", relationshipHandler.CancelDisconnect)
+			relationshipRoutes.GET("/history", relationshipHandler.History)
+			relationshipRoutes.POST("/:id/reconnect", relationshipHandler.Reconnect)
+			relationshipRoutes.DELETE("/:id/reconnect", relationshipHandler.CancelReconnect)
+			// Read-only memories of past relationships
+			relationshipRoutes.GET("/:id/events", eventHandler.ListArchived)
+			relationshipRoutes.GET("/:id/whispers", whisperHandler.ListArchived)
 		}
 
-		// Todos routes
-		todoRoutes := v1.Group("/todos")
+		// Todos routes (protected)
+		todoRoutes := protected.Group("/todos")
 		{
-			todoRoutes.GET("/", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
-			todoRoutes.POST("/", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
-			todoRoutes.PUT("/:id/complete", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
-			todoRoutes.DELETE("/:id", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
+			// Support both with and without trailing slash
+			todoRoutes.GET("/", todoHandler.List)
+			todoRoutes.GET("", todoHandler.List)
+			todoRoutes.POST("/", todoHandler.Create)
+			todoRoutes.POST("", todoHandler.Create)
+			todoRoutes.PUT("/:id", todoHandler.Update)
+			todoRoutes.PUT("/:id/complete", todoHandler.Complete)
+			todoRoutes.DELETE("/:id", todoHandler.Delete)
 		}
 
-		// Public events (explore) routes
+		// Public events (explore) routes; readable without authentication
 		exploreRoutes := v1.Group("/explore")
 		{
-			exploreRoutes.GET("/events", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
-			exploreRoutes.GET("/events/:id", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
+			exploreRoutes.GET("/events", exploreHandler.ListEvents)
+			exploreRoutes.GET("/events/:id", exploreHandler.GetEvent)
 		}
 
-		// Statistics routes
-		statsRoutes := v1.Group("/stats")
+		// Media uploads (protected)
+		mediaRoutes := protected.Group("/media")
 		{
-			statsRoutes.GET("/relationship", func(c *gin.Context) {
-				c.JSON(501, gin.H{"message": "Not implemented yet"})
-			})
+			mediaRoutes.POST("", mediaHandler.Upload)
+			mediaRoutes.POST("/", mediaHandler.Upload)
+			mediaRoutes.GET("/:id", mediaHandler.Get)
+		}
+		// Local storage serves files itself; signed query parameters replace the auth header
+		if files, ok := mediaStorage.(handlers.SignedFileStore); ok {
+			v1.GET("/media/files/*key", handlers.NewMediaFileHandler(files).Serve)
 		}
+
+		// Whisper/event type catalog; public so clients can render pickers before login
+		v1.GET("/catalog", catalogHandler.Get)
+
+		// Statistics routes (protected)
+		statsRoutes := protected.Group("/stats")
+		{
+			statsRoutes.GET("/relationship", statsHandler.Relationship)
+		}
+	}
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Add("account-purge", purgeInterval, func(ctx context.Context) error {
+		purged, err := accountUseCase.PurgeDue(ctx)
+		if purged > 0 {
+			log.Printf("[JOBS][PURGE][DONE] accounts=%d", purged)
+		}
+		return err
+	})
+	scheduler.Add("relationship-disconnect-auto-confirm", 5*time.Minute, func(ctx context.Context) error {
+		confirmed, err := relationshipUseCase.AutoConfirmDisconnects(ctx)
+		if confirmed > 0 {
+			log.Printf("[JOBS][DISCONNECT][DONE] relationships=%d", confirmed)
+		}
+		return err
+	})
+	scheduler.Add("data-export-sweep", time.Minute, func(ctx context.Context) error {
+		if err := dataExportUseCase.ProcessPending(ctx); err != nil {
+			return err
+		}
+		return dataExportUseCase.CleanupExpired(ctx)
+	})
+	return scheduler
+}
+
+// positiveDuration parses a duration setting and refuses to start on an invalid
+// or non-positive one: a zero grace period or TTL would make accounts, links and
+// codes expire as soon as they are created
+func positiveDuration(name, value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s %q: want a positive Go duration such as 1h or 720h (days are not a unit)", name, value)
 	}
+	return d
 }
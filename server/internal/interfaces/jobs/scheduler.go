@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a periodic background task
+type Job func(ctx context.Context) error
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs on fixed intervals until its context is cancelled
+type Scheduler struct {
+	jobs []scheduledJob
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job; it first runs one interval after Start
+func (s *Scheduler) Add(name string, interval time.Duration, run Job) {
+	if interval <= 0 {
+		log.Printf("[JOBS][ADD][WARN] job=%s disabled: interval %s", name, interval)
+		return
+	}
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: run})
+}
+
+// Start launches every job in its own goroutine
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+	log.Printf("[JOBS][START][DONE] jobs=%d", len(s.jobs))
+}
+
+// Wait blocks until all jobs have stopped after the context passed to Start is cancelled
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job scheduledJob) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job.run(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[JOBS][RUN][ERROR] job=%s err=%v", job.name, err)
+			}
+		}
+	}
+}